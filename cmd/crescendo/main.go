@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"io/fs"
 	"log"
 	"net/http"
@@ -14,6 +15,8 @@ import (
 	"github.com/MattHbrook/Crescendo/internal/handlers"
 	"github.com/MattHbrook/Crescendo/internal/hifi"
 	"github.com/MattHbrook/Crescendo/internal/library"
+	"github.com/MattHbrook/Crescendo/internal/playlist"
+	"github.com/MattHbrook/Crescendo/internal/releases"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
@@ -35,17 +38,62 @@ func main() {
 	}
 
 	store := db.NewStore(database)
-	hifiClient := hifi.NewClient(cfg.HiFiAPIURL)
+	if n, err := store.FailInterruptedScans(context.Background()); err != nil {
+		log.Fatalf("db: %v", err)
+	} else if n > 0 {
+		log.Printf("marked %d interrupted scan(s) as failed", n)
+	}
+
+	var cacheStore hifi.CacheStore
+	if cfg.HiFiCachePersist {
+		cacheStore = store
+	}
+	hifiCache := hifi.NewCache(cfg.HiFiCacheSize, cacheStore)
+
+	backends := make([]hifi.Backend, 0, len(cfg.HiFiBackends))
+	for _, b := range cfg.HiFiBackends {
+		backends = append(backends, hifi.Backend{URL: b.URL, Region: b.Region})
+	}
+	hifiClient := hifi.NewFailoverClient(backends)
+	hifiClient.SetRegion(cfg.HiFiRegion)
+	hifiClient.SetCache(hifiCache)
+	hifiClient.SetRetries(cfg.HiFiMaxRetries, cfg.HiFiRetryBackoff)
+	if cfg.HiFiRateLimit > 0 {
+		hifiClient.SetRateLimit(cfg.HiFiRateLimit, cfg.HiFiRateBurst)
+	}
+	if cfg.HiFiBreakerThreshold > 0 {
+		hifiClient.SetBreaker(cfg.HiFiBreakerThreshold, cfg.HiFiBreakerCooldown)
+	}
 	scanner := library.NewScanner(cfg.MusicPath, store, hifiClient)
-	dl := downloader.New(cfg.MusicPath, cfg.MaxConcurrentDownloads, hifiClient, hifiClient, hifiClient, store)
+	scans := library.NewScanManager(scanner, store)
+	dl := downloader.New(cfg.MusicPath, cfg.TrashPath, cfg.MaxConcurrentDownloads, hifiClient, hifiClient, hifiClient, store)
 	disc := discovery.NewEngine(store, hifiClient)
+	completeness := library.NewCompletenessChecker(store, hifiClient)
+	dedupe := library.NewDeduper(cfg.MusicPath, cfg.TrashPath, store)
+	verifier := library.NewVerifier(cfg.MusicPath, cfg.TrashPath, store)
+	playlists := playlist.NewSyncer(cfg.MusicPath, cfg.DefaultQuality, store, hifiClient, dl)
+	monitor := releases.NewMonitor(cfg.DefaultQuality, store, hifiClient, dl)
+
+	go hifiClient.Run(context.Background(), cfg.HiFiHealthInterval)
+	go hifiCache.Run(context.Background(), hifi.CachePruneInterval)
+	go monitor.Run(context.Background(), cfg.ReleaseCheckInterval)
+	go disc.Run(context.Background(), cfg.DiscoveryInterval)
+
+	if cfg.WatchMode != library.WatchOff {
+		watcher := library.NewWatcher(cfg.MusicPath, scans, cfg.WatchMode, cfg.WatchDebounce, cfg.WatchPollInterval)
+		go func() {
+			if err := watcher.Run(context.Background()); err != nil {
+				log.Printf("library watcher: %v", err)
+			}
+		}()
+	}
 
 	templatesFS, err := fs.Sub(crescendo.Content, "templates")
 	if err != nil {
 		log.Fatalf("embedded templates: %v", err)
 	}
 
-	h, err := handlers.New(templatesFS, store, hifiClient, scanner, dl, disc, cfg.DefaultQuality)
+	h, err := handlers.New(templatesFS, store, hifiClient, scans, dl, disc, completeness, dedupe, verifier, playlists, monitor, cfg.DefaultQuality)
 	if err != nil {
 		log.Fatalf("handlers: %v", err)
 	}
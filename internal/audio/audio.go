@@ -0,0 +1,229 @@
+// Package audio reads tags and technical properties from audio files without
+// decoding the audio itself. It understands FLAC, MP3, Ogg (Opus and Vorbis),
+// MP4 (ALAC and AAC), WavPack and WAV. The one exception is VerifyFLAC, which
+// decodes FLAC audio to check it against its checksums.
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Format names recorded per track.
+const (
+	FormatFLAC    = "flac"
+	FormatALAC    = "alac"
+	FormatAAC     = "aac"
+	FormatMP3     = "mp3"
+	FormatOpus    = "opus"
+	FormatVorbis  = "vorbis"
+	FormatWavPack = "wavpack"
+	FormatWAV     = "wav"
+)
+
+// ErrUnsupported is returned by Probe for files that are not a recognised
+// audio container.
+var ErrUnsupported = errors.New("audio: unsupported file type")
+
+// errInvalid is wrapped by the container parsers when a file's structure
+// doesn't match its extension.
+var errInvalid = errors.New("invalid or truncated file")
+
+// Info holds the tags and technical properties of an audio file.
+type Info struct {
+	Format      string // one of the Format constants
+	Title       string
+	Artist      string
+	Album       string
+	TrackNumber int
+	DiscNumber  int
+	Duration    float64 // seconds
+	SampleRate  int     // Hz
+	BitDepth    int     // bits per sample; 0 for lossy formats
+	Channels    int
+	Bitrate     int // average kbit/s
+}
+
+// Lossless reports whether the format stores audio losslessly.
+func (i *Info) Lossless() bool {
+	return IsLossless(i.Format)
+}
+
+// IsLossless reports whether the named format stores audio losslessly.
+func IsLossless(format string) bool {
+	switch format {
+	case FormatFLAC, FormatALAC, FormatWavPack, FormatWAV:
+		return true
+	default:
+		return false
+	}
+}
+
+type prober func(r io.ReadSeeker, size int64) (*Info, error)
+
+// probers maps lowercase file extensions to the parser for that container.
+var probers = map[string]prober{
+	".flac": probeFLAC,
+	".mp3":  probeMP3,
+	".m4a":  probeMP4,
+	".opus": probeOgg,
+	".ogg":  probeOgg,
+	".oga":  probeOgg,
+	".wv":   probeWavPack,
+	".wav":  probeWAV,
+}
+
+// extensionFormats is the format assumed for a file before (or instead of)
+// probing it. Containers holding more than one codec map to the most common.
+var extensionFormats = map[string]string{
+	".flac": FormatFLAC,
+	".mp3":  FormatMP3,
+	".m4a":  FormatAAC,
+	".opus": FormatOpus,
+	".ogg":  FormatVorbis,
+	".oga":  FormatVorbis,
+	".wv":   FormatWavPack,
+	".wav":  FormatWAV,
+}
+
+// IsAudioFile reports whether name has a recognised audio extension. macOS
+// resource-fork artifacts (files starting with "._") are never audio.
+func IsAudioFile(name string) bool {
+	if strings.HasPrefix(name, "._") {
+		return false
+	}
+	_, ok := probers[strings.ToLower(filepath.Ext(name))]
+	return ok
+}
+
+// FormatFromExtension returns the format implied by a file's extension, or ""
+// if the extension isn't recognised. It is used for files that fail to probe.
+func FormatFromExtension(name string) string {
+	return extensionFormats[strings.ToLower(filepath.Ext(name))]
+}
+
+// Probe opens the file at path and reads its tags and stream properties.
+func Probe(path string) (*Info, error) {
+	parse, ok := probers[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return nil, ErrUnsupported
+	}
+
+	f, err := os.Open(path) //nolint:gosec // path comes from walking the music directory
+	if err != nil {
+		return nil, fmt.Errorf("audio: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("audio: stat %s: %w", filepath.Base(path), err)
+	}
+
+	info, err := parse(f, stat.Size())
+	if err != nil {
+		return nil, fmt.Errorf("audio: probe %s: %w", filepath.Base(path), err)
+	}
+
+	if info.Bitrate == 0 && info.Duration > 0 {
+		info.Bitrate = int(float64(stat.Size()) * 8 / info.Duration / 1000)
+	}
+
+	return info, nil
+}
+
+// ---------------------------------------------------------------------------
+// Tag helpers shared by the container parsers
+// ---------------------------------------------------------------------------
+
+// setTag stores a tag value under its normalised field name. Field names are
+// matched case-insensitively against the common Vorbis/APE spellings.
+func (i *Info) setTag(field, value string) {
+	value = strings.TrimSpace(strings.TrimRight(value, "\x00"))
+	if value == "" {
+		return
+	}
+
+	switch strings.ToUpper(field) {
+	case "TITLE":
+		i.Title = value
+	case "ARTIST":
+		i.Artist = value
+	case "ALBUM":
+		i.Album = value
+	case "TRACKNUMBER", "TRACK":
+		i.TrackNumber = parseNumber(value)
+	case "DISCNUMBER", "DISC":
+		i.DiscNumber = parseNumber(value)
+	}
+}
+
+// parseNumber parses track/disc numbers in "3" or "3/12" form, returning 0
+// for anything unparseable.
+func parseNumber(s string) int {
+	if idx := strings.IndexByte(s, '/'); idx >= 0 {
+		s = s[:idx]
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// parseVorbisComment decodes a Vorbis comment block (used by FLAC, Opus and
+// Vorbis) into info.
+func parseVorbisComment(data []byte, info *Info) error {
+	r := bytes.NewReader(data)
+
+	var vendorLen uint32
+	if err := binary.Read(r, binary.LittleEndian, &vendorLen); err != nil {
+		return fmt.Errorf("vorbis comment vendor: %w", errInvalid)
+	}
+	if _, err := r.Seek(int64(vendorLen), io.SeekCurrent); err != nil {
+		return fmt.Errorf("vorbis comment vendor: %w", errInvalid)
+	}
+
+	var count uint32
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return fmt.Errorf("vorbis comment count: %w", errInvalid)
+	}
+
+	for n := uint32(0); n < count; n++ {
+		var length uint32
+		if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+			return fmt.Errorf("vorbis comment length: %w", errInvalid)
+		}
+		if int64(length) > int64(r.Len()) {
+			return fmt.Errorf("vorbis comment %d: %w", n, errInvalid)
+		}
+		comment := make([]byte, length)
+		if _, err := io.ReadFull(r, comment); err != nil {
+			return fmt.Errorf("vorbis comment %d: %w", n, errInvalid)
+		}
+		if key, value, ok := strings.Cut(string(comment), "="); ok {
+			info.setTag(key, value)
+		}
+	}
+
+	return nil
+}
+
+// readAt reads exactly n bytes from r at offset.
+func readAt(r io.ReadSeeker, offset int64, n int) ([]byte, error) {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, errInvalid
+	}
+	return buf, nil
+}
@@ -0,0 +1,137 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFile writes data to name inside a fresh temp directory and returns
+// the full path.
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("writing %s: %v", name, err)
+	}
+	return path
+}
+
+// vorbisComment builds a Vorbis comment block from KEY=value pairs.
+func vorbisComment(comments ...string) []byte {
+	var buf bytes.Buffer
+	vendor := "crescendo-test"
+	binary.Write(&buf, binary.LittleEndian, uint32(len(vendor)))
+	buf.WriteString(vendor)
+	binary.Write(&buf, binary.LittleEndian, uint32(len(comments)))
+	for _, c := range comments {
+		binary.Write(&buf, binary.LittleEndian, uint32(len(c)))
+		buf.WriteString(c)
+	}
+	return buf.Bytes()
+}
+
+// assertInfo compares the fields of got against want, allowing a small
+// tolerance on duration.
+func assertInfo(t *testing.T, got, want *Info) {
+	t.Helper()
+	if got.Format != want.Format {
+		t.Errorf("Format = %q, want %q", got.Format, want.Format)
+	}
+	if got.Title != want.Title || got.Artist != want.Artist || got.Album != want.Album {
+		t.Errorf("tags = %q/%q/%q, want %q/%q/%q", got.Title, got.Artist, got.Album, want.Title, want.Artist, want.Album)
+	}
+	if got.TrackNumber != want.TrackNumber || got.DiscNumber != want.DiscNumber {
+		t.Errorf("track/disc = %d/%d, want %d/%d", got.TrackNumber, got.DiscNumber, want.TrackNumber, want.DiscNumber)
+	}
+	if got.SampleRate != want.SampleRate || got.BitDepth != want.BitDepth || got.Channels != want.Channels {
+		t.Errorf("rate/depth/channels = %d/%d/%d, want %d/%d/%d", got.SampleRate, got.BitDepth, got.Channels, want.SampleRate, want.BitDepth, want.Channels)
+	}
+	if math.Abs(got.Duration-want.Duration) > 0.05 {
+		t.Errorf("Duration = %.3f, want %.3f", got.Duration, want.Duration)
+	}
+}
+
+func TestIsAudioFile(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"01 - Song.flac", true},
+		{"01 - Song.FLAC", true},
+		{"song.mp3", true},
+		{"song.m4a", true},
+		{"song.opus", true},
+		{"song.ogg", true},
+		{"song.wv", true},
+		{"song.wav", true},
+		{"cover.jpg", false},
+		{"notes.txt", false},
+		{"._01.flac", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAudioFile(tt.name); got != tt.want {
+				t.Errorf("IsAudioFile(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatFromExtension(t *testing.T) {
+	if got := FormatFromExtension("a.WV"); got != FormatWavPack {
+		t.Errorf("FormatFromExtension(a.WV) = %q, want %q", got, FormatWavPack)
+	}
+	if got := FormatFromExtension("a.jpg"); got != "" {
+		t.Errorf("FormatFromExtension(a.jpg) = %q, want empty", got)
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := map[string]int{"3": 3, "03/12": 3, " 7 ": 7, "A1": 0, "": 0, "-1": 0}
+	for in, want := range tests {
+		if got := parseNumber(in); got != want {
+			t.Errorf("parseNumber(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestProbe_Errors(t *testing.T) {
+	t.Run("unsupported extension", func(t *testing.T) {
+		path := writeFile(t, "cover.jpg", []byte{0xFF, 0xD8})
+		if _, err := Probe(path); !errors.Is(err, ErrUnsupported) {
+			t.Fatalf("Probe() error = %v, want ErrUnsupported", err)
+		}
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		path := writeFile(t, "empty.flac", nil)
+		if _, err := Probe(path); err == nil {
+			t.Fatal("Probe() on empty file returned nil error")
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := Probe(filepath.Join(t.TempDir(), "gone.mp3")); err == nil {
+			t.Fatal("Probe() on missing file returned nil error")
+		}
+	})
+}
+
+func TestIsLossless(t *testing.T) {
+	for _, f := range []string{FormatFLAC, FormatALAC, FormatWavPack, FormatWAV} {
+		if !IsLossless(f) {
+			t.Errorf("IsLossless(%q) = false, want true", f)
+		}
+	}
+	for _, f := range []string{FormatMP3, FormatAAC, FormatOpus, FormatVorbis} {
+		if IsLossless(f) {
+			t.Errorf("IsLossless(%q) = true, want false", f)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// FLAC metadata block types.
+const (
+	flacStreamInfo    = 0
+	flacVorbisComment = 4
+)
+
+// maxMetadataBlock bounds the size of a metadata block read into memory.
+const maxMetadataBlock = 16 << 20
+
+// streamInfo holds the fields of a FLAC STREAMINFO block.
+type streamInfo struct {
+	MinBlockSize  int
+	MaxBlockSize  int
+	SampleRate    int
+	Channels      int
+	BitsPerSample int
+	TotalSamples  uint64
+	MD5           [16]byte
+}
+
+// probeFLAC reads the STREAMINFO and VORBIS_COMMENT blocks of a FLAC file.
+func probeFLAC(r io.ReadSeeker, _ int64) (*Info, error) {
+	info := &Info{Format: FormatFLAC}
+	si, err := readFLACMetadata(r, info)
+	if err != nil {
+		return nil, err
+	}
+
+	info.SampleRate = si.SampleRate
+	info.Channels = si.Channels
+	info.BitDepth = si.BitsPerSample
+	if si.SampleRate > 0 {
+		info.Duration = float64(si.TotalSamples) / float64(si.SampleRate)
+	}
+	return info, nil
+}
+
+// readFLACMetadata reads the metadata blocks of a FLAC file, storing its
+// Vorbis comments in info, and returns the STREAMINFO. It leaves r at the
+// first audio frame.
+func readFLACMetadata(r io.ReadSeeker, info *Info) (*streamInfo, error) {
+	start, err := skipID3v2(r)
+	if err != nil {
+		return nil, err
+	}
+
+	magic, err := readAt(r, start, 4)
+	if err != nil || string(magic) != "fLaC" {
+		return nil, fmt.Errorf("missing fLaC marker: %w", errInvalid)
+	}
+
+	var si *streamInfo
+	for {
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, fmt.Errorf("metadata block header: %w", errInvalid)
+		}
+		last := header[0]&0x80 != 0
+		blockType := header[0] & 0x7f
+		length := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+
+		switch blockType {
+		case flacStreamInfo, flacVorbisComment:
+			if length > maxMetadataBlock {
+				return nil, fmt.Errorf("metadata block too large: %w", errInvalid)
+			}
+			body := make([]byte, length)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("metadata block body: %w", errInvalid)
+			}
+			if blockType == flacStreamInfo {
+				if si, err = parseStreamInfo(body); err != nil {
+					return nil, err
+				}
+			} else if err := parseVorbisComment(body, info); err != nil {
+				return nil, err
+			}
+		default:
+			if _, err := r.Seek(int64(length), io.SeekCurrent); err != nil {
+				return nil, fmt.Errorf("skipping metadata block: %w", err)
+			}
+		}
+
+		if last {
+			break
+		}
+	}
+
+	if si == nil {
+		return nil, fmt.Errorf("missing STREAMINFO: %w", errInvalid)
+	}
+	return si, nil
+}
+
+// parseStreamInfo decodes the 34-byte STREAMINFO block body.
+func parseStreamInfo(b []byte) (*streamInfo, error) {
+	if len(b) < 34 {
+		return nil, fmt.Errorf("short STREAMINFO: %w", errInvalid)
+	}
+
+	si := &streamInfo{
+		MinBlockSize:  int(binary.BigEndian.Uint16(b[0:2])),
+		MaxBlockSize:  int(binary.BigEndian.Uint16(b[2:4])),
+		SampleRate:    int(b[10])<<12 | int(b[11])<<4 | int(b[12])>>4,
+		Channels:      int(b[12]>>1&0x07) + 1,
+		BitsPerSample: int(b[12]&0x01)<<4 | int(b[13]>>4) + 1,
+		TotalSamples:  uint64(b[13]&0x0f)<<32 | uint64(binary.BigEndian.Uint32(b[14:18])),
+	}
+	copy(si.MD5[:], b[18:34])
+	return si, nil
+}
@@ -0,0 +1,78 @@
+package audio
+
+import (
+	"bytes"
+	"testing"
+)
+
+// buildFLACHeader returns a fLaC marker, STREAMINFO block and (optionally) a
+// VORBIS_COMMENT block. No audio frames follow.
+func buildFLACHeader(sampleRate, channels, bps int, totalSamples uint64, comments ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("fLaC")
+
+	si := make([]byte, 34)
+	si[0], si[1] = 0x10, 0x00 // min block size 4096
+	si[2], si[3] = 0x10, 0x00 // max block size 4096
+	si[10] = byte(sampleRate >> 12)
+	si[11] = byte(sampleRate >> 4)
+	si[12] = byte(sampleRate<<4) | byte(channels-1)<<1 | byte((bps-1)>>4)
+	si[13] = byte((bps-1)<<4) | byte(totalSamples>>32&0x0f)
+	si[14] = byte(totalSamples >> 24)
+	si[15] = byte(totalSamples >> 16)
+	si[16] = byte(totalSamples >> 8)
+	si[17] = byte(totalSamples)
+
+	last := byte(0)
+	if len(comments) == 0 {
+		last = 0x80
+	}
+	buf.Write([]byte{last | flacStreamInfo, 0, 0, 34})
+	buf.Write(si)
+
+	if len(comments) > 0 {
+		vc := vorbisComment(comments...)
+		buf.Write([]byte{0x80 | flacVorbisComment, byte(len(vc) >> 16), byte(len(vc) >> 8), byte(len(vc))})
+		buf.Write(vc)
+	}
+
+	return buf.Bytes()
+}
+
+func TestProbeFLAC(t *testing.T) {
+	data := buildFLACHeader(96000, 2, 24, 96000*185,
+		"TITLE=Airbag", "ARTIST=Radiohead", "ALBUM=OK Computer", "TRACKNUMBER=1/12", "DISCNUMBER=1")
+	path := writeFile(t, "01 - Airbag.flac", data)
+
+	got, err := Probe(path)
+	if err != nil {
+		t.Fatalf("Probe() returned unexpected error: %v", err)
+	}
+
+	assertInfo(t, got, &Info{
+		Format: FormatFLAC, Title: "Airbag", Artist: "Radiohead", Album: "OK Computer",
+		TrackNumber: 1, DiscNumber: 1, Duration: 185, SampleRate: 96000, BitDepth: 24, Channels: 2,
+	})
+}
+
+func TestProbeFLAC_WithID3Prefix(t *testing.T) {
+	id3 := []byte{'I', 'D', '3', 3, 0, 0, 0, 0, 0, 10}
+	id3 = append(id3, make([]byte, 10)...)
+	data := append(id3, buildFLACHeader(44100, 2, 16, 44100*60)...)
+	path := writeFile(t, "tagged.flac", data)
+
+	got, err := Probe(path)
+	if err != nil {
+		t.Fatalf("Probe() returned unexpected error: %v", err)
+	}
+	if got.SampleRate != 44100 || got.BitDepth != 16 {
+		t.Errorf("rate/depth = %d/%d, want 44100/16", got.SampleRate, got.BitDepth)
+	}
+}
+
+func TestProbeFLAC_BadMagic(t *testing.T) {
+	path := writeFile(t, "fake.flac", []byte("RIFF0000WAVE"))
+	if _, err := Probe(path); err == nil {
+		t.Fatal("Probe() on non-FLAC data returned nil error")
+	}
+}
@@ -0,0 +1,353 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// MPEG layer III bitrates in kbit/s, indexed by the header's bitrate field.
+var (
+	mpeg1L3Bitrates = [16]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}
+	mpeg2L3Bitrates = [16]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0}
+)
+
+// MPEG sample rates in Hz, indexed by version then sample-rate field.
+var mpegSampleRates = map[int][3]int{
+	1:  {44100, 48000, 32000},
+	2:  {22050, 24000, 16000},
+	25: {11025, 12000, 8000},
+}
+
+// id3Frames maps ID3v2.3/2.4 and ID3v2.2 frame IDs to tag fields.
+var id3Frames = map[string]string{
+	"TIT2": "TITLE", "TT2": "TITLE",
+	"TPE1": "ARTIST", "TP1": "ARTIST",
+	"TALB": "ALBUM", "TAL": "ALBUM",
+	"TRCK": "TRACK", "TRK": "TRACK",
+	"TPOS": "DISC", "TPA": "DISC",
+}
+
+// mpegFrame holds the fields of an MPEG audio frame header needed to derive
+// stream properties.
+type mpegFrame struct {
+	version    int // 1, 2 or 25 (MPEG 2.5)
+	bitrate    int // kbit/s
+	sampleRate int
+	channels   int
+}
+
+// samplesPerFrame returns the number of samples in a layer III frame.
+func (f mpegFrame) samplesPerFrame() int {
+	if f.version == 1 {
+		return 1152
+	}
+	return 576
+}
+
+// sideInfoSize returns the length of the layer III side information that
+// precedes a Xing/Info header.
+func (f mpegFrame) sideInfoSize() int {
+	switch {
+	case f.version == 1 && f.channels == 1:
+		return 17
+	case f.version == 1:
+		return 32
+	case f.channels == 1:
+		return 9
+	default:
+		return 17
+	}
+}
+
+// probeMP3 reads ID3 tags and the first MPEG frame of an MP3 file. Duration
+// comes from a Xing/Info or VBRI header when present, otherwise it is
+// estimated from the file size and the first frame's bitrate.
+func probeMP3(r io.ReadSeeker, size int64) (*Info, error) {
+	info := &Info{Format: FormatMP3}
+
+	audioStart, err := readID3v2(r, info)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := r.Seek(audioStart, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("seeking to audio: %w", err)
+	}
+	buf := make([]byte, 64<<10)
+	n, _ := io.ReadFull(r, buf)
+	buf = buf[:n]
+
+	offset, frame, ok := findMPEGFrame(buf)
+	if !ok {
+		return nil, fmt.Errorf("no MPEG frame found: %w", errInvalid)
+	}
+
+	info.SampleRate = frame.sampleRate
+	info.Channels = frame.channels
+	info.Bitrate = frame.bitrate
+
+	hasID3v1 := readID3v1(r, size, info)
+
+	if frames, ok := vbrFrameCount(buf[offset:], frame); ok {
+		info.Duration = float64(frames) * float64(frame.samplesPerFrame()) / float64(frame.sampleRate)
+		info.Bitrate = 0 // recomputed from file size by Probe
+	} else if frame.bitrate > 0 {
+		audioBytes := size - audioStart - int64(offset)
+		if hasID3v1 {
+			audioBytes -= 128
+		}
+		info.Duration = float64(audioBytes) * 8 / float64(frame.bitrate*1000)
+	}
+
+	return info, nil
+}
+
+// findMPEGFrame locates the first valid layer III frame header in buf.
+func findMPEGFrame(buf []byte) (int, mpegFrame, bool) {
+	for i := 0; i+4 <= len(buf); i++ {
+		if buf[i] != 0xFF || buf[i+1]&0xE0 != 0xE0 {
+			continue
+		}
+		if frame, ok := parseMPEGHeader(buf[i : i+4]); ok {
+			return i, frame, true
+		}
+	}
+	return 0, mpegFrame{}, false
+}
+
+// parseMPEGHeader decodes a 4-byte layer III frame header.
+func parseMPEGHeader(h []byte) (mpegFrame, bool) {
+	var version int
+	switch h[1] >> 3 & 0x03 {
+	case 0:
+		version = 25
+	case 2:
+		version = 2
+	case 3:
+		version = 1
+	default:
+		return mpegFrame{}, false
+	}
+
+	if h[1]>>1&0x03 != 1 { // layer III only
+		return mpegFrame{}, false
+	}
+
+	bitrateIdx := h[2] >> 4
+	rateIdx := h[2] >> 2 & 0x03
+	if bitrateIdx == 0x0F || rateIdx == 0x03 {
+		return mpegFrame{}, false
+	}
+
+	frame := mpegFrame{
+		version:    version,
+		sampleRate: mpegSampleRates[version][rateIdx],
+		channels:   2,
+	}
+	if version == 1 {
+		frame.bitrate = mpeg1L3Bitrates[bitrateIdx]
+	} else {
+		frame.bitrate = mpeg2L3Bitrates[bitrateIdx]
+	}
+	if h[3]>>6 == 0x03 {
+		frame.channels = 1
+	}
+
+	return frame, true
+}
+
+// vbrFrameCount reads the total frame count from a Xing/Info or VBRI header
+// in the first frame, if one is present.
+func vbrFrameCount(frameData []byte, frame mpegFrame) (uint32, bool) {
+	xing := 4 + frame.sideInfoSize()
+	if len(frameData) >= xing+12 {
+		tag := string(frameData[xing : xing+4])
+		if tag == "Xing" || tag == "Info" {
+			flags := binary.BigEndian.Uint32(frameData[xing+4 : xing+8])
+			if flags&0x01 != 0 {
+				return binary.BigEndian.Uint32(frameData[xing+8 : xing+12]), true
+			}
+		}
+	}
+
+	const vbri = 4 + 32
+	if len(frameData) >= vbri+18 && string(frameData[vbri:vbri+4]) == "VBRI" {
+		return binary.BigEndian.Uint32(frameData[vbri+14 : vbri+18]), true
+	}
+
+	return 0, false
+}
+
+// skipID3v2 returns the offset just past an ID3v2 tag at the start of r, or 0
+// if there is none. Some FLAC files carry an ID3v2 tag before the fLaC marker.
+func skipID3v2(r io.ReadSeeker) (int64, error) {
+	header, err := readAt(r, 0, 10)
+	if err != nil {
+		return 0, fmt.Errorf("reading header: %w", errInvalid)
+	}
+	if string(header[0:3]) != "ID3" {
+		return 0, nil
+	}
+	return id3TagEnd(header), nil
+}
+
+// id3TagEnd returns the total length of an ID3v2 tag from its 10-byte header.
+func id3TagEnd(header []byte) int64 {
+	end := int64(10 + syncsafe(header[6:10]))
+	if header[5]&0x10 != 0 { // footer present
+		end += 10
+	}
+	return end
+}
+
+// readID3v2 parses an ID3v2 tag at the start of r into info and returns the
+// offset where the audio data begins.
+func readID3v2(r io.ReadSeeker, info *Info) (int64, error) {
+	header, err := readAt(r, 0, 10)
+	if err != nil {
+		return 0, fmt.Errorf("reading header: %w", errInvalid)
+	}
+	if string(header[0:3]) != "ID3" {
+		return 0, nil
+	}
+
+	major := header[3]
+	flags := header[5]
+	tagSize := syncsafe(header[6:10])
+	end := id3TagEnd(header)
+
+	if tagSize > maxMetadataBlock {
+		return end, nil // implausibly large; skip without parsing
+	}
+	data := make([]byte, tagSize)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, fmt.Errorf("reading ID3v2 tag: %w", errInvalid)
+	}
+
+	if flags&0x80 != 0 && major < 4 {
+		data = bytes.ReplaceAll(data, []byte{0xFF, 0x00}, []byte{0xFF})
+	}
+
+	if flags&0x40 != 0 && len(data) >= 4 { // extended header
+		var ext int
+		if major == 4 {
+			ext = syncsafe(data[0:4])
+		} else {
+			ext = int(binary.BigEndian.Uint32(data[0:4])) + 4
+		}
+		if ext > len(data) {
+			return end, nil
+		}
+		data = data[ext:]
+	}
+
+	idLen, headerLen := 4, 10
+	if major == 2 {
+		idLen, headerLen = 3, 6
+	}
+
+	for len(data) >= headerLen && data[0] != 0 {
+		id := string(data[:idLen])
+		var size int
+		switch major {
+		case 2:
+			size = int(data[3])<<16 | int(data[4])<<8 | int(data[5])
+		case 4:
+			size = syncsafe(data[4:8])
+		default:
+			size = int(binary.BigEndian.Uint32(data[4:8]))
+		}
+		if size < 0 || headerLen+size > len(data) {
+			break
+		}
+
+		if field, ok := id3Frames[id]; ok {
+			info.setTag(field, decodeID3Text(data[headerLen:headerLen+size]))
+		}
+		data = data[headerLen+size:]
+	}
+
+	return end, nil
+}
+
+// readID3v1 fills any tags missing from info using an ID3v1 tag at the end
+// of the file, reporting whether one was present.
+func readID3v1(r io.ReadSeeker, size int64, info *Info) bool {
+	if size < 128 {
+		return false
+	}
+	tag, err := readAt(r, size-128, 128)
+	if err != nil || string(tag[0:3]) != "TAG" {
+		return false
+	}
+
+	fill := func(current *string, raw []byte) {
+		if *current == "" {
+			*current = strings.TrimSpace(strings.TrimRight(string(raw), "\x00 "))
+		}
+	}
+	fill(&info.Title, tag[3:33])
+	fill(&info.Artist, tag[33:63])
+	fill(&info.Album, tag[63:93])
+	if info.TrackNumber == 0 && tag[125] == 0 && tag[126] != 0 {
+		info.TrackNumber = int(tag[126])
+	}
+	return true
+}
+
+// decodeID3Text decodes an ID3v2 text frame body according to its leading
+// encoding byte.
+func decodeID3Text(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	enc, text := body[0], body[1:]
+
+	switch enc {
+	case 0: // ISO-8859-1
+		runes := make([]rune, len(text))
+		for i, b := range text {
+			runes[i] = rune(b)
+		}
+		return trimNull(string(runes))
+	case 1, 2: // UTF-16 with BOM, UTF-16BE
+		bigEndian := enc == 2
+		if len(text) >= 2 {
+			switch {
+			case text[0] == 0xFF && text[1] == 0xFE:
+				bigEndian, text = false, text[2:]
+			case text[0] == 0xFE && text[1] == 0xFF:
+				bigEndian, text = true, text[2:]
+			}
+		}
+		units := make([]uint16, 0, len(text)/2)
+		for i := 0; i+1 < len(text); i += 2 {
+			if bigEndian {
+				units = append(units, binary.BigEndian.Uint16(text[i:]))
+			} else {
+				units = append(units, binary.LittleEndian.Uint16(text[i:]))
+			}
+		}
+		return trimNull(string(utf16.Decode(units)))
+	default: // UTF-8
+		return trimNull(string(text))
+	}
+}
+
+// trimNull cuts a string at its first NUL. ID3v2.4 separates multiple values
+// with NULs; only the first is kept.
+func trimNull(s string) string {
+	if idx := strings.IndexByte(s, 0); idx >= 0 {
+		s = s[:idx]
+	}
+	return s
+}
+
+// syncsafe decodes a 4-byte ID3v2 synchsafe integer.
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7f)<<21 | int(b[1]&0x7f)<<14 | int(b[2]&0x7f)<<7 | int(b[3]&0x7f)
+}
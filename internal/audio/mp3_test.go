@@ -0,0 +1,120 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+)
+
+// id3v2Frame builds an ID3v2.3 text frame.
+func id3v2Frame(id string, encoding byte, text []byte) []byte {
+	body := append([]byte{encoding}, text...)
+	frame := []byte(id)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(body)))
+	frame = append(frame, 0, 0)
+	return append(frame, body...)
+}
+
+// id3v2Tag wraps frames in an ID3v2.3 header.
+func id3v2Tag(frames ...[]byte) []byte {
+	body := bytes.Join(frames, nil)
+	size := len(body)
+	header := []byte{'I', 'D', '3', 3, 0, 0,
+		byte(size >> 21 & 0x7f), byte(size >> 14 & 0x7f), byte(size >> 7 & 0x7f), byte(size & 0x7f)}
+	return append(header, body...)
+}
+
+// utf16WithBOM encodes s as little-endian UTF-16 with a byte order mark.
+func utf16WithBOM(s string) []byte {
+	out := []byte{0xFF, 0xFE}
+	for _, u := range utf16.Encode([]rune(s)) {
+		out = binary.LittleEndian.AppendUint16(out, u)
+	}
+	return out
+}
+
+// mpeg1Frame returns a single MPEG-1 layer III frame of the given bitrate at
+// 44.1 kHz stereo, optionally carrying a Xing header with a frame count.
+func mpeg1Frame(bitrateIdx byte, xingFrames uint32) []byte {
+	frame := make([]byte, 417)
+	frame[0], frame[1], frame[2], frame[3] = 0xFF, 0xFB, bitrateIdx<<4, 0x00
+	if xingFrames > 0 {
+		copy(frame[4+32:], "Xing")
+		binary.BigEndian.PutUint32(frame[4+32+4:], 0x01)
+		binary.BigEndian.PutUint32(frame[4+32+8:], xingFrames)
+	}
+	return frame
+}
+
+func TestProbeMP3_CBR(t *testing.T) {
+	tag := id3v2Tag(
+		id3v2Frame("TIT2", 3, []byte("Teardrop")),
+		id3v2Frame("TPE1", 1, utf16WithBOM("Massive Attack")),
+		id3v2Frame("TALB", 0, []byte("Mezzanine")),
+		id3v2Frame("TRCK", 3, []byte("3/11")),
+		id3v2Frame("TPOS", 3, []byte("1/1")),
+	)
+
+	// 128 kbit/s (index 9): 40 frames of 417 bytes ≈ 1.04 s.
+	var audio []byte
+	for i := 0; i < 40; i++ {
+		audio = append(audio, mpeg1Frame(9, 0)...)
+	}
+	path := writeFile(t, "03 - Teardrop.mp3", append(tag, audio...))
+
+	got, err := Probe(path)
+	if err != nil {
+		t.Fatalf("Probe() returned unexpected error: %v", err)
+	}
+
+	assertInfo(t, got, &Info{
+		Format: FormatMP3, Title: "Teardrop", Artist: "Massive Attack", Album: "Mezzanine",
+		TrackNumber: 3, DiscNumber: 1, Duration: float64(len(audio)) * 8 / 128000,
+		SampleRate: 44100, Channels: 2,
+	})
+	if got.Bitrate != 128 {
+		t.Errorf("Bitrate = %d, want 128", got.Bitrate)
+	}
+}
+
+func TestProbeMP3_XingVBR(t *testing.T) {
+	// Xing header claims 1000 frames: 1000 * 1152 / 44100 ≈ 26.12 s.
+	data := mpeg1Frame(9, 1000)
+	path := writeFile(t, "vbr.mp3", data)
+
+	got, err := Probe(path)
+	if err != nil {
+		t.Fatalf("Probe() returned unexpected error: %v", err)
+	}
+	want := 1000.0 * 1152 / 44100
+	if got.Duration < want-0.05 || got.Duration > want+0.05 {
+		t.Errorf("Duration = %.3f, want %.3f", got.Duration, want)
+	}
+}
+
+func TestProbeMP3_ID3v1Fallback(t *testing.T) {
+	data := mpeg1Frame(9, 0)
+	v1 := make([]byte, 128)
+	copy(v1, "TAG")
+	copy(v1[3:], "Angel")
+	copy(v1[33:], "Massive Attack")
+	copy(v1[63:], "Mezzanine")
+	v1[126] = 1
+	path := writeFile(t, "v1.mp3", append(data, v1...))
+
+	got, err := Probe(path)
+	if err != nil {
+		t.Fatalf("Probe() returned unexpected error: %v", err)
+	}
+	if got.Title != "Angel" || got.Artist != "Massive Attack" || got.TrackNumber != 1 {
+		t.Errorf("tags = %q/%q/%d, want Angel/Massive Attack/1", got.Title, got.Artist, got.TrackNumber)
+	}
+}
+
+func TestProbeMP3_NoFrame(t *testing.T) {
+	path := writeFile(t, "junk.mp3", bytes.Repeat([]byte{0x00}, 512))
+	if _, err := Probe(path); err == nil {
+		t.Fatal("Probe() on data without MPEG frames returned nil error")
+	}
+}
@@ -0,0 +1,266 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// maxMoovSize bounds the size of the moov box read into memory. It is large
+// enough for embedded cover art.
+const maxMoovSize = 64 << 20
+
+// mp4Tags maps iTunes-style ilst item types to tag fields.
+var mp4Tags = map[string]string{
+	"\xa9nam": "TITLE",
+	"\xa9ART": "ARTIST",
+	"\xa9alb": "ALBUM",
+}
+
+// probeMP4 reads the moov box of an MP4/M4A file to find the audio codec
+// (ALAC or AAC), its stream properties and iTunes-style tags.
+func probeMP4(r io.ReadSeeker, size int64) (*Info, error) {
+	moov, err := findTopLevelBox(r, size, "moov")
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{}
+	var movieDuration float64
+
+	err = forEachBox(moov, func(typ string, body []byte) error {
+		switch typ {
+		case "mvhd":
+			movieDuration = parseMediaDuration(body, 12)
+		case "trak":
+			return parseMP4Track(body, info)
+		case "udta":
+			return forEachBox(body, func(typ string, body []byte) error {
+				if typ != "meta" || len(body) < 4 {
+					return nil
+				}
+				return forEachBox(body[4:], func(typ string, body []byte) error {
+					if typ == "ilst" {
+						parseILST(body, info)
+					}
+					return nil
+				})
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Format == "" {
+		return nil, fmt.Errorf("no ALAC or AAC audio track: %w", ErrUnsupported)
+	}
+	if info.Duration == 0 {
+		info.Duration = movieDuration
+	}
+	return info, nil
+}
+
+// findTopLevelBox scans the top-level boxes of r and returns the body of the
+// first box of the given type.
+func findTopLevelBox(r io.ReadSeeker, size int64, want string) ([]byte, error) {
+	var offset int64
+	for offset+8 <= size {
+		header, err := readAt(r, offset, 8)
+		if err != nil {
+			return nil, err
+		}
+		boxSize := int64(binary.BigEndian.Uint32(header[0:4]))
+		typ := string(header[4:8])
+		headerLen := int64(8)
+
+		switch boxSize {
+		case 0:
+			boxSize = size - offset
+		case 1:
+			ext, err := readAt(r, offset+8, 8)
+			if err != nil {
+				return nil, err
+			}
+			boxSize = int64(binary.BigEndian.Uint64(ext)) //nolint:gosec // validated below
+			headerLen = 16
+		}
+		if boxSize < headerLen || offset+boxSize > size {
+			return nil, fmt.Errorf("box %q overruns file: %w", typ, errInvalid)
+		}
+
+		if typ == want {
+			if boxSize-headerLen > maxMoovSize {
+				return nil, fmt.Errorf("box %q too large: %w", typ, errInvalid)
+			}
+			return readAt(r, offset+headerLen, int(boxSize-headerLen))
+		}
+		offset += boxSize
+	}
+	return nil, fmt.Errorf("no %s box: %w", want, errInvalid)
+}
+
+// forEachBox calls fn for each box in data, which must consist entirely of
+// consecutive boxes.
+func forEachBox(data []byte, fn func(typ string, body []byte) error) error {
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data[0:4]))
+		typ := string(data[4:8])
+		headerLen := uint64(8)
+
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return fmt.Errorf("box %q header: %w", typ, errInvalid)
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			headerLen = 16
+		}
+		if size < headerLen || size > uint64(len(data)) {
+			return fmt.Errorf("box %q overruns parent: %w", typ, errInvalid)
+		}
+
+		if err := fn(typ, data[headerLen:size]); err != nil {
+			return err
+		}
+		data = data[size:]
+	}
+	return nil
+}
+
+// parseMediaDuration decodes the timescale and duration from an mvhd or mdhd
+// full box. offsetV0 is the timescale's offset in a version 0 box.
+func parseMediaDuration(body []byte, offsetV0 int) float64 {
+	if len(body) < 4 {
+		return 0
+	}
+	var timescale uint32
+	var duration uint64
+	if body[0] == 1 {
+		off := offsetV0 + 8 // creation and modification times are 64-bit
+		if len(body) < off+12 {
+			return 0
+		}
+		timescale = binary.BigEndian.Uint32(body[off : off+4])
+		duration = binary.BigEndian.Uint64(body[off+4 : off+12])
+	} else {
+		if len(body) < offsetV0+8 {
+			return 0
+		}
+		timescale = binary.BigEndian.Uint32(body[offsetV0 : offsetV0+4])
+		duration = uint64(binary.BigEndian.Uint32(body[offsetV0+4 : offsetV0+8]))
+	}
+	if timescale == 0 {
+		return 0
+	}
+	return float64(duration) / float64(timescale)
+}
+
+// parseMP4Track fills info from a trak box if it contains an ALAC or AAC
+// sample description. Other tracks (video, chapters) are ignored.
+func parseMP4Track(trak []byte, info *Info) error {
+	var duration float64
+	var found bool
+
+	err := forEachBox(trak, func(typ string, body []byte) error {
+		if typ != "mdia" {
+			return nil
+		}
+		return forEachBox(body, func(typ string, body []byte) error {
+			switch typ {
+			case "mdhd":
+				duration = parseMediaDuration(body, 12)
+			case "minf":
+				return forEachBox(body, func(typ string, body []byte) error {
+					if typ != "stbl" {
+						return nil
+					}
+					return forEachBox(body, func(typ string, body []byte) error {
+						if typ == "stsd" && len(body) >= 8 {
+							found = parseSampleDescription(body[8:], info)
+						}
+						return nil
+					})
+				})
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if found {
+		info.Duration = duration
+	}
+	return nil
+}
+
+// parseSampleDescription reads the first audio sample entry of an stsd box.
+func parseSampleDescription(entries []byte, info *Info) bool {
+	found := false
+	_ = forEachBox(entries, func(typ string, body []byte) error {
+		if found || (typ != "alac" && typ != "mp4a") || len(body) < 28 {
+			return nil
+		}
+
+		info.Channels = int(binary.BigEndian.Uint16(body[16:18]))
+		info.BitDepth = int(binary.BigEndian.Uint16(body[18:20]))
+		info.SampleRate = int(binary.BigEndian.Uint32(body[24:28]) >> 16)
+		found = true
+
+		if typ == "mp4a" {
+			info.Format = FormatAAC
+			info.BitDepth = 0
+			return nil
+		}
+
+		info.Format = FormatALAC
+		// The nested alac box carries the authoritative ALAC config,
+		// including sample rates above 65535 Hz.
+		if version := binary.BigEndian.Uint16(body[8:10]); version != 0 {
+			return nil
+		}
+		return forEachBox(body[28:], func(typ string, cfg []byte) error {
+			if typ == "alac" && len(cfg) >= 28 {
+				info.BitDepth = int(cfg[9])
+				info.Channels = int(cfg[13])
+				info.SampleRate = int(binary.BigEndian.Uint32(cfg[24:28]))
+			}
+			return nil
+		})
+	})
+	return found
+}
+
+// parseILST reads iTunes-style metadata items.
+func parseILST(ilst []byte, info *Info) {
+	_ = forEachBox(ilst, func(item string, body []byte) error {
+		return forEachBox(body, func(typ string, data []byte) error {
+			if typ != "data" || len(data) < 8 {
+				return nil
+			}
+			value := data[8:]
+
+			switch item {
+			case "trkn", "disk":
+				if len(value) >= 4 {
+					n := int(binary.BigEndian.Uint16(value[2:4]))
+					if item == "trkn" {
+						info.TrackNumber = n
+					} else {
+						info.DiscNumber = n
+					}
+				}
+			default:
+				if field, ok := mp4Tags[item]; ok {
+					info.setTag(field, string(value))
+				}
+			}
+			return nil
+		})
+	})
+}
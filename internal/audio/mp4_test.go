@@ -0,0 +1,102 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// box builds an MP4 box from a type and concatenated children/body bytes.
+func box(typ string, body ...[]byte) []byte {
+	payload := bytes.Join(body, nil)
+	out := binary.BigEndian.AppendUint32(nil, uint32(8+len(payload)))
+	out = append(out, typ...)
+	return append(out, payload...)
+}
+
+// mdhd builds a version 0 media header box.
+func mdhd(timescale, duration uint32) []byte {
+	body := make([]byte, 24)
+	binary.BigEndian.PutUint32(body[12:], timescale)
+	binary.BigEndian.PutUint32(body[16:], duration)
+	return box("mdhd", body)
+}
+
+// audioSampleEntry builds an alac or mp4a sample entry with optional children.
+func audioSampleEntry(typ string, channels, sampleSize uint16, sampleRate uint32, children ...[]byte) []byte {
+	body := make([]byte, 28)
+	binary.BigEndian.PutUint16(body[6:], 1)
+	binary.BigEndian.PutUint16(body[16:], channels)
+	binary.BigEndian.PutUint16(body[18:], sampleSize)
+	binary.BigEndian.PutUint32(body[24:], sampleRate<<16)
+	return box(typ, append([][]byte{body}, children...)...)
+}
+
+// ilstItem builds an iTunes metadata item holding a single data box.
+func ilstItem(typ string, value []byte) []byte {
+	data := make([]byte, 8)
+	data[3] = 1 // UTF-8
+	return box(typ, box("data", data, value))
+}
+
+// buildM4A assembles a minimal M4A file around the given sample entry.
+func buildM4A(entry []byte, timescale, duration uint32, items ...[]byte) []byte {
+	stsd := box("stsd", []byte{0, 0, 0, 0, 0, 0, 0, 1}, entry)
+	trak := box("trak", box("mdia", mdhd(timescale, duration), box("minf", box("stbl", stsd))))
+	udta := box("udta", box("meta", []byte{0, 0, 0, 0}, box("ilst", items...)))
+
+	var file []byte
+	file = append(file, box("ftyp", []byte("M4A "), make([]byte, 4))...)
+	file = append(file, box("mdat", make([]byte, 64))...)
+	file = append(file, box("moov", trak, udta)...)
+	return file
+}
+
+func TestProbeMP4_ALAC(t *testing.T) {
+	cfg := make([]byte, 28)
+	cfg[9] = 24 // bit depth
+	cfg[13] = 2 // channels
+	binary.BigEndian.PutUint32(cfg[24:], 96000)
+	entry := audioSampleEntry("alac", 2, 16, 0, box("alac", cfg))
+
+	trkn := []byte{0, 0, 0, 4, 0, 10, 0, 0}
+	disk := []byte{0, 0, 0, 2, 0, 2}
+	data := buildM4A(entry, 96000, 96000*240,
+		ilstItem("\xa9nam", []byte("Hyperballad")),
+		ilstItem("\xa9ART", []byte("Björk")),
+		ilstItem("\xa9alb", []byte("Post")),
+		ilstItem("trkn", trkn),
+		ilstItem("disk", disk),
+	)
+	path := writeFile(t, "04 Hyperballad.m4a", data)
+
+	got, err := Probe(path)
+	if err != nil {
+		t.Fatalf("Probe() returned unexpected error: %v", err)
+	}
+	assertInfo(t, got, &Info{
+		Format: FormatALAC, Title: "Hyperballad", Artist: "Björk", Album: "Post",
+		TrackNumber: 4, DiscNumber: 2, Duration: 240, SampleRate: 96000, BitDepth: 24, Channels: 2,
+	})
+}
+
+func TestProbeMP4_AAC(t *testing.T) {
+	entry := audioSampleEntry("mp4a", 2, 16, 44100, box("esds", make([]byte, 20)))
+	data := buildM4A(entry, 44100, 44100*30)
+	path := writeFile(t, "aac.m4a", data)
+
+	got, err := Probe(path)
+	if err != nil {
+		t.Fatalf("Probe() returned unexpected error: %v", err)
+	}
+	assertInfo(t, got, &Info{Format: FormatAAC, Duration: 30, SampleRate: 44100, Channels: 2})
+}
+
+func TestProbeMP4_NoAudioTrack(t *testing.T) {
+	data := box("ftyp", []byte("M4A "))
+	data = append(data, box("moov", box("mvhd", make([]byte, 100)))...)
+	path := writeFile(t, "video.m4a", data)
+	if _, err := Probe(path); err == nil {
+		t.Fatal("Probe() on file without audio track returned nil error")
+	}
+}
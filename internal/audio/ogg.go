@@ -0,0 +1,160 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// opusGranuleRate is the fixed rate of Opus granule positions.
+const opusGranuleRate = 48000
+
+// oggPacketReader reassembles logical packets from the pages of an Ogg
+// stream. It only follows the first logical bitstream.
+type oggPacketReader struct {
+	r       io.Reader
+	serial  uint32
+	started bool
+	pending [][]byte // complete packets not yet returned
+	partial []byte   // packet continuing onto the next page
+}
+
+// next returns the next complete packet.
+func (p *oggPacketReader) next() ([]byte, error) {
+	for len(p.pending) == 0 {
+		if err := p.readPage(); err != nil {
+			return nil, err
+		}
+	}
+	pkt := p.pending[0]
+	p.pending = p.pending[1:]
+	return pkt, nil
+}
+
+// readPage reads one page and splits its segments into packets.
+func (p *oggPacketReader) readPage() error {
+	header := make([]byte, 27)
+	if _, err := io.ReadFull(p.r, header); err != nil {
+		return fmt.Errorf("ogg page header: %w", errInvalid)
+	}
+	if string(header[0:4]) != "OggS" {
+		return fmt.Errorf("missing OggS capture pattern: %w", errInvalid)
+	}
+
+	serial := binary.LittleEndian.Uint32(header[14:18])
+	segments := make([]byte, header[26])
+	if _, err := io.ReadFull(p.r, segments); err != nil {
+		return fmt.Errorf("ogg segment table: %w", errInvalid)
+	}
+
+	total := 0
+	for _, s := range segments {
+		total += int(s)
+	}
+	body := make([]byte, total)
+	if _, err := io.ReadFull(p.r, body); err != nil {
+		return fmt.Errorf("ogg page body: %w", errInvalid)
+	}
+
+	if !p.started {
+		p.serial, p.started = serial, true
+	}
+	if serial != p.serial {
+		return nil // interleaved stream we don't care about
+	}
+
+	offset := 0
+	for _, s := range segments {
+		p.partial = append(p.partial, body[offset:offset+int(s)]...)
+		offset += int(s)
+		if s < 255 {
+			p.pending = append(p.pending, p.partial)
+			p.partial = nil
+		}
+		if len(p.partial) > maxMetadataBlock {
+			return fmt.Errorf("ogg packet too large: %w", errInvalid)
+		}
+	}
+	return nil
+}
+
+// probeOgg reads the identification and comment headers of an Ogg Opus or
+// Ogg Vorbis file and derives its duration from the final granule position.
+func probeOgg(r io.ReadSeeker, size int64) (*Info, error) {
+	packets := &oggPacketReader{r: r}
+
+	ident, err := packets.next()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{}
+	var preSkip int64
+	var commentPrefix []byte
+
+	switch {
+	case bytes.HasPrefix(ident, []byte("OpusHead")) && len(ident) >= 19:
+		info.Format = FormatOpus
+		info.Channels = int(ident[9])
+		preSkip = int64(binary.LittleEndian.Uint16(ident[10:12]))
+		info.SampleRate = int(binary.LittleEndian.Uint32(ident[12:16]))
+		if info.SampleRate == 0 {
+			info.SampleRate = opusGranuleRate
+		}
+		commentPrefix = []byte("OpusTags")
+	case bytes.HasPrefix(ident, []byte("\x01vorbis")) && len(ident) >= 30:
+		info.Format = FormatVorbis
+		info.Channels = int(ident[11])
+		info.SampleRate = int(binary.LittleEndian.Uint32(ident[12:16]))
+		if nominal := int32(binary.LittleEndian.Uint32(ident[20:24])); nominal > 0 { //nolint:gosec // signed field per spec
+			info.Bitrate = int(nominal) / 1000
+		}
+		commentPrefix = []byte("\x03vorbis")
+	default:
+		return nil, fmt.Errorf("unsupported Ogg codec: %w", ErrUnsupported)
+	}
+
+	comment, err := packets.next()
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(comment, commentPrefix) {
+		if err := parseVorbisComment(comment[len(commentPrefix):], info); err != nil {
+			return nil, err
+		}
+	}
+
+	granule, err := lastGranule(r, size)
+	if err != nil {
+		return nil, err
+	}
+	if info.Format == FormatOpus {
+		info.Duration = float64(granule-preSkip) / opusGranuleRate
+	} else if info.SampleRate > 0 {
+		info.Duration = float64(granule) / float64(info.SampleRate)
+	}
+	if info.Duration < 0 {
+		info.Duration = 0
+	}
+
+	return info, nil
+}
+
+// lastGranule returns the granule position of the last page in the file.
+func lastGranule(r io.ReadSeeker, size int64) (int64, error) {
+	tail := int64(64 << 10)
+	if tail > size {
+		tail = size
+	}
+	buf, err := readAt(r, size-tail, int(tail))
+	if err != nil {
+		return 0, fmt.Errorf("reading final page: %w", err)
+	}
+
+	idx := bytes.LastIndex(buf, []byte("OggS"))
+	if idx < 0 || idx+14 > len(buf) {
+		return 0, fmt.Errorf("final page not found: %w", errInvalid)
+	}
+	return int64(binary.LittleEndian.Uint64(buf[idx+6 : idx+14])), nil //nolint:gosec // granule fits in int64 per spec
+}
@@ -0,0 +1,92 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// oggPage builds a single Ogg page holding one complete packet.
+func oggPage(serial uint32, granule uint64, seq uint32, packet []byte) []byte {
+	var segs []byte
+	n := len(packet)
+	for n >= 255 {
+		segs = append(segs, 255)
+		n -= 255
+	}
+	segs = append(segs, byte(n))
+
+	page := []byte("OggS")
+	page = append(page, 0, 0)
+	page = binary.LittleEndian.AppendUint64(page, granule)
+	page = binary.LittleEndian.AppendUint32(page, serial)
+	page = binary.LittleEndian.AppendUint32(page, seq)
+	page = append(page, 0, 0, 0, 0) // CRC is not checked by the prober
+	page = append(page, byte(len(segs)))
+	page = append(page, segs...)
+	return append(page, packet...)
+}
+
+func TestProbeOgg_Opus(t *testing.T) {
+	head := []byte("OpusHead")
+	head = append(head, 1, 2)                            // version, channels
+	head = binary.LittleEndian.AppendUint16(head, 312)   // pre-skip
+	head = binary.LittleEndian.AppendUint32(head, 44100) // input sample rate
+	head = append(head, 0, 0, 0)                         // gain, mapping family
+	tags := append([]byte("OpusTags"), vorbisComment("TITLE=Windowlicker", "ARTIST=Aphex Twin", "TRACKNUMBER=1")...)
+
+	// Pad the comment packet past one segment to exercise lacing.
+	tags = append(tags, bytes.Repeat([]byte{0}, 300)...)
+
+	var data []byte
+	data = append(data, oggPage(7, 0, 0, head)...)
+	data = append(data, oggPage(7, 0, 1, tags)...)
+	data = append(data, oggPage(7, 48000*10+312, 2, []byte{0xFC})...)
+	path := writeFile(t, "windowlicker.opus", data)
+
+	got, err := Probe(path)
+	if err != nil {
+		t.Fatalf("Probe() returned unexpected error: %v", err)
+	}
+	assertInfo(t, got, &Info{
+		Format: FormatOpus, Title: "Windowlicker", Artist: "Aphex Twin", TrackNumber: 1,
+		Duration: 10, SampleRate: 44100, Channels: 2,
+	})
+}
+
+func TestProbeOgg_Vorbis(t *testing.T) {
+	ident := []byte("\x01vorbis")
+	ident = binary.LittleEndian.AppendUint32(ident, 0)      // version
+	ident = append(ident, 2)                                // channels
+	ident = binary.LittleEndian.AppendUint32(ident, 48000)  // sample rate
+	ident = binary.LittleEndian.AppendUint32(ident, 0)      // bitrate max
+	ident = binary.LittleEndian.AppendUint32(ident, 192000) // bitrate nominal
+	ident = binary.LittleEndian.AppendUint32(ident, 0)      // bitrate min
+	ident = append(ident, 0xB8, 0x01)                       // block sizes, framing
+	comment := append([]byte("\x03vorbis"), vorbisComment("ALBUM=Selected Ambient Works")...)
+
+	var data []byte
+	data = append(data, oggPage(1, 0, 0, ident)...)
+	data = append(data, oggPage(1, 0, 1, comment)...)
+	data = append(data, oggPage(1, 48000*5, 2, []byte{0})...)
+	path := writeFile(t, "track.ogg", data)
+
+	got, err := Probe(path)
+	if err != nil {
+		t.Fatalf("Probe() returned unexpected error: %v", err)
+	}
+	assertInfo(t, got, &Info{
+		Format: FormatVorbis, Album: "Selected Ambient Works", Duration: 5, SampleRate: 48000, Channels: 2,
+	})
+	if got.Bitrate != 192 {
+		t.Errorf("Bitrate = %d, want 192", got.Bitrate)
+	}
+}
+
+func TestProbeOgg_UnknownCodec(t *testing.T) {
+	data := oggPage(1, 0, 0, []byte("\x7fFLAC"))
+	path := writeFile(t, "flac.oga", data)
+	if _, err := Probe(path); err == nil {
+		t.Fatal("Probe() on unsupported Ogg codec returned nil error")
+	}
+}
@@ -0,0 +1,600 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5" //nolint:gosec // FLAC's STREAMINFO checksum is MD5
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+	"path/filepath"
+)
+
+// ErrCorrupt is wrapped by VerifyFLAC when a file's audio fails its
+// checksums or cannot be decoded.
+var ErrCorrupt = errors.New("audio: corrupt audio data")
+
+// VerifyFLAC decodes every frame of a FLAC file, checking each frame's
+// header CRC-8 and frame CRC-16, the total sample count and, when the encoder
+// recorded one, the MD5 of the decoded audio in STREAMINFO. Damage to the
+// audio is reported wrapping ErrCorrupt; other errors mean the file could not
+// be read at all.
+func VerifyFLAC(path string) error {
+	f, err := os.Open(path) //nolint:gosec // path comes from the library index
+	if err != nil {
+		return fmt.Errorf("audio: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	si, err := readFLACMetadata(f, &Info{})
+	if err != nil {
+		return fmt.Errorf("audio: verify %s: %w: %w", filepath.Base(path), ErrCorrupt, err)
+	}
+
+	if err := verifyFrames(bufio.NewReaderSize(f, 1<<16), si); err != nil {
+		return fmt.Errorf("audio: verify %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// verifyFrames decodes the frames that follow the metadata blocks.
+func verifyFrames(r *bufio.Reader, si *streamInfo) error {
+	br := &bitReader{r: r}
+	sum := md5.New() //nolint:gosec // see import
+	var (
+		samples uint64
+		pcm     []byte
+		channel [][]int64
+	)
+
+	for frame := 0; ; frame++ {
+		if end, err := atStreamEnd(r); err != nil {
+			return err
+		} else if end {
+			break
+		}
+
+		h, err := br.frameHeader(si)
+		if err != nil {
+			return fmt.Errorf("frame %d: %w", frame, err)
+		}
+		for len(channel) < h.channels {
+			channel = append(channel, nil)
+		}
+		for ch := range h.channels {
+			if cap(channel[ch]) < h.blockSize {
+				channel[ch] = make([]int64, h.blockSize)
+			}
+			channel[ch] = channel[ch][:h.blockSize]
+			if err := br.subframe(channel[ch], h.subframeBits(ch)); err != nil {
+				return fmt.Errorf("frame %d channel %d: %w", frame, ch, err)
+			}
+		}
+		br.align()
+		want := br.crc16
+		got, err := br.raw(2)
+		if err != nil {
+			return fmt.Errorf("frame %d footer: %w", frame, err)
+		}
+		if uint16(got) != want {
+			return fmt.Errorf("frame %d: CRC-16 mismatch: %w", frame, ErrCorrupt)
+		}
+
+		decorrelate(h.assignment, channel[:h.channels])
+		pcm = appendPCM(pcm[:0], channel[:h.channels], h.bitsPerSample)
+		sum.Write(pcm)
+		samples += uint64(h.blockSize)
+	}
+
+	if si.TotalSamples != 0 && samples != si.TotalSamples {
+		return fmt.Errorf("decoded %d samples, STREAMINFO says %d: %w", samples, si.TotalSamples, ErrCorrupt)
+	}
+	if si.MD5 != [16]byte{} && !bytes.Equal(sum.Sum(nil), si.MD5[:]) {
+		return fmt.Errorf("audio MD5 does not match STREAMINFO: %w", ErrCorrupt)
+	}
+	return nil
+}
+
+// atStreamEnd reports whether r holds no further frames: it is exhausted or
+// only an ID3v1 tag remains.
+func atStreamEnd(r *bufio.Reader) (bool, error) {
+	peek, err := r.Peek(3)
+	switch {
+	case len(peek) == 0 && errors.Is(err, io.EOF):
+		return true, nil
+	case string(peek) == "TAG":
+		return true, nil
+	case len(peek) < 3 && err != nil && !errors.Is(err, io.EOF):
+		return false, err
+	}
+	return false, nil
+}
+
+// ---------------------------------------------------------------------------
+// Frames
+// ---------------------------------------------------------------------------
+
+// Channel assignments of stereo frames that store a side channel.
+const (
+	flacLeftSide  = 8
+	flacSideRight = 9
+	flacMidSide   = 10
+)
+
+// frameHeader holds the fields of a FLAC frame header needed to decode it.
+type frameHeader struct {
+	blockSize     int
+	channels      int
+	assignment    int // 0-7 for independent channels, or a flac*Side constant
+	bitsPerSample int
+}
+
+// subframeBits returns the sample size of channel ch: one bit more than the
+// frame's for a side channel.
+func (h frameHeader) subframeBits(ch int) int {
+	switch {
+	case h.assignment == flacLeftSide && ch == 1,
+		h.assignment == flacSideRight && ch == 0,
+		h.assignment == flacMidSide && ch == 1:
+		return h.bitsPerSample + 1
+	default:
+		return h.bitsPerSample
+	}
+}
+
+// frameHeader reads a frame header and checks its CRC-8.
+func (br *bitReader) frameHeader(si *streamInfo) (frameHeader, error) {
+	br.crc8, br.crc16 = 0, 0
+
+	sync, err := br.read(15)
+	if err != nil {
+		return frameHeader{}, err
+	}
+	if sync != 0x7ffc {
+		return frameHeader{}, fmt.Errorf("lost frame sync: %w", ErrCorrupt)
+	}
+	if _, err := br.read(1); err != nil { // blocking strategy
+		return frameHeader{}, err
+	}
+
+	fields, err := br.read(16)
+	if err != nil {
+		return frameHeader{}, err
+	}
+	sizeCode := int(fields >> 12)
+	rateCode := int(fields >> 8 & 0x0f)
+	assignment := int(fields >> 4 & 0x0f)
+	depthCode := int(fields >> 1 & 0x07)
+
+	// The frame or sample number, UTF-8 coded; only its length matters.
+	first, err := br.read(8)
+	if err != nil {
+		return frameHeader{}, err
+	}
+	for extra := bits.LeadingZeros8(^uint8(first)); extra > 1; extra-- {
+		if _, err := br.read(8); err != nil {
+			return frameHeader{}, err
+		}
+	}
+
+	h := frameHeader{assignment: assignment}
+	switch {
+	case sizeCode == 1:
+		h.blockSize = 192
+	case sizeCode >= 2 && sizeCode <= 5:
+		h.blockSize = 576 << (sizeCode - 2)
+	case sizeCode == 6 || sizeCode == 7:
+		n, err := br.read(8 * uint(sizeCode-5))
+		if err != nil {
+			return frameHeader{}, err
+		}
+		h.blockSize = int(n) + 1
+	case sizeCode >= 8:
+		h.blockSize = 256 << (sizeCode - 8)
+	default:
+		return frameHeader{}, fmt.Errorf("reserved block size: %w", ErrCorrupt)
+	}
+
+	switch rateCode {
+	case 12:
+		_, err = br.read(8)
+	case 13, 14:
+		_, err = br.read(16)
+	case 15:
+		err = fmt.Errorf("invalid sample rate: %w", ErrCorrupt)
+	}
+	if err != nil {
+		return frameHeader{}, err
+	}
+
+	switch {
+	case assignment < 8:
+		h.channels = assignment + 1
+	case assignment <= flacMidSide:
+		h.channels = 2
+	default:
+		return frameHeader{}, fmt.Errorf("reserved channel assignment: %w", ErrCorrupt)
+	}
+
+	switch depthCode {
+	case 0:
+		h.bitsPerSample = si.BitsPerSample
+	case 1:
+		h.bitsPerSample = 8
+	case 2:
+		h.bitsPerSample = 12
+	case 4:
+		h.bitsPerSample = 16
+	case 5:
+		h.bitsPerSample = 20
+	case 6:
+		h.bitsPerSample = 24
+	case 7:
+		h.bitsPerSample = 32
+	default:
+		return frameHeader{}, fmt.Errorf("reserved sample size: %w", ErrCorrupt)
+	}
+
+	want := br.crc8
+	got, err := br.read(8)
+	if err != nil {
+		return frameHeader{}, err
+	}
+	if uint8(got) != want {
+		return frameHeader{}, fmt.Errorf("header CRC-8 mismatch: %w", ErrCorrupt)
+	}
+	return h, nil
+}
+
+// decorrelate restores left and right from a stereo frame's side channel.
+func decorrelate(assignment int, ch [][]int64) {
+	switch assignment {
+	case flacLeftSide:
+		for i, side := range ch[1] {
+			ch[1][i] = ch[0][i] - side
+		}
+	case flacSideRight:
+		for i, side := range ch[0] {
+			ch[0][i] = side + ch[1][i]
+		}
+	case flacMidSide:
+		for i, side := range ch[1] {
+			mid := ch[0][i]<<1 | side&1
+			ch[0][i] = (mid + side) >> 1
+			ch[1][i] = (mid - side) >> 1
+		}
+	}
+}
+
+// appendPCM appends the interleaved little-endian samples MD5 is computed
+// over.
+func appendPCM(buf []byte, ch [][]int64, bitsPerSample int) []byte {
+	width := (bitsPerSample + 7) / 8
+	for i := range ch[0] {
+		for c := range ch {
+			s := ch[c][i]
+			for b := range width {
+				buf = append(buf, byte(s>>(8*b)))
+			}
+		}
+	}
+	return buf
+}
+
+// ---------------------------------------------------------------------------
+// Subframes
+// ---------------------------------------------------------------------------
+
+// fixedCoefficients are the predictors of the FIXED subframe orders.
+var fixedCoefficients = [][]int64{
+	{},
+	{1},
+	{2, -1},
+	{3, -3, 1},
+	{4, -6, 4, -1},
+}
+
+// subframe decodes one channel of a frame into out.
+func (br *bitReader) subframe(out []int64, sampleBits int) error {
+	header, err := br.read(8)
+	if err != nil {
+		return err
+	}
+	if header&0x80 != 0 {
+		return fmt.Errorf("subframe padding bit set: %w", ErrCorrupt)
+	}
+	kind := int(header >> 1 & 0x3f)
+
+	wasted := 0
+	if header&1 != 0 {
+		n, err := br.unary()
+		if err != nil {
+			return err
+		}
+		wasted = n + 1
+		sampleBits -= wasted
+		if sampleBits <= 0 {
+			return fmt.Errorf("wasted bits exceed sample size: %w", ErrCorrupt)
+		}
+	}
+
+	switch {
+	case kind == 0: // CONSTANT
+		v, err := br.signed(uint(sampleBits))
+		if err != nil {
+			return err
+		}
+		for i := range out {
+			out[i] = v
+		}
+	case kind == 1: // VERBATIM
+		for i := range out {
+			if out[i], err = br.signed(uint(sampleBits)); err != nil {
+				return err
+			}
+		}
+	case kind >= 8 && kind <= 12: // FIXED
+		order := kind - 8
+		if err := br.warmUp(out, order, sampleBits); err != nil {
+			return err
+		}
+		if err := br.residual(out, order); err != nil {
+			return err
+		}
+		predict(out, fixedCoefficients[order], 0)
+	case kind >= 32: // LPC
+		order := kind - 31
+		if err := br.lpc(out, order, sampleBits); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("reserved subframe type %d: %w", kind, ErrCorrupt)
+	}
+
+	if wasted > 0 {
+		for i := range out {
+			out[i] <<= wasted
+		}
+	}
+	return nil
+}
+
+// warmUp reads the unpredicted samples that start a FIXED or LPC subframe.
+func (br *bitReader) warmUp(out []int64, order, sampleBits int) error {
+	if order > len(out) {
+		return fmt.Errorf("predictor order %d exceeds block size: %w", order, ErrCorrupt)
+	}
+	for i := range order {
+		v, err := br.signed(uint(sampleBits))
+		if err != nil {
+			return err
+		}
+		out[i] = v
+	}
+	return nil
+}
+
+// lpc decodes an LPC subframe.
+func (br *bitReader) lpc(out []int64, order, sampleBits int) error {
+	if err := br.warmUp(out, order, sampleBits); err != nil {
+		return err
+	}
+	precision, err := br.read(4)
+	if err != nil {
+		return err
+	}
+	if precision == 0x0f {
+		return fmt.Errorf("invalid LPC precision: %w", ErrCorrupt)
+	}
+	shift, err := br.signed(5)
+	if err != nil {
+		return err
+	}
+	if shift < 0 {
+		return fmt.Errorf("negative LPC shift: %w", ErrCorrupt)
+	}
+	coeffs := make([]int64, order)
+	for i := range coeffs {
+		if coeffs[i], err = br.signed(uint(precision) + 1); err != nil {
+			return err
+		}
+	}
+	if err := br.residual(out, order); err != nil {
+		return err
+	}
+	predict(out, coeffs, uint(shift))
+	return nil
+}
+
+// predict adds the prediction from the preceding samples to the residuals
+// in out[len(coeffs):].
+func predict(out, coeffs []int64, shift uint) {
+	for i := len(coeffs); i < len(out); i++ {
+		var sum int64
+		for j, c := range coeffs {
+			sum += c * out[i-j-1]
+		}
+		out[i] += sum >> shift
+	}
+}
+
+// residual reads the Rice-coded residual of a predicted subframe into
+// out[order:].
+func (br *bitReader) residual(out []int64, order int) error {
+	method, err := br.read(2)
+	if err != nil {
+		return err
+	}
+	if method > 1 {
+		return fmt.Errorf("reserved residual coding: %w", ErrCorrupt)
+	}
+	paramBits := uint(4 + method)
+	escape := uint64(1)<<paramBits - 1
+
+	partitionOrder, err := br.read(4)
+	if err != nil {
+		return err
+	}
+	partitions := 1 << partitionOrder
+	if len(out)%partitions != 0 || len(out)>>partitionOrder < order {
+		return fmt.Errorf("invalid residual partition order: %w", ErrCorrupt)
+	}
+
+	i := order
+	for p := range partitions {
+		n := len(out) >> partitionOrder
+		if p == 0 {
+			n -= order
+		}
+		param, err := br.read(paramBits)
+		if err != nil {
+			return err
+		}
+		if param == escape {
+			width, err := br.read(5)
+			if err != nil {
+				return err
+			}
+			for range n {
+				if out[i], err = br.signed(uint(width)); err != nil {
+					return err
+				}
+				i++
+			}
+			continue
+		}
+		for range n {
+			q, err := br.unary()
+			if err != nil {
+				return err
+			}
+			low, err := br.read(uint(param))
+			if err != nil {
+				return err
+			}
+			u := uint64(q)<<param | low
+			out[i] = int64(u>>1) ^ -int64(u&1)
+			i++
+		}
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// Bit reader
+// ---------------------------------------------------------------------------
+
+// bitReader reads big-endian bit fields, keeping the CRC-8 and CRC-16 of the
+// bytes consumed so far. It never reads ahead of the bits requested, so the
+// checksums cover exactly the bytes a frame has used.
+type bitReader struct {
+	r     *bufio.Reader
+	cache uint64 // the low n bits are unread
+	n     uint
+	crc8  uint8
+	crc16 uint16
+}
+
+// fill reads one more byte into the cache.
+func (br *bitReader) fill() error {
+	b, err := br.r.ReadByte()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("truncated frame: %w", ErrCorrupt)
+		}
+		return err
+	}
+	br.crc8 = crc8Table[br.crc8^b]
+	br.crc16 = br.crc16<<8 ^ crc16Table[byte(br.crc16>>8)^b]
+	br.cache = br.cache<<8 | uint64(b)
+	br.n += 8
+	return nil
+}
+
+// read returns the next n bits (n <= 56) as an unsigned value.
+func (br *bitReader) read(n uint) (uint64, error) {
+	for br.n < n {
+		if err := br.fill(); err != nil {
+			return 0, err
+		}
+	}
+	br.n -= n
+	v := br.cache >> br.n & (1<<n - 1)
+	br.cache &= 1<<br.n - 1
+	return v, nil
+}
+
+// raw reads n whole bytes outside the bit stream, e.g. a frame's CRC-16.
+func (br *bitReader) raw(n uint) (uint64, error) {
+	return br.read(8 * n)
+}
+
+// signed returns the next n bits as a two's complement value.
+func (br *bitReader) signed(n uint) (int64, error) {
+	if n == 0 {
+		return 0, nil
+	}
+	v, err := br.read(n)
+	if err != nil {
+		return 0, err
+	}
+	return int64(v<<(64-n)) >> (64 - n), nil
+}
+
+// unary counts zero bits up to the next one bit, which it consumes.
+func (br *bitReader) unary() (int, error) {
+	count := 0
+	for {
+		if br.n == 0 {
+			if err := br.fill(); err != nil {
+				return 0, err
+			}
+		}
+		if br.cache == 0 {
+			count += int(br.n)
+			br.n = 0
+			continue
+		}
+		zeros := uint(bits.LeadingZeros64(br.cache)) - (64 - br.n)
+		count += int(zeros)
+		br.n -= zeros + 1
+		br.cache &= 1<<br.n - 1
+		return count, nil
+	}
+}
+
+// align skips to the next byte boundary.
+func (br *bitReader) align() {
+	br.n -= br.n % 8
+	br.cache &= 1<<br.n - 1
+}
+
+// crc8Table and crc16Table implement FLAC's header CRC-8 (polynomial 0x07)
+// and frame CRC-16 (polynomial 0x8005).
+var (
+	crc8Table  [256]uint8
+	crc16Table [256]uint16
+)
+
+func init() {
+	for i := range 256 {
+		c8 := uint8(i)
+		c16 := uint16(i) << 8
+		for range 8 {
+			if c8&0x80 != 0 {
+				c8 = c8<<1 ^ 0x07
+			} else {
+				c8 <<= 1
+			}
+			if c16&0x8000 != 0 {
+				c16 = c16<<1 ^ 0x8005
+			} else {
+				c16 <<= 1
+			}
+		}
+		crc8Table[i] = c8
+		crc16Table[i] = c16
+	}
+}
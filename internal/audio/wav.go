@@ -0,0 +1,97 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// wavInfoTags maps RIFF INFO chunk IDs to tag fields.
+var wavInfoTags = map[string]string{
+	"INAM": "TITLE",
+	"IART": "ARTIST",
+	"IPRD": "ALBUM",
+	"ITRK": "TRACK",
+	"IPRT": "TRACK",
+}
+
+// probeWAV walks the RIFF chunks of a WAV file, reading the fmt chunk, the
+// data chunk's length and any LIST/INFO tags.
+func probeWAV(r io.ReadSeeker, size int64) (*Info, error) {
+	header, err := readAt(r, 0, 12)
+	if err != nil || string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("missing RIFF/WAVE header: %w", errInvalid)
+	}
+
+	info := &Info{Format: FormatWAV}
+	var byteRate, dataSize int64
+	sawFmt := false
+
+	offset := int64(12)
+	for offset+8 <= size {
+		chunk, err := readAt(r, offset, 8)
+		if err != nil {
+			break
+		}
+		id := string(chunk[0:4])
+		length := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		body := offset + 8
+
+		switch id {
+		case "fmt ":
+			if length < 16 {
+				return nil, fmt.Errorf("short fmt chunk: %w", errInvalid)
+			}
+			f, err := readAt(r, body, 16)
+			if err != nil {
+				return nil, err
+			}
+			info.Channels = int(binary.LittleEndian.Uint16(f[2:4]))
+			info.SampleRate = int(binary.LittleEndian.Uint32(f[4:8]))
+			byteRate = int64(binary.LittleEndian.Uint32(f[8:12]))
+			info.BitDepth = int(binary.LittleEndian.Uint16(f[14:16]))
+			sawFmt = true
+		case "data":
+			dataSize = length
+			if body+dataSize > size {
+				dataSize = size - body
+			}
+		case "LIST":
+			if length >= 4 && length <= maxMetadataBlock {
+				list, err := readAt(r, body, int(length))
+				if err == nil && string(list[0:4]) == "INFO" {
+					parseRIFFInfo(list[4:], info)
+				}
+			}
+		}
+
+		offset = body + length + length%2 // chunks are word-aligned
+	}
+
+	if !sawFmt {
+		return nil, fmt.Errorf("missing fmt chunk: %w", errInvalid)
+	}
+	if byteRate > 0 {
+		info.Duration = float64(dataSize) / float64(byteRate)
+	}
+	return info, nil
+}
+
+// parseRIFFInfo reads the sub-chunks of a LIST/INFO chunk.
+func parseRIFFInfo(data []byte, info *Info) {
+	for len(data) >= 8 {
+		id := string(data[0:4])
+		length := int(binary.LittleEndian.Uint32(data[4:8]))
+		if 8+length > len(data) {
+			return
+		}
+		if field, ok := wavInfoTags[id]; ok {
+			info.setTag(field, string(data[8:8+length]))
+		}
+		next := 8 + length + length%2
+		if next > len(data) {
+			return
+		}
+		data = data[next:]
+	}
+}
@@ -0,0 +1,59 @@
+package audio
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// riffChunk builds a word-aligned RIFF chunk.
+func riffChunk(id string, body []byte) []byte {
+	out := append([]byte(id), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+	out = append(out, body...)
+	if len(body)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+func TestProbeWAV(t *testing.T) {
+	fmtBody := make([]byte, 16)
+	binary.LittleEndian.PutUint16(fmtBody[0:], 1)         // PCM
+	binary.LittleEndian.PutUint16(fmtBody[2:], 2)         // channels
+	binary.LittleEndian.PutUint32(fmtBody[4:], 48000)     // sample rate
+	binary.LittleEndian.PutUint32(fmtBody[8:], 48000*2*3) // byte rate
+	binary.LittleEndian.PutUint16(fmtBody[12:], 6)        // block align
+	binary.LittleEndian.PutUint16(fmtBody[14:], 24)       // bits per sample
+
+	info := []byte("INFO")
+	info = append(info, riffChunk("INAM", []byte("Avril 14th\x00"))...)
+	info = append(info, riffChunk("IART", []byte("Aphex Twin\x00"))...)
+	info = append(info, riffChunk("ITRK", []byte("4\x00"))...)
+
+	var body []byte
+	body = append(body, "WAVE"...)
+	body = append(body, riffChunk("fmt ", fmtBody)...)
+	body = append(body, riffChunk("LIST", info)...)
+	body = append(body, riffChunk("data", make([]byte, 48000*2*3))...)
+
+	data := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+	data = append(data, body...)
+	path := writeFile(t, "avril.wav", data)
+
+	got, err := Probe(path)
+	if err != nil {
+		t.Fatalf("Probe() returned unexpected error: %v", err)
+	}
+	assertInfo(t, got, &Info{
+		Format: FormatWAV, Title: "Avril 14th", Artist: "Aphex Twin", TrackNumber: 4,
+		Duration: 1, SampleRate: 48000, BitDepth: 24, Channels: 2,
+	})
+}
+
+func TestProbeWAV_MissingFmt(t *testing.T) {
+	data := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, 4)...)
+	data = append(data, "WAVE"...)
+	path := writeFile(t, "nofmt.wav", data)
+	if _, err := Probe(path); err == nil {
+		t.Fatal("Probe() on WAV without fmt chunk returned nil error")
+	}
+}
@@ -0,0 +1,96 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// wavpackSampleRates is indexed by bits 23-26 of the block header flags.
+// Index 15 means a non-standard rate stored in metadata, reported as 0.
+var wavpackSampleRates = [15]int{
+	6000, 8000, 9600, 11025, 12000, 16000, 22050, 24000,
+	32000, 44100, 48000, 64000, 88200, 96000, 192000,
+}
+
+// WavPack block header flags.
+const (
+	wvBytesPerSampleMask = 0x03
+	wvMono               = 0x04
+	wvShiftMask          = 0x1f << 13
+	wvSampleRateMask     = 0x0f << 23
+)
+
+// probeWavPack reads the first block header of a WavPack file and any APEv2
+// tag at its end.
+func probeWavPack(r io.ReadSeeker, size int64) (*Info, error) {
+	header, err := readAt(r, 0, 32)
+	if err != nil || string(header[0:4]) != "wvpk" {
+		return nil, fmt.Errorf("missing wvpk header: %w", errInvalid)
+	}
+
+	flags := binary.LittleEndian.Uint32(header[24:28])
+	info := &Info{
+		Format:   FormatWavPack,
+		Channels: 2,
+		BitDepth: int(flags&wvBytesPerSampleMask+1)*8 - int(flags&wvShiftMask>>13),
+	}
+	if flags&wvMono != 0 {
+		info.Channels = 1
+	}
+	if idx := flags & wvSampleRateMask >> 23; idx < uint32(len(wavpackSampleRates)) {
+		info.SampleRate = wavpackSampleRates[idx]
+	}
+
+	totalSamples := binary.LittleEndian.Uint32(header[12:16])
+	if totalSamples != 0xFFFFFFFF && info.SampleRate > 0 {
+		total := uint64(header[11])<<32 | uint64(totalSamples)
+		info.Duration = float64(total) / float64(info.SampleRate)
+	}
+
+	readAPETag(r, size, info)
+	return info, nil
+}
+
+// readAPETag parses an APEv2 tag at the end of the file (optionally followed
+// by an ID3v1 tag) into info. Files without one are left untouched.
+func readAPETag(r io.ReadSeeker, size int64, info *Info) {
+	end := size
+	if size >= 128 {
+		if tag, err := readAt(r, size-128, 3); err == nil && string(tag) == "TAG" {
+			end -= 128
+		}
+	}
+	if end < 32 {
+		return
+	}
+
+	footer, err := readAt(r, end-32, 32)
+	if err != nil || string(footer[0:8]) != "APETAGEX" {
+		return
+	}
+
+	tagSize := int64(binary.LittleEndian.Uint32(footer[12:16])) // items + footer
+	count := binary.LittleEndian.Uint32(footer[16:20])
+	if tagSize < 32 || tagSize > end || tagSize > maxMetadataBlock {
+		return
+	}
+
+	items, err := readAt(r, end-tagSize, int(tagSize-32))
+	if err != nil {
+		return
+	}
+
+	for n := uint32(0); n < count && len(items) >= 8; n++ {
+		valueLen := int(binary.LittleEndian.Uint32(items[0:4]))
+		keyEnd := strings.IndexByte(string(items[8:]), 0)
+		if keyEnd < 0 || 8+keyEnd+1+valueLen > len(items) {
+			return
+		}
+		key := string(items[8 : 8+keyEnd])
+		valueStart := 8 + keyEnd + 1
+		info.setTag(key, string(items[valueStart:valueStart+valueLen]))
+		items = items[valueStart+valueLen:]
+	}
+}
@@ -0,0 +1,66 @@
+package audio
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// apeItem builds a single APEv2 tag item.
+func apeItem(key, value string) []byte {
+	item := binary.LittleEndian.AppendUint32(nil, uint32(len(value)))
+	item = binary.LittleEndian.AppendUint32(item, 0)
+	item = append(item, key...)
+	item = append(item, 0)
+	return append(item, value...)
+}
+
+// apeTag builds an APEv2 tag (items followed by footer).
+func apeTag(items ...[]byte) []byte {
+	var body []byte
+	for _, it := range items {
+		body = append(body, it...)
+	}
+	footer := []byte("APETAGEX")
+	footer = binary.LittleEndian.AppendUint32(footer, 2000)
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(body)+32))
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(items)))
+	footer = binary.LittleEndian.AppendUint32(footer, 0)
+	footer = append(footer, make([]byte, 8)...)
+	return append(body, footer...)
+}
+
+func TestProbeWavPack(t *testing.T) {
+	header := make([]byte, 32)
+	copy(header, "wvpk")
+	binary.LittleEndian.PutUint32(header[4:], 24)
+	binary.LittleEndian.PutUint16(header[8:], 0x410)
+	binary.LittleEndian.PutUint32(header[12:], 88200*90) // total samples
+	flags := uint32(2)                                   // 3 bytes per sample
+	flags |= 12 << 23                                    // 88.2 kHz
+	binary.LittleEndian.PutUint32(header[24:], flags)
+
+	data := append(header, make([]byte, 256)...)
+	data = append(data, apeTag(
+		apeItem("Title", "Roygbiv"),
+		apeItem("Artist", "Boards of Canada"),
+		apeItem("Album", "Music Has the Right to Children"),
+		apeItem("Track", "5/17"),
+	)...)
+	path := writeFile(t, "roygbiv.wv", data)
+
+	got, err := Probe(path)
+	if err != nil {
+		t.Fatalf("Probe() returned unexpected error: %v", err)
+	}
+	assertInfo(t, got, &Info{
+		Format: FormatWavPack, Title: "Roygbiv", Artist: "Boards of Canada", Album: "Music Has the Right to Children",
+		TrackNumber: 5, Duration: 90, SampleRate: 88200, BitDepth: 24, Channels: 2,
+	})
+}
+
+func TestProbeWavPack_BadHeader(t *testing.T) {
+	path := writeFile(t, "bad.wv", make([]byte, 64))
+	if _, err := Probe(path); err == nil {
+		t.Fatal("Probe() on invalid WavPack returned nil error")
+	}
+}
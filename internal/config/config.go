@@ -2,8 +2,13 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -11,11 +16,33 @@ import (
 // Config holds all runtime configuration sourced from environment variables.
 type Config struct {
 	Port                   string
-	HiFiAPIURL             string
+	HiFiBackends           []HiFiBackend // hifi-api instances, in order of preference
+	HiFiRegion             string        // region whose backends are tried first, "" for none
+	HiFiHealthInterval     time.Duration // how often to check that the backends are up
 	MusicPath              string
 	DataPath               string
+	TrashPath              string // where replaced album folders are kept
 	DefaultQuality         string
 	MaxConcurrentDownloads int
+	WatchMode              string // "off", "inotify" or "poll"
+	WatchDebounce          time.Duration
+	WatchPollInterval      time.Duration
+	ReleaseCheckInterval   time.Duration // how often to look for new releases
+	DiscoveryInterval      time.Duration // how often to refresh recommendations
+	HiFiCacheSize          int           // hifi-api responses cached in memory, 0 to disable
+	HiFiCachePersist       bool          // keep cached responses in the database
+	HiFiMaxRetries         int           // retries of a failed hifi-api request
+	HiFiRetryBackoff       time.Duration // wait before the first retry, doubled after
+	HiFiRateLimit          float64       // hifi-api requests a second, 0 for no limit
+	HiFiRateBurst          int           // requests allowed at once within the rate limit
+	HiFiBreakerThreshold   int           // failed requests in a row that stop requests, 0 to never stop
+	HiFiBreakerCooldown    time.Duration // how long requests stay stopped
+}
+
+// HiFiBackend is a hifi-api instance and the region of its Tidal catalogue.
+type HiFiBackend struct {
+	URL    string
+	Region string // "" if not given
 }
 
 // Load reads configuration from environment variables (optionally preceded by
@@ -39,13 +66,118 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("config: MAX_CONCURRENT_DOWNLOADS must be >= 1, got %d", concurrent)
 	}
 
+	watchMode := envOrDefault("WATCH_MODE", "off")
+	if watchMode != "off" && watchMode != "inotify" && watchMode != "poll" {
+		return nil, fmt.Errorf("config: invalid WATCH_MODE %q, must be off, inotify or poll", watchMode)
+	}
+
+	debounce, err := envDuration("WATCH_DEBOUNCE", "10s")
+	if err != nil {
+		return nil, err
+	}
+
+	pollInterval, err := envDuration("WATCH_POLL_INTERVAL", "60s")
+	if err != nil {
+		return nil, err
+	}
+
+	releaseInterval, err := envDuration("RELEASE_CHECK_INTERVAL", "24h")
+	if err != nil {
+		return nil, err
+	}
+
+	discoveryInterval, err := envDuration("DISCOVERY_REFRESH_INTERVAL", "24h")
+	if err != nil {
+		return nil, err
+	}
+
+	cacheSize, err := envInt("HIFI_CACHE_SIZE", "1000", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	rawPersist := envOrDefault("HIFI_CACHE_PERSIST", "true")
+	persist, err := strconv.ParseBool(rawPersist)
+	if err != nil {
+		return nil, fmt.Errorf("config: invalid HIFI_CACHE_PERSIST %q: %w", rawPersist, err)
+	}
+
+	retries, err := envInt("HIFI_MAX_RETRIES", "3", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	backoff, err := envDuration("HIFI_RETRY_BACKOFF", "500ms")
+	if err != nil {
+		return nil, err
+	}
+
+	rawRate := envOrDefault("HIFI_RATE_LIMIT", "10")
+	rate, err := strconv.ParseFloat(rawRate, 64)
+	if err != nil {
+		return nil, fmt.Errorf("config: invalid HIFI_RATE_LIMIT %q: %w", rawRate, err)
+	}
+	if rate < 0 {
+		return nil, fmt.Errorf("config: HIFI_RATE_LIMIT must be >= 0, got %g", rate)
+	}
+
+	burst, err := envInt("HIFI_RATE_BURST", "20", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold, err := envInt("HIFI_BREAKER_THRESHOLD", "5", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	cooldown, err := envDuration("HIFI_BREAKER_COOLDOWN", "30s")
+	if err != nil {
+		return nil, err
+	}
+
+	backends, err := parseBackends(envOrDefault("HIFI_API_URL", "http://localhost:8000"))
+	if err != nil {
+		return nil, err
+	}
+
+	region := strings.ToUpper(os.Getenv("HIFI_REGION"))
+	if region != "" && !slices.ContainsFunc(backends, func(b HiFiBackend) bool { return b.Region == region }) {
+		return nil, fmt.Errorf("config: HIFI_REGION %q matches no HIFI_API_URL backend", region)
+	}
+
+	healthInterval, err := envDuration("HIFI_HEALTH_INTERVAL", "1m")
+	if err != nil {
+		return nil, err
+	}
+
+	// The trash defaults to a hidden folder in the music root, which scans
+	// skip, so replaced folders can be moved there with a cheap rename.
+	musicPath := envOrDefault("MUSIC_PATH", "/music")
+
 	return &Config{
 		Port:                   envOrDefault("PORT", "8888"),
-		HiFiAPIURL:             envOrDefault("HIFI_API_URL", "http://localhost:8000"),
-		MusicPath:              envOrDefault("MUSIC_PATH", "/music"),
+		HiFiBackends:           backends,
+		HiFiRegion:             region,
+		HiFiHealthInterval:     healthInterval,
+		MusicPath:              musicPath,
 		DataPath:               envOrDefault("DATA_PATH", "/data"),
+		TrashPath:              envOrDefault("TRASH_PATH", filepath.Join(musicPath, ".crescendo-trash")),
 		DefaultQuality:         quality,
 		MaxConcurrentDownloads: concurrent,
+		WatchMode:              watchMode,
+		WatchDebounce:          debounce,
+		WatchPollInterval:      pollInterval,
+		ReleaseCheckInterval:   releaseInterval,
+		DiscoveryInterval:      discoveryInterval,
+		HiFiCacheSize:          cacheSize,
+		HiFiCachePersist:       persist,
+		HiFiMaxRetries:         retries,
+		HiFiRetryBackoff:       backoff,
+		HiFiRateLimit:          rate,
+		HiFiRateBurst:          burst,
+		HiFiBreakerThreshold:   threshold,
+		HiFiBreakerCooldown:    cooldown,
 	}, nil
 }
 
@@ -55,3 +187,62 @@ func envOrDefault(key, fallback string) string {
 	}
 	return fallback
 }
+
+// parseBackends parses a comma-separated list of hifi-api base URLs, each
+// optionally preceded by the region of its catalogue, as in
+// "US=http://hifi-us:8000,GB=http://hifi-gb:8000".
+func parseBackends(raw string) ([]HiFiBackend, error) {
+	var backends []HiFiBackend
+	for entry := range strings.SplitSeq(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		var b HiFiBackend
+		region, rawURL, found := strings.Cut(entry, "=")
+		if found && !strings.Contains(region, "://") {
+			b.Region, b.URL = strings.ToUpper(strings.TrimSpace(region)), strings.TrimSpace(rawURL)
+		} else {
+			b.URL = entry
+		}
+
+		u, err := url.Parse(b.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("config: invalid HIFI_API_URL entry %q: want an http(s) URL, optionally preceded by REGION=", entry)
+		}
+		backends = append(backends, b)
+	}
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("config: HIFI_API_URL lists no backends")
+	}
+	return backends, nil
+}
+
+// envInt parses an integer of at least minimum from the environment,
+// falling back to the given default.
+func envInt(key, fallback string, minimum int) (int, error) {
+	raw := envOrDefault(key, fallback)
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("config: invalid %s %q: %w", key, raw, err)
+	}
+	if n < minimum {
+		return 0, fmt.Errorf("config: %s must be >= %d, got %d", key, minimum, n)
+	}
+	return n, nil
+}
+
+// envDuration parses a positive Go duration (e.g. "30s", "5m") from the
+// environment, falling back to the given default.
+func envDuration(key, fallback string) (time.Duration, error) {
+	raw := envOrDefault(key, fallback)
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("config: invalid %s %q: %w", key, raw, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("config: %s must be > 0, got %s", key, d)
+	}
+	return d, nil
+}
@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"slices"
 	"testing"
+	"time"
 )
 
 func TestLoad(t *testing.T) {
@@ -18,11 +20,31 @@ func TestLoad(t *testing.T) {
 		}
 
 		assertString(t, "Port", cfg.Port, "8888")
-		assertString(t, "HiFiAPIURL", cfg.HiFiAPIURL, "http://localhost:8000")
+		assertBackends(t, cfg.HiFiBackends, []HiFiBackend{{URL: "http://localhost:8000"}})
+		assertString(t, "HiFiRegion", cfg.HiFiRegion, "")
+		assertDuration(t, "HiFiHealthInterval", cfg.HiFiHealthInterval, time.Minute)
 		assertString(t, "MusicPath", cfg.MusicPath, "/music")
 		assertString(t, "DataPath", cfg.DataPath, "/data")
+		assertString(t, "TrashPath", cfg.TrashPath, "/music/.crescendo-trash")
 		assertString(t, "DefaultQuality", cfg.DefaultQuality, "LOSSLESS")
 		assertInt(t, "MaxConcurrentDownloads", cfg.MaxConcurrentDownloads, 3)
+		assertString(t, "WatchMode", cfg.WatchMode, "off")
+		assertDuration(t, "WatchDebounce", cfg.WatchDebounce, 10*time.Second)
+		assertDuration(t, "WatchPollInterval", cfg.WatchPollInterval, time.Minute)
+		assertDuration(t, "ReleaseCheckInterval", cfg.ReleaseCheckInterval, 24*time.Hour)
+		assertDuration(t, "DiscoveryInterval", cfg.DiscoveryInterval, 24*time.Hour)
+		assertInt(t, "HiFiCacheSize", cfg.HiFiCacheSize, 1000)
+		if !cfg.HiFiCachePersist {
+			t.Error("HiFiCachePersist = false, want true")
+		}
+		assertInt(t, "HiFiMaxRetries", cfg.HiFiMaxRetries, 3)
+		assertDuration(t, "HiFiRetryBackoff", cfg.HiFiRetryBackoff, 500*time.Millisecond)
+		if cfg.HiFiRateLimit != 10 {
+			t.Errorf("HiFiRateLimit = %g, want 10", cfg.HiFiRateLimit)
+		}
+		assertInt(t, "HiFiRateBurst", cfg.HiFiRateBurst, 20)
+		assertInt(t, "HiFiBreakerThreshold", cfg.HiFiBreakerThreshold, 5)
+		assertDuration(t, "HiFiBreakerCooldown", cfg.HiFiBreakerCooldown, 30*time.Second)
 	})
 
 	envOverrides := []struct {
@@ -41,7 +63,21 @@ func TestLoad(t *testing.T) {
 			name:   "HIFI_API_URL override",
 			envKey: "HIFI_API_URL",
 			envVal: "http://hifi:5000",
-			check:  func(t *testing.T, c *Config) { assertString(t, "HiFiAPIURL", c.HiFiAPIURL, "http://hifi:5000") },
+			check: func(t *testing.T, c *Config) {
+				assertBackends(t, c.HiFiBackends, []HiFiBackend{{URL: "http://hifi:5000"}})
+			},
+		},
+		{
+			name:   "HIFI_API_URL with several backends",
+			envKey: "HIFI_API_URL",
+			envVal: "us=http://hifi-us:8000, https://hifi.example.com/api?x=1 ,GB=http://hifi-gb:8000,",
+			check: func(t *testing.T, c *Config) {
+				assertBackends(t, c.HiFiBackends, []HiFiBackend{
+					{URL: "http://hifi-us:8000", Region: "US"},
+					{URL: "https://hifi.example.com/api?x=1"},
+					{URL: "http://hifi-gb:8000", Region: "GB"},
+				})
+			},
 		},
 		{
 			name:   "MUSIC_PATH override",
@@ -55,6 +91,20 @@ func TestLoad(t *testing.T) {
 			envVal: "/var/lib/crescendo",
 			check:  func(t *testing.T, c *Config) { assertString(t, "DataPath", c.DataPath, "/var/lib/crescendo") },
 		},
+		{
+			name:   "TRASH_PATH follows MUSIC_PATH by default",
+			envKey: "MUSIC_PATH",
+			envVal: "/mnt/nas/music",
+			check: func(t *testing.T, c *Config) {
+				assertString(t, "TrashPath", c.TrashPath, "/mnt/nas/music/.crescendo-trash")
+			},
+		},
+		{
+			name:   "TRASH_PATH override",
+			envKey: "TRASH_PATH",
+			envVal: "/mnt/nas/trash",
+			check:  func(t *testing.T, c *Config) { assertString(t, "TrashPath", c.TrashPath, "/mnt/nas/trash") },
+		},
 		{
 			name:   "DEFAULT_QUALITY override to HI_RES_LOSSLESS",
 			envKey: "DEFAULT_QUALITY",
@@ -67,6 +117,74 @@ func TestLoad(t *testing.T) {
 			envVal: "10",
 			check:  func(t *testing.T, c *Config) { assertInt(t, "MaxConcurrentDownloads", c.MaxConcurrentDownloads, 10) },
 		},
+		{
+			name:   "WATCH_MODE override",
+			envKey: "WATCH_MODE",
+			envVal: "poll",
+			check:  func(t *testing.T, c *Config) { assertString(t, "WatchMode", c.WatchMode, "poll") },
+		},
+		{
+			name:   "WATCH_DEBOUNCE override",
+			envKey: "WATCH_DEBOUNCE",
+			envVal: "2s",
+			check:  func(t *testing.T, c *Config) { assertDuration(t, "WatchDebounce", c.WatchDebounce, 2*time.Second) },
+		},
+		{
+			name:   "WATCH_POLL_INTERVAL override",
+			envKey: "WATCH_POLL_INTERVAL",
+			envVal: "5m",
+			check: func(t *testing.T, c *Config) {
+				assertDuration(t, "WatchPollInterval", c.WatchPollInterval, 5*time.Minute)
+			},
+		},
+		{
+			name:   "RELEASE_CHECK_INTERVAL override",
+			envKey: "RELEASE_CHECK_INTERVAL",
+			envVal: "6h",
+			check: func(t *testing.T, c *Config) {
+				assertDuration(t, "ReleaseCheckInterval", c.ReleaseCheckInterval, 6*time.Hour)
+			},
+		},
+		{
+			name:   "DISCOVERY_REFRESH_INTERVAL override",
+			envKey: "DISCOVERY_REFRESH_INTERVAL",
+			envVal: "12h",
+			check: func(t *testing.T, c *Config) {
+				assertDuration(t, "DiscoveryInterval", c.DiscoveryInterval, 12*time.Hour)
+			},
+		},
+		{
+			name:   "HIFI_CACHE_SIZE override",
+			envKey: "HIFI_CACHE_SIZE",
+			envVal: "0",
+			check:  func(t *testing.T, c *Config) { assertInt(t, "HiFiCacheSize", c.HiFiCacheSize, 0) },
+		},
+		{
+			name:   "HIFI_CACHE_PERSIST override",
+			envKey: "HIFI_CACHE_PERSIST",
+			envVal: "false",
+			check: func(t *testing.T, c *Config) {
+				if c.HiFiCachePersist {
+					t.Error("HiFiCachePersist = true, want false")
+				}
+			},
+		},
+		{
+			name:   "HIFI_RATE_LIMIT override",
+			envKey: "HIFI_RATE_LIMIT",
+			envVal: "2.5",
+			check: func(t *testing.T, c *Config) {
+				if c.HiFiRateLimit != 2.5 {
+					t.Errorf("HiFiRateLimit = %g, want 2.5", c.HiFiRateLimit)
+				}
+			},
+		},
+		{
+			name:   "HIFI_BREAKER_THRESHOLD override",
+			envKey: "HIFI_BREAKER_THRESHOLD",
+			envVal: "0",
+			check:  func(t *testing.T, c *Config) { assertInt(t, "HiFiBreakerThreshold", c.HiFiBreakerThreshold, 0) },
+		},
 	}
 
 	for _, tc := range envOverrides {
@@ -82,6 +200,18 @@ func TestLoad(t *testing.T) {
 		})
 	}
 
+	t.Run("HIFI_REGION matching a backend", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv("HIFI_API_URL", "US=http://hifi-us:8000,GB=http://hifi-gb:8000")
+		t.Setenv("HIFI_REGION", "gb")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertString(t, "HiFiRegion", cfg.HiFiRegion, "GB")
+	})
+
 	validationErrors := []struct {
 		name   string
 		envKey string
@@ -112,6 +242,90 @@ func TestLoad(t *testing.T) {
 			envVal: "-5",
 			errSub: "must be >= 1",
 		},
+		{
+			name:   "invalid watch mode",
+			envKey: "WATCH_MODE",
+			envVal: "fanotify",
+			errSub: "invalid WATCH_MODE",
+		},
+		{
+			name:   "unparseable watch debounce",
+			envKey: "WATCH_DEBOUNCE",
+			envVal: "soon",
+			errSub: "invalid WATCH_DEBOUNCE",
+		},
+		{
+			name:   "zero poll interval",
+			envKey: "WATCH_POLL_INTERVAL",
+			envVal: "0s",
+			errSub: "must be > 0",
+		},
+		{
+			name:   "invalid release check interval",
+			envKey: "RELEASE_CHECK_INTERVAL",
+			envVal: "daily",
+			errSub: "invalid RELEASE_CHECK_INTERVAL",
+		},
+		{
+			name:   "zero discovery refresh interval",
+			envKey: "DISCOVERY_REFRESH_INTERVAL",
+			envVal: "0s",
+			errSub: "must be > 0",
+		},
+		{
+			name:   "negative hifi cache size",
+			envKey: "HIFI_CACHE_SIZE",
+			envVal: "-1",
+			errSub: "must be >= 0",
+		},
+		{
+			name:   "invalid hifi cache persist",
+			envKey: "HIFI_CACHE_PERSIST",
+			envVal: "sometimes",
+			errSub: "invalid HIFI_CACHE_PERSIST",
+		},
+		{
+			name:   "negative hifi max retries",
+			envKey: "HIFI_MAX_RETRIES",
+			envVal: "-1",
+			errSub: "HIFI_MAX_RETRIES must be >= 0",
+		},
+		{
+			name:   "hifi backend without a scheme",
+			envKey: "HIFI_API_URL",
+			envVal: "http://hifi-us:8000,hifi-gb:8000",
+			errSub: `invalid HIFI_API_URL entry "hifi-gb:8000"`,
+		},
+		{
+			name:   "no hifi backends",
+			envKey: "HIFI_API_URL",
+			envVal: " , ",
+			errSub: "HIFI_API_URL lists no backends",
+		},
+		{
+			name:   "hifi region without a backend",
+			envKey: "HIFI_REGION",
+			envVal: "GB",
+			errSub: `HIFI_REGION "GB" matches no HIFI_API_URL backend`,
+		},
+		{
+			name:   "invalid hifi rate limit",
+			envKey: "HIFI_RATE_LIMIT",
+			envVal: "fast",
+			errSub: "invalid HIFI_RATE_LIMIT",
+		},
+		{
+			name:   "zero hifi rate burst",
+			envKey: "HIFI_RATE_BURST",
+			envVal: "0",
+			errSub: "HIFI_RATE_BURST must be >= 1",
+		},
+		{
+			name:   "zero hifi breaker cooldown",
+			envKey: "HIFI_BREAKER_COOLDOWN",
+			envVal: "0s",
+			errSub: "must be > 0",
+		},
 	}
 
 	for _, tc := range validationErrors {
@@ -138,8 +352,24 @@ func clearEnv(t *testing.T) {
 		"HIFI_API_URL",
 		"MUSIC_PATH",
 		"DATA_PATH",
+		"TRASH_PATH",
 		"DEFAULT_QUALITY",
 		"MAX_CONCURRENT_DOWNLOADS",
+		"WATCH_MODE",
+		"WATCH_DEBOUNCE",
+		"WATCH_POLL_INTERVAL",
+		"RELEASE_CHECK_INTERVAL",
+		"DISCOVERY_REFRESH_INTERVAL",
+		"HIFI_CACHE_SIZE",
+		"HIFI_CACHE_PERSIST",
+		"HIFI_MAX_RETRIES",
+		"HIFI_RETRY_BACKOFF",
+		"HIFI_RATE_LIMIT",
+		"HIFI_RATE_BURST",
+		"HIFI_BREAKER_THRESHOLD",
+		"HIFI_BREAKER_COOLDOWN",
+		"HIFI_REGION",
+		"HIFI_HEALTH_INTERVAL",
 	} {
 		t.Setenv(key, "")
 		os.Unsetenv(key)
@@ -153,6 +383,13 @@ func assertString(t *testing.T, field, got, want string) {
 	}
 }
 
+func assertBackends(t *testing.T, got, want []HiFiBackend) {
+	t.Helper()
+	if !slices.Equal(got, want) {
+		t.Errorf("HiFiBackends = %+v, want %+v", got, want)
+	}
+}
+
 func assertInt(t *testing.T, field string, got, want int) {
 	t.Helper()
 	if got != want {
@@ -160,6 +397,13 @@ func assertInt(t *testing.T, field string, got, want int) {
 	}
 }
 
+func assertDuration(t *testing.T, field string, got, want time.Duration) {
+	t.Helper()
+	if got != want {
+		t.Errorf("%s = %s, want %s", field, got, want)
+	}
+}
+
 func contains(s, sub string) bool {
 	return len(s) >= len(sub) && searchString(s, sub)
 }
@@ -0,0 +1,193 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrCandidateNotFound is returned when accepting a candidate that is not in
+// the review queue.
+var ErrCandidateNotFound = errors.New("store: artist candidate not found")
+
+// ArtistCandidate represents a row in the artist_candidates table: a
+// possible Tidal match for a local artist folder awaiting review.
+type ArtistCandidate struct {
+	ID         int64
+	FolderName string
+	TidalID    int64
+	TidalName  string
+	PictureURL *string
+	Popularity int
+	Score      float64
+	NameScore  float64
+	AlbumScore *float64 // nil when no album evidence was available
+	CreatedAt  string
+}
+
+// ArtistReview groups the candidates queued for one artist folder, best
+// first.
+type ArtistReview struct {
+	FolderName string
+	Candidates []ArtistCandidate
+}
+
+// ReplaceArtistCandidates replaces the review queue entries for folderName
+// with candidates in a single transaction.
+func (s *Store) ReplaceArtistCandidates(ctx context.Context, folderName string, candidates []ArtistCandidate) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("store: replace artist candidates %q begin: %w", folderName, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM artist_candidates WHERE folder_name = ?`, folderName); err != nil {
+		return fmt.Errorf("store: replace artist candidates %q delete: %w", folderName, err)
+	}
+
+	for _, c := range candidates {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO artist_candidates (folder_name, tidal_id, tidal_name, picture_url,
+			                               popularity, score, name_score, album_score, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))`,
+			folderName, c.TidalID, c.TidalName, c.PictureURL,
+			c.Popularity, c.Score, c.NameScore, c.AlbumScore,
+		); err != nil {
+			return fmt.Errorf("store: insert artist candidate %q/%d: %w", folderName, c.TidalID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("store: replace artist candidates %q commit: %w", folderName, err)
+	}
+	return nil
+}
+
+// DeleteArtistCandidates removes every queued candidate for folderName.
+func (s *Store) DeleteArtistCandidates(ctx context.Context, folderName string) error {
+	_, err := s.db.ExecContext(ctx, `
+		DELETE FROM artist_candidates
+		WHERE folder_name = ?`,
+		folderName,
+	)
+	if err != nil {
+		return fmt.Errorf("store: delete artist candidates %q: %w", folderName, err)
+	}
+	return nil
+}
+
+// ListArtistReviews returns the review queue grouped by folder, ordered by
+// folder name, with each folder's candidates ordered by descending score.
+func (s *Store) ListArtistReviews(ctx context.Context) ([]ArtistReview, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, folder_name, tidal_id, tidal_name, picture_url, popularity,
+		       score, name_score, album_score, created_at
+		FROM artist_candidates
+		ORDER BY folder_name, score DESC, popularity DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("store: list artist reviews: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var reviews []ArtistReview
+	for rows.Next() {
+		c, err := scanCandidate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("store: list artist reviews scan: %w", err)
+		}
+		if n := len(reviews); n == 0 || reviews[n-1].FolderName != c.FolderName {
+			reviews = append(reviews, ArtistReview{FolderName: c.FolderName})
+		}
+		last := &reviews[len(reviews)-1]
+		last.Candidates = append(last.Candidates, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: list artist reviews rows: %w", err)
+	}
+
+	return reviews, nil
+}
+
+// CountArtistReviews returns the number of artist folders awaiting review.
+func (s *Store) CountArtistReviews(ctx context.Context) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT folder_name)
+		FROM artist_candidates`,
+	).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("store: count artist reviews: %w", err)
+	}
+	return n, nil
+}
+
+// AcceptArtistCandidate maps folderName to the queued candidate with the
+// given Tidal ID, locking the mapping as a manual choice, and clears the
+// folder's review queue, in one transaction.
+// It returns ErrCandidateNotFound if no such candidate is queued.
+func (s *Store) AcceptArtistCandidate(ctx context.Context, folderName string, tidalID int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("store: accept artist candidate %q/%d begin: %w", folderName, tidalID, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var name string
+	var picture sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		SELECT tidal_name, picture_url
+		FROM artist_candidates
+		WHERE folder_name = ? AND tidal_id = ?`,
+		folderName, tidalID,
+	).Scan(&name, &picture)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrCandidateNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("store: accept artist candidate %q/%d: %w", folderName, tidalID, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO artist_mapping (folder_name, tidal_id, tidal_name, picture_url, last_updated, locked)
+		VALUES (?, ?, ?, ?, datetime('now'), 1)
+		ON CONFLICT(folder_name) DO UPDATE SET
+			tidal_id = excluded.tidal_id, tidal_name = excluded.tidal_name,
+			picture_url = excluded.picture_url, last_updated = excluded.last_updated,
+			locked = 1, ignored = 0`,
+		folderName, tidalID, name, picture.String,
+	); err != nil {
+		return fmt.Errorf("store: accept artist candidate %q/%d mapping: %w", folderName, tidalID, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM artist_candidates WHERE folder_name = ?`, folderName); err != nil {
+		return fmt.Errorf("store: accept artist candidate %q/%d clear: %w", folderName, tidalID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("store: accept artist candidate %q/%d commit: %w", folderName, tidalID, err)
+	}
+	return nil
+}
+
+// scanCandidate scans one artist_candidates row.
+func scanCandidate(row rowScanner) (*ArtistCandidate, error) {
+	var c ArtistCandidate
+	var picture sql.NullString
+	var albumScore sql.NullFloat64
+
+	if err := row.Scan(
+		&c.ID, &c.FolderName, &c.TidalID, &c.TidalName, &picture,
+		&c.Popularity, &c.Score, &c.NameScore, &albumScore, &c.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if picture.Valid {
+		c.PictureURL = &picture.String
+	}
+	if albumScore.Valid {
+		c.AlbumScore = &albumScore.Float64
+	}
+	return &c, nil
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestArtistCandidates(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	albumScore := 0.75
+	genesis := []ArtistCandidate{
+		{TidalID: 2, TidalName: "Genesis", Popularity: 20, Score: 0.82, NameScore: 1},
+		{TidalID: 1, TidalName: "Genesis", PictureURL: strPtr("pic-1"), Popularity: 60, Score: 0.88, NameScore: 1, AlbumScore: &albumScore},
+	}
+	if err := store.ReplaceArtistCandidates(ctx, "Genesis", genesis); err != nil {
+		t.Fatalf("replace Genesis: %v", err)
+	}
+	if err := store.ReplaceArtistCandidates(ctx, "Nirvana", []ArtistCandidate{{TidalID: 9, TidalName: "Nirvana", Score: 0.7, NameScore: 1}}); err != nil {
+		t.Fatalf("replace Nirvana: %v", err)
+	}
+
+	// Replacing again must not duplicate rows.
+	if err := store.ReplaceArtistCandidates(ctx, "Genesis", genesis); err != nil {
+		t.Fatalf("replace Genesis again: %v", err)
+	}
+
+	count, err := store.CountArtistReviews(ctx)
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("CountArtistReviews() = %d, want 2", count)
+	}
+
+	reviews, err := store.ListArtistReviews(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(reviews) != 2 || reviews[0].FolderName != "Genesis" || reviews[1].FolderName != "Nirvana" {
+		t.Fatalf("reviews = %+v, want Genesis then Nirvana", reviews)
+	}
+	got := reviews[0].Candidates
+	if len(got) != 2 || got[0].TidalID != 1 {
+		t.Fatalf("Genesis candidates = %+v, want ID 1 first", got)
+	}
+	if got[0].AlbumScore == nil || *got[0].AlbumScore != 0.75 {
+		t.Errorf("AlbumScore = %v, want 0.75", got[0].AlbumScore)
+	}
+	if got[1].AlbumScore != nil {
+		t.Errorf("AlbumScore = %v, want nil", got[1].AlbumScore)
+	}
+	if got[0].PictureURL == nil || *got[0].PictureURL != "pic-1" {
+		t.Errorf("PictureURL = %v, want pic-1", got[0].PictureURL)
+	}
+
+	if err := store.DeleteArtistCandidates(ctx, "Nirvana"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	count, err = store.CountArtistReviews(ctx)
+	if err != nil {
+		t.Fatalf("count after delete: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("CountArtistReviews() after delete = %d, want 1", count)
+	}
+}
+
+func TestAcceptArtistCandidate(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	candidates := []ArtistCandidate{
+		{TidalID: 1, TidalName: "Genesis", PictureURL: strPtr("pic-1"), Score: 0.88, NameScore: 1},
+		{TidalID: 2, TidalName: "Genesis", Score: 0.82, NameScore: 1},
+	}
+	if err := store.ReplaceArtistCandidates(ctx, "Genesis", candidates); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+
+	if err := store.AcceptArtistCandidate(ctx, "Genesis", 3); !errors.Is(err, ErrCandidateNotFound) {
+		t.Fatalf("accept unknown candidate error = %v, want ErrCandidateNotFound", err)
+	}
+
+	if err := store.AcceptArtistCandidate(ctx, "Genesis", 2); err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+
+	m, err := store.GetArtistMapping(ctx, "Genesis")
+	if err != nil {
+		t.Fatalf("get mapping: %v", err)
+	}
+	if m == nil || m.TidalID == nil || *m.TidalID != 2 {
+		t.Fatalf("mapping = %+v, want Tidal ID 2", m)
+	}
+
+	reviews, err := store.ListArtistReviews(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(reviews) != 0 {
+		t.Errorf("review queue = %+v, want empty after accept", reviews)
+	}
+}
@@ -0,0 +1,208 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrAlbumNotFound is returned when a library album ID does not exist.
+var ErrAlbumNotFound = errors.New("store: library album not found")
+
+// MissingTrack is a track on a library album's linked Tidal album that has
+// no local counterpart, as found by the last completeness check.
+type MissingTrack struct {
+	AlbumID      int64
+	TidalTrackID int64
+	DiscNumber   int
+	TrackNumber  int
+	Title        string
+}
+
+// IncompleteAlbum is a library album that the last completeness check found
+// to be missing tracks.
+type IncompleteAlbum struct {
+	Album          LibraryAlbum
+	ExpectedTracks int
+	CheckedAt      string
+	Missing        []MissingTrack
+}
+
+// GetLibraryAlbum returns the library album with the given ID, or
+// ErrAlbumNotFound.
+func (s *Store) GetLibraryAlbum(ctx context.Context, id int64) (*LibraryAlbum, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, artist_folder, album_folder, track_count, path, last_scanned,
+		       tidal_album_id, match_score
+		FROM library_albums
+		WHERE id = ?`,
+		id,
+	)
+	a, err := scanLibraryAlbum(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAlbumNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("store: get library album %d: %w", id, err)
+	}
+	return a, nil
+}
+
+// ListLinkedLibraryAlbums returns every library album linked to a Tidal
+// album, ordered by artist and album folder.
+func (s *Store) ListLinkedLibraryAlbums(ctx context.Context) ([]LibraryAlbum, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, artist_folder, album_folder, track_count, path, last_scanned,
+		       tidal_album_id, match_score
+		FROM library_albums
+		WHERE tidal_album_id IS NOT NULL
+		ORDER BY artist_folder, album_folder`)
+	if err != nil {
+		return nil, fmt.Errorf("store: list linked library albums: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var albums []LibraryAlbum
+	for rows.Next() {
+		a, err := scanLibraryAlbum(rows)
+		if err != nil {
+			return nil, fmt.Errorf("store: list linked library albums scan: %w", err)
+		}
+		albums = append(albums, *a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: list linked library albums rows: %w", err)
+	}
+	return albums, nil
+}
+
+// RecordAlbumCompleteness stores the outcome of checking one library album
+// against its Tidal track list, replacing any previous result.
+func (s *Store) RecordAlbumCompleteness(ctx context.Context, albumID, tidalAlbumID int64, expected int, missing []MissingTrack) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("store: record completeness for album %d begin: %w", albumID, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO album_completeness (album_id, tidal_album_id, expected_tracks, missing_tracks, checked_at)
+		VALUES (?, ?, ?, ?, datetime('now'))
+		ON CONFLICT(album_id) DO UPDATE SET
+			tidal_album_id = excluded.tidal_album_id, expected_tracks = excluded.expected_tracks,
+			missing_tracks = excluded.missing_tracks, checked_at = excluded.checked_at`,
+		albumID, tidalAlbumID, expected, len(missing),
+	); err != nil {
+		return fmt.Errorf("store: record completeness for album %d: %w", albumID, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM album_missing_tracks WHERE album_id = ?`, albumID); err != nil {
+		return fmt.Errorf("store: record completeness for album %d clear: %w", albumID, err)
+	}
+	for _, m := range missing {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO album_missing_tracks (album_id, tidal_track_id, disc_number, track_number, title)
+			VALUES (?, ?, ?, ?, ?)`,
+			albumID, m.TidalTrackID, m.DiscNumber, m.TrackNumber, m.Title,
+		); err != nil {
+			return fmt.Errorf("store: insert missing track %d for album %d: %w", m.TidalTrackID, albumID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("store: record completeness for album %d commit: %w", albumID, err)
+	}
+	return nil
+}
+
+// ListIncompleteAlbums returns the library albums whose last completeness
+// check found missing tracks, ordered by artist and album folder. Results
+// for albums since relinked to a different Tidal album are skipped.
+func (s *Store) ListIncompleteAlbums(ctx context.Context) ([]IncompleteAlbum, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT la.id, la.artist_folder, la.album_folder, la.track_count, la.path, la.last_scanned,
+		       la.tidal_album_id, la.match_score,
+		       ac.expected_tracks, ac.checked_at
+		FROM album_completeness ac
+		JOIN library_albums la ON la.id = ac.album_id AND la.tidal_album_id = ac.tidal_album_id
+		WHERE ac.missing_tracks > 0
+		ORDER BY la.artist_folder, la.album_folder`)
+	if err != nil {
+		return nil, fmt.Errorf("store: list incomplete albums: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var albums []IncompleteAlbum
+	for rows.Next() {
+		var ia IncompleteAlbum
+		var tidalAlbumID sql.NullInt64
+		var matchScore sql.NullFloat64
+		a := &ia.Album
+		if err := rows.Scan(&a.ID, &a.ArtistFolder, &a.AlbumFolder, &a.TrackCount, &a.Path, &a.LastScanned,
+			&tidalAlbumID, &matchScore, &ia.ExpectedTracks, &ia.CheckedAt); err != nil {
+			return nil, fmt.Errorf("store: list incomplete albums scan: %w", err)
+		}
+		if tidalAlbumID.Valid {
+			a.TidalAlbumID = &tidalAlbumID.Int64
+		}
+		if matchScore.Valid {
+			a.MatchScore = &matchScore.Float64
+		}
+		albums = append(albums, ia)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: list incomplete albums rows: %w", err)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("store: list incomplete albums close: %w", err)
+	}
+
+	for i := range albums {
+		missing, err := s.ListMissingTracks(ctx, albums[i].Album.ID)
+		if err != nil {
+			return nil, err
+		}
+		albums[i].Missing = missing
+	}
+	return albums, nil
+}
+
+// ListMissingTracks returns the missing tracks recorded for a library album,
+// in disc and track order.
+func (s *Store) ListMissingTracks(ctx context.Context, albumID int64) ([]MissingTrack, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT album_id, tidal_track_id, disc_number, track_number, title
+		FROM album_missing_tracks
+		WHERE album_id = ?
+		ORDER BY disc_number, track_number, title`,
+		albumID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store: list missing tracks for album %d: %w", albumID, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var missing []MissingTrack
+	for rows.Next() {
+		var m MissingTrack
+		if err := rows.Scan(&m.AlbumID, &m.TidalTrackID, &m.DiscNumber, &m.TrackNumber, &m.Title); err != nil {
+			return nil, fmt.Errorf("store: list missing tracks for album %d scan: %w", albumID, err)
+		}
+		missing = append(missing, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: list missing tracks for album %d rows: %w", albumID, err)
+	}
+	return missing, nil
+}
+
+// LastCompletenessCheck returns when any album was last checked for
+// completeness, or "" if none has been.
+func (s *Store) LastCompletenessCheck(ctx context.Context) (string, error) {
+	var checkedAt sql.NullString
+	if err := s.db.QueryRowContext(ctx, `SELECT MAX(checked_at) FROM album_completeness`).Scan(&checkedAt); err != nil {
+		return "", fmt.Errorf("store: last completeness check: %w", err)
+	}
+	return checkedAt.String, nil
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRecordAlbumCompleteness(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	okID := seedAlbum(t, store, "Radiohead", "OK Computer")
+	kidID := seedAlbum(t, store, "Radiohead", "Kid A")
+	seedAlbum(t, store, "Radiohead", "Unlinked")
+	if err := store.LinkLibraryAlbum(ctx, okID, 100, 0.9); err != nil {
+		t.Fatalf("link: %v", err)
+	}
+	if err := store.LinkLibraryAlbum(ctx, kidID, 200, 0.9); err != nil {
+		t.Fatalf("link: %v", err)
+	}
+
+	linked, err := store.ListLinkedLibraryAlbums(ctx)
+	if err != nil {
+		t.Fatalf("ListLinkedLibraryAlbums: %v", err)
+	}
+	if len(linked) != 2 || linked[0].AlbumFolder != "Kid A" || linked[1].AlbumFolder != "OK Computer" {
+		t.Errorf("ListLinkedLibraryAlbums = %+v, want Kid A and OK Computer", linked)
+	}
+
+	if err := store.RecordAlbumCompleteness(ctx, okID, 100, 12, []MissingTrack{
+		{TidalTrackID: 3, TrackNumber: 3, Title: "Subterranean Homesick Alien"},
+		{TidalTrackID: 2, TrackNumber: 2, Title: "Paranoid Android"},
+	}); err != nil {
+		t.Fatalf("record OK Computer: %v", err)
+	}
+	if err := store.RecordAlbumCompleteness(ctx, kidID, 200, 10, nil); err != nil {
+		t.Fatalf("record Kid A: %v", err)
+	}
+
+	incomplete, err := store.ListIncompleteAlbums(ctx)
+	if err != nil {
+		t.Fatalf("ListIncompleteAlbums: %v", err)
+	}
+	if len(incomplete) != 1 {
+		t.Fatalf("got %d incomplete albums, want 1", len(incomplete))
+	}
+	ia := incomplete[0]
+	if ia.Album.ID != okID || ia.ExpectedTracks != 12 || ia.CheckedAt == "" {
+		t.Errorf("incomplete album = %+v", ia)
+	}
+	if len(ia.Missing) != 2 || ia.Missing[0].TrackNumber != 2 || ia.Missing[1].TrackNumber != 3 {
+		t.Errorf("Missing = %+v, want tracks 2 and 3 in order", ia.Missing)
+	}
+
+	// A later check replaces the previous result.
+	if err := store.RecordAlbumCompleteness(ctx, okID, 100, 12, nil); err != nil {
+		t.Fatalf("re-record: %v", err)
+	}
+	incomplete, err = store.ListIncompleteAlbums(ctx)
+	if err != nil {
+		t.Fatalf("ListIncompleteAlbums after re-record: %v", err)
+	}
+	if len(incomplete) != 0 {
+		t.Errorf("got %d incomplete albums after completion, want 0", len(incomplete))
+	}
+	missing, err := store.ListMissingTracks(ctx, okID)
+	if err != nil || len(missing) != 0 {
+		t.Errorf("ListMissingTracks = %v, %v; want none", missing, err)
+	}
+
+	last, err := store.LastCompletenessCheck(ctx)
+	if err != nil || last == "" {
+		t.Errorf("LastCompletenessCheck = %q, %v; want a timestamp", last, err)
+	}
+}
+
+func TestListIncompleteAlbums_SkipsRelinked(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	id := seedAlbum(t, store, "Radiohead", "OK Computer")
+	if err := store.LinkLibraryAlbum(ctx, id, 100, 0.9); err != nil {
+		t.Fatalf("link: %v", err)
+	}
+	if err := store.RecordAlbumCompleteness(ctx, id, 100, 12, []MissingTrack{{TidalTrackID: 2, Title: "Paranoid Android"}}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if err := store.LinkLibraryAlbum(ctx, id, 101, 0.9); err != nil {
+		t.Fatalf("relink: %v", err)
+	}
+
+	incomplete, err := store.ListIncompleteAlbums(ctx)
+	if err != nil {
+		t.Fatalf("ListIncompleteAlbums: %v", err)
+	}
+	if len(incomplete) != 0 {
+		t.Errorf("got %d incomplete albums, want the stale result skipped", len(incomplete))
+	}
+}
+
+func TestGetLibraryAlbum(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	id := seedAlbum(t, store, "Radiohead", "OK Computer")
+	a, err := store.GetLibraryAlbum(ctx, id)
+	if err != nil {
+		t.Fatalf("GetLibraryAlbum: %v", err)
+	}
+	if a.AlbumFolder != "OK Computer" || a.Path != "/music/Radiohead/OK Computer" {
+		t.Errorf("album = %+v", a)
+	}
+
+	if _, err := store.GetLibraryAlbum(ctx, id+100); !errors.Is(err, ErrAlbumNotFound) {
+		t.Errorf("GetLibraryAlbum(missing) error = %v, want ErrAlbumNotFound", err)
+	}
+}
@@ -39,7 +39,7 @@ func TestMigrate(t *testing.T) {
 			t.Fatalf("migrate: %v", err)
 		}
 
-		wantTables := []string{"artist_mapping", "library_albums", "downloads"}
+		wantTables := []string{"artist_mapping", "library_albums", "downloads", "scans", "scan_errors", "library_tracks", "artist_candidates"}
 		for _, table := range wantTables {
 			assertTableExists(t, handle, table)
 		}
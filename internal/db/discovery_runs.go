@@ -0,0 +1,189 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrDiscoveryRunNotFound is returned when a discovery run does not exist.
+var ErrDiscoveryRunNotFound = errors.New("store: discovery run not found")
+
+// DiscoveryRun represents a row in the discovery_runs table: a saved set of
+// album recommendations.
+type DiscoveryRun struct {
+	ID        int64
+	Seeds     []string // library artists the run started from
+	Albums    int      // number of recommended albums
+	CreatedAt string
+}
+
+// DiscoveryRunAlbum represents a row in the discovery_run_albums table: an
+// album recommended by a discovery run, in the run's order.
+type DiscoveryRunAlbum struct {
+	TidalAlbumID  int64
+	Title         string
+	ArtistTidalID int64
+	ArtistName    string
+	Cover         string // UUID
+	ReleaseDate   string
+	Seeds         []string // library artists that led to it, strongest first
+	Score         float64
+}
+
+// Seed lists are stored one name per line.
+const seedSeparator = "\n"
+
+// CreateDiscoveryRun saves a discovery run started from the given seeds,
+// with its albums in order, and returns the run's ID.
+func (s *Store) CreateDiscoveryRun(ctx context.Context, seeds []string, albums []DiscoveryRunAlbum) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("store: create discovery run begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO discovery_runs (seeds) VALUES (?)`, strings.Join(seeds, seedSeparator))
+	if err != nil {
+		return 0, fmt.Errorf("store: create discovery run: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("store: create discovery run id: %w", err)
+	}
+
+	for i, a := range albums {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO discovery_run_albums (run_id, position, tidal_album_id, title, artist_tidal_id, artist_name, cover, release_date, seeds, score)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, i, a.TidalAlbumID, a.Title, a.ArtistTidalID, a.ArtistName, a.Cover, a.ReleaseDate, strings.Join(a.Seeds, seedSeparator), a.Score,
+		); err != nil {
+			return 0, fmt.Errorf("store: create discovery run album %d: %w", a.TidalAlbumID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("store: create discovery run commit: %w", err)
+	}
+	return id, nil
+}
+
+// GetDiscoveryRun returns the discovery run with the given ID.
+func (s *Store) GetDiscoveryRun(ctx context.Context, id int64) (*DiscoveryRun, error) {
+	run, err := scanDiscoveryRun(s.db.QueryRowContext(ctx, `
+		SELECT r.id, r.seeds, r.created_at,
+		       (SELECT COUNT(*) FROM discovery_run_albums a WHERE a.run_id = r.id)
+		FROM discovery_runs r WHERE r.id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("store: get discovery run %d: %w", id, err)
+	}
+	return run, nil
+}
+
+// LatestDiscoveryRun returns the most recent discovery run, or
+// ErrDiscoveryRunNotFound if there has been none.
+func (s *Store) LatestDiscoveryRun(ctx context.Context) (*DiscoveryRun, error) {
+	run, err := scanDiscoveryRun(s.db.QueryRowContext(ctx, `
+		SELECT r.id, r.seeds, r.created_at,
+		       (SELECT COUNT(*) FROM discovery_run_albums a WHERE a.run_id = r.id)
+		FROM discovery_runs r ORDER BY r.id DESC LIMIT 1`))
+	if err != nil {
+		return nil, fmt.Errorf("store: latest discovery run: %w", err)
+	}
+	return run, nil
+}
+
+// scanDiscoveryRun reads a discovery run from a single-row query, mapping
+// no rows to ErrDiscoveryRunNotFound.
+func scanDiscoveryRun(row *sql.Row) (*DiscoveryRun, error) {
+	var run DiscoveryRun
+	var seeds string
+	err := row.Scan(&run.ID, &seeds, &run.CreatedAt, &run.Albums)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDiscoveryRunNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	run.Seeds = splitSeeds(seeds)
+	return &run, nil
+}
+
+// ListDiscoveryRuns returns up to limit discovery runs, newest first,
+// skipping the first offset.
+func (s *Store) ListDiscoveryRuns(ctx context.Context, limit, offset int) ([]DiscoveryRun, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.id, r.seeds, r.created_at, COUNT(a.run_id)
+		FROM discovery_runs r
+		LEFT JOIN discovery_run_albums a ON a.run_id = r.id
+		GROUP BY r.id
+		ORDER BY r.id DESC
+		LIMIT ? OFFSET ?`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("store: list discovery runs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var runs []DiscoveryRun
+	for rows.Next() {
+		var run DiscoveryRun
+		var seeds string
+		if err := rows.Scan(&run.ID, &seeds, &run.CreatedAt, &run.Albums); err != nil {
+			return nil, fmt.Errorf("store: list discovery runs scan: %w", err)
+		}
+		run.Seeds = splitSeeds(seeds)
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: list discovery runs rows: %w", err)
+	}
+	return runs, nil
+}
+
+// CountDiscoveryRuns returns how many discovery runs have been saved.
+func (s *Store) CountDiscoveryRuns(ctx context.Context) (int, error) {
+	var n int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM discovery_runs`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("store: count discovery runs: %w", err)
+	}
+	return n, nil
+}
+
+// ListDiscoveryRunAlbums returns the albums recommended by a discovery run,
+// in the run's order.
+func (s *Store) ListDiscoveryRunAlbums(ctx context.Context, runID int64) ([]DiscoveryRunAlbum, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT tidal_album_id, title, artist_tidal_id, artist_name, cover, release_date, seeds, score
+		FROM discovery_run_albums
+		WHERE run_id = ?
+		ORDER BY position`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("store: list discovery run %d albums: %w", runID, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var albums []DiscoveryRunAlbum
+	for rows.Next() {
+		var a DiscoveryRunAlbum
+		var seeds string
+		if err := rows.Scan(&a.TidalAlbumID, &a.Title, &a.ArtistTidalID, &a.ArtistName, &a.Cover, &a.ReleaseDate, &seeds, &a.Score); err != nil {
+			return nil, fmt.Errorf("store: list discovery run %d albums scan: %w", runID, err)
+		}
+		a.Seeds = splitSeeds(seeds)
+		albums = append(albums, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: list discovery run %d albums rows: %w", runID, err)
+	}
+	return albums, nil
+}
+
+// splitSeeds splits a stored seed list, which is "" when empty.
+func splitSeeds(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, seedSeparator)
+}
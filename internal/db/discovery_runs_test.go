@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestDiscoveryRuns(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	if _, err := store.LatestDiscoveryRun(ctx); !errors.Is(err, ErrDiscoveryRunNotFound) {
+		t.Fatalf("LatestDiscoveryRun() on empty store: err = %v, want ErrDiscoveryRunNotFound", err)
+	}
+
+	first, err := store.CreateDiscoveryRun(ctx, []string{"Portishead"}, []DiscoveryRunAlbum{
+		{TidalAlbumID: 100, Title: "Mezzanine", ArtistTidalID: 10, ArtistName: "Massive Attack", Seeds: []string{"Portishead"}, Score: 0.9},
+	})
+	if err != nil {
+		t.Fatalf("CreateDiscoveryRun(first): %v", err)
+	}
+	second, err := store.CreateDiscoveryRun(ctx, []string{"Björk", "Portishead"}, []DiscoveryRunAlbum{
+		{TidalAlbumID: 200, Title: "Maxinquaye", ArtistTidalID: 20, ArtistName: "Tricky", Cover: "ab-cd", ReleaseDate: "1995-02-20", Seeds: []string{"Portishead", "Björk"}, Score: 0.8},
+		{TidalAlbumID: 100, Title: "Mezzanine", ArtistTidalID: 10, ArtistName: "Massive Attack", Score: 0.5},
+	})
+	if err != nil {
+		t.Fatalf("CreateDiscoveryRun(second): %v", err)
+	}
+	if _, err := store.CreateDiscoveryRun(ctx, nil, nil); err != nil {
+		t.Fatalf("CreateDiscoveryRun(empty): %v", err)
+	}
+
+	latest, err := store.LatestDiscoveryRun(ctx)
+	if err != nil {
+		t.Fatalf("LatestDiscoveryRun: %v", err)
+	}
+	if latest.ID <= second || latest.Albums != 0 || latest.Seeds != nil || latest.CreatedAt == "" {
+		t.Errorf("LatestDiscoveryRun() = %+v, want the empty run", latest)
+	}
+
+	run, err := store.GetDiscoveryRun(ctx, second)
+	if err != nil {
+		t.Fatalf("GetDiscoveryRun: %v", err)
+	}
+	if run.Albums != 2 || !slices.Equal(run.Seeds, []string{"Björk", "Portishead"}) {
+		t.Errorf("GetDiscoveryRun() = %+v", run)
+	}
+	if _, err := store.GetDiscoveryRun(ctx, 999); !errors.Is(err, ErrDiscoveryRunNotFound) {
+		t.Errorf("GetDiscoveryRun(999): err = %v, want ErrDiscoveryRunNotFound", err)
+	}
+
+	albums, err := store.ListDiscoveryRunAlbums(ctx, second)
+	if err != nil {
+		t.Fatalf("ListDiscoveryRunAlbums: %v", err)
+	}
+	if len(albums) != 2 {
+		t.Fatalf("ListDiscoveryRunAlbums() returned %d, want 2", len(albums))
+	}
+	if a := albums[0]; a.TidalAlbumID != 200 || a.Cover != "ab-cd" || a.ReleaseDate != "1995-02-20" || a.Score != 0.8 || !slices.Equal(a.Seeds, []string{"Portishead", "Björk"}) {
+		t.Errorf("albums[0] = %+v", a)
+	}
+	if a := albums[1]; a.TidalAlbumID != 100 || a.Seeds != nil {
+		t.Errorf("albums[1] = %+v", a)
+	}
+
+	n, err := store.CountDiscoveryRuns(ctx)
+	if err != nil {
+		t.Fatalf("CountDiscoveryRuns: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("CountDiscoveryRuns() = %d, want 3", n)
+	}
+
+	page, err := store.ListDiscoveryRuns(ctx, 2, 1)
+	if err != nil {
+		t.Fatalf("ListDiscoveryRuns: %v", err)
+	}
+	if len(page) != 2 || page[0].ID != second || page[0].Albums != 2 || page[1].ID != first || page[1].Albums != 1 {
+		t.Errorf("ListDiscoveryRuns(2, 1) = %+v, want the second and first runs", page)
+	}
+}
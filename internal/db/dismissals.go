@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrDismissalNotFound is returned when a dismissal ID does not exist.
+var ErrDismissalNotFound = errors.New("store: dismissal not found")
+
+// Kinds of dismissal: a single album, or every album by an artist.
+const (
+	DismissAlbum  = "album"
+	DismissArtist = "artist"
+)
+
+// Dismissal represents a row in the discovery_dismissals table: an album or
+// artist the user doesn't want recommended, for good or until SnoozedUntil.
+type Dismissal struct {
+	ID           int64
+	Kind         string // DismissAlbum or DismissArtist
+	TidalID      int64
+	Title        string // album title, or artist name for an artist
+	ArtistName   string
+	SnoozedUntil *string // nil for a permanent dismissal
+	CreatedAt    string
+}
+
+// AddDismissal records that an album or artist should not be recommended.
+// With snoozeDays > 0 the dismissal lapses after that many days, otherwise
+// it is permanent. Dismissing the same album or artist again replaces the
+// earlier dismissal.
+func (s *Store) AddDismissal(ctx context.Context, kind string, tidalID int64, title, artistName string, snoozeDays int) error {
+	var until sql.NullString
+	if snoozeDays > 0 {
+		until = sql.NullString{String: fmt.Sprintf("+%d days", snoozeDays), Valid: true}
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO discovery_dismissals (kind, tidal_id, title, artist_name, snoozed_until)
+		VALUES (?, ?, ?, ?, datetime('now', ?))
+		ON CONFLICT(kind, tidal_id) DO UPDATE SET
+			title = excluded.title, artist_name = excluded.artist_name,
+			snoozed_until = excluded.snoozed_until, created_at = CURRENT_TIMESTAMP`,
+		kind, tidalID, title, artistName, until,
+	)
+	if err != nil {
+		return fmt.Errorf("store: add %s dismissal %d: %w", kind, tidalID, err)
+	}
+	return nil
+}
+
+// ListDismissals returns the dismissals in force, newest first. Snoozes that
+// have lapsed are left out.
+func (s *Store) ListDismissals(ctx context.Context) ([]Dismissal, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, kind, tidal_id, title, artist_name, snoozed_until, created_at
+		FROM discovery_dismissals
+		WHERE snoozed_until IS NULL OR snoozed_until > datetime('now')
+		ORDER BY created_at DESC, id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("store: list dismissals: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var dismissals []Dismissal
+	for rows.Next() {
+		var d Dismissal
+		var until sql.NullString
+		if err := rows.Scan(&d.ID, &d.Kind, &d.TidalID, &d.Title, &d.ArtistName, &until, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("store: list dismissals scan: %w", err)
+		}
+		if until.Valid {
+			d.SnoozedUntil = &until.String
+		}
+		dismissals = append(dismissals, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: list dismissals rows: %w", err)
+	}
+	return dismissals, nil
+}
+
+// DeleteDismissal removes a dismissal, so its album or artist may be
+// recommended again. It returns ErrDismissalNotFound if there is no
+// dismissal with that ID.
+func (s *Store) DeleteDismissal(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM discovery_dismissals WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("store: delete dismissal %d: %w", id, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("store: delete dismissal %d: %w", id, err)
+	}
+	if n == 0 {
+		return ErrDismissalNotFound
+	}
+	return nil
+}
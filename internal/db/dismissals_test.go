@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDismissals(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	if err := store.AddDismissal(ctx, DismissAlbum, 100, "Dummy", "Portishead", 0); err != nil {
+		t.Fatalf("AddDismissal(album): %v", err)
+	}
+	if err := store.AddDismissal(ctx, DismissArtist, 10, "Muse", "Muse", 0); err != nil {
+		t.Fatalf("AddDismissal(artist): %v", err)
+	}
+	if err := store.AddDismissal(ctx, DismissAlbum, 200, "Third", "Portishead", 90); err != nil {
+		t.Fatalf("AddDismissal(snooze): %v", err)
+	}
+
+	all, err := store.ListDismissals(ctx)
+	if err != nil {
+		t.Fatalf("ListDismissals: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("ListDismissals() returned %d, want 3: %+v", len(all), all)
+	}
+	byID := make(map[int64]Dismissal)
+	for _, d := range all {
+		byID[d.TidalID] = d
+	}
+	if d := byID[100]; d.Kind != DismissAlbum || d.Title != "Dummy" || d.ArtistName != "Portishead" || d.SnoozedUntil != nil {
+		t.Errorf("album dismissal = %+v", d)
+	}
+	if d := byID[10]; d.Kind != DismissArtist || d.SnoozedUntil != nil {
+		t.Errorf("artist dismissal = %+v", d)
+	}
+	if d := byID[200]; d.SnoozedUntil == nil {
+		t.Errorf("snoozed dismissal = %+v, want a snooze end", d)
+	}
+
+	// Dismissing the snoozed album for good replaces the snooze.
+	if err := store.AddDismissal(ctx, DismissAlbum, 200, "Third", "Portishead", 0); err != nil {
+		t.Fatalf("AddDismissal(again): %v", err)
+	}
+	all, err = store.ListDismissals(ctx)
+	if err != nil {
+		t.Fatalf("ListDismissals: %v", err)
+	}
+	if len(all) != 3 || all[0].TidalID != 200 || all[0].SnoozedUntil != nil {
+		t.Errorf("ListDismissals() = %+v, want the permanent Third dismissal first of 3", all)
+	}
+
+	// A lapsed snooze is no longer in force.
+	if err := store.AddDismissal(ctx, DismissAlbum, 300, "Roseland NYC Live", "Portishead", 90); err != nil {
+		t.Fatalf("AddDismissal(snooze): %v", err)
+	}
+	if _, err := store.db.ExecContext(ctx, `UPDATE discovery_dismissals SET snoozed_until = datetime('now', '-1 day') WHERE tidal_id = 300`); err != nil {
+		t.Fatalf("backdate snooze: %v", err)
+	}
+	all, err = store.ListDismissals(ctx)
+	if err != nil {
+		t.Fatalf("ListDismissals: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("ListDismissals() returned %d, want the lapsed snooze left out", len(all))
+	}
+
+	if err := store.DeleteDismissal(ctx, byID[10].ID); err != nil {
+		t.Fatalf("DeleteDismissal: %v", err)
+	}
+	if err := store.DeleteDismissal(ctx, byID[10].ID); !errors.Is(err, ErrDismissalNotFound) {
+		t.Errorf("DeleteDismissal() again error = %v, want ErrDismissalNotFound", err)
+	}
+	all, err = store.ListDismissals(ctx)
+	if err != nil {
+		t.Fatalf("ListDismissals: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("ListDismissals() returned %d after undo, want 2", len(all))
+	}
+}
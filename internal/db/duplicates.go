@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// DuplicateCandidate is a library album with the evidence used to spot
+// duplicate copies of it: its quality, size on disk and track durations.
+type DuplicateCandidate struct {
+	Album     LibraryAlbum
+	Holding   AlbumHolding // quality of the album's tracks
+	SizeBytes int64
+	Durations []float64 // seconds, in disc and track order
+}
+
+// ListDuplicateCandidates returns every library album with its quality,
+// size and track durations, ordered by artist and album folder.
+func (s *Store) ListDuplicateCandidates(ctx context.Context) ([]DuplicateCandidate, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT la.id, la.artist_folder, la.album_folder, la.track_count, la.path, la.last_scanned,
+		       la.tidal_album_id, la.match_score,
+		       COALESCE(MIN(lt.bit_depth), 0), COALESCE(MIN(lt.sample_rate), 0),
+		       COALESCE(SUM(CASE WHEN lt.bit_depth = 0 THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(lt.size_bytes), 0)
+		FROM library_albums la
+		LEFT JOIN library_tracks lt ON lt.album_id = la.id
+		GROUP BY la.id
+		ORDER BY la.artist_folder, la.album_folder`)
+	if err != nil {
+		return nil, fmt.Errorf("store: list duplicate candidates: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var candidates []DuplicateCandidate
+	index := make(map[int64]int)
+	for rows.Next() {
+		var (
+			c            DuplicateCandidate
+			tidalAlbumID sql.NullInt64
+			matchScore   sql.NullFloat64
+		)
+		a, h := &c.Album, &c.Holding
+		if err := rows.Scan(&a.ID, &a.ArtistFolder, &a.AlbumFolder, &a.TrackCount, &a.Path, &a.LastScanned,
+			&tidalAlbumID, &matchScore, &h.MinBitDepth, &h.MinSampleRate, &h.LossyTracks, &c.SizeBytes); err != nil {
+			return nil, fmt.Errorf("store: list duplicate candidates scan: %w", err)
+		}
+		if tidalAlbumID.Valid {
+			a.TidalAlbumID = &tidalAlbumID.Int64
+			h.TidalAlbumID = tidalAlbumID.Int64
+		}
+		if matchScore.Valid {
+			a.MatchScore = &matchScore.Float64
+		}
+		h.LibraryAlbumID, h.Path, h.TrackCount = a.ID, a.Path, a.TrackCount
+
+		index[a.ID] = len(candidates)
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: list duplicate candidates rows: %w", err)
+	}
+	_ = rows.Close()
+
+	durRows, err := s.db.QueryContext(ctx, `
+		SELECT album_id, COALESCE(duration, 0)
+		FROM library_tracks
+		ORDER BY album_id, disc_number, track_number, filename`)
+	if err != nil {
+		return nil, fmt.Errorf("store: list duplicate candidate durations: %w", err)
+	}
+	defer func() { _ = durRows.Close() }()
+
+	for durRows.Next() {
+		var (
+			albumID  int64
+			duration float64
+		)
+		if err := durRows.Scan(&albumID, &duration); err != nil {
+			return nil, fmt.Errorf("store: list duplicate candidate durations scan: %w", err)
+		}
+		if i, ok := index[albumID]; ok {
+			candidates[i].Durations = append(candidates[i].Durations, duration)
+		}
+	}
+	if err := durRows.Err(); err != nil {
+		return nil, fmt.Errorf("store: list duplicate candidate durations rows: %w", err)
+	}
+
+	return candidates, nil
+}
+
+// DeleteLibraryAlbum removes a library album and, by cascade, its tracks and
+// completeness records.
+func (s *Store) DeleteLibraryAlbum(ctx context.Context, id int64) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM library_albums WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("store: delete library album %d: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestListDuplicateCandidates(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	id := seedAlbum(t, store, "AC_DC", "Back in Black")
+	seedAlbum(t, store, "AC-DC", "Back in Black")
+	if err := store.SyncLibraryTracks(ctx, "AC_DC", "Back in Black", []LibraryTrack{
+		{Filename: "02.flac", Path: "/music/AC_DC/Back in Black/02.flac", Format: "flac", TrackNumber: 2, Duration: 210.5, BitDepth: 16, SampleRate: 44100, SizeBytes: 300},
+		{Filename: "01.flac", Path: "/music/AC_DC/Back in Black/01.flac", Format: "flac", TrackNumber: 1, Duration: 255, BitDepth: 24, SampleRate: 96000, SizeBytes: 700},
+	}); err != nil {
+		t.Fatalf("sync tracks: %v", err)
+	}
+	if err := store.LinkLibraryAlbum(ctx, id, 100, 0.9); err != nil {
+		t.Fatalf("link: %v", err)
+	}
+
+	candidates, err := store.ListDuplicateCandidates(ctx)
+	if err != nil {
+		t.Fatalf("ListDuplicateCandidates: %v", err)
+	}
+	if len(candidates) != 2 {
+		t.Fatalf("got %d candidates, want 2", len(candidates))
+	}
+
+	got := candidates[1] // "AC_DC" sorts after "AC-DC"
+	if got.Album.ID != id || got.Holding.TidalAlbumID != 100 || got.Holding.LibraryAlbumID != id {
+		t.Errorf("candidate = %+v, want album %d linked to 100", got, id)
+	}
+	if got.Holding.MinBitDepth != 16 || got.Holding.MinSampleRate != 44100 || got.SizeBytes != 1000 {
+		t.Errorf("quality = %d/%d size %d, want 16/44100 size 1000", got.Holding.MinBitDepth, got.Holding.MinSampleRate, got.SizeBytes)
+	}
+	if len(got.Durations) != 2 || got.Durations[0] != 255 || got.Durations[1] != 210.5 {
+		t.Errorf("Durations = %v, want track order [255 210.5]", got.Durations)
+	}
+	if len(candidates[0].Durations) != 0 {
+		t.Errorf("album without tracks has durations %v", candidates[0].Durations)
+	}
+}
+
+func TestDeleteLibraryAlbum(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	id := seedAlbum(t, store, "Radiohead", "OK Computer")
+	if err := store.SyncLibraryTracks(ctx, "Radiohead", "OK Computer", []LibraryTrack{
+		{Filename: "01.flac", Path: "/music/Radiohead/OK Computer/01.flac", Format: "flac"},
+	}); err != nil {
+		t.Fatalf("sync tracks: %v", err)
+	}
+
+	if err := store.DeleteLibraryAlbum(ctx, id); err != nil {
+		t.Fatalf("DeleteLibraryAlbum: %v", err)
+	}
+	if _, err := store.GetLibraryAlbum(ctx, id); !errors.Is(err, ErrAlbumNotFound) {
+		t.Errorf("GetLibraryAlbum after delete = %v, want ErrAlbumNotFound", err)
+	}
+	tracks, err := store.ListTracksForAlbum(ctx, id)
+	if err != nil || len(tracks) != 0 {
+		t.Errorf("tracks after delete = %v, %v; want none", tracks, err)
+	}
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// LoadHiFiCacheEntry returns the cached hifi-api response body stored under
+// key and when it was fetched, or a nil body if there is none.
+func (s *Store) LoadHiFiCacheEntry(ctx context.Context, key string) ([]byte, time.Time, error) {
+	var (
+		body      []byte
+		fetchedAt int64
+	)
+	err := s.db.QueryRowContext(ctx,
+		`SELECT body, fetched_at FROM hifi_cache WHERE key = ?`, key,
+	).Scan(&body, &fetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("store: load hifi cache entry: %w", err)
+	}
+	return body, time.Unix(fetchedAt, 0), nil
+}
+
+// SaveHiFiCacheEntry stores a hifi-api response body under key, replacing
+// any earlier one. Fetch times are kept to the second.
+func (s *Store) SaveHiFiCacheEntry(ctx context.Context, key string, body []byte, fetchedAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO hifi_cache (key, body, fetched_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET body = excluded.body, fetched_at = excluded.fetched_at`,
+		key, body, fetchedAt.Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("store: save hifi cache entry: %w", err)
+	}
+	return nil
+}
+
+// DeleteHiFiCacheEntries removes the cached responses fetched before the
+// given time, or all of them if before is zero, and returns how many were
+// removed.
+func (s *Store) DeleteHiFiCacheEntries(ctx context.Context, before time.Time) (int64, error) {
+	query, args := `DELETE FROM hifi_cache`, []any{}
+	if !before.IsZero() {
+		query, args = query+` WHERE fetched_at < ?`, append(args, before.Unix())
+	}
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("store: delete hifi cache entries: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("store: delete hifi cache entries rows affected: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHiFiCacheEntries(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	body, _, err := store.LoadHiFiCacheEntry(ctx, "/album/?id=1")
+	if err != nil {
+		t.Fatalf("load missing: %v", err)
+	}
+	if body != nil {
+		t.Fatalf("missing entry body = %q, want nil", body)
+	}
+
+	old := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	if err := store.SaveHiFiCacheEntry(ctx, "/album/?id=1", []byte(`{"v":1}`), old); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if err := store.SaveHiFiCacheEntry(ctx, "/album/?id=1", []byte(`{"v":2}`), old.Add(time.Hour)); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if err := store.SaveHiFiCacheEntry(ctx, "/album/?id=2", []byte(`{}`), old); err != nil {
+		t.Fatalf("save second: %v", err)
+	}
+
+	body, fetchedAt, err := store.LoadHiFiCacheEntry(ctx, "/album/?id=1")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if string(body) != `{"v":2}` {
+		t.Errorf("body = %q, want the replacement", body)
+	}
+	if !fetchedAt.Equal(old.Add(time.Hour)) {
+		t.Errorf("fetchedAt = %v, want %v", fetchedAt, old.Add(time.Hour))
+	}
+
+	n, err := store.DeleteHiFiCacheEntries(ctx, old.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("delete old: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("deleted %d old entries, want 1", n)
+	}
+	if body, _, _ := store.LoadHiFiCacheEntry(ctx, "/album/?id=2"); body != nil {
+		t.Error("old entry still cached")
+	}
+
+	n, err = store.DeleteHiFiCacheEntries(ctx, time.Time{})
+	if err != nil {
+		t.Fatalf("delete all: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("deleted %d entries, want 1", n)
+	}
+}
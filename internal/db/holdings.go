@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// AlbumHolding summarises what we hold of one Tidal album: the linked
+// library copy with the most tracks or, when no library album is linked, the
+// most recent completed download.
+type AlbumHolding struct {
+	TidalAlbumID   int64
+	LibraryAlbumID int64  // 0 when only a download record exists
+	Path           string // library album folder or download output path
+	TrackCount     int
+	MinBitDepth    int // lowest bit depth across the library tracks
+	MinSampleRate  int // lowest sample rate across the library tracks
+	LossyTracks    int // library tracks in a lossy format
+	// DownloadQuality is the requested quality of a download-only holding,
+	// e.g. "LOSSLESS"; empty for library holdings.
+	DownloadQuality string
+}
+
+// GetAlbumHoldings returns holdings for the given Tidal album IDs, keyed by
+// ID. Albums we hold nothing of are absent from the map.
+func (s *Store) GetAlbumHoldings(ctx context.Context, tidalAlbumIDs []int64) (map[int64]AlbumHolding, error) {
+	holdings := make(map[int64]AlbumHolding)
+	if len(tidalAlbumIDs) == 0 {
+		return holdings, nil
+	}
+
+	placeholders := "?" + strings.Repeat(", ?", len(tidalAlbumIDs)-1)
+	args := make([]any, 0, len(tidalAlbumIDs))
+	for _, id := range tidalAlbumIDs {
+		args = append(args, id)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT la.tidal_album_id, la.id, la.path, la.track_count,
+		       COALESCE(MIN(lt.bit_depth), 0), COALESCE(MIN(lt.sample_rate), 0),
+		       COALESCE(SUM(CASE WHEN lt.bit_depth = 0 THEN 1 ELSE 0 END), 0)
+		FROM library_albums la
+		LEFT JOIN library_tracks lt ON lt.album_id = la.id
+		WHERE la.tidal_album_id IN (`+placeholders+`)
+		GROUP BY la.id
+		ORDER BY la.track_count DESC, la.id`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store: get album holdings: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var h AlbumHolding
+		if err := rows.Scan(&h.TidalAlbumID, &h.LibraryAlbumID, &h.Path, &h.TrackCount, &h.MinBitDepth, &h.MinSampleRate, &h.LossyTracks); err != nil {
+			return nil, fmt.Errorf("store: get album holdings scan: %w", err)
+		}
+		if _, ok := holdings[h.TidalAlbumID]; !ok { // ordered by most tracks first
+			holdings[h.TidalAlbumID] = h
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: get album holdings rows: %w", err)
+	}
+
+	dlRows, err := s.db.QueryContext(ctx, `
+		SELECT tidal_album_id, COALESCE(output_path, ''), total_tracks, COALESCE(quality, '')
+		FROM downloads
+		WHERE status = 'complete' AND tidal_album_id IN (`+placeholders+`)
+		ORDER BY completed_at DESC, id DESC`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store: get album holdings downloads: %w", err)
+	}
+	defer func() { _ = dlRows.Close() }()
+
+	for dlRows.Next() {
+		var h AlbumHolding
+		if err := dlRows.Scan(&h.TidalAlbumID, &h.Path, &h.TrackCount, &h.DownloadQuality); err != nil {
+			return nil, fmt.Errorf("store: get album holdings downloads scan: %w", err)
+		}
+		if _, ok := holdings[h.TidalAlbumID]; !ok {
+			holdings[h.TidalAlbumID] = h
+		}
+	}
+	if err := dlRows.Err(); err != nil {
+		return nil, fmt.Errorf("store: get album holdings downloads rows: %w", err)
+	}
+
+	return holdings, nil
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGetAlbumHoldings(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	// Two library copies of album 100; the fuller one is reported.
+	partialID := seedAlbum(t, store, "Radiohead", "OK Computer (partial)")
+	fullID := seedAlbum(t, store, "Radiohead", "OK Computer")
+	if err := store.SyncLibraryTracks(ctx, "Radiohead", "OK Computer", []LibraryTrack{
+		{Filename: "01.flac", Path: "/music/Radiohead/OK Computer/01.flac", Format: "flac", BitDepth: 24, SampleRate: 96000},
+		{Filename: "02.mp3", Path: "/music/Radiohead/OK Computer/02.mp3", Format: "mp3", SampleRate: 44100},
+		{Filename: "03.flac", Path: "/music/Radiohead/OK Computer/03.flac", Format: "flac", BitDepth: 16, SampleRate: 44100},
+	}); err != nil {
+		t.Fatalf("sync tracks: %v", err)
+	}
+	if err := store.UpsertLibraryAlbum(ctx, "Radiohead", "OK Computer", 3, "/music/Radiohead/OK Computer"); err != nil {
+		t.Fatalf("update track count: %v", err)
+	}
+	for _, id := range []int64{partialID, fullID} {
+		if err := store.LinkLibraryAlbum(ctx, id, 100, 0.9); err != nil {
+			t.Fatalf("link %d: %v", id, err)
+		}
+	}
+
+	// Album 200 exists only as a completed download; 300 is still queued.
+	dlID, err := store.CreateDownload(ctx, 200, "Radiohead", "Kid A", "HI_RES_LOSSLESS", 10)
+	if err != nil {
+		t.Fatalf("create download: %v", err)
+	}
+	if err := store.CompleteDownload(ctx, dlID, "/music/Radiohead/Kid A"); err != nil {
+		t.Fatalf("complete download: %v", err)
+	}
+	if _, err := store.CreateDownload(ctx, 300, "Radiohead", "Amnesiac", "LOSSLESS", 11); err != nil {
+		t.Fatalf("create queued download: %v", err)
+	}
+
+	holdings, err := store.GetAlbumHoldings(ctx, []int64{100, 200, 300, 400})
+	if err != nil {
+		t.Fatalf("GetAlbumHoldings: %v", err)
+	}
+	if len(holdings) != 2 {
+		t.Fatalf("got %d holdings, want 2: %+v", len(holdings), holdings)
+	}
+
+	lib := holdings[100]
+	if lib.LibraryAlbumID != fullID || lib.TrackCount != 3 || lib.Path != "/music/Radiohead/OK Computer" {
+		t.Errorf("library holding = %+v, want album %d with 3 tracks", lib, fullID)
+	}
+	if lib.MinBitDepth != 0 || lib.MinSampleRate != 44100 || lib.LossyTracks != 1 {
+		t.Errorf("library holding quality = depth %d rate %d lossy %d, want 0/44100/1", lib.MinBitDepth, lib.MinSampleRate, lib.LossyTracks)
+	}
+
+	dl := holdings[200]
+	if dl.LibraryAlbumID != 0 || dl.DownloadQuality != "HI_RES_LOSSLESS" || dl.TrackCount != 10 || dl.Path != "/music/Radiohead/Kid A" {
+		t.Errorf("download holding = %+v", dl)
+	}
+
+	empty, err := store.GetAlbumHoldings(ctx, nil)
+	if err != nil || len(empty) != 0 {
+		t.Errorf("GetAlbumHoldings(nil) = %v, %v; want empty", empty, err)
+	}
+}
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS scans (
+    id INTEGER PRIMARY KEY,
+    status TEXT NOT NULL DEFAULT 'running',
+    artists_total INTEGER DEFAULT 0,
+    artists_processed INTEGER DEFAULT 0,
+    artists_found INTEGER DEFAULT 0,
+    albums_found INTEGER DEFAULT 0,
+    artists_matched INTEGER DEFAULT 0,
+    error_count INTEGER DEFAULT 0,
+    error TEXT,
+    started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    completed_at DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS scan_errors (
+    id INTEGER PRIMARY KEY,
+    scan_id INTEGER NOT NULL REFERENCES scans(id) ON DELETE CASCADE,
+    message TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_scan_errors_scan_id ON scan_errors(scan_id);
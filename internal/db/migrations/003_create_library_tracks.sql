@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS library_tracks (
+    id INTEGER PRIMARY KEY,
+    album_id INTEGER NOT NULL REFERENCES library_albums(id) ON DELETE CASCADE,
+    filename TEXT NOT NULL,
+    path TEXT NOT NULL,
+    format TEXT NOT NULL,
+    title TEXT,
+    artist TEXT,
+    album TEXT,
+    track_number INTEGER DEFAULT 0,
+    disc_number INTEGER DEFAULT 0,
+    duration REAL DEFAULT 0,
+    sample_rate INTEGER DEFAULT 0,
+    bit_depth INTEGER DEFAULT 0,
+    channels INTEGER DEFAULT 0,
+    bitrate INTEGER DEFAULT 0,
+    size_bytes INTEGER DEFAULT 0,
+    UNIQUE(album_id, filename)
+);
+
+CREATE INDEX IF NOT EXISTS idx_library_tracks_format ON library_tracks(format);
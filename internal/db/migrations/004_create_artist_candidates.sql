@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS artist_candidates (
+    id INTEGER PRIMARY KEY,
+    folder_name TEXT NOT NULL,
+    tidal_id INTEGER NOT NULL,
+    tidal_name TEXT NOT NULL,
+    picture_url TEXT,
+    popularity INTEGER DEFAULT 0,
+    score REAL NOT NULL,
+    name_score REAL NOT NULL,
+    album_score REAL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(folder_name, tidal_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_artist_candidates_folder ON artist_candidates(folder_name);
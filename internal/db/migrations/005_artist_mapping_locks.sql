@@ -0,0 +1,2 @@
+ALTER TABLE artist_mapping ADD COLUMN locked INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE artist_mapping ADD COLUMN ignored INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+ALTER TABLE library_albums ADD COLUMN tidal_album_id INTEGER;
+ALTER TABLE library_albums ADD COLUMN match_score REAL;
+
+CREATE INDEX IF NOT EXISTS idx_library_albums_tidal_album_id ON library_albums(tidal_album_id);
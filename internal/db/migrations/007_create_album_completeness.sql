@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS album_completeness (
+    album_id INTEGER PRIMARY KEY REFERENCES library_albums(id) ON DELETE CASCADE,
+    tidal_album_id INTEGER NOT NULL,
+    expected_tracks INTEGER NOT NULL,
+    missing_tracks INTEGER NOT NULL,
+    checked_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS album_missing_tracks (
+    album_id INTEGER NOT NULL REFERENCES library_albums(id) ON DELETE CASCADE,
+    tidal_track_id INTEGER NOT NULL,
+    disc_number INTEGER DEFAULT 0,
+    track_number INTEGER DEFAULT 0,
+    title TEXT NOT NULL,
+    PRIMARY KEY (album_id, tidal_track_id)
+);
@@ -0,0 +1 @@
+ALTER TABLE library_albums ADD COLUMN tidal_quality TEXT;
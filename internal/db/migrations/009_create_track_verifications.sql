@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS track_verifications (
+    track_id INTEGER PRIMARY KEY REFERENCES library_tracks(id) ON DELETE CASCADE,
+    size_bytes INTEGER NOT NULL,
+    error TEXT,
+    verified_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_track_verifications_error ON track_verifications(error) WHERE error IS NOT NULL;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS playlists (
+    tidal_uuid TEXT PRIMARY KEY,
+    title TEXT NOT NULL,
+    tidal_updated TEXT NOT NULL DEFAULT '',
+    total_tracks INTEGER NOT NULL DEFAULT 0,
+    local_tracks INTEGER NOT NULL DEFAULT 0,
+    m3u_path TEXT NOT NULL DEFAULT '',
+    synced_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
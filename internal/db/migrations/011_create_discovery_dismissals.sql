@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS discovery_dismissals (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL,
+    tidal_id INTEGER NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    artist_name TEXT NOT NULL DEFAULT '',
+    snoozed_until DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(kind, tidal_id)
+);
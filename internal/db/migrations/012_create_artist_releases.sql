@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS artist_releases (
+    tidal_album_id INTEGER PRIMARY KEY,
+    artist_tidal_id INTEGER NOT NULL,
+    artist_name TEXT NOT NULL DEFAULT '',
+    title TEXT NOT NULL DEFAULT '',
+    cover TEXT NOT NULL DEFAULT '',
+    release_date TEXT NOT NULL DEFAULT '',
+    is_new INTEGER NOT NULL DEFAULT 0,
+    queued INTEGER NOT NULL DEFAULT 0,
+    first_seen_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_artist_releases_new ON artist_releases(is_new, release_date);
+
+CREATE TABLE IF NOT EXISTS release_checks (
+    artist_tidal_id INTEGER PRIMARY KEY,
+    checked_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS artist_watchlist (
+    artist_tidal_id INTEGER PRIMARY KEY,
+    artist_name TEXT NOT NULL DEFAULT '',
+    auto_download INTEGER NOT NULL DEFAULT 0,
+    quality TEXT NOT NULL DEFAULT '',
+    skip_singles INTEGER NOT NULL DEFAULT 0,
+    skip_live INTEGER NOT NULL DEFAULT 0
+);
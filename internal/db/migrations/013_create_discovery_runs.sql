@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS discovery_runs (
+    id INTEGER PRIMARY KEY,
+    seeds TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS discovery_run_albums (
+    run_id INTEGER NOT NULL REFERENCES discovery_runs(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    tidal_album_id INTEGER NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    artist_tidal_id INTEGER NOT NULL DEFAULT 0,
+    artist_name TEXT NOT NULL DEFAULT '',
+    cover TEXT NOT NULL DEFAULT '',
+    release_date TEXT NOT NULL DEFAULT '',
+    seeds TEXT NOT NULL DEFAULT '',
+    score REAL NOT NULL DEFAULT 0,
+    PRIMARY KEY (run_id, position)
+);
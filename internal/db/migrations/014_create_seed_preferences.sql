@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS seed_preferences (
+    folder_name TEXT PRIMARY KEY,
+    favourite INTEGER NOT NULL DEFAULT 0,
+    pinned INTEGER NOT NULL DEFAULT 0
+);
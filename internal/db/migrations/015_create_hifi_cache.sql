@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS hifi_cache (
+    key TEXT PRIMARY KEY,
+    body BLOB NOT NULL,
+    fetched_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_hifi_cache_fetched_at ON hifi_cache(fetched_at);
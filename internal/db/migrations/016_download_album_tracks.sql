@@ -0,0 +1 @@
+ALTER TABLE downloads ADD COLUMN album_tracks INTEGER NOT NULL DEFAULT 0;
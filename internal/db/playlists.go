@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrPlaylistNotFound is returned when a playlist UUID has not been added.
+var ErrPlaylistNotFound = errors.New("store: playlist not found")
+
+// Playlist represents a row in the playlists table: a Tidal playlist kept in
+// sync with an M3U file in the library.
+type Playlist struct {
+	TidalUUID    string
+	Title        string // the UUID until the first sync
+	TidalUpdated string // Tidal's last-updated time as of the last sync
+	TotalTracks  int    // tracks on the Tidal playlist
+	LocalTracks  int    // tracks in the M3U file
+	M3UPath      string // "" until the first sync
+	SyncedAt     *string
+	CreatedAt    string
+}
+
+// scanPlaylist scans a single playlists row selected as tidal_uuid, title,
+// tidal_updated, total_tracks, local_tracks, m3u_path, synced_at,
+// created_at.
+func scanPlaylist(row rowScanner) (*Playlist, error) {
+	var p Playlist
+	var syncedAt sql.NullString
+
+	if err := row.Scan(&p.TidalUUID, &p.Title, &p.TidalUpdated, &p.TotalTracks, &p.LocalTracks, &p.M3UPath, &syncedAt, &p.CreatedAt); err != nil {
+		return nil, err
+	}
+	if syncedAt.Valid {
+		p.SyncedAt = &syncedAt.String
+	}
+	return &p, nil
+}
+
+// AddPlaylist records a Tidal playlist to keep in sync. Adding a playlist
+// twice is not an error.
+func (s *Store) AddPlaylist(ctx context.Context, tidalUUID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO playlists (tidal_uuid, title) VALUES (?, ?)
+		ON CONFLICT(tidal_uuid) DO NOTHING`,
+		tidalUUID, tidalUUID,
+	)
+	if err != nil {
+		return fmt.Errorf("store: add playlist %s: %w", tidalUUID, err)
+	}
+	return nil
+}
+
+// GetPlaylist returns the playlist with the given Tidal UUID, or
+// ErrPlaylistNotFound.
+func (s *Store) GetPlaylist(ctx context.Context, tidalUUID string) (*Playlist, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT tidal_uuid, title, tidal_updated, total_tracks, local_tracks,
+		       m3u_path, synced_at, created_at
+		FROM playlists
+		WHERE tidal_uuid = ?`,
+		tidalUUID,
+	)
+
+	p, err := scanPlaylist(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPlaylistNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("store: get playlist %s: %w", tidalUUID, err)
+	}
+	return p, nil
+}
+
+// ListPlaylists returns every playlist, ordered by title.
+func (s *Store) ListPlaylists(ctx context.Context) ([]Playlist, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT tidal_uuid, title, tidal_updated, total_tracks, local_tracks,
+		       m3u_path, synced_at, created_at
+		FROM playlists
+		ORDER BY title COLLATE NOCASE, tidal_uuid`)
+	if err != nil {
+		return nil, fmt.Errorf("store: list playlists: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var playlists []Playlist
+	for rows.Next() {
+		p, err := scanPlaylist(rows)
+		if err != nil {
+			return nil, fmt.Errorf("store: list playlists scan: %w", err)
+		}
+		playlists = append(playlists, *p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: list playlists rows: %w", err)
+	}
+	return playlists, nil
+}
+
+// RecordPlaylistSync records the outcome of syncing a playlist: its current
+// title and last-updated time on Tidal, the M3U file written and how many of
+// the playlist's tracks it holds.
+func (s *Store) RecordPlaylistSync(ctx context.Context, tidalUUID, title, tidalUpdated, m3uPath string, totalTracks, localTracks int) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO playlists (tidal_uuid, title, tidal_updated, total_tracks, local_tracks, m3u_path, synced_at)
+		VALUES (?, ?, ?, ?, ?, ?, datetime('now'))
+		ON CONFLICT(tidal_uuid) DO UPDATE SET
+			title = excluded.title, tidal_updated = excluded.tidal_updated,
+			total_tracks = excluded.total_tracks, local_tracks = excluded.local_tracks,
+			m3u_path = excluded.m3u_path, synced_at = excluded.synced_at`,
+		tidalUUID, title, tidalUpdated, totalTracks, localTracks, m3uPath,
+	)
+	if err != nil {
+		return fmt.Errorf("store: record sync of playlist %s: %w", tidalUUID, err)
+	}
+	return nil
+}
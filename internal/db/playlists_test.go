@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPlaylists(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	const roadTrip, focus = "36ea71a8-445e-41a4-82ab-6628c581535d", "0b5df2a0-7c6c-4bd4-9ad2-8d4e1a1e0f4a"
+
+	if _, err := store.GetPlaylist(ctx, roadTrip); !errors.Is(err, ErrPlaylistNotFound) {
+		t.Fatalf("GetPlaylist() error = %v, want ErrPlaylistNotFound", err)
+	}
+
+	for _, id := range []string{roadTrip, focus, roadTrip} {
+		if err := store.AddPlaylist(ctx, id); err != nil {
+			t.Fatalf("AddPlaylist(%s): %v", id, err)
+		}
+	}
+
+	p, err := store.GetPlaylist(ctx, roadTrip)
+	if err != nil {
+		t.Fatalf("GetPlaylist: %v", err)
+	}
+	if p.Title != roadTrip || p.SyncedAt != nil || p.M3UPath != "" {
+		t.Errorf("unsynced playlist = %+v, want the UUID as title and no sync", p)
+	}
+
+	if err := store.RecordPlaylistSync(ctx, roadTrip, "Road Trip", "2024-05-01T10:00:00.000+0000", "/music/Playlists/Road Trip.m3u8", 12, 11); err != nil {
+		t.Fatalf("RecordPlaylistSync: %v", err)
+	}
+
+	p, err = store.GetPlaylist(ctx, roadTrip)
+	if err != nil {
+		t.Fatalf("GetPlaylist: %v", err)
+	}
+	if p.Title != "Road Trip" || p.TidalUpdated != "2024-05-01T10:00:00.000+0000" ||
+		p.M3UPath != "/music/Playlists/Road Trip.m3u8" || p.TotalTracks != 12 || p.LocalTracks != 11 || p.SyncedAt == nil {
+		t.Errorf("synced playlist = %+v", p)
+	}
+
+	all, err := store.ListPlaylists(ctx)
+	if err != nil {
+		t.Fatalf("ListPlaylists: %v", err)
+	}
+	if len(all) != 2 || all[0].TidalUUID != focus || all[1].Title != "Road Trip" {
+		t.Errorf("ListPlaylists() = %+v, want the unsynced playlist then Road Trip", all)
+	}
+}
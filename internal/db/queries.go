@@ -3,7 +3,9 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 )
 
 // ArtistMapping represents a row in the artist_mapping table.
@@ -14,6 +16,8 @@ type ArtistMapping struct {
 	TidalName   *string
 	PictureURL  *string
 	LastUpdated string
+	Locked      bool // set manually; scans never overwrite it
+	Ignored     bool // folder has no Tidal equivalent
 }
 
 // LibraryAlbum represents a row in the library_albums table.
@@ -24,6 +28,8 @@ type LibraryAlbum struct {
 	TrackCount   int
 	Path         string
 	LastScanned  string
+	TidalAlbumID *int64   // linked Tidal album, if matched
+	MatchScore   *float64 // confidence of the Tidal link
 }
 
 // Download represents a row in the downloads table.
@@ -57,11 +63,16 @@ func NewStore(db *sql.DB) *Store {
 // Artist Mapping
 // ---------------------------------------------------------------------------
 
-// UpsertArtistMapping inserts or replaces an artist mapping entry.
+// UpsertArtistMapping inserts or updates an artist mapping entry. Locked
+// mappings are left untouched.
 func (s *Store) UpsertArtistMapping(ctx context.Context, folderName string, tidalID int64, tidalName, pictureURL string) error {
 	_, err := s.db.ExecContext(ctx, `
-		INSERT OR REPLACE INTO artist_mapping (folder_name, tidal_id, tidal_name, picture_url, last_updated)
-		VALUES (?, ?, ?, ?, datetime('now'))`,
+		INSERT INTO artist_mapping (folder_name, tidal_id, tidal_name, picture_url, last_updated)
+		VALUES (?, ?, ?, ?, datetime('now'))
+		ON CONFLICT(folder_name) DO UPDATE SET
+			tidal_id = excluded.tidal_id, tidal_name = excluded.tidal_name,
+			picture_url = excluded.picture_url, last_updated = excluded.last_updated
+		WHERE artist_mapping.locked = 0`,
 		folderName, tidalID, tidalName, pictureURL,
 	)
 	if err != nil {
@@ -74,42 +85,28 @@ func (s *Store) UpsertArtistMapping(ctx context.Context, folderName string, tida
 // no row exists.
 func (s *Store) GetArtistMapping(ctx context.Context, folderName string) (*ArtistMapping, error) {
 	row := s.db.QueryRowContext(ctx, `
-		SELECT id, folder_name, tidal_id, tidal_name, picture_url, last_updated
+		SELECT id, folder_name, tidal_id, tidal_name, picture_url, last_updated, locked, ignored
 		FROM artist_mapping
 		WHERE folder_name = ?`,
 		folderName,
 	)
 
-	var m ArtistMapping
-	var tidalID sql.NullInt64
-	var tidalName, pictureURL sql.NullString
-
-	err := row.Scan(&m.ID, &m.FolderName, &tidalID, &tidalName, &pictureURL, &m.LastUpdated)
-	if err == sql.ErrNoRows {
+	m, err := scanArtistMapping(row)
+	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("store: get artist mapping %q: %w", folderName, err)
 	}
 
-	if tidalID.Valid {
-		m.TidalID = &tidalID.Int64
-	}
-	if tidalName.Valid {
-		m.TidalName = &tidalName.String
-	}
-	if pictureURL.Valid {
-		m.PictureURL = &pictureURL.String
-	}
-
-	return &m, nil
+	return m, nil
 }
 
 // ListArtistMappings returns all artist mappings that have a non-null tidal_id,
 // ordered by folder_name.
 func (s *Store) ListArtistMappings(ctx context.Context) ([]ArtistMapping, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, folder_name, tidal_id, tidal_name, picture_url, last_updated
+		SELECT id, folder_name, tidal_id, tidal_name, picture_url, last_updated, locked, ignored
 		FROM artist_mapping
 		WHERE tidal_id IS NOT NULL
 		ORDER BY folder_name`)
@@ -120,25 +117,11 @@ func (s *Store) ListArtistMappings(ctx context.Context) ([]ArtistMapping, error)
 
 	var mappings []ArtistMapping
 	for rows.Next() {
-		var m ArtistMapping
-		var tidalID sql.NullInt64
-		var tidalName, pictureURL sql.NullString
-
-		if err := rows.Scan(&m.ID, &m.FolderName, &tidalID, &tidalName, &pictureURL, &m.LastUpdated); err != nil {
+		m, err := scanArtistMapping(rows)
+		if err != nil {
 			return nil, fmt.Errorf("store: list artist mappings scan: %w", err)
 		}
-
-		if tidalID.Valid {
-			m.TidalID = &tidalID.Int64
-		}
-		if tidalName.Valid {
-			m.TidalName = &tidalName.String
-		}
-		if pictureURL.Valid {
-			m.PictureURL = &pictureURL.String
-		}
-
-		mappings = append(mappings, m)
+		mappings = append(mappings, *m)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("store: list artist mappings rows: %w", err)
@@ -147,60 +130,137 @@ func (s *Store) ListArtistMappings(ctx context.Context) ([]ArtistMapping, error)
 	return mappings, nil
 }
 
-// GetRandomArtistMappings returns up to limit random artist mappings that have
-// a non-null tidal_id.
-func (s *Store) GetRandomArtistMappings(ctx context.Context, limit int) ([]ArtistMapping, error) {
+// ListArtistFolders returns one entry per artist folder known to the library
+// (from scanned albums or existing mappings), ordered by folder name. Folders
+// without a mapping row have a zero ID and nil Tidal fields.
+func (s *Store) ListArtistFolders(ctx context.Context) ([]ArtistMapping, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, folder_name, tidal_id, tidal_name, picture_url, last_updated
-		FROM artist_mapping
-		WHERE tidal_id IS NOT NULL
-		ORDER BY RANDOM()
-		LIMIT ?`,
-		limit,
-	)
+		SELECT COALESCE(m.id, 0), f.folder_name, m.tidal_id, m.tidal_name, m.picture_url,
+		       COALESCE(m.last_updated, ''), COALESCE(m.locked, 0), COALESCE(m.ignored, 0)
+		FROM (
+			SELECT artist_folder AS folder_name FROM library_albums
+			UNION
+			SELECT folder_name FROM artist_mapping
+		) f
+		LEFT JOIN artist_mapping m ON m.folder_name = f.folder_name
+		ORDER BY f.folder_name`)
 	if err != nil {
-		return nil, fmt.Errorf("store: get random artist mappings: %w", err)
+		return nil, fmt.Errorf("store: list artist folders: %w", err)
 	}
 	defer func() { _ = rows.Close() }()
 
-	var mappings []ArtistMapping
+	var folders []ArtistMapping
 	for rows.Next() {
-		var m ArtistMapping
-		var tidalID sql.NullInt64
-		var tidalName, pictureURL sql.NullString
-
-		if err := rows.Scan(&m.ID, &m.FolderName, &tidalID, &tidalName, &pictureURL, &m.LastUpdated); err != nil {
-			return nil, fmt.Errorf("store: get random artist mappings scan: %w", err)
+		m, err := scanArtistMapping(rows)
+		if err != nil {
+			return nil, fmt.Errorf("store: list artist folders scan: %w", err)
 		}
+		folders = append(folders, *m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: list artist folders rows: %w", err)
+	}
 
-		if tidalID.Valid {
-			m.TidalID = &tidalID.Int64
-		}
-		if tidalName.Valid {
-			m.TidalName = &tidalName.String
-		}
-		if pictureURL.Valid {
-			m.PictureURL = &pictureURL.String
-		}
+	return folders, nil
+}
+
+// SetArtistMapping records a manual choice of Tidal artist for a folder. The
+// mapping is locked so later scans keep it, and any queued review candidates
+// for the folder are discarded.
+func (s *Store) SetArtistMapping(ctx context.Context, folderName string, tidalID int64, tidalName, pictureURL string) error {
+	return s.setLockedMapping(ctx, folderName, &tidalID, &tidalName, &pictureURL, false)
+}
+
+// IgnoreArtist marks a folder as having no Tidal equivalent. It is locked so
+// scans stop trying to resolve it, and is excluded from discovery seeding.
+func (s *Store) IgnoreArtist(ctx context.Context, folderName string) error {
+	return s.setLockedMapping(ctx, folderName, nil, nil, nil, true)
+}
 
-		mappings = append(mappings, m)
+// UnlockArtistMapping forgets a folder's manual mapping, or that it was
+// ignored, along with its Tidal album links, so the next scan resolves the
+// folder afresh.
+func (s *Store) UnlockArtistMapping(ctx context.Context, folderName string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("store: unlock artist mapping %q begin: %w", folderName, err)
 	}
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("store: get random artist mappings rows: %w", err)
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE artist_mapping
+		SET tidal_id = NULL, tidal_name = NULL, picture_url = NULL,
+		    locked = 0, ignored = 0, last_updated = datetime('now')
+		WHERE folder_name = ?`,
+		folderName,
+	); err != nil {
+		return fmt.Errorf("store: unlock artist mapping %q: %w", folderName, err)
 	}
 
-	return mappings, nil
+	// Album links were matched against the forgotten artist's discography.
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE library_albums SET tidal_album_id = NULL, match_score = NULL, tidal_quality = NULL
+		WHERE artist_folder = ?`, folderName); err != nil {
+		return fmt.Errorf("store: unlock artist mapping %q clear album links: %w", folderName, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("store: unlock artist mapping %q commit: %w", folderName, err)
+	}
+	return nil
+}
+
+// setLockedMapping writes a locked mapping row and clears the folder's
+// review queue and Tidal album links in one transaction.
+func (s *Store) setLockedMapping(ctx context.Context, folderName string, tidalID *int64, tidalName, pictureURL *string, ignored bool) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("store: set artist mapping %q begin: %w", folderName, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO artist_mapping (folder_name, tidal_id, tidal_name, picture_url, last_updated, locked, ignored)
+		VALUES (?, ?, ?, ?, datetime('now'), 1, ?)
+		ON CONFLICT(folder_name) DO UPDATE SET
+			tidal_id = excluded.tidal_id, tidal_name = excluded.tidal_name,
+			picture_url = excluded.picture_url, last_updated = excluded.last_updated,
+			locked = 1, ignored = excluded.ignored`,
+		folderName, tidalID, tidalName, pictureURL, ignored,
+	); err != nil {
+		return fmt.Errorf("store: set artist mapping %q: %w", folderName, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM artist_candidates WHERE folder_name = ?`, folderName); err != nil {
+		return fmt.Errorf("store: set artist mapping %q clear candidates: %w", folderName, err)
+	}
+
+	// Album links were matched against the previous artist's discography.
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE library_albums SET tidal_album_id = NULL, match_score = NULL, tidal_quality = NULL
+		WHERE artist_folder = ?`, folderName); err != nil {
+		return fmt.Errorf("store: set artist mapping %q clear album links: %w", folderName, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("store: set artist mapping %q commit: %w", folderName, err)
+	}
+	return nil
 }
 
 // ---------------------------------------------------------------------------
 // Library Albums
 // ---------------------------------------------------------------------------
 
-// UpsertLibraryAlbum inserts or replaces a library album entry.
+// UpsertLibraryAlbum inserts a library album entry or updates the existing
+// one in place, keeping its ID (and therefore its tracks) stable.
 func (s *Store) UpsertLibraryAlbum(ctx context.Context, artistFolder, albumFolder string, trackCount int, path string) error {
 	_, err := s.db.ExecContext(ctx, `
-		INSERT OR REPLACE INTO library_albums (artist_folder, album_folder, track_count, path, last_scanned)
-		VALUES (?, ?, ?, ?, datetime('now'))`,
+		INSERT INTO library_albums (artist_folder, album_folder, track_count, path, last_scanned)
+		VALUES (?, ?, ?, ?, datetime('now'))
+		ON CONFLICT(artist_folder, album_folder) DO UPDATE SET
+			track_count = excluded.track_count, path = excluded.path,
+			last_scanned = excluded.last_scanned`,
 		artistFolder, albumFolder, trackCount, path,
 	)
 	if err != nil {
@@ -257,7 +317,8 @@ func (s *Store) ListLibraryArtists(ctx context.Context) ([]string, error) {
 // ordered by album folder name.
 func (s *Store) ListAlbumsForArtist(ctx context.Context, artistFolder string) ([]LibraryAlbum, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, artist_folder, album_folder, track_count, path, last_scanned
+		SELECT id, artist_folder, album_folder, track_count, path, last_scanned,
+		       tidal_album_id, match_score
 		FROM library_albums
 		WHERE artist_folder = ?
 		ORDER BY album_folder`,
@@ -270,11 +331,11 @@ func (s *Store) ListAlbumsForArtist(ctx context.Context, artistFolder string) ([
 
 	var albums []LibraryAlbum
 	for rows.Next() {
-		var a LibraryAlbum
-		if err := rows.Scan(&a.ID, &a.ArtistFolder, &a.AlbumFolder, &a.TrackCount, &a.Path, &a.LastScanned); err != nil {
+		a, err := scanLibraryAlbum(rows)
+		if err != nil {
 			return nil, fmt.Errorf("store: list albums for artist %q scan: %w", artistFolder, err)
 		}
-		albums = append(albums, a)
+		albums = append(albums, *a)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("store: list albums for artist %q rows: %w", artistFolder, err)
@@ -283,6 +344,37 @@ func (s *Store) ListAlbumsForArtist(ctx context.Context, artistFolder string) ([
 	return albums, nil
 }
 
+// LinkLibraryAlbum records the Tidal album a library album was matched to,
+// with the match confidence.
+func (s *Store) LinkLibraryAlbum(ctx context.Context, albumID, tidalAlbumID int64, score float64) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE library_albums
+		SET tidal_album_id = ?, match_score = ?
+		WHERE id = ?`,
+		tidalAlbumID, score, albumID,
+	)
+	if err != nil {
+		return fmt.Errorf("store: link library album %d to %d: %w", albumID, tidalAlbumID, err)
+	}
+	return nil
+}
+
+// IsAlbumOwned returns true if the given Tidal album is linked to a library
+// album or has been downloaded whole. Downloads of some of its tracks only,
+// such as a playlist's, do not count.
+func (s *Store) IsAlbumOwned(ctx context.Context, tidalAlbumID int64) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM library_albums WHERE tidal_album_id = ?)
+		    OR EXISTS(SELECT 1 FROM downloads WHERE tidal_album_id = ? AND status = 'complete' AND total_tracks >= album_tracks)`,
+		tidalAlbumID, tidalAlbumID,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("store: check album owned %d: %w", tidalAlbumID, err)
+	}
+	return exists, nil
+}
+
 // DeleteLibraryAlbumsByArtist removes all library album entries for the given
 // artist folder, supporting a full rescan.
 func (s *Store) DeleteLibraryAlbumsByArtist(ctx context.Context, artistFolder string) error {
@@ -297,16 +389,42 @@ func (s *Store) DeleteLibraryAlbumsByArtist(ctx context.Context, artistFolder st
 	return nil
 }
 
+// PruneLibraryAlbums removes the library albums of artistFolder whose album
+// folder is not in keep. Their tracks are removed by cascade.
+func (s *Store) PruneLibraryAlbums(ctx context.Context, artistFolder string, keep []string) error {
+	args := make([]any, 0, len(keep)+1)
+	args = append(args, artistFolder)
+	query := `DELETE FROM library_albums WHERE artist_folder = ?`
+	if len(keep) > 0 {
+		query += ` AND album_folder NOT IN (?` + strings.Repeat(", ?", len(keep)-1) + `)`
+		for _, k := range keep {
+			args = append(args, k)
+		}
+	}
+
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("store: prune library albums for artist %q: %w", artistFolder, err)
+	}
+	return nil
+}
+
 // ---------------------------------------------------------------------------
 // Downloads
 // ---------------------------------------------------------------------------
 
 // CreateDownload inserts a new download record and returns its ID.
 func (s *Store) CreateDownload(ctx context.Context, tidalAlbumID int64, artistName, albumTitle, quality string, totalTracks int) (int64, error) {
+	return s.CreateTrackDownload(ctx, tidalAlbumID, artistName, albumTitle, quality, totalTracks, totalTracks)
+}
+
+// CreateTrackDownload inserts a new download record for totalTracks of an
+// album's albumTracks and returns its ID. Unless it is for all of them, the
+// download does not count as owning the album (see IsAlbumOwned).
+func (s *Store) CreateTrackDownload(ctx context.Context, tidalAlbumID int64, artistName, albumTitle, quality string, totalTracks, albumTracks int) (int64, error) {
 	result, err := s.db.ExecContext(ctx, `
-		INSERT INTO downloads (tidal_album_id, artist_name, album_title, quality, total_tracks, status, progress, completed_tracks, created_at)
-		VALUES (?, ?, ?, ?, ?, 'queued', 0, 0, datetime('now'))`,
-		tidalAlbumID, artistName, albumTitle, quality, totalTracks,
+		INSERT INTO downloads (tidal_album_id, artist_name, album_title, quality, total_tracks, album_tracks, status, progress, completed_tracks, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, 'queued', 0, 0, datetime('now'))`,
+		tidalAlbumID, artistName, albumTitle, quality, totalTracks, albumTracks,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("store: create download for album %d: %w", tidalAlbumID, err)
@@ -422,6 +540,53 @@ func (s *Store) IsAlbumDownloaded(ctx context.Context, tidalAlbumID int64) (bool
 // Helpers
 // ---------------------------------------------------------------------------
 
+// scanArtistMapping scans one artist_mapping row selected with the columns
+// id, folder_name, tidal_id, tidal_name, picture_url, last_updated, locked,
+// ignored.
+func scanArtistMapping(row rowScanner) (*ArtistMapping, error) {
+	var m ArtistMapping
+	var tidalID sql.NullInt64
+	var tidalName, pictureURL sql.NullString
+
+	if err := row.Scan(&m.ID, &m.FolderName, &tidalID, &tidalName, &pictureURL, &m.LastUpdated, &m.Locked, &m.Ignored); err != nil {
+		return nil, err
+	}
+
+	if tidalID.Valid {
+		m.TidalID = &tidalID.Int64
+	}
+	if tidalName.Valid {
+		m.TidalName = &tidalName.String
+	}
+	if pictureURL.Valid {
+		m.PictureURL = &pictureURL.String
+	}
+
+	return &m, nil
+}
+
+// scanLibraryAlbum scans one library_albums row selected with the columns
+// id, artist_folder, album_folder, track_count, path, last_scanned,
+// tidal_album_id, match_score.
+func scanLibraryAlbum(row rowScanner) (*LibraryAlbum, error) {
+	var a LibraryAlbum
+	var tidalAlbumID sql.NullInt64
+	var matchScore sql.NullFloat64
+
+	if err := row.Scan(&a.ID, &a.ArtistFolder, &a.AlbumFolder, &a.TrackCount, &a.Path, &a.LastScanned, &tidalAlbumID, &matchScore); err != nil {
+		return nil, err
+	}
+
+	if tidalAlbumID.Valid {
+		a.TidalAlbumID = &tidalAlbumID.Int64
+	}
+	if matchScore.Valid {
+		a.MatchScore = &matchScore.Float64
+	}
+
+	return &a, nil
+}
+
 // scanDownloads scans all rows into a slice of Download values.
 func scanDownloads(rows *sql.Rows) ([]Download, error) {
 	var downloads []Download
@@ -117,32 +117,129 @@ func TestListArtistMappings(t *testing.T) {
 	}
 }
 
-func TestGetRandomArtistMappings(t *testing.T) {
+func TestSetArtistMapping_LocksAgainstScans(t *testing.T) {
 	store := newTestStore(t)
 	ctx := context.Background()
 
-	for i := int64(1); i <= 5; i++ {
-		if err := store.UpsertArtistMapping(ctx, "Artist"+string(rune('A'-1+i)), i*10, "Name", "https://img.example.com/pic.jpg"); err != nil {
-			t.Fatalf("upsert artist %d: %v", i, err)
-		}
+	if err := store.UpsertArtistMapping(ctx, "Nirvana", 1, "Nirvana (UK)", "pic-uk"); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if err := store.ReplaceArtistCandidates(ctx, "Nirvana", []ArtistCandidate{{TidalID: 2, TidalName: "Nirvana", Score: 0.7, NameScore: 1}}); err != nil {
+		t.Fatalf("replace candidates: %v", err)
+	}
+
+	if err := store.SetArtistMapping(ctx, "Nirvana", 2, "Nirvana", "pic-us"); err != nil {
+		t.Fatalf("set mapping: %v", err)
 	}
 
-	got, err := store.GetRandomArtistMappings(ctx, 2)
+	// A later scan must not overwrite the manual choice.
+	if err := store.UpsertArtistMapping(ctx, "Nirvana", 1, "Nirvana (UK)", "pic-uk"); err != nil {
+		t.Fatalf("upsert after lock: %v", err)
+	}
+
+	m, err := store.GetArtistMapping(ctx, "Nirvana")
 	if err != nil {
-		t.Fatalf("get random: %v", err)
+		t.Fatalf("get: %v", err)
 	}
-	if len(got) != 2 {
-		t.Fatalf("got %d mappings, want 2", len(got))
+	if m.TidalID == nil || *m.TidalID != 2 || !m.Locked || m.Ignored {
+		t.Errorf("mapping = %+v, want locked Tidal ID 2", m)
 	}
 
-	// Verify each returned mapping has a valid tidal_id.
-	for i, m := range got {
-		if m.TidalID == nil {
-			t.Errorf("mappings[%d].TidalID is nil", i)
-		}
-		if m.FolderName == "" {
-			t.Errorf("mappings[%d].FolderName is empty", i)
-		}
+	count, err := store.CountArtistReviews(ctx)
+	if err != nil {
+		t.Fatalf("count reviews: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("CountArtistReviews() = %d, want 0 after manual mapping", count)
+	}
+
+	if err := store.UnlockArtistMapping(ctx, "Nirvana"); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	m, err = store.GetArtistMapping(ctx, "Nirvana")
+	if err != nil {
+		t.Fatalf("get after unlock: %v", err)
+	}
+	if m.TidalID != nil || m.TidalName != nil || m.Locked {
+		t.Errorf("mapping after unlock = %+v, want it unresolved", m)
+	}
+	if err := store.UpsertArtistMapping(ctx, "Nirvana", 1, "Nirvana (UK)", "pic-uk"); err != nil {
+		t.Fatalf("upsert after unlock: %v", err)
+	}
+	m, err = store.GetArtistMapping(ctx, "Nirvana")
+	if err != nil {
+		t.Fatalf("get after rescan: %v", err)
+	}
+	if *m.TidalID != 1 || m.Locked {
+		t.Errorf("mapping after unlock = %+v, want unlocked Tidal ID 1", m)
+	}
+}
+
+func TestIgnoreArtist(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	if err := store.UpsertArtistMapping(ctx, "Local Band", 5, "Wrong Band", ""); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if err := store.IgnoreArtist(ctx, "Local Band"); err != nil {
+		t.Fatalf("ignore: %v", err)
+	}
+
+	m, err := store.GetArtistMapping(ctx, "Local Band")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if m.TidalID != nil || !m.Ignored || !m.Locked {
+		t.Errorf("mapping = %+v, want ignored, locked and without Tidal ID", m)
+	}
+
+	mappings, err := store.ListArtistMappings(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(mappings) != 0 {
+		t.Errorf("ListArtistMappings() = %+v, want ignored folder excluded", mappings)
+	}
+}
+
+func TestListArtistFolders(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	if err := store.UpsertLibraryAlbum(ctx, "Unmatched", "Demo", 3, "/music/Unmatched/Demo"); err != nil {
+		t.Fatalf("upsert album: %v", err)
+	}
+	if err := store.UpsertLibraryAlbum(ctx, "Radiohead", "OK Computer", 12, "/music/Radiohead/OK Computer"); err != nil {
+		t.Fatalf("upsert album: %v", err)
+	}
+	if err := store.UpsertArtistMapping(ctx, "Radiohead", 1, "Radiohead", "pic"); err != nil {
+		t.Fatalf("upsert mapping: %v", err)
+	}
+	if err := store.IgnoreArtist(ctx, "Bootlegs"); err != nil {
+		t.Fatalf("ignore: %v", err)
+	}
+
+	folders, err := store.ListArtistFolders(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(folders) != 3 {
+		t.Fatalf("len(folders) = %d, want 3: %+v", len(folders), folders)
+	}
+
+	byName := make(map[string]ArtistMapping)
+	for _, f := range folders {
+		byName[f.FolderName] = f
+	}
+	if f := byName["Unmatched"]; f.ID != 0 || f.TidalID != nil {
+		t.Errorf("Unmatched = %+v, want no mapping", f)
+	}
+	if f := byName["Radiohead"]; f.TidalID == nil || *f.TidalID != 1 {
+		t.Errorf("Radiohead = %+v, want Tidal ID 1", f)
+	}
+	if f := byName["Bootlegs"]; !f.Ignored {
+		t.Errorf("Bootlegs = %+v, want ignored", f)
 	}
 }
 
@@ -290,10 +387,174 @@ func TestDeleteLibraryAlbumsByArtist(t *testing.T) {
 	}
 }
 
+func TestPruneLibraryAlbums(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	for _, album := range []string{"Animals", "The Wall", "Meddle"} {
+		if err := store.UpsertLibraryAlbum(ctx, "Pink Floyd", album, 5, "/music/Pink Floyd/"+album); err != nil {
+			t.Fatalf("upsert %s: %v", album, err)
+		}
+	}
+
+	if err := store.PruneLibraryAlbums(ctx, "Pink Floyd", []string{"Animals", "Meddle"}); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+
+	albums, err := store.ListAlbumsForArtist(ctx, "Pink Floyd")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(albums) != 2 || albums[0].AlbumFolder != "Animals" || albums[1].AlbumFolder != "Meddle" {
+		t.Errorf("albums after prune = %+v, want Animals and Meddle", albums)
+	}
+
+	if err := store.PruneLibraryAlbums(ctx, "Pink Floyd", nil); err != nil {
+		t.Fatalf("prune all: %v", err)
+	}
+	albums, err = store.ListAlbumsForArtist(ctx, "Pink Floyd")
+	if err != nil {
+		t.Fatalf("list after prune all: %v", err)
+	}
+	if len(albums) != 0 {
+		t.Errorf("expected 0 albums after pruning with empty keep list, got %d", len(albums))
+	}
+}
+
+func TestUpsertLibraryAlbum_KeepsID(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	if err := store.UpsertLibraryAlbum(ctx, "Pink Floyd", "Animals", 5, "/music/Pink Floyd/Animals"); err != nil {
+		t.Fatalf("first upsert: %v", err)
+	}
+	before, err := store.ListAlbumsForArtist(ctx, "Pink Floyd")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+
+	if err := store.UpsertLibraryAlbum(ctx, "Pink Floyd", "Animals", 6, "/music/Pink Floyd/Animals"); err != nil {
+		t.Fatalf("second upsert: %v", err)
+	}
+	after, err := store.ListAlbumsForArtist(ctx, "Pink Floyd")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+
+	if len(after) != 1 {
+		t.Fatalf("expected 1 album, got %d", len(after))
+	}
+	if after[0].ID != before[0].ID {
+		t.Errorf("ID changed on upsert: %d -> %d", before[0].ID, after[0].ID)
+	}
+	if after[0].TrackCount != 6 {
+		t.Errorf("TrackCount = %d, want 6", after[0].TrackCount)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Downloads
 // ---------------------------------------------------------------------------
 
+func TestLinkLibraryAlbum(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	albumID := seedAlbum(t, store, "Radiohead", "OK Computer")
+	seedAlbum(t, store, "Radiohead", "Kid A")
+
+	if err := store.LinkLibraryAlbum(ctx, albumID, 100, 0.92); err != nil {
+		t.Fatalf("link: %v", err)
+	}
+
+	// The link survives a rescan of the album.
+	if err := store.UpsertLibraryAlbum(ctx, "Radiohead", "OK Computer", 12, "/music/Radiohead/OK Computer"); err != nil {
+		t.Fatalf("re-upsert: %v", err)
+	}
+
+	albums, err := store.ListAlbumsForArtist(ctx, "Radiohead")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	for _, a := range albums {
+		switch a.AlbumFolder {
+		case "OK Computer":
+			if a.TidalAlbumID == nil || *a.TidalAlbumID != 100 {
+				t.Errorf("TidalAlbumID = %v, want 100", a.TidalAlbumID)
+			}
+			if a.MatchScore == nil || *a.MatchScore != 0.92 {
+				t.Errorf("MatchScore = %v, want 0.92", a.MatchScore)
+			}
+		case "Kid A":
+			if a.TidalAlbumID != nil {
+				t.Errorf("Kid A TidalAlbumID = %d, want nil", *a.TidalAlbumID)
+			}
+		}
+	}
+
+	// A manual remap of the artist invalidates its album links.
+	if err := store.SetArtistMapping(ctx, "Radiohead", 2, "Radiohead", ""); err != nil {
+		t.Fatalf("set mapping: %v", err)
+	}
+	albums, err = store.ListAlbumsForArtist(ctx, "Radiohead")
+	if err != nil {
+		t.Fatalf("list after remap: %v", err)
+	}
+	for _, a := range albums {
+		if a.TidalAlbumID != nil || a.MatchScore != nil {
+			t.Errorf("%s still linked after remap", a.AlbumFolder)
+		}
+	}
+}
+
+func TestIsAlbumOwned(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	albumID := seedAlbum(t, store, "Radiohead", "OK Computer")
+	if err := store.LinkLibraryAlbum(ctx, albumID, 100, 0.9); err != nil {
+		t.Fatalf("link: %v", err)
+	}
+
+	dlID, err := store.CreateDownload(ctx, 200, "Radiohead", "Kid A", "LOSSLESS", 10)
+	if err != nil {
+		t.Fatalf("create download: %v", err)
+	}
+	if err := store.CompleteDownload(ctx, dlID, "/music/Radiohead/Kid A"); err != nil {
+		t.Fatalf("complete download: %v", err)
+	}
+	if _, err := store.CreateDownload(ctx, 300, "Radiohead", "Amnesiac", "LOSSLESS", 11); err != nil {
+		t.Fatalf("create queued download: %v", err)
+	}
+	trackID, err := store.CreateTrackDownload(ctx, 500, "Radiohead", "Hail to the Thief", "LOSSLESS", 1, 14)
+	if err != nil {
+		t.Fatalf("create track download: %v", err)
+	}
+	if err := store.CompleteDownload(ctx, trackID, "/music/Radiohead/Hail to the Thief"); err != nil {
+		t.Fatalf("complete track download: %v", err)
+	}
+
+	tests := []struct {
+		id   int64
+		want bool
+	}{
+		{100, true},  // linked library album
+		{200, true},  // completed download
+		{300, false}, // queued download
+		{500, false}, // one track downloaded, e.g. for a playlist
+		{400, false}, // unknown
+	}
+	for _, tt := range tests {
+		owned, err := store.IsAlbumOwned(ctx, tt.id)
+		if err != nil {
+			t.Fatalf("IsAlbumOwned(%d): %v", tt.id, err)
+		}
+		if owned != tt.want {
+			t.Errorf("IsAlbumOwned(%d) = %v, want %v", tt.id, owned, tt.want)
+		}
+	}
+}
+
 func TestCreateAndGetDownloads(t *testing.T) {
 	store := newTestStore(t)
 	ctx := context.Background()
@@ -0,0 +1,220 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Release represents a row in the artist_releases table: an album seen in
+// a monitored artist's Tidal discography.
+type Release struct {
+	TidalAlbumID  int64
+	ArtistTidalID int64
+	ArtistName    string
+	Title         string
+	Cover         string // UUID
+	ReleaseDate   string // YYYY-MM-DD
+	IsNew         bool   // released since the artist was last checked
+	Queued        bool   // downloaded automatically by a watch rule
+	FirstSeenAt   string
+}
+
+// WatchRule represents a row in the artist_watchlist table: a Tidal artist
+// whose new releases are monitored, whether or not they are in the library,
+// and what to do with them.
+type WatchRule struct {
+	ArtistTidalID int64
+	ArtistName    string
+	AutoDownload  bool
+	Quality       string // download quality; "" for the configured default
+	SkipSingles   bool   // don't auto-download singles
+	SkipLive      bool   // don't auto-download live albums
+}
+
+// ReleaseCheckedAt returns when the artist's releases were last recorded
+// (see RecordReleases), or "" if they never were.
+func (s *Store) ReleaseCheckedAt(ctx context.Context, artistTidalID int64) (string, error) {
+	var checkedAt string
+	err := s.db.QueryRowContext(ctx, `SELECT checked_at FROM release_checks WHERE artist_tidal_id = ?`, artistTidalID).Scan(&checkedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("store: release check for artist %d: %w", artistTidalID, err)
+	}
+	return checkedAt, nil
+}
+
+// LastReleaseCheck returns when any artist's releases were last recorded,
+// or "" if none have been.
+func (s *Store) LastReleaseCheck(ctx context.Context) (string, error) {
+	var checkedAt sql.NullString
+	if err := s.db.QueryRowContext(ctx, `SELECT MAX(checked_at) FROM release_checks`).Scan(&checkedAt); err != nil {
+		return "", fmt.Errorf("store: last release check: %w", err)
+	}
+	return checkedAt.String, nil
+}
+
+// KnownReleases reports which of the given Tidal album IDs have been
+// recorded already, under any artist.
+func (s *Store) KnownReleases(ctx context.Context, tidalAlbumIDs []int64) (map[int64]bool, error) {
+	known := make(map[int64]bool)
+	if len(tidalAlbumIDs) == 0 {
+		return known, nil
+	}
+
+	placeholders := "?" + strings.Repeat(", ?", len(tidalAlbumIDs)-1)
+	args := make([]any, 0, len(tidalAlbumIDs))
+	for _, id := range tidalAlbumIDs {
+		args = append(args, id)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT tidal_album_id FROM artist_releases
+		WHERE tidal_album_id IN (`+placeholders+`)`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store: known releases: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("store: known releases scan: %w", err)
+		}
+		known[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: known releases rows: %w", err)
+	}
+	return known, nil
+}
+
+// RecordReleases records albums seen in an artist's discography and marks
+// the artist as checked now. Albums recorded before are left as they are.
+func (s *Store) RecordReleases(ctx context.Context, artistTidalID int64, releases []Release) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("store: record releases for artist %d begin: %w", artistTidalID, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, r := range releases {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO artist_releases (tidal_album_id, artist_tidal_id, artist_name, title, cover, release_date, is_new)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(tidal_album_id) DO NOTHING`,
+			r.TidalAlbumID, artistTidalID, r.ArtistName, r.Title, r.Cover, r.ReleaseDate, r.IsNew,
+		); err != nil {
+			return fmt.Errorf("store: record release %d: %w", r.TidalAlbumID, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO release_checks (artist_tidal_id, checked_at) VALUES (?, datetime('now'))
+		ON CONFLICT(artist_tidal_id) DO UPDATE SET checked_at = excluded.checked_at`,
+		artistTidalID,
+	); err != nil {
+		return fmt.Errorf("store: record release check for artist %d: %w", artistTidalID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("store: record releases for artist %d commit: %w", artistTidalID, err)
+	}
+	return nil
+}
+
+// MarkReleaseQueued records that a new release was queued for download.
+func (s *Store) MarkReleaseQueued(ctx context.Context, tidalAlbumID int64) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE artist_releases SET queued = 1 WHERE tidal_album_id = ?`, tidalAlbumID); err != nil {
+		return fmt.Errorf("store: mark release %d queued: %w", tidalAlbumID, err)
+	}
+	return nil
+}
+
+// ListNewReleases returns up to limit new releases, latest first.
+func (s *Store) ListNewReleases(ctx context.Context, limit int) ([]Release, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT tidal_album_id, artist_tidal_id, artist_name, title, cover,
+		       release_date, is_new, queued, first_seen_at
+		FROM artist_releases
+		WHERE is_new = 1
+		ORDER BY release_date DESC, first_seen_at DESC, tidal_album_id DESC
+		LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store: list new releases: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var releases []Release
+	for rows.Next() {
+		var r Release
+		if err := rows.Scan(&r.TidalAlbumID, &r.ArtistTidalID, &r.ArtistName, &r.Title, &r.Cover,
+			&r.ReleaseDate, &r.IsNew, &r.Queued, &r.FirstSeenAt); err != nil {
+			return nil, fmt.Errorf("store: list new releases scan: %w", err)
+		}
+		releases = append(releases, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: list new releases rows: %w", err)
+	}
+	return releases, nil
+}
+
+// SetWatchRule adds an artist to the watchlist, or updates what to do with
+// their new releases.
+func (s *Store) SetWatchRule(ctx context.Context, rule WatchRule) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO artist_watchlist (artist_tidal_id, artist_name, auto_download, quality, skip_singles, skip_live)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(artist_tidal_id) DO UPDATE SET
+			artist_name = excluded.artist_name, auto_download = excluded.auto_download,
+			quality = excluded.quality, skip_singles = excluded.skip_singles,
+			skip_live = excluded.skip_live`,
+		rule.ArtistTidalID, rule.ArtistName, rule.AutoDownload, rule.Quality, rule.SkipSingles, rule.SkipLive,
+	)
+	if err != nil {
+		return fmt.Errorf("store: set watch rule for artist %d: %w", rule.ArtistTidalID, err)
+	}
+	return nil
+}
+
+// ListWatchRules returns the watchlist, keyed by Tidal artist ID.
+func (s *Store) ListWatchRules(ctx context.Context) (map[int64]WatchRule, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT artist_tidal_id, artist_name, auto_download, quality, skip_singles, skip_live
+		FROM artist_watchlist`)
+	if err != nil {
+		return nil, fmt.Errorf("store: list watch rules: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	rules := make(map[int64]WatchRule)
+	for rows.Next() {
+		var r WatchRule
+		if err := rows.Scan(&r.ArtistTidalID, &r.ArtistName, &r.AutoDownload, &r.Quality, &r.SkipSingles, &r.SkipLive); err != nil {
+			return nil, fmt.Errorf("store: list watch rules scan: %w", err)
+		}
+		rules[r.ArtistTidalID] = r
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: list watch rules rows: %w", err)
+	}
+	return rules, nil
+}
+
+// DeleteWatchRule removes an artist from the watchlist. Removing an artist
+// that isn't on it is not an error.
+func (s *Store) DeleteWatchRule(ctx context.Context, artistTidalID int64) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM artist_watchlist WHERE artist_tidal_id = ?`, artistTidalID); err != nil {
+		return fmt.Errorf("store: delete watch rule for artist %d: %w", artistTidalID, err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRecordReleases(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	checked, err := store.ReleaseCheckedAt(ctx, 10)
+	if err != nil || checked != "" {
+		t.Fatalf("ReleaseCheckedAt() = %q, %v; want never checked", checked, err)
+	}
+
+	err = store.RecordReleases(ctx, 10, []Release{
+		{TidalAlbumID: 100, ArtistName: "Portishead", Title: "Dummy", ReleaseDate: "1994-08-22"},
+		{TidalAlbumID: 101, ArtistName: "Portishead", Title: "Third", ReleaseDate: "2008-04-28"},
+	})
+	if err != nil {
+		t.Fatalf("RecordReleases: %v", err)
+	}
+	if checked, err := store.ReleaseCheckedAt(ctx, 10); err != nil || checked == "" {
+		t.Errorf("ReleaseCheckedAt() = %q, %v; want a check time", checked, err)
+	}
+	if last, err := store.LastReleaseCheck(ctx); err != nil || last == "" {
+		t.Errorf("LastReleaseCheck() = %q, %v; want a check time", last, err)
+	}
+
+	// A collaboration seen again under another artist keeps its first record.
+	err = store.RecordReleases(ctx, 20, []Release{
+		{TidalAlbumID: 101, ArtistName: "Beth Gibbons", Title: "Third", ReleaseDate: "2008-04-28", IsNew: true},
+		{TidalAlbumID: 200, ArtistName: "Beth Gibbons", Title: "Lives Outgrown", ReleaseDate: "2024-05-17", IsNew: true},
+		{TidalAlbumID: 201, ArtistName: "Beth Gibbons", Title: "Out of Season", ReleaseDate: "2002-10-28", IsNew: true},
+	})
+	if err != nil {
+		t.Fatalf("RecordReleases: %v", err)
+	}
+
+	known, err := store.KnownReleases(ctx, []int64{100, 101, 200, 999})
+	if err != nil {
+		t.Fatalf("KnownReleases: %v", err)
+	}
+	if len(known) != 3 || !known[100] || !known[101] || !known[200] {
+		t.Errorf("KnownReleases() = %v, want 100, 101 and 200", known)
+	}
+
+	if err := store.MarkReleaseQueued(ctx, 200); err != nil {
+		t.Fatalf("MarkReleaseQueued: %v", err)
+	}
+
+	releases, err := store.ListNewReleases(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListNewReleases: %v", err)
+	}
+	if len(releases) != 2 {
+		t.Fatalf("ListNewReleases() returned %d, want 2: %+v", len(releases), releases)
+	}
+	if r := releases[0]; r.TidalAlbumID != 200 || r.ArtistTidalID != 20 || r.Title != "Lives Outgrown" || !r.IsNew || !r.Queued {
+		t.Errorf("releases[0] = %+v, want queued Lives Outgrown", r)
+	}
+	if r := releases[1]; r.TidalAlbumID != 201 || r.Queued {
+		t.Errorf("releases[1] = %+v, want Out of Season", r)
+	}
+
+	if releases, err := store.ListNewReleases(ctx, 1); err != nil || len(releases) != 1 {
+		t.Errorf("ListNewReleases(1) returned %d, %v; want 1", len(releases), err)
+	}
+}
+
+func TestWatchRules(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	rules, err := store.ListWatchRules(ctx)
+	if err != nil || len(rules) != 0 {
+		t.Fatalf("ListWatchRules() = %v, %v; want none", rules, err)
+	}
+
+	for _, r := range []WatchRule{
+		{ArtistTidalID: 10, ArtistName: "Portishead", AutoDownload: true},
+		{ArtistTidalID: 20, ArtistName: "Beth Gibbons", AutoDownload: true, Quality: "HI_RES_LOSSLESS", SkipSingles: true, SkipLive: true},
+		{ArtistTidalID: 10, ArtistName: "Portishead", AutoDownload: false},
+	} {
+		if err := store.SetWatchRule(ctx, r); err != nil {
+			t.Fatalf("SetWatchRule(%d): %v", r.ArtistTidalID, err)
+		}
+	}
+
+	rules, err = store.ListWatchRules(ctx)
+	if err != nil {
+		t.Fatalf("ListWatchRules: %v", err)
+	}
+	want := WatchRule{ArtistTidalID: 20, ArtistName: "Beth Gibbons", AutoDownload: true, Quality: "HI_RES_LOSSLESS", SkipSingles: true, SkipLive: true}
+	if len(rules) != 2 || rules[10].AutoDownload || rules[20] != want {
+		t.Errorf("ListWatchRules() = %+v", rules)
+	}
+
+	for range 2 {
+		if err := store.DeleteWatchRule(ctx, 10); err != nil {
+			t.Fatalf("DeleteWatchRule: %v", err)
+		}
+	}
+	rules, err = store.ListWatchRules(ctx)
+	if err != nil {
+		t.Fatalf("ListWatchRules: %v", err)
+	}
+	if _, ok := rules[10]; ok || len(rules) != 1 {
+		t.Errorf("ListWatchRules() after delete = %+v, want only artist 20", rules)
+	}
+}
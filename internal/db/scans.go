@@ -0,0 +1,253 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Scan represents a row in the scans table: one background library scan.
+type Scan struct {
+	ID               int64
+	Status           string // "running", "complete" or "failed"
+	ArtistsTotal     int
+	ArtistsProcessed int
+	ArtistsFound     int
+	AlbumsFound      int
+	ArtistsMatched   int
+	ErrorCount       int
+	Error            *string // fatal error that aborted the scan
+	StartedAt        string
+	CompletedAt      *string
+}
+
+// CreateScan inserts a new running scan record and returns its ID.
+func (s *Store) CreateScan(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO scans (status, started_at)
+		VALUES ('running', datetime('now'))`)
+	if err != nil {
+		return 0, fmt.Errorf("store: create scan: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("store: create scan last insert id: %w", err)
+	}
+
+	return id, nil
+}
+
+// UpdateScanProgress records how many artist folders a running scan has
+// processed out of the total.
+func (s *Store) UpdateScanProgress(ctx context.Context, id int64, processed, total int) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE scans
+		SET artists_processed = ?, artists_total = ?
+		WHERE id = ?`,
+		processed, total, id,
+	)
+	if err != nil {
+		return fmt.Errorf("store: update scan progress %d: %w", id, err)
+	}
+	return nil
+}
+
+// CompleteScan marks a scan as complete, storing its totals and every
+// per-artist error message in a single transaction.
+func (s *Store) CompleteScan(ctx context.Context, id int64, artistsFound, albumsFound, artistsMatched int, errs []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("store: complete scan %d begin: %w", id, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE scans
+		SET status = 'complete', artists_found = ?, albums_found = ?,
+		    artists_matched = ?, error_count = ?, artists_processed = artists_total,
+		    completed_at = datetime('now')
+		WHERE id = ?`,
+		artistsFound, albumsFound, artistsMatched, len(errs), id,
+	); err != nil {
+		return fmt.Errorf("store: complete scan %d: %w", id, err)
+	}
+
+	for _, msg := range errs {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO scan_errors (scan_id, message)
+			VALUES (?, ?)`,
+			id, msg,
+		); err != nil {
+			return fmt.Errorf("store: record scan %d error: %w", id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("store: complete scan %d commit: %w", id, err)
+	}
+	return nil
+}
+
+// FailScan marks a scan as failed with the given error message.
+func (s *Store) FailScan(ctx context.Context, id int64, errMsg string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE scans
+		SET status = 'failed', error = ?, completed_at = datetime('now')
+		WHERE id = ?`,
+		errMsg, id,
+	)
+	if err != nil {
+		return fmt.Errorf("store: fail scan %d: %w", id, err)
+	}
+	return nil
+}
+
+// FailInterruptedScans marks scans left in the running state (for example by
+// a restart mid-scan) as failed. It returns the number of scans updated.
+func (s *Store) FailInterruptedScans(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE scans
+		SET status = 'failed', error = 'interrupted by restart', completed_at = datetime('now')
+		WHERE status = 'running'`)
+	if err != nil {
+		return 0, fmt.Errorf("store: fail interrupted scans: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("store: fail interrupted scans rows affected: %w", err)
+	}
+	return n, nil
+}
+
+// GetScan returns the scan with the given ID, or nil if no row exists.
+func (s *Store) GetScan(ctx context.Context, id int64) (*Scan, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, status, artists_total, artists_processed, artists_found,
+		       albums_found, artists_matched, error_count, error,
+		       started_at, completed_at
+		FROM scans
+		WHERE id = ?`,
+		id,
+	)
+
+	scan, err := scanScan(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("store: get scan %d: %w", id, err)
+	}
+	return scan, nil
+}
+
+// GetLatestScan returns the most recently started scan, or nil if no scan
+// has ever run.
+func (s *Store) GetLatestScan(ctx context.Context) (*Scan, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, status, artists_total, artists_processed, artists_found,
+		       albums_found, artists_matched, error_count, error,
+		       started_at, completed_at
+		FROM scans
+		ORDER BY id DESC
+		LIMIT 1`)
+
+	scan, err := scanScan(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("store: get latest scan: %w", err)
+	}
+	return scan, nil
+}
+
+// ListScans returns up to limit scans, newest first.
+func (s *Store) ListScans(ctx context.Context, limit int) ([]Scan, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, status, artists_total, artists_processed, artists_found,
+		       albums_found, artists_matched, error_count, error,
+		       started_at, completed_at
+		FROM scans
+		ORDER BY id DESC
+		LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store: list scans: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var scans []Scan
+	for rows.Next() {
+		scan, err := scanScan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("store: list scans scan: %w", err)
+		}
+		scans = append(scans, *scan)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: list scans rows: %w", err)
+	}
+
+	return scans, nil
+}
+
+// ListScanErrors returns every error message recorded for the given scan, in
+// the order they occurred.
+func (s *Store) ListScanErrors(ctx context.Context, scanID int64) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT message
+		FROM scan_errors
+		WHERE scan_id = ?
+		ORDER BY id`,
+		scanID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store: list scan errors %d: %w", scanID, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var msgs []string
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return nil, fmt.Errorf("store: list scan errors %d scan: %w", scanID, err)
+		}
+		msgs = append(msgs, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: list scan errors %d rows: %w", scanID, err)
+	}
+
+	return msgs, nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanScan scans a single scans row.
+func scanScan(row rowScanner) (*Scan, error) {
+	var sc Scan
+	var errMsg, completedAt sql.NullString
+
+	if err := row.Scan(
+		&sc.ID, &sc.Status, &sc.ArtistsTotal, &sc.ArtistsProcessed,
+		&sc.ArtistsFound, &sc.AlbumsFound, &sc.ArtistsMatched,
+		&sc.ErrorCount, &errMsg, &sc.StartedAt, &completedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if errMsg.Valid {
+		sc.Error = &errMsg.String
+	}
+	if completedAt.Valid {
+		sc.CompletedAt = &completedAt.String
+	}
+
+	return &sc, nil
+}
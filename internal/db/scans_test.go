@@ -0,0 +1,165 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCreateAndCompleteScan(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	id, err := store.CreateScan(ctx)
+	if err != nil {
+		t.Fatalf("create scan: %v", err)
+	}
+
+	if err := store.UpdateScanProgress(ctx, id, 3, 10); err != nil {
+		t.Fatalf("update progress: %v", err)
+	}
+
+	got, err := store.GetScan(ctx, id)
+	if err != nil {
+		t.Fatalf("get scan: %v", err)
+	}
+	if got == nil {
+		t.Fatal("expected non-nil scan, got nil")
+	}
+	if got.Status != "running" {
+		t.Errorf("Status = %q, want %q", got.Status, "running")
+	}
+	if got.ArtistsProcessed != 3 || got.ArtistsTotal != 10 {
+		t.Errorf("progress = %d/%d, want 3/10", got.ArtistsProcessed, got.ArtistsTotal)
+	}
+
+	errs := []string{"no Tidal match found for artist X", "reading artist directory Y: denied"}
+	if err := store.CompleteScan(ctx, id, 10, 42, 8, errs); err != nil {
+		t.Fatalf("complete scan: %v", err)
+	}
+
+	got, err = store.GetScan(ctx, id)
+	if err != nil {
+		t.Fatalf("get scan after complete: %v", err)
+	}
+	if got.Status != "complete" {
+		t.Errorf("Status = %q, want %q", got.Status, "complete")
+	}
+	if got.ArtistsFound != 10 || got.AlbumsFound != 42 || got.ArtistsMatched != 8 {
+		t.Errorf("totals = %d/%d/%d, want 10/42/8", got.ArtistsFound, got.AlbumsFound, got.ArtistsMatched)
+	}
+	if got.ErrorCount != 2 {
+		t.Errorf("ErrorCount = %d, want 2", got.ErrorCount)
+	}
+	if got.ArtistsProcessed != 10 {
+		t.Errorf("ArtistsProcessed = %d, want 10", got.ArtistsProcessed)
+	}
+	if got.CompletedAt == nil {
+		t.Error("CompletedAt is nil, want non-nil")
+	}
+
+	msgs, err := store.ListScanErrors(ctx, id)
+	if err != nil {
+		t.Fatalf("list scan errors: %v", err)
+	}
+	if len(msgs) != 2 || msgs[0] != errs[0] || msgs[1] != errs[1] {
+		t.Errorf("errors = %v, want %v", msgs, errs)
+	}
+}
+
+func TestFailScan(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	id, err := store.CreateScan(ctx)
+	if err != nil {
+		t.Fatalf("create scan: %v", err)
+	}
+	if err := store.FailScan(ctx, id, "reading music directory: permission denied"); err != nil {
+		t.Fatalf("fail scan: %v", err)
+	}
+
+	got, err := store.GetScan(ctx, id)
+	if err != nil {
+		t.Fatalf("get scan: %v", err)
+	}
+	if got.Status != "failed" {
+		t.Errorf("Status = %q, want %q", got.Status, "failed")
+	}
+	if got.Error == nil || *got.Error != "reading music directory: permission denied" {
+		t.Errorf("Error = %v, want permission denied message", got.Error)
+	}
+}
+
+func TestFailInterruptedScans(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	running, _ := store.CreateScan(ctx)
+	done, _ := store.CreateScan(ctx)
+	if err := store.CompleteScan(ctx, done, 1, 1, 1, nil); err != nil {
+		t.Fatalf("complete scan: %v", err)
+	}
+
+	n, err := store.FailInterruptedScans(ctx)
+	if err != nil {
+		t.Fatalf("fail interrupted scans: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("updated = %d, want 1", n)
+	}
+
+	got, _ := store.GetScan(ctx, running)
+	if got.Status != "failed" {
+		t.Errorf("running scan Status = %q, want %q", got.Status, "failed")
+	}
+	got, _ = store.GetScan(ctx, done)
+	if got.Status != "complete" {
+		t.Errorf("completed scan Status = %q, want %q", got.Status, "complete")
+	}
+}
+
+func TestGetLatestScanAndList(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	latest, err := store.GetLatestScan(ctx)
+	if err != nil {
+		t.Fatalf("get latest scan on empty db: %v", err)
+	}
+	if latest != nil {
+		t.Fatalf("expected nil latest scan, got %+v", latest)
+	}
+
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		id, err := store.CreateScan(ctx)
+		if err != nil {
+			t.Fatalf("create scan %d: %v", i, err)
+		}
+		ids = append(ids, id)
+	}
+
+	latest, err = store.GetLatestScan(ctx)
+	if err != nil {
+		t.Fatalf("get latest scan: %v", err)
+	}
+	if latest == nil || latest.ID != ids[2] {
+		t.Errorf("latest = %+v, want ID %d", latest, ids[2])
+	}
+
+	scans, err := store.ListScans(ctx, 2)
+	if err != nil {
+		t.Fatalf("list scans: %v", err)
+	}
+	if len(scans) != 2 || scans[0].ID != ids[2] || scans[1].ID != ids[1] {
+		t.Errorf("ListScans(2) = %+v, want newest two", scans)
+	}
+
+	missing, err := store.GetScan(ctx, 9999)
+	if err != nil {
+		t.Fatalf("get missing scan: %v", err)
+	}
+	if missing != nil {
+		t.Errorf("expected nil for missing scan, got %+v", missing)
+	}
+}
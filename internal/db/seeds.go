@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// SeedCandidate is a Tidal-mapped library artist that discovery can start
+// from, with the signals used to weight it.
+type SeedCandidate struct {
+	FolderName      string
+	TidalID         int64
+	TidalName       string // "" if not known
+	Albums          int    // albums in the library
+	RecentDownloads int    // albums by the artist downloaded recently
+	Favourite       bool   // marked as a favourite by the user
+	Pinned          bool   // always used as a seed
+}
+
+// ListSeedCandidates returns every Tidal-mapped library artist with its
+// album count, the number of its albums downloaded in the last recentDays
+// days, and the user's seed preferences, ordered by folder name.
+func (s *Store) ListSeedCandidates(ctx context.Context, recentDays int) ([]SeedCandidate, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT m.folder_name, m.tidal_id, m.tidal_name,
+		       (SELECT COUNT(*) FROM library_albums a WHERE a.artist_folder = m.folder_name),
+		       (SELECT COUNT(*) FROM downloads d
+		        WHERE d.status = 'complete' AND d.completed_at >= datetime('now', ?)
+		          AND d.artist_name = m.tidal_name COLLATE NOCASE),
+		       COALESCE(p.favourite, 0), COALESCE(p.pinned, 0)
+		FROM artist_mapping m
+		LEFT JOIN seed_preferences p ON p.folder_name = m.folder_name
+		WHERE m.tidal_id IS NOT NULL
+		ORDER BY m.folder_name`,
+		fmt.Sprintf("-%d days", recentDays),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store: list seed candidates: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var candidates []SeedCandidate
+	for rows.Next() {
+		var c SeedCandidate
+		var tidalName sql.NullString
+		if err := rows.Scan(&c.FolderName, &c.TidalID, &tidalName, &c.Albums, &c.RecentDownloads, &c.Favourite, &c.Pinned); err != nil {
+			return nil, fmt.Errorf("store: list seed candidates scan: %w", err)
+		}
+		c.TidalName = tidalName.String
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: list seed candidates rows: %w", err)
+	}
+	return candidates, nil
+}
+
+// SetSeedPreference marks an artist folder as a favourite, pinned as a
+// discovery seed, both or neither.
+func (s *Store) SetSeedPreference(ctx context.Context, folderName string, favourite, pinned bool) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO seed_preferences (folder_name, favourite, pinned) VALUES (?, ?, ?)
+		ON CONFLICT(folder_name) DO UPDATE SET favourite = excluded.favourite, pinned = excluded.pinned`,
+		folderName, favourite, pinned,
+	)
+	if err != nil {
+		return fmt.Errorf("store: set seed preference for %q: %w", folderName, err)
+	}
+	return nil
+}
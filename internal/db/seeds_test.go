@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+func TestListSeedCandidates(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	if err := store.UpsertArtistMapping(ctx, "Portishead", 10, "Portishead", ""); err != nil {
+		t.Fatalf("upsert Portishead: %v", err)
+	}
+	if err := store.UpsertArtistMapping(ctx, "bjork", 20, "Björk", ""); err != nil {
+		t.Fatalf("upsert Björk: %v", err)
+	}
+	if _, err := store.db.ExecContext(ctx, `INSERT INTO artist_mapping (folder_name) VALUES ('Unmatched')`); err != nil {
+		t.Fatalf("insert unmatched: %v", err)
+	}
+	for _, album := range []string{"Dummy", "Portishead", "Third"} {
+		if err := store.UpsertLibraryAlbum(ctx, "Portishead", album, 10, "/music/Portishead/"+album); err != nil {
+			t.Fatalf("upsert album %s: %v", album, err)
+		}
+	}
+	if err := store.UpsertLibraryAlbum(ctx, "bjork", "Debut", 11, "/music/bjork/Debut"); err != nil {
+		t.Fatalf("upsert Debut: %v", err)
+	}
+
+	// Two downloads of Björk albums, one too long ago, and one still running.
+	for _, title := range []string{"Vespertine", "Homogenic", "Medúlla"} {
+		id, err := store.CreateDownload(ctx, 1, "björk", title, "LOSSLESS", 10)
+		if err != nil {
+			t.Fatalf("create download %s: %v", title, err)
+		}
+		if title == "Medúlla" {
+			continue
+		}
+		if err := store.CompleteDownload(ctx, id, "/music/bjork/"+title); err != nil {
+			t.Fatalf("complete download %s: %v", title, err)
+		}
+	}
+	if _, err := store.db.ExecContext(ctx, `UPDATE downloads SET completed_at = datetime('now', '-100 days') WHERE album_title = 'Homogenic'`); err != nil {
+		t.Fatalf("backdate download: %v", err)
+	}
+
+	if err := store.SetSeedPreference(ctx, "bjork", true, false); err != nil {
+		t.Fatalf("SetSeedPreference: %v", err)
+	}
+	if err := store.SetSeedPreference(ctx, "Portishead", true, true); err != nil {
+		t.Fatalf("SetSeedPreference: %v", err)
+	}
+	if err := store.SetSeedPreference(ctx, "Portishead", false, true); err != nil {
+		t.Fatalf("SetSeedPreference (update): %v", err)
+	}
+
+	got, err := store.ListSeedCandidates(ctx, 90)
+	if err != nil {
+		t.Fatalf("ListSeedCandidates: %v", err)
+	}
+	want := []SeedCandidate{
+		{FolderName: "Portishead", TidalID: 10, TidalName: "Portishead", Albums: 3, Pinned: true},
+		{FolderName: "bjork", TidalID: 20, TidalName: "Björk", Albums: 1, RecentDownloads: 1, Favourite: true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ListSeedCandidates() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("candidate %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// LibraryStats aggregates the size and makeup of the library and how it has
+// grown through downloads.
+type LibraryStats struct {
+	Artists     int
+	Albums      int
+	Tracks      int
+	Duration    float64 // seconds
+	SizeBytes   int64
+	Formats     []FormatCount     // see ListLibraryFormats
+	Resolutions []ResolutionCount // most tracks first
+	Downloads   []MonthlyDownloads
+	TopArtists  []ArtistCount
+}
+
+// ResolutionCount is the number of library tracks at one bit depth and
+// sample rate. Lossy tracks have a bit depth of 0.
+type ResolutionCount struct {
+	BitDepth   int
+	SampleRate int
+	Tracks     int
+}
+
+// MonthlyDownloads is the number of albums and tracks downloaded in one
+// calendar month, e.g. "2025-06".
+type MonthlyDownloads struct {
+	Month  string
+	Albums int
+	Tracks int
+}
+
+// ArtistCount is the number of albums and tracks held of one artist folder.
+type ArtistCount struct {
+	ArtistFolder string
+	Albums       int
+	Tracks       int
+}
+
+// GetLibraryStats returns library totals and distributions. Downloads are
+// counted by the month they completed, oldest first; topArtists limits the
+// artists returned by album count.
+func (s *Store) GetLibraryStats(ctx context.Context, topArtists int) (*LibraryStats, error) {
+	var st LibraryStats
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(DISTINCT artist_folder) FROM library_albums),
+		       (SELECT COUNT(*) FROM library_albums),
+		       COUNT(*), COALESCE(SUM(duration), 0), COALESCE(SUM(size_bytes), 0)
+		FROM library_tracks`,
+	).Scan(&st.Artists, &st.Albums, &st.Tracks, &st.Duration, &st.SizeBytes)
+	if err != nil {
+		return nil, fmt.Errorf("store: get library stats: %w", err)
+	}
+
+	if st.Formats, err = s.ListLibraryFormats(ctx); err != nil {
+		return nil, err
+	}
+	if st.Resolutions, err = s.resolutionCounts(ctx); err != nil {
+		return nil, err
+	}
+	if st.Downloads, err = s.monthlyDownloads(ctx); err != nil {
+		return nil, err
+	}
+	if st.TopArtists, err = s.topArtists(ctx, topArtists); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func (s *Store) resolutionCounts(ctx context.Context) ([]ResolutionCount, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT bit_depth, sample_rate, COUNT(*)
+		FROM library_tracks
+		GROUP BY bit_depth, sample_rate
+		ORDER BY COUNT(*) DESC, bit_depth DESC, sample_rate DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("store: get resolution counts: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var counts []ResolutionCount
+	for rows.Next() {
+		var r ResolutionCount
+		if err := rows.Scan(&r.BitDepth, &r.SampleRate, &r.Tracks); err != nil {
+			return nil, fmt.Errorf("store: get resolution counts scan: %w", err)
+		}
+		counts = append(counts, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: get resolution counts rows: %w", err)
+	}
+	return counts, nil
+}
+
+func (s *Store) monthlyDownloads(ctx context.Context) ([]MonthlyDownloads, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT strftime('%Y-%m', completed_at) AS month, COUNT(*), COALESCE(SUM(completed_tracks), 0)
+		FROM downloads
+		WHERE status = 'complete' AND completed_at IS NOT NULL
+		GROUP BY month
+		ORDER BY month`)
+	if err != nil {
+		return nil, fmt.Errorf("store: get monthly downloads: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var months []MonthlyDownloads
+	for rows.Next() {
+		var m MonthlyDownloads
+		if err := rows.Scan(&m.Month, &m.Albums, &m.Tracks); err != nil {
+			return nil, fmt.Errorf("store: get monthly downloads scan: %w", err)
+		}
+		months = append(months, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: get monthly downloads rows: %w", err)
+	}
+	return months, nil
+}
+
+func (s *Store) topArtists(ctx context.Context, limit int) ([]ArtistCount, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT la.artist_folder, COUNT(DISTINCT la.id), COUNT(lt.id)
+		FROM library_albums la
+		LEFT JOIN library_tracks lt ON lt.album_id = la.id
+		GROUP BY la.artist_folder
+		ORDER BY COUNT(DISTINCT la.id) DESC, COUNT(lt.id) DESC, la.artist_folder
+		LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store: get top artists: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var artists []ArtistCount
+	for rows.Next() {
+		var a ArtistCount
+		if err := rows.Scan(&a.ArtistFolder, &a.Albums, &a.Tracks); err != nil {
+			return nil, fmt.Errorf("store: get top artists scan: %w", err)
+		}
+		artists = append(artists, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: get top artists rows: %w", err)
+	}
+	return artists, nil
+}
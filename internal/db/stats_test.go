@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGetLibraryStats(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	seedAlbum(t, store, "Radiohead", "OK Computer")
+	seedAlbum(t, store, "Radiohead", "Kid A")
+	seedAlbum(t, store, "Portishead", "Dummy")
+	for album, tracks := range map[string][]LibraryTrack{
+		"OK Computer": {
+			{Filename: "01.flac", Path: "/a/01.flac", Format: "flac", BitDepth: 16, SampleRate: 44100, Duration: 284, SizeBytes: 30_000_000},
+			{Filename: "02.flac", Path: "/a/02.flac", Format: "flac", BitDepth: 16, SampleRate: 44100, Duration: 383, SizeBytes: 40_000_000},
+		},
+		"Kid A": {
+			{Filename: "01.flac", Path: "/b/01.flac", Format: "flac", BitDepth: 24, SampleRate: 96000, Duration: 250, SizeBytes: 80_000_000},
+		},
+	} {
+		if err := store.SyncLibraryTracks(ctx, "Radiohead", album, tracks); err != nil {
+			t.Fatalf("sync tracks: %v", err)
+		}
+	}
+	if err := store.SyncLibraryTracks(ctx, "Portishead", "Dummy", []LibraryTrack{
+		{Filename: "01.mp3", Path: "/c/01.mp3", Format: "mp3", SampleRate: 44100, Duration: 300, SizeBytes: 7_000_000},
+	}); err != nil {
+		t.Fatalf("sync tracks: %v", err)
+	}
+
+	done, err := store.CreateDownload(ctx, 1, "Radiohead", "Kid A", "LOSSLESS", 10)
+	if err != nil {
+		t.Fatalf("create download: %v", err)
+	}
+	if err := store.UpdateDownloadProgress(ctx, done, 10, 100); err != nil {
+		t.Fatalf("update download: %v", err)
+	}
+	if err := store.CompleteDownload(ctx, done, "/music/Radiohead/Kid A"); err != nil {
+		t.Fatalf("complete download: %v", err)
+	}
+	if _, err := store.CreateDownload(ctx, 2, "Radiohead", "Amnesiac", "LOSSLESS", 11); err != nil {
+		t.Fatalf("create download: %v", err)
+	}
+
+	st, err := store.GetLibraryStats(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetLibraryStats: %v", err)
+	}
+
+	if st.Artists != 2 || st.Albums != 3 || st.Tracks != 4 {
+		t.Errorf("totals = %d artists, %d albums, %d tracks; want 2, 3, 4", st.Artists, st.Albums, st.Tracks)
+	}
+	if st.Duration != 1217 || st.SizeBytes != 157_000_000 {
+		t.Errorf("duration %v, size %d; want 1217, 157000000", st.Duration, st.SizeBytes)
+	}
+	if len(st.Formats) != 2 || st.Formats[0] != (FormatCount{Format: "flac", Tracks: 3}) {
+		t.Errorf("formats = %+v, want flac first with 3 tracks", st.Formats)
+	}
+	if len(st.Resolutions) != 3 || st.Resolutions[0] != (ResolutionCount{BitDepth: 16, SampleRate: 44100, Tracks: 2}) {
+		t.Errorf("resolutions = %+v, want 16/44100 first with 2 tracks", st.Resolutions)
+	}
+	if len(st.Downloads) != 1 || st.Downloads[0].Albums != 1 || st.Downloads[0].Tracks != 10 || len(st.Downloads[0].Month) != len("2006-01") {
+		t.Errorf("downloads = %+v, want one month with 1 album and 10 tracks", st.Downloads)
+	}
+	if len(st.TopArtists) != 1 || st.TopArtists[0] != (ArtistCount{ArtistFolder: "Radiohead", Albums: 2, Tracks: 3}) {
+		t.Errorf("top artists = %+v, want Radiohead with 2 albums", st.TopArtists)
+	}
+}
+
+func TestGetLibraryStats_Empty(t *testing.T) {
+	st, err := newTestStore(t).GetLibraryStats(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("GetLibraryStats: %v", err)
+	}
+	if st.Albums != 0 || st.Tracks != 0 || st.Formats != nil || st.TopArtists != nil {
+		t.Errorf("stats = %+v, want empty", st)
+	}
+}
@@ -0,0 +1,209 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// LibraryTrack represents a row in the library_tracks table: one audio file
+// inside a library album folder.
+type LibraryTrack struct {
+	ID          int64
+	AlbumID     int64
+	Filename    string
+	Path        string
+	Format      string // audio.Format* constant, e.g. "flac" or "alac"
+	Title       *string
+	Artist      *string
+	Album       *string
+	TrackNumber int
+	DiscNumber  int
+	Duration    float64 // seconds
+	SampleRate  int
+	BitDepth    int // 0 for lossy formats
+	Channels    int
+	Bitrate     int // kbit/s
+	SizeBytes   int64
+}
+
+// FormatCount pairs an audio format with the number of library tracks stored
+// in it.
+type FormatCount struct {
+	Format string
+	Tracks int
+}
+
+// SyncLibraryTracks replaces the track list of the given album with tracks in
+// a single transaction. Existing rows are updated in place (keyed by
+// filename) so their IDs survive a rescan; rows for files no longer present
+// are deleted. The album must already exist.
+func (s *Store) SyncLibraryTracks(ctx context.Context, artistFolder, albumFolder string, tracks []LibraryTrack) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("store: sync tracks %q/%q begin: %w", artistFolder, albumFolder, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var albumID int64
+	err = tx.QueryRowContext(ctx, `
+		SELECT id FROM library_albums
+		WHERE artist_folder = ? AND album_folder = ?`,
+		artistFolder, albumFolder,
+	).Scan(&albumID)
+	if err != nil {
+		return fmt.Errorf("store: sync tracks %q/%q album: %w", artistFolder, albumFolder, err)
+	}
+
+	filenames := make([]any, 0, len(tracks)+1)
+	filenames = append(filenames, albumID)
+	for _, t := range tracks {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO library_tracks (album_id, filename, path, format, title, artist, album,
+			                            track_number, disc_number, duration, sample_rate,
+			                            bit_depth, channels, bitrate, size_bytes)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(album_id, filename) DO UPDATE SET
+				path = excluded.path, format = excluded.format, title = excluded.title,
+				artist = excluded.artist, album = excluded.album,
+				track_number = excluded.track_number, disc_number = excluded.disc_number,
+				duration = excluded.duration, sample_rate = excluded.sample_rate,
+				bit_depth = excluded.bit_depth, channels = excluded.channels,
+				bitrate = excluded.bitrate, size_bytes = excluded.size_bytes`,
+			albumID, t.Filename, t.Path, t.Format, t.Title, t.Artist, t.Album,
+			t.TrackNumber, t.DiscNumber, t.Duration, t.SampleRate,
+			t.BitDepth, t.Channels, t.Bitrate, t.SizeBytes,
+		); err != nil {
+			return fmt.Errorf("store: sync track %q: %w", t.Path, err)
+		}
+		filenames = append(filenames, t.Filename)
+	}
+
+	query := `DELETE FROM library_tracks WHERE album_id = ?`
+	if len(tracks) > 0 {
+		query += ` AND filename NOT IN (?` + strings.Repeat(", ?", len(tracks)-1) + `)`
+	}
+	if _, err := tx.ExecContext(ctx, query, filenames...); err != nil {
+		return fmt.Errorf("store: sync tracks %q/%q prune: %w", artistFolder, albumFolder, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("store: sync tracks %q/%q commit: %w", artistFolder, albumFolder, err)
+	}
+	return nil
+}
+
+// ListTracksForAlbum returns the tracks of a library album ordered by disc,
+// track number and filename.
+func (s *Store) ListTracksForAlbum(ctx context.Context, albumID int64) ([]LibraryTrack, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, album_id, filename, path, format, title, artist, album,
+		       track_number, disc_number, duration, sample_rate, bit_depth,
+		       channels, bitrate, size_bytes
+		FROM library_tracks
+		WHERE album_id = ?
+		ORDER BY disc_number, track_number, filename`,
+		albumID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store: list tracks for album %d: %w", albumID, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	return scanTracks(rows)
+}
+
+// ListLibraryFormats returns every audio format present in the library with
+// its track count, most common first.
+func (s *Store) ListLibraryFormats(ctx context.Context) ([]FormatCount, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT format, COUNT(*)
+		FROM library_tracks
+		GROUP BY format
+		ORDER BY COUNT(*) DESC, format`)
+	if err != nil {
+		return nil, fmt.Errorf("store: list library formats: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var formats []FormatCount
+	for rows.Next() {
+		var f FormatCount
+		if err := rows.Scan(&f.Format, &f.Tracks); err != nil {
+			return nil, fmt.Errorf("store: list library formats scan: %w", err)
+		}
+		formats = append(formats, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: list library formats rows: %w", err)
+	}
+
+	return formats, nil
+}
+
+// ListArtistFoldersByFormat returns the artist folders that own at least one
+// track in the given format, ordered alphabetically.
+func (s *Store) ListArtistFoldersByFormat(ctx context.Context, format string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT a.artist_folder
+		FROM library_albums a
+		JOIN library_tracks t ON t.album_id = a.id
+		WHERE t.format = ?
+		ORDER BY a.artist_folder`,
+		format,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store: list artist folders by format %q: %w", format, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var artists []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("store: list artist folders by format %q scan: %w", format, err)
+		}
+		artists = append(artists, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: list artist folders by format %q rows: %w", format, err)
+	}
+
+	return artists, nil
+}
+
+// scanTracks scans all rows into a slice of LibraryTrack values.
+func scanTracks(rows *sql.Rows) ([]LibraryTrack, error) {
+	var tracks []LibraryTrack
+	for rows.Next() {
+		var t LibraryTrack
+		var title, artist, album sql.NullString
+
+		if err := rows.Scan(
+			&t.ID, &t.AlbumID, &t.Filename, &t.Path, &t.Format,
+			&title, &artist, &album, &t.TrackNumber, &t.DiscNumber,
+			&t.Duration, &t.SampleRate, &t.BitDepth, &t.Channels,
+			&t.Bitrate, &t.SizeBytes,
+		); err != nil {
+			return nil, fmt.Errorf("store: scan track row: %w", err)
+		}
+
+		if title.Valid {
+			t.Title = &title.String
+		}
+		if artist.Valid {
+			t.Artist = &artist.String
+		}
+		if album.Valid {
+			t.Album = &album.String
+		}
+
+		tracks = append(tracks, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: scan track rows: %w", err)
+	}
+
+	return tracks, nil
+}
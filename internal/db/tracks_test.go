@@ -0,0 +1,181 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+func strPtr(s string) *string { return &s }
+
+// seedAlbum inserts a library album and returns its ID.
+func seedAlbum(t *testing.T, store *Store, artist, album string) int64 {
+	t.Helper()
+	ctx := context.Background()
+
+	if err := store.UpsertLibraryAlbum(ctx, artist, album, 2, "/music/"+artist+"/"+album); err != nil {
+		t.Fatalf("upsert album %s/%s: %v", artist, album, err)
+	}
+	albums, err := store.ListAlbumsForArtist(ctx, artist)
+	if err != nil {
+		t.Fatalf("list albums: %v", err)
+	}
+	for _, a := range albums {
+		if a.AlbumFolder == album {
+			return a.ID
+		}
+	}
+	t.Fatalf("album %s/%s not found after upsert", artist, album)
+	return 0
+}
+
+func TestSyncLibraryTracks(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	albumID := seedAlbum(t, store, "Radiohead", "OK Computer")
+
+	tracks := []LibraryTrack{
+		{Filename: "02 - Paranoid Android.flac", Path: "/music/Radiohead/OK Computer/02 - Paranoid Android.flac", Format: "flac", Title: strPtr("Paranoid Android"), TrackNumber: 2, Duration: 383.2, SampleRate: 44100, BitDepth: 16, Channels: 2, Bitrate: 950, SizeBytes: 45_000_000},
+		{Filename: "01 - Airbag.flac", Path: "/music/Radiohead/OK Computer/01 - Airbag.flac", Format: "flac", Title: strPtr("Airbag"), TrackNumber: 1, Duration: 284.5, SampleRate: 44100, BitDepth: 16, Channels: 2},
+	}
+	if err := store.SyncLibraryTracks(ctx, "Radiohead", "OK Computer", tracks); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	got, err := store.ListTracksForAlbum(ctx, albumID)
+	if err != nil {
+		t.Fatalf("list tracks: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 tracks, got %d", len(got))
+	}
+	if got[0].Filename != "01 - Airbag.flac" {
+		t.Errorf("first track = %q, want Airbag (ordered by track number)", got[0].Filename)
+	}
+	if got[1].Title == nil || *got[1].Title != "Paranoid Android" {
+		t.Errorf("Title = %v, want Paranoid Android", got[1].Title)
+	}
+	if got[1].SizeBytes != 45_000_000 || got[1].Bitrate != 950 {
+		t.Errorf("size/bitrate = %d/%d, want 45000000/950", got[1].SizeBytes, got[1].Bitrate)
+	}
+	if got[0].Artist != nil {
+		t.Errorf("Artist = %v, want nil", got[0].Artist)
+	}
+	airbagID := got[0].ID
+
+	// Resync with one track replaced by an MP3 and one removed.
+	tracks = []LibraryTrack{
+		{Filename: "01 - Airbag.flac", Path: "/music/Radiohead/OK Computer/01 - Airbag.flac", Format: "flac", TrackNumber: 1, BitDepth: 24},
+		{Filename: "03 - Subterranean.mp3", Path: "/music/Radiohead/OK Computer/03 - Subterranean.mp3", Format: "mp3", TrackNumber: 3},
+	}
+	if err := store.SyncLibraryTracks(ctx, "Radiohead", "OK Computer", tracks); err != nil {
+		t.Fatalf("resync: %v", err)
+	}
+
+	got, err = store.ListTracksForAlbum(ctx, albumID)
+	if err != nil {
+		t.Fatalf("list tracks after resync: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 tracks after resync, got %d", len(got))
+	}
+	if got[0].ID != airbagID {
+		t.Errorf("Airbag ID changed on resync: %d -> %d", airbagID, got[0].ID)
+	}
+	if got[0].BitDepth != 24 {
+		t.Errorf("BitDepth = %d, want 24 after update", got[0].BitDepth)
+	}
+	if got[1].Format != "mp3" {
+		t.Errorf("second track format = %q, want mp3", got[1].Format)
+	}
+
+	// Syncing an empty list removes all tracks.
+	if err := store.SyncLibraryTracks(ctx, "Radiohead", "OK Computer", nil); err != nil {
+		t.Fatalf("sync empty: %v", err)
+	}
+	got, err = store.ListTracksForAlbum(ctx, albumID)
+	if err != nil {
+		t.Fatalf("list tracks after empty sync: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("expected 0 tracks after empty sync, got %d", len(got))
+	}
+}
+
+func TestSyncLibraryTracks_MissingAlbum(t *testing.T) {
+	store := newTestStore(t)
+
+	err := store.SyncLibraryTracks(context.Background(), "Nobody", "Nothing", []LibraryTrack{{Filename: "a.flac", Path: "a.flac", Format: "flac"}})
+	if err == nil {
+		t.Fatal("expected error syncing tracks for missing album, got nil")
+	}
+}
+
+func TestLibraryTracks_CascadeOnAlbumDelete(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	albumID := seedAlbum(t, store, "Björk", "Post")
+
+	if err := store.SyncLibraryTracks(ctx, "Björk", "Post", []LibraryTrack{{Filename: "01.m4a", Path: "/music/Björk/Post/01.m4a", Format: "alac"}}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if err := store.DeleteLibraryAlbumsByArtist(ctx, "Björk"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	got, err := store.ListTracksForAlbum(ctx, albumID)
+	if err != nil {
+		t.Fatalf("list tracks: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("expected tracks to cascade-delete with album, got %d", len(got))
+	}
+}
+
+func TestLibraryFormats(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	seedAlbum(t, store, "Radiohead", "OK Computer")
+	seedAlbum(t, store, "Björk", "Post")
+	seedAlbum(t, store, "Aphex Twin", "Drukqs")
+
+	syncs := map[[2]string][]LibraryTrack{
+		{"Radiohead", "OK Computer"}: {{Filename: "1.flac", Path: "1.flac", Format: "flac"}, {Filename: "2.flac", Path: "2.flac", Format: "flac"}},
+		{"Björk", "Post"}:            {{Filename: "1.m4a", Path: "1.m4a", Format: "alac"}, {Filename: "2.flac", Path: "2.flac", Format: "flac"}},
+		{"Aphex Twin", "Drukqs"}:     {{Filename: "1.mp3", Path: "1.mp3", Format: "mp3"}},
+	}
+	for key, tracks := range syncs {
+		if err := store.SyncLibraryTracks(ctx, key[0], key[1], tracks); err != nil {
+			t.Fatalf("sync %v: %v", key, err)
+		}
+	}
+
+	formats, err := store.ListLibraryFormats(ctx)
+	if err != nil {
+		t.Fatalf("list formats: %v", err)
+	}
+	want := []FormatCount{{"flac", 3}, {"alac", 1}, {"mp3", 1}}
+	if len(formats) != len(want) {
+		t.Fatalf("formats = %+v, want %+v", formats, want)
+	}
+	for i := range want {
+		if formats[i] != want[i] {
+			t.Errorf("formats[%d] = %+v, want %+v", i, formats[i], want[i])
+		}
+	}
+
+	artists, err := store.ListArtistFoldersByFormat(ctx, "flac")
+	if err != nil {
+		t.Fatalf("list artists by format: %v", err)
+	}
+	if len(artists) != 2 || artists[0] != "Björk" || artists[1] != "Radiohead" {
+		t.Errorf("flac artists = %v, want [Björk Radiohead]", artists)
+	}
+
+	artists, err = store.ListArtistFoldersByFormat(ctx, "opus")
+	if err != nil {
+		t.Fatalf("list artists by format: %v", err)
+	}
+	if len(artists) != 0 {
+		t.Errorf("opus artists = %v, want none", artists)
+	}
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// LinkedAlbumQuality pairs a linked library album's on-disk quality with the
+// best quality Tidal offers for the album it is linked to.
+type LinkedAlbumQuality struct {
+	ArtistFolder string
+	AlbumFolder  string
+	Holding      AlbumHolding
+	// TidalQuality is the quality last recorded for the Tidal album, e.g.
+	// "HI_RES_LOSSLESS"; empty until a scan or completeness check records it.
+	TidalQuality string
+}
+
+// SetTidalAlbumQuality records the best quality Tidal offers for an album on
+// every library album linked to it.
+func (s *Store) SetTidalAlbumQuality(ctx context.Context, tidalAlbumID int64, quality string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE library_albums
+		SET tidal_quality = ?
+		WHERE tidal_album_id = ?`,
+		quality, tidalAlbumID,
+	)
+	if err != nil {
+		return fmt.Errorf("store: set tidal quality of album %d: %w", tidalAlbumID, err)
+	}
+	return nil
+}
+
+// ListLinkedAlbumQualities returns the quality of every library album linked
+// to a Tidal album, ordered by artist and album folder.
+func (s *Store) ListLinkedAlbumQualities(ctx context.Context) ([]LinkedAlbumQuality, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT la.artist_folder, la.album_folder, la.tidal_album_id, la.id, la.path, la.track_count,
+		       COALESCE(MIN(lt.bit_depth), 0), COALESCE(MIN(lt.sample_rate), 0),
+		       COALESCE(SUM(CASE WHEN lt.bit_depth = 0 THEN 1 ELSE 0 END), 0),
+		       COALESCE(la.tidal_quality, '')
+		FROM library_albums la
+		LEFT JOIN library_tracks lt ON lt.album_id = la.id
+		WHERE la.tidal_album_id IS NOT NULL
+		GROUP BY la.id
+		ORDER BY la.artist_folder, la.album_folder`)
+	if err != nil {
+		return nil, fmt.Errorf("store: list linked album qualities: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var albums []LinkedAlbumQuality
+	for rows.Next() {
+		var a LinkedAlbumQuality
+		h := &a.Holding
+		if err := rows.Scan(&a.ArtistFolder, &a.AlbumFolder, &h.TidalAlbumID, &h.LibraryAlbumID, &h.Path, &h.TrackCount,
+			&h.MinBitDepth, &h.MinSampleRate, &h.LossyTracks, &a.TidalQuality); err != nil {
+			return nil, fmt.Errorf("store: list linked album qualities scan: %w", err)
+		}
+		albums = append(albums, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: list linked album qualities rows: %w", err)
+	}
+	return albums, nil
+}
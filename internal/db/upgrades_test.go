@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+func TestListLinkedAlbumQualities(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	linkedID := seedAlbum(t, store, "Radiohead", "OK Computer")
+	seedAlbum(t, store, "Radiohead", "Unlinked")
+	if err := store.SyncLibraryTracks(ctx, "Radiohead", "OK Computer", []LibraryTrack{
+		{Filename: "01.flac", Path: "/music/Radiohead/OK Computer/01.flac", Format: "flac", BitDepth: 16, SampleRate: 44100},
+		{Filename: "02.flac", Path: "/music/Radiohead/OK Computer/02.flac", Format: "flac", BitDepth: 24, SampleRate: 96000},
+	}); err != nil {
+		t.Fatalf("sync tracks: %v", err)
+	}
+	if err := store.LinkLibraryAlbum(ctx, linkedID, 100, 0.9); err != nil {
+		t.Fatalf("link: %v", err)
+	}
+
+	albums, err := store.ListLinkedAlbumQualities(ctx)
+	if err != nil {
+		t.Fatalf("ListLinkedAlbumQualities: %v", err)
+	}
+	if len(albums) != 1 {
+		t.Fatalf("got %d albums, want 1: %+v", len(albums), albums)
+	}
+	a := albums[0]
+	if a.AlbumFolder != "OK Computer" || a.Holding.LibraryAlbumID != linkedID || a.Holding.TidalAlbumID != 100 {
+		t.Errorf("album = %+v", a)
+	}
+	if a.Holding.MinBitDepth != 16 || a.Holding.MinSampleRate != 44100 || a.Holding.LossyTracks != 0 {
+		t.Errorf("quality = depth %d rate %d lossy %d, want 16/44100/0", a.Holding.MinBitDepth, a.Holding.MinSampleRate, a.Holding.LossyTracks)
+	}
+	if a.TidalQuality != "" {
+		t.Errorf("TidalQuality = %q before it was recorded, want empty", a.TidalQuality)
+	}
+
+	if err := store.SetTidalAlbumQuality(ctx, 100, "HI_RES_LOSSLESS"); err != nil {
+		t.Fatalf("SetTidalAlbumQuality: %v", err)
+	}
+	albums, err = store.ListLinkedAlbumQualities(ctx)
+	if err != nil {
+		t.Fatalf("ListLinkedAlbumQualities: %v", err)
+	}
+	if albums[0].TidalQuality != "HI_RES_LOSSLESS" {
+		t.Errorf("TidalQuality = %q, want HI_RES_LOSSLESS", albums[0].TidalQuality)
+	}
+}
@@ -0,0 +1,184 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// CorruptTrack is a library track whose last verification found damaged
+// audio, with the album it belongs to.
+type CorruptTrack struct {
+	Track      LibraryTrack
+	Album      LibraryAlbum
+	Error      string
+	VerifiedAt string
+}
+
+// VerificationSummary counts the FLAC tracks in the library by verification
+// state.
+type VerificationSummary struct {
+	Tracks       int    // FLAC tracks in the library
+	Due          int    // never verified, changed since, or verified too long ago
+	Corrupt      int    // failed their last verification
+	LastVerified string // "" if no track has been verified
+}
+
+// dueForVerification matches FLAC tracks (lt) with no current verification
+// (v): never verified, resized since or verified more than ? days ago.
+const dueForVerification = `lt.format = 'flac' AND (
+	v.track_id IS NULL OR v.size_bytes != lt.size_bytes
+	OR v.verified_at < datetime('now', '-' || ? || ' days'))`
+
+// RecordTrackVerification records the result of verifying a track's audio:
+// verifyErr is "" when the track is intact. sizeBytes is the track's indexed
+// size, so the result lapses if a rescan finds the file has changed.
+func (s *Store) RecordTrackVerification(ctx context.Context, trackID, sizeBytes int64, verifyErr string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO track_verifications (track_id, size_bytes, error, verified_at)
+		VALUES (?, ?, NULLIF(?, ''), datetime('now'))
+		ON CONFLICT(track_id) DO UPDATE SET
+			size_bytes = excluded.size_bytes, error = excluded.error, verified_at = excluded.verified_at`,
+		trackID, sizeBytes, verifyErr,
+	)
+	if err != nil {
+		return fmt.Errorf("store: record verification of track %d: %w", trackID, err)
+	}
+	return nil
+}
+
+// ListTracksDueForVerification returns the FLAC tracks that have never been
+// verified, have changed since, or were last verified more than maxAgeDays
+// ago. Never-verified tracks come first, then the longest unverified.
+func (s *Store) ListTracksDueForVerification(ctx context.Context, maxAgeDays int) ([]LibraryTrack, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT lt.id, lt.album_id, lt.filename, lt.path, lt.format, lt.title, lt.artist, lt.album,
+		       lt.track_number, lt.disc_number, lt.duration, lt.sample_rate, lt.bit_depth,
+		       lt.channels, lt.bitrate, lt.size_bytes
+		FROM library_tracks lt
+		LEFT JOIN track_verifications v ON v.track_id = lt.id
+		WHERE `+dueForVerification+`
+		ORDER BY v.verified_at IS NOT NULL, v.verified_at, lt.id`,
+		maxAgeDays,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store: list tracks due for verification: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	return scanTracks(rows)
+}
+
+// GetVerificationSummary counts the library's FLAC tracks by verification
+// state; see ListTracksDueForVerification for maxAgeDays.
+func (s *Store) GetVerificationSummary(ctx context.Context, maxAgeDays int) (*VerificationSummary, error) {
+	var (
+		sum  VerificationSummary
+		last sql.NullString
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*),
+		       COALESCE(SUM(CASE WHEN `+dueForVerification+` THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN v.error IS NOT NULL AND v.size_bytes = lt.size_bytes THEN 1 ELSE 0 END), 0),
+		       MAX(v.verified_at)
+		FROM library_tracks lt
+		LEFT JOIN track_verifications v ON v.track_id = lt.id
+		WHERE lt.format = 'flac'`,
+		maxAgeDays,
+	).Scan(&sum.Tracks, &sum.Due, &sum.Corrupt, &last)
+	if err != nil {
+		return nil, fmt.Errorf("store: get verification summary: %w", err)
+	}
+	sum.LastVerified = last.String
+	return &sum, nil
+}
+
+// ListCorruptTracks returns the tracks that failed their last verification
+// and have not changed since, ordered by artist, album and track.
+func (s *Store) ListCorruptTracks(ctx context.Context) ([]CorruptTrack, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT lt.id, lt.album_id, lt.filename, lt.path, lt.format, lt.title, lt.artist, lt.album,
+		       lt.track_number, lt.disc_number, lt.duration, lt.sample_rate, lt.bit_depth,
+		       lt.channels, lt.bitrate, lt.size_bytes,
+		       la.id, la.artist_folder, la.album_folder, la.track_count, la.path, la.last_scanned,
+		       la.tidal_album_id, la.match_score,
+		       v.error, v.verified_at
+		FROM track_verifications v
+		JOIN library_tracks lt ON lt.id = v.track_id AND lt.size_bytes = v.size_bytes
+		JOIN library_albums la ON la.id = lt.album_id
+		WHERE v.error IS NOT NULL
+		ORDER BY la.artist_folder, la.album_folder, lt.disc_number, lt.track_number, lt.filename`)
+	if err != nil {
+		return nil, fmt.Errorf("store: list corrupt tracks: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var corrupt []CorruptTrack
+	for rows.Next() {
+		var (
+			c                    CorruptTrack
+			title, artist, album sql.NullString
+			tidalAlbumID         sql.NullInt64
+			matchScore           sql.NullFloat64
+		)
+		t, a := &c.Track, &c.Album
+		if err := rows.Scan(
+			&t.ID, &t.AlbumID, &t.Filename, &t.Path, &t.Format,
+			&title, &artist, &album, &t.TrackNumber, &t.DiscNumber,
+			&t.Duration, &t.SampleRate, &t.BitDepth, &t.Channels,
+			&t.Bitrate, &t.SizeBytes,
+			&a.ID, &a.ArtistFolder, &a.AlbumFolder, &a.TrackCount, &a.Path, &a.LastScanned,
+			&tidalAlbumID, &matchScore,
+			&c.Error, &c.VerifiedAt,
+		); err != nil {
+			return nil, fmt.Errorf("store: list corrupt tracks scan: %w", err)
+		}
+		if title.Valid {
+			t.Title = &title.String
+		}
+		if artist.Valid {
+			t.Artist = &artist.String
+		}
+		if album.Valid {
+			t.Album = &album.String
+		}
+		if tidalAlbumID.Valid {
+			a.TidalAlbumID = &tidalAlbumID.Int64
+		}
+		if matchScore.Valid {
+			a.MatchScore = &matchScore.Float64
+		}
+		corrupt = append(corrupt, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: list corrupt tracks rows: %w", err)
+	}
+	return corrupt, nil
+}
+
+// DeleteLibraryTrack removes a track, and its verification, from the index
+// and from its album's track count. The file itself is left alone.
+func (s *Store) DeleteLibraryTrack(ctx context.Context, id int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("store: delete library track %d begin: %w", id, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE library_albums
+		SET track_count = MAX(track_count - 1, 0)
+		WHERE id = (SELECT album_id FROM library_tracks WHERE id = ?)`,
+		id,
+	); err != nil {
+		return fmt.Errorf("store: delete library track %d count: %w", id, err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM library_tracks WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("store: delete library track %d: %w", id, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("store: delete library track %d commit: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTrackVerification(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	albumID := seedAlbum(t, store, "Radiohead", "OK Computer")
+	if err := store.LinkLibraryAlbum(ctx, albumID, 100, 0.9); err != nil {
+		t.Fatalf("link: %v", err)
+	}
+	tracks := []LibraryTrack{
+		{Filename: "01.flac", Path: "/music/Radiohead/OK Computer/01.flac", Format: "flac", TrackNumber: 1, SizeBytes: 1000},
+		{Filename: "02.flac", Path: "/music/Radiohead/OK Computer/02.flac", Format: "flac", TrackNumber: 2, SizeBytes: 2000},
+		{Filename: "03.mp3", Path: "/music/Radiohead/OK Computer/03.mp3", Format: "mp3", TrackNumber: 3, SizeBytes: 500},
+	}
+	if err := store.SyncLibraryTracks(ctx, "Radiohead", "OK Computer", tracks); err != nil {
+		t.Fatalf("sync tracks: %v", err)
+	}
+
+	due, err := store.ListTracksDueForVerification(ctx, 30)
+	if err != nil {
+		t.Fatalf("ListTracksDueForVerification: %v", err)
+	}
+	if len(due) != 2 {
+		t.Fatalf("got %d tracks due, want the 2 FLACs: %+v", len(due), due)
+	}
+
+	due0 := due[0].ID
+	if err := store.RecordTrackVerification(ctx, due[0].ID, due[0].SizeBytes, ""); err != nil {
+		t.Fatalf("RecordTrackVerification: %v", err)
+	}
+	if err := store.RecordTrackVerification(ctx, due[1].ID, due[1].SizeBytes, "frame 3: CRC-16 mismatch"); err != nil {
+		t.Fatalf("RecordTrackVerification: %v", err)
+	}
+
+	if due, err = store.ListTracksDueForVerification(ctx, 30); err != nil || len(due) != 0 {
+		t.Errorf("tracks due after verifying = %d (%v), want 0", len(due), err)
+	}
+	if _, err := store.db.ExecContext(ctx, `UPDATE track_verifications SET verified_at = datetime('now', '-45 days') WHERE track_id = ?`, due0); err != nil {
+		t.Fatalf("backdate verification: %v", err)
+	}
+	if due, err = store.ListTracksDueForVerification(ctx, 30); err != nil || len(due) != 1 || due[0].ID != due0 {
+		t.Errorf("tracks due after 45 days = %+v (%v), want track %d", due, err, due0)
+	}
+
+	sum, err := store.GetVerificationSummary(ctx, 30)
+	if err != nil {
+		t.Fatalf("GetVerificationSummary: %v", err)
+	}
+	if sum.Tracks != 2 || sum.Due != 1 || sum.Corrupt != 1 || sum.LastVerified == "" {
+		t.Errorf("summary = %+v, want 2 tracks, 1 due, 1 corrupt", sum)
+	}
+
+	corrupt, err := store.ListCorruptTracks(ctx)
+	if err != nil {
+		t.Fatalf("ListCorruptTracks: %v", err)
+	}
+	if len(corrupt) != 1 {
+		t.Fatalf("got %d corrupt tracks, want 1", len(corrupt))
+	}
+	c := corrupt[0]
+	if c.Track.Filename != "02.flac" || c.Error != "frame 3: CRC-16 mismatch" || c.Album.ID != albumID {
+		t.Errorf("corrupt track = %+v", c)
+	}
+	if c.Album.TidalAlbumID == nil || *c.Album.TidalAlbumID != 100 {
+		t.Errorf("corrupt track album link = %v, want 100", c.Album.TidalAlbumID)
+	}
+
+	// A rescan that finds the file resized makes the result stale.
+	tracks[1].SizeBytes = 2100
+	if err := store.SyncLibraryTracks(ctx, "Radiohead", "OK Computer", tracks); err != nil {
+		t.Fatalf("sync tracks: %v", err)
+	}
+	if corrupt, err = store.ListCorruptTracks(ctx); err != nil || len(corrupt) != 0 {
+		t.Errorf("corrupt tracks after resize = %d (%v), want 0", len(corrupt), err)
+	}
+	if due, err = store.ListTracksDueForVerification(ctx, 30); err != nil || len(due) != 2 || due[1].Filename != "02.flac" {
+		t.Errorf("tracks due after resize = %+v (%v), want 01.flac (stale) and 02.flac", due, err)
+	}
+}
+
+func TestDeleteLibraryTrack(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	albumID := seedAlbum(t, store, "Radiohead", "OK Computer")
+	if err := store.SyncLibraryTracks(ctx, "Radiohead", "OK Computer", []LibraryTrack{
+		{Filename: "01.flac", Path: "/a/01.flac", Format: "flac"},
+		{Filename: "02.flac", Path: "/a/02.flac", Format: "flac"},
+	}); err != nil {
+		t.Fatalf("sync tracks: %v", err)
+	}
+	tracks, err := store.ListTracksForAlbum(ctx, albumID)
+	if err != nil {
+		t.Fatalf("list tracks: %v", err)
+	}
+	if err := store.RecordTrackVerification(ctx, tracks[0].ID, 0, "bad"); err != nil {
+		t.Fatalf("RecordTrackVerification: %v", err)
+	}
+
+	if err := store.DeleteLibraryTrack(ctx, tracks[0].ID); err != nil {
+		t.Fatalf("DeleteLibraryTrack: %v", err)
+	}
+
+	if tracks, err = store.ListTracksForAlbum(ctx, albumID); err != nil || len(tracks) != 1 {
+		t.Errorf("tracks after delete = %d (%v), want 1", len(tracks), err)
+	}
+	album, err := store.GetLibraryAlbum(ctx, albumID)
+	if err != nil {
+		t.Fatalf("GetLibraryAlbum: %v", err)
+	}
+	if album.TrackCount != 1 {
+		t.Errorf("TrackCount = %d, want 1", album.TrackCount)
+	}
+	if corrupt, err := store.ListCorruptTracks(ctx); err != nil || len(corrupt) != 0 {
+		t.Errorf("corrupt tracks after delete = %d (%v), want 0", len(corrupt), err)
+	}
+}
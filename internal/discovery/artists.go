@@ -0,0 +1,246 @@
+package discovery
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+)
+
+// Limits on the similar-artist walk.
+const (
+	// secondHopBranches is how many of a seed's similar artists are
+	// followed to the second hop, most similar first. It bounds the Tidal
+	// calls to one per branch per seed.
+	secondHopBranches = 5
+	// artistTopAlbums is how many albums are shown per recommended artist.
+	artistTopAlbums = 3
+)
+
+// newcomer is an artist reached from the library, with the seeds that
+// reached it and in how many hops.
+type newcomer struct {
+	artist hifi.SimilarArtist
+	hops   map[string]int // seed name → fewest hops
+	seeds  []string       // in the order reached
+}
+
+// link records that seed reaches the newcomer in hops.
+func (n *newcomer) link(seed string, hops int) {
+	if prev, ok := n.hops[seed]; ok {
+		n.hops[seed] = min(prev, hops)
+		return
+	}
+	n.hops[seed] = hops
+	n.seeds = append(n.seeds, seed)
+}
+
+// closest returns the fewest hops from any seed.
+func (n *newcomer) closest() int {
+	hops := 0
+	for _, h := range n.hops {
+		if hops == 0 || h < hops {
+			hops = h
+		}
+	}
+	return hops
+}
+
+// direct counts the seeds that reach the newcomer in one hop.
+func (n *newcomer) direct() int {
+	count := 0
+	for _, h := range n.hops {
+		if h == 1 {
+			count++
+		}
+	}
+	return count
+}
+
+// within returns the newcomer as reached by walking at most hops out, or
+// nil if it isn't reached that close.
+func (n *newcomer) within(hops int) *newcomer {
+	w := &newcomer{artist: n.artist, hops: make(map[string]int)}
+	for _, seed := range n.seeds {
+		if h := n.hops[seed]; h <= hops {
+			w.link(seed, h)
+		}
+	}
+	if len(w.seeds) == 0 {
+		return nil
+	}
+	return w
+}
+
+// DiscoverArtists picks seed artists from the library (see pickSeeds) and
+// walks the similar-artist graph up to maxHops (1 or 2) out from them,
+// collecting artists that aren't mapped to any library folder and haven't
+// been dismissed. Up to maxResults newcomers are returned, ranked by how
+// many seeds lead to them, then by how many do so directly, then by
+// popularity, each with its top albums the user doesn't own yet.
+func (e *Engine) DiscoverArtists(ctx context.Context, seedCount, maxResults, maxHops int) ([]ArtistRecommendation, error) {
+	byHops, err := e.discoverArtists(ctx, seedCount, maxResults, maxHops)
+	if err != nil {
+		return nil, err
+	}
+	return byHops[max(maxHops, 1)], nil
+}
+
+// discoverArtists walks the similar-artist graph once, maxHops out, and
+// returns the recommendations DiscoverArtists would make for every walk
+// of 1 to maxHops, keyed by its length. Artists recommended for several
+// lengths have their albums fetched once.
+func (e *Engine) discoverArtists(ctx context.Context, seedCount, maxResults, maxHops int) (map[int][]ArtistRecommendation, error) {
+	mapped, err := e.store.ListArtistMappings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("discovery: listing library artists: %w", err)
+	}
+	known := make(map[int64]bool, len(mapped))
+	for _, m := range mapped {
+		known[*m.TidalID] = true
+	}
+
+	skip, err := e.loadDismissed(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seeds, err := e.pickSeeds(ctx, seedCount)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []*newcomer
+	byID := make(map[int64]*newcomer)
+	reach := func(a hifi.SimilarArtist, seed string, hops int) {
+		if known[a.ID] || skip.artists[a.ID] {
+			return
+		}
+		n, ok := byID[a.ID]
+		if !ok {
+			n = &newcomer{artist: a, hops: make(map[string]int)}
+			byID[a.ID] = n
+			found = append(found, n)
+		}
+		n.link(seed, hops)
+	}
+
+	// Several seeds often share neighbours, so each artist's similar
+	// artists are fetched once.
+	similar := make(map[int64][]hifi.SimilarArtist)
+	similarTo := func(id int64, name string) []hifi.SimilarArtist {
+		if artists, ok := similar[id]; ok {
+			return artists
+		}
+		artists, err := e.finder.GetSimilarArtists(ctx, id)
+		if err != nil {
+			e.logger.Printf("similar artists for %s: %v", name, err)
+		}
+		similar[id] = artists
+		return artists
+	}
+
+	for _, seed := range seeds {
+		seedName := seed.Name
+
+		firstHop := similarTo(seed.ArtistID, seedName)
+		for _, a := range firstHop {
+			reach(a, seedName, 1)
+		}
+		if maxHops < 2 {
+			continue
+		}
+		// Library artists are walked through too: they are not
+		// recommended, but their neighbours may be.
+		for _, a := range firstHop[:min(secondHopBranches, len(firstHop))] {
+			for _, b := range similarTo(a.ID, a.Name) {
+				if b.ID != seed.ArtistID {
+					reach(b, seedName, 2)
+				}
+			}
+		}
+	}
+
+	byHops := make(map[int][]ArtistRecommendation)
+	albums := make(map[int64][]hifi.Album)
+	for hops := 1; hops <= max(maxHops, 1); hops++ {
+		var near []*newcomer
+		for _, n := range found {
+			if w := n.within(hops); w != nil {
+				near = append(near, w)
+			}
+		}
+		byHops[hops] = e.rankArtists(ctx, near, maxResults, skip, albums)
+	}
+	return byHops, nil
+}
+
+// rankArtists returns up to maxResults of the newcomers as
+// recommendations, ranked as DiscoverArtists describes. Their albums are
+// looked up in albums first, and added to it once fetched.
+func (e *Engine) rankArtists(ctx context.Context, found []*newcomer, maxResults int, skip dismissed, albums map[int64][]hifi.Album) []ArtistRecommendation {
+	slices.SortStableFunc(found, func(a, b *newcomer) int {
+		return cmp.Or(
+			cmp.Compare(len(b.seeds), len(a.seeds)),
+			cmp.Compare(b.direct(), a.direct()),
+			cmp.Compare(b.artist.Popularity, a.artist.Popularity),
+			strings.Compare(a.artist.Name, b.artist.Name),
+		)
+	})
+	found = found[:min(maxResults, len(found))]
+
+	recs := make([]ArtistRecommendation, 0, len(found))
+	for _, n := range found {
+		seedNames := slices.Clone(n.seeds)
+		slices.SortStableFunc(seedNames, func(a, b string) int { return cmp.Compare(n.hops[a], n.hops[b]) })
+
+		top, ok := albums[n.artist.ID]
+		if !ok {
+			top = e.topAlbums(ctx, n.artist, skip)
+			albums[n.artist.ID] = top
+		}
+		recs = append(recs, ArtistRecommendation{
+			ArtistID:   n.artist.ID,
+			Name:       n.artist.Name,
+			Picture:    n.artist.Picture,
+			Popularity: n.artist.Popularity,
+			Hops:       n.closest(),
+			Seeds:      seedNames,
+			Albums:     top,
+		})
+	}
+	return recs
+}
+
+// topAlbums returns the first artistTopAlbums of an artist's albums, in
+// Tidal's order, that the user neither owns nor has dismissed. Failures are
+// logged and leave the list short.
+func (e *Engine) topAlbums(ctx context.Context, artist hifi.SimilarArtist, skip dismissed) []hifi.Album {
+	albums, err := e.finder.GetArtistAlbums(ctx, artist.ID)
+	if err != nil {
+		e.logger.Printf("albums for %s: %v", artist.Name, err)
+		return nil
+	}
+
+	var top []hifi.Album
+	for _, a := range albums {
+		if len(top) == artistTopAlbums {
+			break
+		}
+		if skip.albums[a.ID] {
+			continue
+		}
+		owned, err := e.store.IsAlbumOwned(ctx, a.ID)
+		if err != nil {
+			e.logger.Printf("checking ownership of album %d: %v", a.ID, err)
+			continue
+		}
+		if !owned {
+			top = append(top, a)
+		}
+	}
+	return top
+}
@@ -0,0 +1,214 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+)
+
+// artistGraph is a small similar-artist graph around three library artists:
+//
+//	Radiohead (1) → Portishead (10), Muse (11), Björk (2, library)
+//	Massive Attack (3) → Portishead (10), Tricky (12)
+//	Björk (2) → Portishead (10), Sigur Rós (13)
+//	Muse (11) → Placebo (14), Radiohead (1)
+//	Portishead (10) → Beth Gibbons (15)
+func artistGraph() (*mockSeedStore, *mockSimilarFinder) {
+	store := &mockSeedStore{
+		mappings: []db.ArtistMapping{
+			{ID: 1, FolderName: "Radiohead", TidalID: ptr(int64(1)), TidalName: ptr("Radiohead")},
+			{ID: 2, FolderName: "Massive Attack", TidalID: ptr(int64(3)), TidalName: ptr("Massive Attack")},
+		},
+		owned: map[int64]bool{101: true},
+	}
+	// Björk is in the library but not picked as a seed.
+	store.library = []db.ArtistMapping{{ID: 3, FolderName: "Bjork", TidalID: ptr(int64(2))}}
+
+	finder := &mockSimilarFinder{
+		artists: map[int64][]hifi.SimilarArtist{
+			1:  {{ID: 10, Name: "Portishead", Popularity: 0.6}, {ID: 11, Name: "Muse", Popularity: 0.9}, {ID: 2, Name: "Björk"}},
+			3:  {{ID: 10, Name: "Portishead", Popularity: 0.6}, {ID: 12, Name: "Tricky", Popularity: 0.4}},
+			2:  {{ID: 10, Name: "Portishead", Popularity: 0.6}, {ID: 13, Name: "Sigur Rós", Popularity: 0.5}},
+			11: {{ID: 14, Name: "Placebo", Popularity: 0.7}, {ID: 1, Name: "Radiohead"}},
+			10: {{ID: 15, Name: "Beth Gibbons", Popularity: 0.3}},
+		},
+		own: map[int64][]hifi.Album{
+			10: {{ID: 100, Title: "Dummy"}, {ID: 101, Title: "Portishead"}, {ID: 102, Title: "Third"}, {ID: 103, Title: "Roseland NYC Live"}, {ID: 104, Title: "Extra"}},
+		},
+	}
+	return store, finder
+}
+
+func TestDiscoverArtists_OneHop(t *testing.T) {
+	store, finder := artistGraph()
+	store.library = nil // Björk not in the library
+
+	recs, err := NewEngine(store, finder).DiscoverArtists(context.Background(), 2, 10, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, r := range recs {
+		names = append(names, r.Name)
+	}
+	// Portishead is linked from both seeds; then by popularity.
+	if want := []string{"Portishead", "Muse", "Tricky", "Björk"}; !slices.Equal(names, want) {
+		t.Fatalf("artists = %v, want %v", names, want)
+	}
+	if want := []string{"Radiohead", "Massive Attack"}; !slices.Equal(recs[0].Seeds, want) {
+		t.Errorf("Portishead seeds = %v, want %v", recs[0].Seeds, want)
+	}
+
+	// Owned album 101 is skipped and the list capped.
+	var albums []int64
+	for _, a := range recs[0].Albums {
+		albums = append(albums, a.ID)
+	}
+	if want := []int64{100, 102, 103}; !slices.Equal(albums, want) {
+		t.Errorf("Portishead albums = %v, want %v", albums, want)
+	}
+}
+
+func TestDiscoverArtists_TwoHops(t *testing.T) {
+	store, finder := artistGraph()
+
+	recs, err := NewEngine(store, finder).DiscoverArtists(context.Background(), 2, 10, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	byName := make(map[string]ArtistRecommendation)
+	var names []string
+	for _, r := range recs {
+		byName[r.Name] = r
+		names = append(names, r.Name)
+	}
+
+	if _, ok := byName["Björk"]; ok {
+		t.Error("library artist Björk should not be recommended")
+	}
+	if _, ok := byName["Radiohead"]; ok {
+		t.Error("seed Radiohead should not be recommended")
+	}
+	// Beth Gibbons is two hops from both seeds (via Portishead), Sigur Rós
+	// two hops from Radiohead via library artist Björk.
+	if r := byName["Beth Gibbons"]; r.Hops != 2 || len(r.Seeds) != 2 {
+		t.Errorf("Beth Gibbons = %+v, want 2 hops from 2 seeds", r)
+	}
+	if r := byName["Sigur Rós"]; r.Hops != 2 || !slices.Equal(r.Seeds, []string{"Radiohead"}) {
+		t.Errorf("Sigur Rós = %+v, want 2 hops from Radiohead", r)
+	}
+	if names[0] != "Portishead" || names[1] != "Beth Gibbons" {
+		t.Errorf("artists = %v, want Portishead then Beth Gibbons (two seeds each, Portishead directly)", names)
+	}
+	if finder.calls[10] != 1 {
+		t.Errorf("similar artists of Portishead fetched %d times, want once", finder.calls[10])
+	}
+}
+
+func TestDiscoverArtists_MaxResults(t *testing.T) {
+	store, finder := artistGraph()
+
+	recs, err := NewEngine(store, finder).DiscoverArtists(context.Background(), 2, 2, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recs) != 2 {
+		t.Errorf("got %d artists, want 2", len(recs))
+	}
+}
+
+func TestDiscoverArtists_SkipsDismissed(t *testing.T) {
+	store, finder := artistGraph()
+	store.dismissals = []db.Dismissal{
+		{Kind: db.DismissArtist, TidalID: 11}, // Muse
+		{Kind: db.DismissAlbum, TidalID: 100}, // Dummy
+	}
+
+	recs, err := NewEngine(store, finder).DiscoverArtists(context.Background(), 2, 10, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	names := make(map[string]bool)
+	for _, r := range recs {
+		names[r.Name] = true
+	}
+	if names["Muse"] {
+		t.Error("dismissed artist Muse should not be recommended")
+	}
+	if !names["Placebo"] {
+		t.Error("Placebo should still be reached through dismissed Muse")
+	}
+
+	var albums []int64
+	for _, a := range recs[0].Albums {
+		albums = append(albums, a.ID)
+	}
+	if want := []int64{102, 103, 104}; !slices.Equal(albums, want) {
+		t.Errorf("Portishead albums = %v, want %v", albums, want)
+	}
+}
+
+func TestArtistSnapshot(t *testing.T) {
+	store, finder := artistGraph()
+	eng := NewEngine(store, finder)
+	ctx := context.Background()
+
+	if _, err := eng.ArtistSnapshot(ctx, 2); !errors.Is(err, ErrNoSnapshot) {
+		t.Fatalf("ArtistSnapshot() before any refresh: err = %v, want ErrNoSnapshot", err)
+	}
+	if _, err := eng.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	names := func(recs []ArtistRecommendation) []string {
+		var names []string
+		for _, r := range recs {
+			names = append(names, r.Name)
+		}
+		return names
+	}
+	for hops := 1; hops <= SnapshotHops; hops++ {
+		want, err := eng.DiscoverArtists(ctx, SnapshotSeeds, SnapshotArtists, hops)
+		if err != nil {
+			t.Fatalf("DiscoverArtists(%d hops): %v", hops, err)
+		}
+		got, err := eng.ArtistSnapshot(ctx, hops)
+		if err != nil {
+			t.Fatalf("ArtistSnapshot(%d): %v", hops, err)
+		}
+		if !slices.Equal(names(got), names(want)) {
+			t.Errorf("ArtistSnapshot(%d) = %v, want %v", hops, names(got), names(want))
+		}
+	}
+
+	// Artists and albums dismissed or owned since the refresh drop out,
+	// without Tidal being asked again.
+	finder.calls = nil
+	store.dismissals = []db.Dismissal{{Kind: db.DismissArtist, TidalID: 11}} // Muse
+	store.owned[102] = true                                                  // Third
+
+	recs, err := eng.ArtistSnapshot(ctx, 1)
+	if err != nil {
+		t.Fatalf("ArtistSnapshot: %v", err)
+	}
+	if slices.Contains(names(recs), "Muse") {
+		t.Errorf("artists = %v, want Muse dropped", names(recs))
+	}
+	var albums []int64
+	for _, a := range recs[0].Albums {
+		albums = append(albums, a.ID)
+	}
+	if want := []int64{100, 103}; recs[0].Name != "Portishead" || !slices.Equal(albums, want) {
+		t.Errorf("%s albums = %v, want Portishead's %v", recs[0].Name, albums, want)
+	}
+	if len(finder.calls) != 0 {
+		t.Errorf("GetSimilarArtists calls = %v, want none", finder.calls)
+	}
+}
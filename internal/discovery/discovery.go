@@ -1,35 +1,69 @@
 package discovery
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"log"
+	"math/rand/v2"
 	"os"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/MattHbrook/Crescendo/internal/db"
 	"github.com/MattHbrook/Crescendo/internal/hifi"
+	"github.com/MattHbrook/Crescendo/internal/job"
 )
 
-// SeedStore provides random artist seeds from the library.
+// SnoozeDays is how long a snoozed recommendation stays hidden.
+const SnoozeDays = 90
+
+// SeedStore provides the library artists to seed discovery from, and what
+// the user already owns or has dismissed, and keeps recommendation
+// snapshots.
 type SeedStore interface {
-	GetRandomArtistMappings(ctx context.Context, limit int) ([]db.ArtistMapping, error)
-	IsAlbumDownloaded(ctx context.Context, tidalAlbumID int64) (bool, error)
+	ListSeedCandidates(ctx context.Context, recentDays int) ([]db.SeedCandidate, error)
+	ListArtistMappings(ctx context.Context) ([]db.ArtistMapping, error)
+	IsAlbumOwned(ctx context.Context, tidalAlbumID int64) (bool, error)
+	ListDismissals(ctx context.Context) ([]db.Dismissal, error)
+	CreateDiscoveryRun(ctx context.Context, seeds []string, albums []db.DiscoveryRunAlbum) (int64, error)
+	GetDiscoveryRun(ctx context.Context, id int64) (*db.DiscoveryRun, error)
+	LatestDiscoveryRun(ctx context.Context) (*db.DiscoveryRun, error)
+	ListDiscoveryRunAlbums(ctx context.Context, runID int64) ([]db.DiscoveryRunAlbum, error)
 }
 
-// SimilarFinder fetches similar artists and albums from Tidal.
+// SimilarFinder fetches similar artists and albums, and artists' albums,
+// from Tidal.
 type SimilarFinder interface {
 	GetSimilarArtists(ctx context.Context, id int64) ([]hifi.SimilarArtist, error)
 	GetSimilarAlbums(ctx context.Context, id int64) ([]hifi.SimilarAlbum, error)
+	GetArtistAlbums(ctx context.Context, id int64) ([]hifi.Album, error)
 }
 
 // Recommendation is a suggested album from Tidal that the user doesn't have yet.
 type Recommendation struct {
 	AlbumID     int64
 	AlbumTitle  string
+	ArtistID    int64
 	ArtistName  string
 	Cover       string // UUID
 	ReleaseDate string
-	SeedArtist  string // which library artist led to this recommendation
+	SeedArtist  string   // the library artist that lent most weight to it
+	Seeds       []string // every library artist that led to it, strongest first
+	Score       float64
+}
+
+// ArtistRecommendation is a Tidal artist that is not in the library, found
+// by walking the similar-artist graph out from library artists.
+type ArtistRecommendation struct {
+	ArtistID   int64
+	Name       string
+	Picture    string       // UUID
+	Popularity float64      // 0-1
+	Hops       int          // 1 if similar to a library artist, 2 if only via another artist
+	Seeds      []string     // library artists that lead to it, closest first
+	Albums     []hifi.Album // its top albums the user doesn't own yet
 }
 
 // Engine generates music recommendations from the user's library.
@@ -37,6 +71,14 @@ type Engine struct {
 	store  SeedStore
 	finder SimilarFinder
 	logger *log.Logger
+	random func() float64 // in [0, 1), for seed sampling
+
+	job.Runner // runs refreshes in the background
+
+	mu sync.Mutex
+	// artists holds the artist recommendations of the last refresh, by
+	// the length of the walk that found them; nil before the first.
+	artists map[int][]ArtistRecommendation
 }
 
 // NewEngine creates a discovery engine backed by the given store and finder.
@@ -45,70 +87,170 @@ func NewEngine(store SeedStore, finder SimilarFinder) *Engine {
 		store:  store,
 		finder: finder,
 		logger: log.New(os.Stderr, "[discovery] ", log.LstdFlags),
+		random: rand.Float64,
+	}
+}
+
+// dismissed holds the albums and artists the user doesn't want recommended.
+type dismissed struct {
+	albums  map[int64]bool
+	artists map[int64]bool
+}
+
+// loadDismissed loads the dismissals in force.
+func (e *Engine) loadDismissed(ctx context.Context) (dismissed, error) {
+	list, err := e.store.ListDismissals(ctx)
+	if err != nil {
+		return dismissed{}, fmt.Errorf("discovery: listing dismissals: %w", err)
+	}
+	d := dismissed{albums: make(map[int64]bool), artists: make(map[int64]bool)}
+	for _, x := range list {
+		switch x.Kind {
+		case db.DismissAlbum:
+			d.albums[x.TidalID] = true
+		case db.DismissArtist:
+			d.artists[x.TidalID] = true
+		}
 	}
+	return d, nil
 }
 
-// Discover picks random seed artists from the library, fetches similar albums
-// from Tidal, and returns up to maxResults recommendations the user hasn't
-// downloaded yet.
+// Discover picks seed artists from the library, favouring those it holds
+// most of (see pickSeeds), and recommends albums from them (see
+// DiscoverFrom).
 func (e *Engine) Discover(ctx context.Context, seedCount, maxResults int) ([]Recommendation, error) {
-	seeds, err := e.store.GetRandomArtistMappings(ctx, seedCount)
+	recs, _, err := e.discover(ctx, seedCount, maxResults)
+	return recs, err
+}
+
+// DiscoverFrom recommends up to maxResults albums similar to those of the
+// given seed, a library artist or a single album, that the user doesn't
+// already own and hasn't dismissed.
+func (e *Engine) DiscoverFrom(ctx context.Context, seed Seed, maxResults int) ([]Recommendation, error) {
+	return e.recommend(ctx, []Seed{seed}, maxResults)
+}
+
+// discover implements Discover, also returning the names of the seed
+// artists it started from.
+func (e *Engine) discover(ctx context.Context, seedCount, maxResults int) ([]Recommendation, []string, error) {
+	seeds, err := e.pickSeeds(ctx, seedCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	recs, err := e.recommend(ctx, seeds, maxResults)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := make([]string, 0, len(seeds))
+	for _, seed := range seeds {
+		names = append(names, seed.Name)
+	}
+	return recs, names, nil
+}
+
+// recommend fetches albums similar to each seed from Tidal and returns up
+// to maxResults the user doesn't already own, either downloaded or linked
+// to a library album, and hasn't dismissed, either the album itself or its
+// artist. Albums are scored on the evidence across all seeds (see
+// scoreCandidate) and picked in turns between seeds and artists (see
+// diversify).
+func (e *Engine) recommend(ctx context.Context, seeds []Seed, maxResults int) ([]Recommendation, error) {
+	skip, err := e.loadDismissed(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("discovery: fetching seed artists: %w", err)
+		return nil, err
 	}
 
-	seen := make(map[int64]bool)
-	var recs []Recommendation
+	var candidates []*candidate
+	byAlbum := make(map[int64]*candidate)
+	popularity := make(map[int64]float64) // artist ID → Tidal popularity
 
 	for _, seed := range seeds {
-		if seed.TidalID == nil {
-			continue
-		}
+		seedName := seed.Name
 
-		seedName := seed.FolderName
-		if seed.TidalName != nil {
-			seedName = *seed.TidalName
+		// Similar artists only contribute popularity, so a failure here
+		// still leaves the albums to go on.
+		if seed.ArtistID != 0 {
+			artists, err := e.finder.GetSimilarArtists(ctx, seed.ArtistID)
+			if err != nil {
+				e.logger.Printf("similar artists for %s: %v", seedName, err)
+			}
+			for _, a := range artists {
+				popularity[a.ID] = max(popularity[a.ID], a.Popularity)
+			}
 		}
 
-		albums, err := e.finder.GetSimilarAlbums(ctx, *seed.TidalID)
+		similarTo := seed.ArtistID
+		if seed.AlbumID != 0 {
+			similarTo = seed.AlbumID
+		}
+		albums, err := e.finder.GetSimilarAlbums(ctx, similarTo)
 		if err != nil {
 			e.logger.Printf("similar albums for %s: %v", seedName, err)
 			continue
 		}
 
-		for _, album := range albums {
-			if seen[album.ID] {
+		for rank, album := range albums {
+			if album.ID == seed.AlbumID {
 				continue
 			}
-			seen[album.ID] = true
-
-			downloaded, err := e.store.IsAlbumDownloaded(ctx, album.ID)
-			if err != nil {
-				e.logger.Printf("checking download status for album %d: %v", album.ID, err)
-				continue
-			}
-			if downloaded {
-				continue
+			c, ok := byAlbum[album.ID]
+			if !ok {
+				c = &candidate{album: album}
+				byAlbum[album.ID] = c
+				candidates = append(candidates, c)
 			}
-
-			artistName := ""
-			if len(album.Artists) > 0 {
-				artistName = album.Artists[0].Name
+			if !slices.ContainsFunc(c.votes, func(v vote) bool { return v.seed == seedName }) {
+				c.votes = append(c.votes, vote{seed: seedName, rank: rank})
 			}
+		}
+	}
 
-			recs = append(recs, Recommendation{
-				AlbumID:     album.ID,
-				AlbumTitle:  album.Title,
-				ArtistName:  artistName,
-				Cover:       album.Cover,
-				ReleaseDate: album.ReleaseDate,
-				SeedArtist:  seedName,
-			})
+	now := time.Now()
+	scored := make([]*candidate, 0, len(candidates))
+	for _, c := range candidates {
+		if skip.albums[c.album.ID] || skip.artists[c.artistID()] {
+			continue
+		}
+		owned, err := e.store.IsAlbumOwned(ctx, c.album.ID)
+		if err != nil {
+			e.logger.Printf("checking ownership of album %d: %v", c.album.ID, err)
+			continue
+		}
+		if owned {
+			continue
 		}
+
+		c.popularity = popularity[c.artistID()]
+		scoreCandidate(c, now)
+		scored = append(scored, c)
 	}
+	slices.SortStableFunc(scored, func(a, b *candidate) int { return cmp.Compare(b.score, a.score) })
+
+	picked := diversify(scored, maxResults)
+	recs := make([]Recommendation, 0, len(picked))
+	for _, c := range picked {
+		artistName := ""
+		if len(c.album.Artists) > 0 {
+			artistName = c.album.Artists[0].Name
+		}
+
+		seedNames := make([]string, 0, len(c.votes))
+		for _, v := range c.votes {
+			seedNames = append(seedNames, v.seed)
+		}
 
-	if len(recs) > maxResults {
-		recs = recs[:maxResults]
+		recs = append(recs, Recommendation{
+			AlbumID:     c.album.ID,
+			AlbumTitle:  c.album.Title,
+			ArtistID:    c.artistID(),
+			ArtistName:  artistName,
+			Cover:       c.album.Cover,
+			ReleaseDate: c.album.ReleaseDate,
+			SeedArtist:  seedNames[0],
+			Seeds:       seedNames,
+			Score:       c.score,
+		})
 	}
 
 	return recs, nil
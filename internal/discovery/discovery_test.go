@@ -3,7 +3,9 @@ package discovery
 import (
 	"context"
 	"errors"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/MattHbrook/Crescendo/internal/db"
 	"github.com/MattHbrook/Crescendo/internal/hifi"
@@ -12,33 +14,106 @@ import (
 // --- mocks ---
 
 type mockSeedStore struct {
-	mappings        []db.ArtistMapping
-	downloaded      map[int64]bool // albumID -> downloaded
-	getMappingsErr  error
-	isDownloadedErr error
+	mappings       []db.ArtistMapping // returned as seeds
+	library        []db.ArtistMapping // further mapped artists, never seeds
+	candidates     []db.SeedCandidate // weighted seeds, after mappings
+	owned          map[int64]bool     // albumID -> owned
+	dismissals     []db.Dismissal
+	runs           []db.DiscoveryRun
+	runAlbums      map[int64][]db.DiscoveryRunAlbum // run ID -> albums
+	getMappingsErr error
+	isOwnedErr     error
 }
 
-func (m *mockSeedStore) GetRandomArtistMappings(_ context.Context, _ int) ([]db.ArtistMapping, error) {
+// ListSeedCandidates returns the mapped artists in mappings, pinned so that
+// they are always the seeds, in order, followed by candidates.
+func (m *mockSeedStore) ListSeedCandidates(_ context.Context, _ int) ([]db.SeedCandidate, error) {
 	if m.getMappingsErr != nil {
 		return nil, m.getMappingsErr
 	}
-	return m.mappings, nil
+	var seeds []db.SeedCandidate
+	for _, a := range m.mappings {
+		if a.TidalID == nil {
+			continue
+		}
+		c := db.SeedCandidate{FolderName: a.FolderName, TidalID: *a.TidalID, Pinned: true}
+		if a.TidalName != nil {
+			c.TidalName = *a.TidalName
+		}
+		seeds = append(seeds, c)
+	}
+	return append(seeds, m.candidates...), nil
+}
+
+func (m *mockSeedStore) ListArtistMappings(_ context.Context) ([]db.ArtistMapping, error) {
+	var mapped []db.ArtistMapping
+	for _, a := range append(slices.Clone(m.mappings), m.library...) {
+		if a.TidalID != nil {
+			mapped = append(mapped, a)
+		}
+	}
+	return mapped, nil
+}
+
+func (m *mockSeedStore) IsAlbumOwned(_ context.Context, tidalAlbumID int64) (bool, error) {
+	if m.isOwnedErr != nil {
+		return false, m.isOwnedErr
+	}
+	return m.owned[tidalAlbumID], nil
+}
+
+func (m *mockSeedStore) ListDismissals(_ context.Context) ([]db.Dismissal, error) {
+	return m.dismissals, nil
+}
+
+func (m *mockSeedStore) CreateDiscoveryRun(_ context.Context, seeds []string, albums []db.DiscoveryRunAlbum) (int64, error) {
+	id := int64(len(m.runs) + 1)
+	m.runs = append(m.runs, db.DiscoveryRun{ID: id, Seeds: seeds, Albums: len(albums), CreatedAt: time.Now().UTC().Format(time.RFC3339)})
+	if m.runAlbums == nil {
+		m.runAlbums = make(map[int64][]db.DiscoveryRunAlbum)
+	}
+	m.runAlbums[id] = albums
+	return id, nil
 }
 
-func (m *mockSeedStore) IsAlbumDownloaded(_ context.Context, tidalAlbumID int64) (bool, error) {
-	if m.isDownloadedErr != nil {
-		return false, m.isDownloadedErr
+func (m *mockSeedStore) GetDiscoveryRun(_ context.Context, id int64) (*db.DiscoveryRun, error) {
+	for _, r := range m.runs {
+		if r.ID == id {
+			return &r, nil
+		}
+	}
+	return nil, db.ErrDiscoveryRunNotFound
+}
+
+func (m *mockSeedStore) LatestDiscoveryRun(_ context.Context) (*db.DiscoveryRun, error) {
+	if len(m.runs) == 0 {
+		return nil, db.ErrDiscoveryRunNotFound
 	}
-	return m.downloaded[tidalAlbumID], nil
+	return &m.runs[len(m.runs)-1], nil
+}
+
+func (m *mockSeedStore) ListDiscoveryRunAlbums(_ context.Context, runID int64) ([]db.DiscoveryRunAlbum, error) {
+	return m.runAlbums[runID], nil
 }
 
 type mockSimilarFinder struct {
-	albums  map[int64][]hifi.SimilarAlbum // artistTidalID -> similar albums
+	albums  map[int64][]hifi.SimilarAlbum  // artistTidalID -> similar albums
+	artists map[int64][]hifi.SimilarArtist // artistTidalID -> similar artists
+	own     map[int64][]hifi.Album         // artistTidalID -> artist's albums
+	calls   map[int64]int                  // artistTidalID -> GetSimilarArtists calls
 	findErr error
 }
 
-func (m *mockSimilarFinder) GetSimilarArtists(_ context.Context, _ int64) ([]hifi.SimilarArtist, error) {
-	return nil, nil
+func (m *mockSimilarFinder) GetSimilarArtists(_ context.Context, id int64) ([]hifi.SimilarArtist, error) {
+	if m.calls == nil {
+		m.calls = make(map[int64]int)
+	}
+	m.calls[id]++
+	return m.artists[id], nil
+}
+
+func (m *mockSimilarFinder) GetArtistAlbums(_ context.Context, id int64) ([]hifi.Album, error) {
+	return m.own[id], nil
 }
 
 func (m *mockSimilarFinder) GetSimilarAlbums(_ context.Context, id int64) ([]hifi.SimilarAlbum, error) {
@@ -60,8 +135,8 @@ func TestDiscover_Success(t *testing.T) {
 			{ID: 1, FolderName: "artist_a", TidalID: ptr(int64(100)), TidalName: ptr("Artist A")},
 			{ID: 2, FolderName: "artist_b", TidalID: ptr(int64(200)), TidalName: ptr("Artist B")},
 		},
-		downloaded: map[int64]bool{
-			1001: true, // Album Y is already downloaded
+		owned: map[int64]bool{
+			1001: true, // Album Y is already owned
 		},
 	}
 
@@ -119,8 +194,8 @@ func TestDiscover_Success(t *testing.T) {
 
 func TestDiscover_NoSeeds(t *testing.T) {
 	store := &mockSeedStore{
-		mappings:   []db.ArtistMapping{},
-		downloaded: map[int64]bool{},
+		mappings: []db.ArtistMapping{},
+		owned:    map[int64]bool{},
 	}
 	finder := &mockSimilarFinder{
 		albums: map[int64][]hifi.SimilarAlbum{},
@@ -154,7 +229,7 @@ func TestDiscover_SimilarAlbumsError(t *testing.T) {
 		mappings: []db.ArtistMapping{
 			{ID: 1, FolderName: "artist_a", TidalID: ptr(int64(100)), TidalName: ptr("Artist A")},
 		},
-		downloaded: map[int64]bool{},
+		owned: map[int64]bool{},
 	}
 	finder := &mockSimilarFinder{
 		findErr: errors.New("tidal API timeout"),
@@ -178,7 +253,7 @@ func TestDiscover_MaxResultsTruncation(t *testing.T) {
 			{ID: 2, FolderName: "a2", TidalID: ptr(int64(200)), TidalName: ptr("Seed 2")},
 			{ID: 3, FolderName: "a3", TidalID: ptr(int64(300)), TidalName: ptr("Seed 3")},
 		},
-		downloaded: map[int64]bool{},
+		owned: map[int64]bool{},
 	}
 
 	albums := map[int64][]hifi.SimilarAlbum{}
@@ -213,7 +288,7 @@ func TestDiscover_SkipsNilTidalID(t *testing.T) {
 		mappings: []db.ArtistMapping{
 			{ID: 1, FolderName: "unmapped_artist", TidalID: nil},
 		},
-		downloaded: map[int64]bool{},
+		owned: map[int64]bool{},
 	}
 	finder := &mockSimilarFinder{
 		albums: map[int64][]hifi.SimilarAlbum{},
@@ -228,3 +303,169 @@ func TestDiscover_SkipsNilTidalID(t *testing.T) {
 		t.Fatalf("expected 0 recommendations, got %d", len(recs))
 	}
 }
+
+// similarAlbums returns n similar albums with IDs from first, each by a
+// different artist.
+func similarAlbums(first int64, n int) []hifi.SimilarAlbum {
+	albums := make([]hifi.SimilarAlbum, n)
+	for i := range albums {
+		id := first + int64(i)
+		albums[i] = hifi.SimilarAlbum{ID: id, Title: "Album", Artists: []hifi.ArtistRef{{ID: id, Name: "Artist"}}}
+	}
+	return albums
+}
+
+func TestDiscover_AggregatesSeeds(t *testing.T) {
+	store := &mockSeedStore{
+		mappings: []db.ArtistMapping{
+			{ID: 1, FolderName: "a1", TidalID: ptr(int64(100)), TidalName: ptr("Seed 1")},
+			{ID: 2, FolderName: "a2", TidalID: ptr(int64(200)), TidalName: ptr("Seed 2")},
+			{ID: 3, FolderName: "a3", TidalID: ptr(int64(300)), TidalName: ptr("Seed 3")},
+		},
+		owned: map[int64]bool{},
+	}
+	// Album 999 is a middling pick for every seed; each seed's own top
+	// pick appears nowhere else.
+	shared := hifi.SimilarAlbum{ID: 999, Title: "Shared", Artists: []hifi.ArtistRef{{ID: 9, Name: "Everyone's Favourite"}}}
+	finder := &mockSimilarFinder{albums: map[int64][]hifi.SimilarAlbum{
+		100: append(similarAlbums(1000, 3), shared),
+		200: append(similarAlbums(2000, 2), shared),
+		300: append(similarAlbums(3000, 1), shared),
+	}}
+
+	recs, err := NewEngine(store, finder).Discover(context.Background(), 3, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if recs[0].AlbumID != 999 {
+		t.Fatalf("recs[0].AlbumID = %d, want the album every seed points to", recs[0].AlbumID)
+	}
+	if want := []string{"Seed 3", "Seed 2", "Seed 1"}; !slices.Equal(recs[0].Seeds, want) {
+		t.Errorf("recs[0].Seeds = %v, want %v (strongest first)", recs[0].Seeds, want)
+	}
+	if recs[0].SeedArtist != "Seed 3" {
+		t.Errorf("recs[0].SeedArtist = %q, want the strongest seed", recs[0].SeedArtist)
+	}
+	if recs[0].Score <= recs[1].Score {
+		t.Errorf("scores %v and %v, want the shared album ahead", recs[0].Score, recs[1].Score)
+	}
+}
+
+func TestDiscover_RoundRobinAcrossSeeds(t *testing.T) {
+	store := &mockSeedStore{
+		mappings: []db.ArtistMapping{
+			{ID: 1, FolderName: "a1", TidalID: ptr(int64(100)), TidalName: ptr("Seed 1")},
+			{ID: 2, FolderName: "a2", TidalID: ptr(int64(200)), TidalName: ptr("Seed 2")},
+			{ID: 3, FolderName: "a3", TidalID: ptr(int64(300)), TidalName: ptr("Seed 3")},
+		},
+		owned: map[int64]bool{},
+	}
+	finder := &mockSimilarFinder{albums: map[int64][]hifi.SimilarAlbum{
+		100: similarAlbums(1000, 10),
+		200: similarAlbums(2000, 10),
+		300: similarAlbums(3000, 10),
+	}}
+
+	recs, err := NewEngine(store, finder).Discover(context.Background(), 3, 6)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	perSeed := make(map[string]int)
+	for _, r := range recs {
+		perSeed[r.SeedArtist]++
+	}
+	if perSeed["Seed 1"] != 2 || perSeed["Seed 2"] != 2 || perSeed["Seed 3"] != 2 {
+		t.Errorf("recommendations per seed = %v, want 2 each", perSeed)
+	}
+}
+
+func TestDiscover_SpreadsArtists(t *testing.T) {
+	store := &mockSeedStore{
+		mappings: []db.ArtistMapping{
+			{ID: 1, FolderName: "a1", TidalID: ptr(int64(100)), TidalName: ptr("Seed 1")},
+		},
+		owned: map[int64]bool{},
+	}
+	prolific := []hifi.ArtistRef{{ID: 7, Name: "Prolific"}}
+	finder := &mockSimilarFinder{albums: map[int64][]hifi.SimilarAlbum{
+		100: {
+			{ID: 1, Title: "First", Artists: prolific},
+			{ID: 2, Title: "Second", Artists: prolific},
+			{ID: 3, Title: "Other", Artists: []hifi.ArtistRef{{ID: 8, Name: "Other"}}},
+		},
+	}}
+
+	recs, err := NewEngine(store, finder).Discover(context.Background(), 1, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []int64
+	for _, r := range recs {
+		got = append(got, r.AlbumID)
+	}
+	if want := []int64{1, 3, 2}; !slices.Equal(got, want) {
+		t.Errorf("album order = %v, want %v (the second album by an artist last)", got, want)
+	}
+}
+
+func TestDiscover_PopularityBreaksTies(t *testing.T) {
+	store := &mockSeedStore{
+		mappings: []db.ArtistMapping{
+			{ID: 1, FolderName: "a1", TidalID: ptr(int64(100)), TidalName: ptr("Seed 1")},
+			{ID: 2, FolderName: "a2", TidalID: ptr(int64(200)), TidalName: ptr("Seed 2")},
+		},
+		owned: map[int64]bool{},
+	}
+	finder := &mockSimilarFinder{
+		albums: map[int64][]hifi.SimilarAlbum{
+			100: similarAlbums(1000, 1),
+			200: similarAlbums(2000, 1),
+		},
+		artists: map[int64][]hifi.SimilarArtist{
+			200: {{ID: 2000, Name: "Artist", Popularity: 0.9}},
+		},
+	}
+
+	recs, err := NewEngine(store, finder).Discover(context.Background(), 2, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recs) != 2 || recs[0].AlbumID != 2000 {
+		t.Errorf("recs = %+v, want the popular artist's album first", recs)
+	}
+}
+
+func TestDiscover_SkipsDismissed(t *testing.T) {
+	store := &mockSeedStore{
+		mappings: []db.ArtistMapping{
+			{ID: 1, FolderName: "a1", TidalID: ptr(int64(100)), TidalName: ptr("Seed 1")},
+		},
+		dismissals: []db.Dismissal{
+			{Kind: db.DismissAlbum, TidalID: 1000},
+			{Kind: db.DismissArtist, TidalID: 1002},
+		},
+	}
+	finder := &mockSimilarFinder{
+		albums: map[int64][]hifi.SimilarAlbum{
+			100: similarAlbums(1000, 4),
+		},
+	}
+
+	recs, err := NewEngine(store, finder).Discover(context.Background(), 1, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var ids []int64
+	for _, r := range recs {
+		ids = append(ids, r.AlbumID)
+	}
+	if want := []int64{1001, 1003}; !slices.Equal(ids, want) {
+		t.Errorf("recommended albums = %v, want %v", ids, want)
+	}
+	if recs[0].ArtistID != 1001 {
+		t.Errorf("recs[0].ArtistID = %d, want 1001", recs[0].ArtistID)
+	}
+}
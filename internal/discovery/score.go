@@ -0,0 +1,122 @@
+package discovery
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+)
+
+// Scoring weights. Each seed artist that points at an album adds up to 1 to
+// its score, less the further down that seed's similar albums it appears,
+// so agreement between seeds outweighs a single high rank. Popularity and
+// recency only separate albums with similar evidence.
+const (
+	rankHalving      = 5   // similarity rank at which a seed's vote is halved
+	popularityWeight = 0.5 // bonus for an artist at full Tidal popularity
+	recencyWeight    = 0.5 // bonus for an album released today
+	recencyYears     = 10  // age at which an album no longer earns a bonus
+)
+
+// vote records that a seed artist's similar albums include an album.
+type vote struct {
+	seed string
+	rank int // position in the seed's similar albums, from 0
+}
+
+// weight is how much a vote adds to an album's score.
+func (v vote) weight() float64 {
+	return 1 / (1 + float64(v.rank)/rankHalving)
+}
+
+// candidate is a similar album together with the evidence for it.
+type candidate struct {
+	album      hifi.SimilarAlbum
+	votes      []vote  // strongest first once scored
+	popularity float64 // Tidal popularity of its artist, 0-1, if known
+	score      float64
+}
+
+// artistID returns the ID of the album's main artist, or 0 if unknown.
+func (c *candidate) artistID() int64 {
+	if len(c.album.Artists) == 0 {
+		return 0
+	}
+	return c.album.Artists[0].ID
+}
+
+// scoreCandidate computes c's score as of now and orders its votes
+// strongest first.
+func scoreCandidate(c *candidate, now time.Time) {
+	slices.SortStableFunc(c.votes, func(a, b vote) int { return cmp.Compare(a.rank, b.rank) })
+
+	c.score = 0
+	for _, v := range c.votes {
+		c.score += v.weight()
+	}
+	c.score += popularityWeight * c.popularity
+	c.score += recencyWeight * recency(c.album.ReleaseDate, now)
+}
+
+// recency is 1 for an album released now (or announced for later), falling
+// to 0 at recencyYears old. Unknown release dates count as old.
+func recency(releaseDate string, now time.Time) float64 {
+	released, err := time.Parse("2006-01-02", releaseDate)
+	if err != nil {
+		return 0
+	}
+	years := now.Sub(released).Hours() / 24 / 365.25
+	return min(1, max(0, 1-years/recencyYears))
+}
+
+// diversify picks up to n candidates from scored, which must be ordered by
+// score, taking turns between the seed artists that lent them most weight
+// so that one seed cannot fill the list. Within a round each seed offers
+// its best candidate by an artist not yet picked; repeats of an artist are
+// only picked once every seed has run out of new artists.
+func diversify(scored []*candidate, n int) []*candidate {
+	var (
+		seeds  []string
+		bySeed = make(map[string][]*candidate)
+	)
+	for _, c := range scored {
+		seed := c.votes[0].seed
+		if _, ok := bySeed[seed]; !ok {
+			seeds = append(seeds, seed)
+		}
+		bySeed[seed] = append(bySeed[seed], c)
+	}
+
+	var (
+		picked      []*candidate
+		artists     = make(map[int64]bool)
+		allowRepeat bool
+	)
+	for len(picked) < n {
+		progress := false
+		for _, seed := range seeds {
+			if len(picked) == n {
+				break
+			}
+			queue := bySeed[seed]
+			for i, c := range queue {
+				if id := c.artistID(); id != 0 && artists[id] && !allowRepeat {
+					continue
+				}
+				picked = append(picked, c)
+				artists[c.artistID()] = true
+				bySeed[seed] = slices.Delete(queue, i, i+1)
+				progress = true
+				break
+			}
+		}
+		if !progress {
+			if allowRepeat {
+				break
+			}
+			allowRepeat = true
+		}
+	}
+	return picked
+}
@@ -0,0 +1,47 @@
+package discovery
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+)
+
+func TestRecency(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		date string
+		want float64
+	}{
+		{"2025-06-01", 1},
+		{"2026-01-01", 1}, // announced
+		{"2020-06-01", 0.5},
+		{"1997-05-21", 0},
+		{"", 0},
+		{"2020", 0},
+	}
+	for _, tt := range tests {
+		if got := recency(tt.date, now); math.Abs(got-tt.want) > 0.001 {
+			t.Errorf("recency(%q) = %.3f, want %.3f", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestScoreCandidate(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	c := &candidate{
+		album:      hifi.SimilarAlbum{ReleaseDate: "2020-06-01"},
+		votes:      []vote{{seed: "B", rank: 5}, {seed: "A", rank: 0}},
+		popularity: 0.4,
+	}
+	scoreCandidate(c, now)
+
+	// 1 (rank 0) + 0.5 (rank 5) + 0.5*0.4 + 0.5*0.5
+	if want := 1.95; math.Abs(c.score-want) > 0.001 {
+		t.Errorf("score = %.3f, want %.3f", c.score, want)
+	}
+	if c.votes[0].seed != "A" {
+		t.Errorf("votes = %+v, want the rank 0 vote first", c.votes)
+	}
+}
@@ -0,0 +1,122 @@
+package discovery
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+)
+
+// Seed weighting. An artist's chance of seeding a run grows with the
+// number of its albums in the library, but only logarithmically, so a
+// large discography doesn't crowd everything else out. Recent downloads
+// add a little each, and favourites count several times over.
+const (
+	RecentDays          = 90 // how far back downloads count as recent
+	recentDownloadBonus = 0.5
+	maxRecentDownloads  = 4
+	favouriteFactor     = 3
+)
+
+// Seed is a starting point for discovery: a library artist, or a single
+// album.
+type Seed struct {
+	Name     string
+	ArtistID int64 // Tidal artist ID; 0 for an album of unknown artist
+	AlbumID  int64 // Tidal album ID for an album seed, otherwise 0
+}
+
+// WeightedSeed is a library artist that can seed discovery, with how
+// likely it is to be picked relative to the others.
+type WeightedSeed struct {
+	db.SeedCandidate
+	Weight float64
+}
+
+// SeedWeight returns the relative weight of a library artist as a seed:
+// 1 for an artist with a single album and nothing else going for it.
+func SeedWeight(c db.SeedCandidate) float64 {
+	w := math.Log2(1+float64(c.Albums)) + recentDownloadBonus*float64(min(c.RecentDownloads, maxRecentDownloads))
+	w = max(w, 1)
+	if c.Favourite {
+		w *= favouriteFactor
+	}
+	return w
+}
+
+// SeedCandidates returns every library artist that can seed discovery with
+// its weight, pinned artists first, then heaviest first.
+func (e *Engine) SeedCandidates(ctx context.Context) ([]WeightedSeed, error) {
+	candidates, err := e.store.ListSeedCandidates(ctx, RecentDays)
+	if err != nil {
+		return nil, fmt.Errorf("discovery: listing seed artists: %w", err)
+	}
+
+	seeds := make([]WeightedSeed, 0, len(candidates))
+	for _, c := range candidates {
+		seeds = append(seeds, WeightedSeed{SeedCandidate: c, Weight: SeedWeight(c)})
+	}
+	slices.SortStableFunc(seeds, func(a, b WeightedSeed) int {
+		if a.Pinned != b.Pinned {
+			if a.Pinned {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(b.Weight, a.Weight)
+	})
+	return seeds, nil
+}
+
+// pickSeeds picks up to n library artists to start discovery from: every
+// pinned artist, up to n, then a weighted random sample of the rest.
+func (e *Engine) pickSeeds(ctx context.Context, n int) ([]Seed, error) {
+	candidates, err := e.SeedCandidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var picked []Seed
+	var rest []WeightedSeed
+	for _, c := range candidates {
+		if c.Pinned && len(picked) < n {
+			picked = append(picked, artistSeed(c.SeedCandidate))
+		} else if !c.Pinned {
+			rest = append(rest, c)
+		}
+	}
+
+	for _, c := range weightedSample(rest, n-len(picked), e.random) {
+		picked = append(picked, artistSeed(c.SeedCandidate))
+	}
+	return picked, nil
+}
+
+// artistSeed returns the seed for a library artist, named as on Tidal if
+// known.
+func artistSeed(c db.SeedCandidate) Seed {
+	name := c.TidalName
+	if name == "" {
+		name = c.FolderName
+	}
+	return Seed{Name: name, ArtistID: c.TidalID}
+}
+
+// weightedSample picks up to n of seeds at random without replacement,
+// each with probability proportional to its weight (Efraimidis-Spirakis:
+// every seed draws the key u^(1/w) and the n highest keys win).
+func weightedSample(seeds []WeightedSeed, n int, random func() float64) []WeightedSeed {
+	if n <= 0 {
+		return nil
+	}
+	keys := make(map[string]float64, len(seeds))
+	for _, s := range seeds {
+		keys[s.FolderName] = math.Pow(random(), 1/s.Weight)
+	}
+	sorted := slices.Clone(seeds)
+	slices.SortStableFunc(sorted, func(a, b WeightedSeed) int { return cmp.Compare(keys[b.FolderName], keys[a.FolderName]) })
+	return sorted[:min(n, len(sorted))]
+}
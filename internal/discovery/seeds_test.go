@@ -0,0 +1,113 @@
+package discovery
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+)
+
+func TestSeedWeight(t *testing.T) {
+	tests := []struct {
+		name string
+		c    db.SeedCandidate
+		want float64
+	}{
+		{"single album", db.SeedCandidate{Albums: 1}, 1},
+		{"no albums", db.SeedCandidate{}, 1},
+		{"seven albums", db.SeedCandidate{Albums: 7}, 3},
+		{"recent downloads", db.SeedCandidate{Albums: 3, RecentDownloads: 2}, 3},
+		{"recent downloads capped", db.SeedCandidate{Albums: 3, RecentDownloads: 10}, 4},
+		{"favourite", db.SeedCandidate{Albums: 1, Favourite: true}, 3},
+	}
+	for _, tt := range tests {
+		if got := SeedWeight(tt.c); got != tt.want {
+			t.Errorf("%s: SeedWeight() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPickSeeds(t *testing.T) {
+	store := &mockSeedStore{candidates: []db.SeedCandidate{
+		{FolderName: "stray", TidalID: 1, Albums: 1},
+		{FolderName: "portishead", TidalID: 2, TidalName: "Portishead", Albums: 3, Pinned: true},
+		{FolderName: "bjork", TidalID: 3, TidalName: "Björk", Albums: 7, Favourite: true},
+		{FolderName: "tricky", TidalID: 4, Albums: 1},
+	}}
+	eng := NewEngine(store, &mockSimilarFinder{})
+	// The same draw for everyone leaves only the weights to decide.
+	eng.random = func() float64 { return 0.5 }
+
+	seeds, err := eng.pickSeeds(context.Background(), 3)
+	if err != nil {
+		t.Fatalf("pickSeeds: %v", err)
+	}
+	want := []Seed{
+		{Name: "Portishead", ArtistID: 2},
+		{Name: "Björk", ArtistID: 3},
+		{Name: "stray", ArtistID: 1},
+	}
+	if !slices.Equal(seeds, want) {
+		t.Errorf("pickSeeds() = %+v, want %+v", seeds, want)
+	}
+
+	seeds, err = eng.pickSeeds(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("pickSeeds: %v", err)
+	}
+	if !slices.Equal(seeds, want[:1]) {
+		t.Errorf("pickSeeds(1) = %+v, want only the pinned artist", seeds)
+	}
+}
+
+func TestWeightedSample_FavoursHeavierSeeds(t *testing.T) {
+	seeds := []WeightedSeed{
+		{SeedCandidate: db.SeedCandidate{FolderName: "light"}, Weight: 1},
+		{SeedCandidate: db.SeedCandidate{FolderName: "heavy"}, Weight: 9},
+	}
+	// Sweep a grid of draws for the two seeds: the heavy one should win
+	// 90% of the time.
+	const steps = 100
+	heavy, trials := 0, 0
+	for i := range steps {
+		for j := range steps {
+			draws := []float64{(float64(i) + 0.5) / steps, (float64(j) + 0.5) / steps}
+			random := func() float64 { d := draws[0]; draws = draws[1:]; return d }
+			if weightedSample(seeds, 1, random)[0].FolderName == "heavy" {
+				heavy++
+			}
+			trials++
+		}
+	}
+	if share := float64(heavy) / float64(trials); share < 0.88 || share > 0.92 {
+		t.Errorf("heavy seed picked %d times out of %d, want about 900", heavy, trials)
+	}
+}
+
+func TestDiscoverFrom_Album(t *testing.T) {
+	store := &mockSeedStore{owned: map[int64]bool{1002: true}}
+	finder := &mockSimilarFinder{
+		albums: map[int64][]hifi.SimilarAlbum{
+			500: {
+				{ID: 500, Title: "Dummy"},
+				{ID: 1001, Title: "Maxinquaye", Artists: []hifi.ArtistRef{{ID: 11, Name: "Tricky"}}},
+				{ID: 1002, Title: "Mezzanine", Artists: []hifi.ArtistRef{{ID: 12, Name: "Massive Attack"}}},
+			},
+		},
+		artists: map[int64][]hifi.SimilarArtist{10: {{ID: 11, Name: "Tricky", Popularity: 0.8}}},
+	}
+	eng := NewEngine(store, finder)
+
+	recs, err := eng.DiscoverFrom(context.Background(), Seed{Name: "Dummy", ArtistID: 10, AlbumID: 500}, 10)
+	if err != nil {
+		t.Fatalf("DiscoverFrom: %v", err)
+	}
+	if len(recs) != 1 || recs[0].AlbumID != 1001 || recs[0].SeedArtist != "Dummy" {
+		t.Fatalf("DiscoverFrom() = %+v, want only Maxinquaye via Dummy", recs)
+	}
+	if finder.calls[10] != 1 {
+		t.Errorf("similar artists of the album's artist fetched %d times, want 1", finder.calls[10])
+	}
+}
@@ -0,0 +1,234 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+)
+
+// Snapshot sizes: how many library artists each refresh starts from, how
+// many albums it keeps, and how many artists it keeps for each walk of up
+// to SnapshotHops out.
+const (
+	SnapshotSeeds   = 5
+	SnapshotSize    = 20
+	SnapshotArtists = 20
+	SnapshotHops    = 2
+)
+
+// ErrRefreshInProgress is returned by Engine.Start when a refresh is
+// already running.
+var ErrRefreshInProgress = errors.New("discovery: a refresh is already running")
+
+// ErrNoSnapshot is returned by Engine.Snapshot when no recommendations
+// have been saved yet.
+var ErrNoSnapshot = errors.New("discovery: no recommendations saved yet")
+
+// Snapshot is a saved set of album recommendations.
+type Snapshot struct {
+	ID              int64
+	CreatedAt       string
+	Seeds           []string // library artists the run started from
+	Recommendations []Recommendation
+}
+
+// Refresh generates a fresh set of recommendations (see Discover) and saves
+// it as the latest snapshot, then replaces the artist recommendations (see
+// ArtistSnapshot).
+func (e *Engine) Refresh(ctx context.Context) (*Snapshot, error) {
+	recs, seeds, err := e.discover(ctx, SnapshotSeeds, SnapshotSize)
+	if err != nil {
+		return nil, err
+	}
+
+	albums := make([]db.DiscoveryRunAlbum, 0, len(recs))
+	for _, r := range recs {
+		albums = append(albums, db.DiscoveryRunAlbum{
+			TidalAlbumID:  r.AlbumID,
+			Title:         r.AlbumTitle,
+			ArtistTidalID: r.ArtistID,
+			ArtistName:    r.ArtistName,
+			Cover:         r.Cover,
+			ReleaseDate:   r.ReleaseDate,
+			Seeds:         r.Seeds,
+			Score:         r.Score,
+		})
+	}
+	id, err := e.store.CreateDiscoveryRun(ctx, seeds, albums)
+	if err != nil {
+		return nil, fmt.Errorf("discovery: saving snapshot: %w", err)
+	}
+
+	artists, err := e.discoverArtists(ctx, SnapshotSeeds, SnapshotArtists, SnapshotHops)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	e.artists = artists
+	e.mu.Unlock()
+
+	return &Snapshot{ID: id, Seeds: seeds, Recommendations: recs}, nil
+}
+
+// ArtistSnapshot returns the artist recommendations of the last refresh
+// for a walk of maxHops (1 or 2) out, without the artists mapped or
+// dismissed and the albums owned or dismissed since. They are kept in
+// memory only, so it returns ErrNoSnapshot until a refresh has run.
+func (e *Engine) ArtistSnapshot(ctx context.Context, maxHops int) ([]ArtistRecommendation, error) {
+	e.mu.Lock()
+	if e.artists == nil {
+		e.mu.Unlock()
+		return nil, ErrNoSnapshot
+	}
+	saved := e.artists[min(max(maxHops, 1), SnapshotHops)]
+	e.mu.Unlock()
+
+	mapped, err := e.store.ListArtistMappings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("discovery: listing library artists: %w", err)
+	}
+	known := make(map[int64]bool, len(mapped))
+	for _, m := range mapped {
+		known[*m.TidalID] = true
+	}
+	skip, err := e.loadDismissed(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	recs := make([]ArtistRecommendation, 0, len(saved))
+	for _, r := range saved {
+		if known[r.ArtistID] || skip.artists[r.ArtistID] {
+			continue
+		}
+		var albums []hifi.Album
+		for _, a := range r.Albums {
+			if skip.albums[a.ID] {
+				continue
+			}
+			owned, err := e.store.IsAlbumOwned(ctx, a.ID)
+			if err != nil {
+				e.logger.Printf("checking ownership of album %d: %v", a.ID, err)
+			}
+			if !owned {
+				albums = append(albums, a)
+			}
+		}
+		r.Albums = albums
+		recs = append(recs, r)
+	}
+	return recs, nil
+}
+
+// Snapshot returns the saved recommendations with the given ID, or the
+// latest if id is 0, without the albums owned or dismissed since they were
+// saved. It returns ErrNoSnapshot if there are none yet, and wraps
+// db.ErrDiscoveryRunNotFound for an unknown ID.
+func (e *Engine) Snapshot(ctx context.Context, id int64) (*Snapshot, error) {
+	var run *db.DiscoveryRun
+	var err error
+	if id == 0 {
+		run, err = e.store.LatestDiscoveryRun(ctx)
+		if errors.Is(err, db.ErrDiscoveryRunNotFound) {
+			return nil, ErrNoSnapshot
+		}
+	} else {
+		run, err = e.store.GetDiscoveryRun(ctx, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("discovery: loading snapshot: %w", err)
+	}
+
+	albums, err := e.store.ListDiscoveryRunAlbums(ctx, run.ID)
+	if err != nil {
+		return nil, fmt.Errorf("discovery: loading snapshot: %w", err)
+	}
+	skip, err := e.loadDismissed(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &Snapshot{ID: run.ID, CreatedAt: run.CreatedAt, Seeds: run.Seeds}
+	for _, a := range albums {
+		if skip.albums[a.TidalAlbumID] || skip.artists[a.ArtistTidalID] {
+			continue
+		}
+		owned, err := e.store.IsAlbumOwned(ctx, a.TidalAlbumID)
+		if err != nil {
+			e.logger.Printf("checking ownership of album %d: %v", a.TidalAlbumID, err)
+		}
+		if owned {
+			continue
+		}
+
+		rec := Recommendation{
+			AlbumID:     a.TidalAlbumID,
+			AlbumTitle:  a.Title,
+			ArtistID:    a.ArtistTidalID,
+			ArtistName:  a.ArtistName,
+			Cover:       a.Cover,
+			ReleaseDate: a.ReleaseDate,
+			Seeds:       a.Seeds,
+			Score:       a.Score,
+		}
+		if len(a.Seeds) > 0 {
+			rec.SeedArtist = a.Seeds[0]
+		}
+		snap.Recommendations = append(snap.Recommendations, rec)
+	}
+	return snap, nil
+}
+
+// Run refreshes the recommendations every interval until ctx is cancelled,
+// starting straight away if the latest snapshot is older than interval.
+func (e *Engine) Run(ctx context.Context, interval time.Duration) {
+	latest, err := e.store.LatestDiscoveryRun(ctx)
+	if err != nil && !errors.Is(err, db.ErrDiscoveryRunNotFound) {
+		e.logger.Printf("reading latest snapshot: %v", err)
+	}
+	if latest == nil {
+		e.startScheduled(ctx)
+	} else if created, err := time.Parse(time.RFC3339, latest.CreatedAt); err != nil || time.Since(created) >= interval {
+		e.startScheduled(ctx)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.startScheduled(ctx)
+		}
+	}
+}
+
+// startScheduled starts a scheduled refresh, logging rather than returning
+// a clash with one started by hand.
+func (e *Engine) startScheduled(ctx context.Context) {
+	if err := e.Start(ctx); err != nil {
+		e.logger.Printf("scheduled refresh skipped: %v", err)
+	}
+}
+
+// Start runs a refresh in the background, detached from ctx's cancellation.
+// It returns ErrRefreshInProgress if a refresh is already running.
+func (e *Engine) Start(ctx context.Context) error {
+	if !e.Runner.Start(ctx, func(ctx context.Context) {
+		snap, err := e.Refresh(ctx)
+		if err != nil {
+			e.logger.Printf("refresh failed: %v", err)
+			return
+		}
+		e.logger.Printf("refresh complete: %d recommendations from %d seeds", len(snap.Recommendations), len(snap.Seeds))
+	}) {
+		return ErrRefreshInProgress
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+)
+
+func TestRefreshAndSnapshot(t *testing.T) {
+	store := &mockSeedStore{
+		mappings: []db.ArtistMapping{
+			{ID: 1, FolderName: "portishead", TidalID: ptr(int64(100)), TidalName: ptr("Portishead")},
+			{ID: 2, FolderName: "bjork", TidalID: ptr(int64(200))},
+		},
+		owned: map[int64]bool{},
+	}
+	finder := &mockSimilarFinder{
+		albums: map[int64][]hifi.SimilarAlbum{
+			100: {
+				{ID: 1000, Title: "Mezzanine", Artists: []hifi.ArtistRef{{ID: 10, Name: "Massive Attack"}}},
+				{ID: 1001, Title: "Maxinquaye", Artists: []hifi.ArtistRef{{ID: 11, Name: "Tricky"}}},
+			},
+			200: {
+				{ID: 1002, Title: "Vespertine", Artists: []hifi.ArtistRef{{ID: 12, Name: "Björk"}}},
+			},
+		},
+	}
+	eng := NewEngine(store, finder)
+	ctx := context.Background()
+
+	if _, err := eng.Snapshot(ctx, 0); !errors.Is(err, ErrNoSnapshot) {
+		t.Fatalf("Snapshot() before any refresh: err = %v, want ErrNoSnapshot", err)
+	}
+
+	snap, err := eng.Refresh(ctx)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if !slices.Equal(snap.Seeds, []string{"Portishead", "bjork"}) {
+		t.Errorf("seeds = %v, want [Portishead bjork]", snap.Seeds)
+	}
+	if len(snap.Recommendations) != 3 || len(store.runAlbums[snap.ID]) != 3 {
+		t.Fatalf("refresh saved %d of %d recommendations, want 3", len(store.runAlbums[snap.ID]), len(snap.Recommendations))
+	}
+
+	// Albums bought or dismissed since the refresh drop out of the snapshot.
+	store.owned[1000] = true
+	store.dismissals = []db.Dismissal{{Kind: db.DismissArtist, TidalID: 12}}
+
+	latest, err := eng.Snapshot(ctx, 0)
+	if err != nil {
+		t.Fatalf("Snapshot(0): %v", err)
+	}
+	if latest.ID != snap.ID || latest.CreatedAt == "" {
+		t.Errorf("Snapshot(0) = %+v, want run %d", latest, snap.ID)
+	}
+	if len(latest.Recommendations) != 1 {
+		t.Fatalf("Snapshot(0) has %d recommendations, want 1: %+v", len(latest.Recommendations), latest.Recommendations)
+	}
+	if r := latest.Recommendations[0]; r.AlbumID != 1001 || r.ArtistID != 11 || r.SeedArtist != "Portishead" {
+		t.Errorf("recommendation = %+v, want Maxinquaye via Portishead", r)
+	}
+
+	if _, err := eng.Snapshot(ctx, 99); !errors.Is(err, db.ErrDiscoveryRunNotFound) {
+		t.Errorf("Snapshot(99): err = %v, want ErrDiscoveryRunNotFound", err)
+	}
+}
+
+func TestStartRefresh(t *testing.T) {
+	store := &mockSeedStore{mappings: []db.ArtistMapping{{ID: 1, FolderName: "portishead", TidalID: ptr(int64(100))}}}
+	finder := &mockSimilarFinder{albums: map[int64][]hifi.SimilarAlbum{100: {{ID: 1000, Title: "Mezzanine"}}}}
+	eng := NewEngine(store, finder)
+
+	if err := eng.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	eng.Wait()
+
+	if eng.Running() {
+		t.Error("Running() = true after Wait")
+	}
+	if len(store.runs) != 1 {
+		t.Errorf("saved %d runs, want 1", len(store.runs))
+	}
+}
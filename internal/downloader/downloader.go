@@ -2,13 +2,17 @@ package downloader
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"sync"
 
 	"github.com/MattHbrook/Crescendo/internal/hifi"
 	"github.com/MattHbrook/Crescendo/internal/library"
@@ -32,7 +36,7 @@ type CoverFetcher interface {
 
 // DownloadStore persists download state.
 type DownloadStore interface {
-	CreateDownload(ctx context.Context, tidalAlbumID int64, artistName, albumTitle, quality string, totalTracks int) (int64, error)
+	CreateTrackDownload(ctx context.Context, tidalAlbumID int64, artistName, albumTitle, quality string, totalTracks, albumTracks int) (int64, error)
 	UpdateDownloadProgress(ctx context.Context, id int64, completedTracks int, progress float64) error
 	CompleteDownload(ctx context.Context, id int64, outputPath string) error
 	FailDownload(ctx context.Context, id int64, errMsg string) error
@@ -42,29 +46,69 @@ type DownloadStore interface {
 type Request struct {
 	TidalAlbumID int64
 	Quality      string // "LOSSLESS" or "HI_RES_LOSSLESS"
+	// OutputDir overrides the album directory, e.g. to complete a partial
+	// library copy in place; a cover.jpg already there is kept. Empty means
+	// library.AlbumDir.
+	OutputDir string
+	// TrackIDs restricts the download to these tracks. Empty means the
+	// whole album.
+	TrackIDs []int64
+	// Naming is the track filename pattern, so tracks added to an existing
+	// folder match their neighbours. The zero value is "01 - Title.flac".
+	Naming library.TrackNaming
+	// Replace is an existing album folder the download supersedes, e.g. for
+	// a quality upgrade. The album is downloaded into a staging folder and
+	// only swapped in once every track has arrived; the old folder is moved
+	// to the trash. OutputDir is ignored when Replace is set.
+	Replace string
+	// Supersede, if set, is called once every track has been downloaded to
+	// make way for them in OutputDir, e.g. by moving the damaged copies they
+	// replace to the trash. Until then the tracks are kept in a staging
+	// folder, so a failed download leaves OutputDir as it was; if Supersede
+	// fails the download does too and nothing is moved.
+	Supersede func(ctx context.Context) error
 }
 
+// stagingFolder is where replacement downloads are assembled. It lives in
+// the music root so the final swap is a rename on the same filesystem, and
+// is hidden so library scans skip it.
+const stagingFolder = ".crescendo-staging"
+
 // Downloader manages concurrent album downloads.
 type Downloader struct {
 	musicPath string
+	trashPath string // where replaced album folders are moved
 	player    TrackPlayer
 	albums    AlbumFetcher
 	covers    CoverFetcher
 	store     DownloadStore
-	sem       chan struct{} // limits concurrency
 	logger    *log.Logger
+
+	maxConcurrent int
+	mu            sync.Mutex
+	queue         []queuedDownload // waiting for a worker
+	workers       int              // goroutines draining queue
+}
+
+// queuedDownload is a request passed to DownloadAsync, waiting its turn.
+type queuedDownload struct {
+	ctx context.Context
+	req Request
 }
 
 // New creates a Downloader with the given concurrency limit and dependencies.
-func New(musicPath string, maxConcurrent int, player TrackPlayer, albums AlbumFetcher, covers CoverFetcher, store DownloadStore) *Downloader {
+// Album folders replaced by upgrades are kept under trashPath.
+func New(musicPath, trashPath string, maxConcurrent int, player TrackPlayer, albums AlbumFetcher, covers CoverFetcher, store DownloadStore) *Downloader {
 	return &Downloader{
 		musicPath: musicPath,
+		trashPath: trashPath,
 		player:    player,
 		albums:    albums,
 		covers:    covers,
 		store:     store,
-		sem:       make(chan struct{}, maxConcurrent),
 		logger:    log.New(os.Stderr, "[downloader] ", log.LstdFlags),
+
+		maxConcurrent: max(maxConcurrent, 1),
 	}
 }
 
@@ -75,7 +119,35 @@ func (d *Downloader) Download(ctx context.Context, req Request) error {
 		return fmt.Errorf("downloader: fetching album %d: %w", req.TidalAlbumID, err)
 	}
 
-	outputDir := library.AlbumDir(d.musicPath, album.Artist.Name, album.Title)
+	outputDir := req.OutputDir
+	if outputDir == "" {
+		outputDir = library.AlbumDir(d.musicPath, album.Artist.Name, album.Title)
+	}
+	finalDir := outputDir
+	if req.Replace != "" {
+		finalDir = req.Replace
+	}
+	if req.Replace != "" || req.Supersede != nil {
+		outputDir, err = d.stagingDir()
+		if err != nil {
+			return fmt.Errorf("downloader: creating staging directory: %w", err)
+		}
+		// A no-op once the staged album has been swapped in.
+		defer func() { _ = os.RemoveAll(outputDir) }()
+	}
+
+	tracks := album.Tracks
+	if len(req.TrackIDs) > 0 {
+		tracks = make([]hifi.Track, 0, len(req.TrackIDs))
+		for _, t := range album.Tracks {
+			if slices.Contains(req.TrackIDs, t.ID) {
+				tracks = append(tracks, t)
+			}
+		}
+		if len(tracks) == 0 {
+			return fmt.Errorf("downloader: none of the requested tracks are on album %d", req.TidalAlbumID)
+		}
+	}
 
 	if err := os.MkdirAll(outputDir, 0o750); err != nil {
 		return fmt.Errorf("downloader: creating album directory: %w", err)
@@ -92,9 +164,11 @@ func (d *Downloader) Download(ctx context.Context, req Request) error {
 			coverURL = cover.URL1280
 		}
 		if coverURL != "" {
-			coverJPEG, err = downloadCover(ctx, coverURL, outputDir)
+			coverJPEG, err = downloadCover(ctx, coverURL)
 			if err != nil {
 				d.logger.Printf("cover download failed for album %d: %v", req.TidalAlbumID, err)
+			} else if err := saveCover(outputDir, coverJPEG); err != nil {
+				d.logger.Printf("cover not saved for album %d: %v", req.TidalAlbumID, err)
 			}
 		}
 	}
@@ -105,12 +179,13 @@ func (d *Downloader) Download(ctx context.Context, req Request) error {
 		releaseDate = releaseDate[:4]
 	}
 
-	downloadID, err := d.store.CreateDownload(ctx, req.TidalAlbumID, album.Artist.Name, album.Title, req.Quality, len(album.Tracks))
+	downloadID, err := d.store.CreateTrackDownload(ctx, req.TidalAlbumID, album.Artist.Name, album.Title, req.Quality, len(tracks), len(album.Tracks))
 	if err != nil {
 		return fmt.Errorf("downloader: creating download record: %w", err)
 	}
 
-	for i, track := range album.Tracks {
+	var trackPaths []string
+	for i, track := range tracks {
 		playback, err := d.player.GetTrackPlayback(ctx, track.ID, req.Quality)
 		if err != nil {
 			_ = d.store.FailDownload(ctx, downloadID, err.Error())
@@ -123,7 +198,8 @@ func (d *Downloader) Download(ctx context.Context, req Request) error {
 			return fmt.Errorf("downloader: decoding manifest for track %d: %w", track.ID, err)
 		}
 
-		trackPath := library.TrackPath(d.musicPath, album.Artist.Name, album.Title, track.TrackNumber, track.Title)
+		trackPath := filepath.Join(outputDir, req.Naming.Filename(track.TrackNumber, track.Title))
+		trackPaths = append(trackPaths, trackPath)
 
 		if err := d.downloadTrack(ctx, manifestResult, trackPath); err != nil {
 			_ = d.store.FailDownload(ctx, downloadID, err.Error())
@@ -144,30 +220,124 @@ func (d *Downloader) Download(ctx context.Context, req Request) error {
 			d.logger.Printf("tagging failed for track %d (%s): %v", track.ID, track.Title, err)
 		}
 
-		progress := float64(i+1) / float64(len(album.Tracks)) * 100
+		progress := float64(i+1) / float64(len(tracks)) * 100
 		if err := d.store.UpdateDownloadProgress(ctx, downloadID, i+1, progress); err != nil {
 			_ = d.store.FailDownload(ctx, downloadID, err.Error())
 			return fmt.Errorf("downloader: updating progress: %w", err)
 		}
 	}
 
-	if err := d.store.CompleteDownload(ctx, downloadID, outputDir); err != nil {
+	switch {
+	case req.Replace != "":
+		if err := d.replaceDir(outputDir, req.Replace); err != nil {
+			_ = d.store.FailDownload(ctx, downloadID, err.Error())
+			return fmt.Errorf("downloader: replacing %s: %w", req.Replace, err)
+		}
+	case req.Supersede != nil:
+		if err := req.Supersede(ctx); err != nil {
+			_ = d.store.FailDownload(ctx, downloadID, err.Error())
+			return fmt.Errorf("downloader: making way for tracks in %s: %w", finalDir, err)
+		}
+		if err := moveTracks(trackPaths, finalDir); err != nil {
+			_ = d.store.FailDownload(ctx, downloadID, err.Error())
+			return fmt.Errorf("downloader: moving tracks into %s: %w", finalDir, err)
+		}
+	}
+
+	if err := d.store.CompleteDownload(ctx, downloadID, finalDir); err != nil {
 		return fmt.Errorf("downloader: completing download record: %w", err)
 	}
 
 	return nil
 }
 
-// DownloadAsync acquires a semaphore slot and runs Download in a goroutine.
+// DownloadAsync queues req to be run by Download, detached from ctx's
+// cancellation so it outlives the HTTP request that queued it. It returns
+// immediately. At most maxConcurrent workers drain the queue, in order.
 // Errors are logged but not returned (fire-and-forget).
 func (d *Downloader) DownloadAsync(ctx context.Context, req Request) {
-	d.sem <- struct{}{}
-	go func() {
-		defer func() { <-d.sem }()
-		if err := d.Download(ctx, req); err != nil {
-			d.logger.Printf("download failed for album %d: %v", req.TidalAlbumID, err)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.queue = append(d.queue, queuedDownload{ctx: context.WithoutCancel(ctx), req: req})
+	if d.workers < d.maxConcurrent {
+		d.workers++
+		go d.work()
+	}
+}
+
+// work runs queued downloads until the queue is empty.
+func (d *Downloader) work() {
+	for {
+		d.mu.Lock()
+		if len(d.queue) == 0 {
+			d.workers--
+			d.mu.Unlock()
+			return
+		}
+		next := d.queue[0]
+		d.queue[0] = queuedDownload{}
+		d.queue = d.queue[1:]
+		d.mu.Unlock()
+
+		if err := d.Download(next.ctx, next.req); err != nil {
+			d.logger.Printf("download failed for album %d: %v", next.req.TidalAlbumID, err)
 		}
-	}()
+	}
+}
+
+// stagingDir creates a fresh, empty folder for a replacement download.
+func (d *Downloader) stagingDir() (string, error) {
+	root := filepath.Join(d.musicPath, stagingFolder)
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(root, "album-*")
+}
+
+// moveTracks moves staged track files into dir.
+func moveTracks(paths []string, dir string) error {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	for _, p := range paths {
+		if err := os.Rename(p, filepath.Join(dir, filepath.Base(p))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceDir moves the album folder at target into the trash (see
+// library.MoveToTrash), then renames staged into its place. If the second
+// rename fails the old folder is moved back, so a failed swap leaves the old
+// copy in place.
+func (d *Downloader) replaceDir(staged, target string) error {
+	trashed, err := library.MoveToTrash(d.musicPath, d.trashPath, target)
+	moved := err == nil
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		// Nothing left to preserve.
+		if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+			return fmt.Errorf("creating album directory: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("moving old copy to trash: %w", err)
+	}
+
+	if err := os.Rename(staged, target); err != nil {
+		if moved {
+			if restoreErr := os.Rename(trashed, target); restoreErr != nil {
+				d.logger.Printf("restoring %s from %s failed: %v", target, trashed, restoreErr)
+			}
+		}
+		return fmt.Errorf("moving new copy into place: %w", err)
+	}
+
+	if moved {
+		d.logger.Printf("replaced %s; old copy kept at %s", target, trashed)
+	}
+	return nil
 }
 
 // downloadTrack downloads a single track, choosing the strategy based on codec.
@@ -1,10 +1,13 @@
 package downloader
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"image"
+	"image/jpeg"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -14,7 +17,9 @@ import (
 	"time"
 
 	"github.com/MattHbrook/Crescendo/internal/hifi"
+	"github.com/MattHbrook/Crescendo/internal/library"
 	"github.com/MattHbrook/Crescendo/internal/manifest"
+	flac "github.com/go-flac/go-flac/v2"
 )
 
 // --- mock implementations ---
@@ -57,6 +62,7 @@ type downloadRecord struct {
 	albumTitle   string
 	quality      string
 	totalTracks  int
+	albumTracks  int
 }
 
 type progressUpdate struct {
@@ -87,7 +93,7 @@ func newMockDownloadStore() *mockDownloadStore {
 	}
 }
 
-func (s *mockDownloadStore) CreateDownload(_ context.Context, tidalAlbumID int64, artistName, albumTitle, quality string, totalTracks int) (int64, error) {
+func (s *mockDownloadStore) CreateTrackDownload(_ context.Context, tidalAlbumID int64, artistName, albumTitle, quality string, totalTracks, albumTracks int) (int64, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if s.createErr != nil {
@@ -101,6 +107,7 @@ func (s *mockDownloadStore) CreateDownload(_ context.Context, tidalAlbumID int64
 		albumTitle:   albumTitle,
 		quality:      quality,
 		totalTracks:  totalTracks,
+		albumTracks:  albumTracks,
 	}
 	return id, nil
 }
@@ -222,7 +229,7 @@ func TestDownload_Success(t *testing.T) {
 
 	store := newMockDownloadStore()
 	tmpDir := t.TempDir()
-	dl := New(tmpDir, 3, player, fetcher, noCoverFetcher(), store)
+	dl := New(tmpDir, t.TempDir(), 3, player, fetcher, noCoverFetcher(), store)
 
 	err := dl.Download(context.Background(), Request{
 		TidalAlbumID: 42,
@@ -265,12 +272,326 @@ func TestDownload_Success(t *testing.T) {
 	}
 }
 
+func TestDownload_MissingTracksIntoExistingDir(t *testing.T) {
+	fakeFlac := []byte("fake-flac-data")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(fakeFlac)
+	}))
+	defer srv.Close()
+
+	b64Manifest := encodeBTSManifest(srv.URL)
+
+	player := &mockPlayer{
+		playbacks: map[int64]*hifi.Playback{
+			2: {TrackID: 2, ManifestMimeType: manifest.MimeTypeBTS, Manifest: b64Manifest},
+		},
+	}
+	fetcher := &mockAlbumFetcher{
+		albums: map[int64]*hifi.AlbumDetail{
+			42: {
+				Album: hifi.Album{ID: 42, Title: "Test Album", Artist: hifi.ArtistRef{ID: 1, Name: "Test Artist"}},
+				Tracks: []hifi.Track{
+					{ID: 1, Title: "Song One", TrackNumber: 1},
+					{ID: 2, Title: "Song Two", TrackNumber: 2},
+				},
+			},
+		},
+	}
+
+	store := newMockDownloadStore()
+	tmpDir := t.TempDir()
+	existing := filepath.Join(tmpDir, "Test Artist", "1999 - Test Album")
+	dl := New(tmpDir, t.TempDir(), 1, player, fetcher, noCoverFetcher(), store)
+
+	err := dl.Download(context.Background(), Request{
+		TidalAlbumID: 42,
+		Quality:      "LOSSLESS",
+		OutputDir:    existing,
+		TrackIDs:     []int64{2},
+	})
+	if err != nil {
+		t.Fatalf("Download returned unexpected error: %v", err)
+	}
+
+	if got := store.downloads[1]; got.totalTracks != 1 || got.albumTracks != 2 {
+		t.Errorf("download of %d of %d tracks, want 1 of 2", got.totalTracks, got.albumTracks)
+	}
+	if _, err := os.Stat(filepath.Join(existing, "02 - Song Two.flac")); err != nil {
+		t.Errorf("missing track not written to existing dir: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(existing, "01 - Song One.flac")); !os.IsNotExist(err) {
+		t.Errorf("track 1 should not be downloaded, stat err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "Test Artist", "Test Album")); !os.IsNotExist(err) {
+		t.Errorf("default album dir should not be created, stat err = %v", err)
+	}
+}
+
+func TestDownload_CoverArt(t *testing.T) {
+	track, err := os.ReadFile(filepath.Join("..", "audio", "testdata", "libflac.flac"))
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+	var cover bytes.Buffer
+	if err := jpeg.Encode(&cover, image.NewGray(image.Rect(0, 0, 1, 1)), nil); err != nil {
+		t.Fatalf("encoding cover: %v", err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/cover.jpg" {
+			w.Write(cover.Bytes())
+			return
+		}
+		w.Write(track)
+	}))
+	defer srv.Close()
+
+	player := &mockPlayer{
+		playbacks: map[int64]*hifi.Playback{
+			2: {TrackID: 2, ManifestMimeType: manifest.MimeTypeBTS, Manifest: encodeBTSManifest(srv.URL + "/track.flac")},
+		},
+	}
+	fetcher := &mockAlbumFetcher{
+		albums: map[int64]*hifi.AlbumDetail{
+			42: {
+				Album:  hifi.Album{ID: 42, Title: "Test Album", Artist: hifi.ArtistRef{ID: 1, Name: "Test Artist"}},
+				Tracks: []hifi.Track{{ID: 1, Title: "Song One", TrackNumber: 1}, {ID: 2, Title: "Song Two", TrackNumber: 2}},
+			},
+		},
+	}
+	covers := &mockCoverFetcher{covers: map[int64]*hifi.Cover{42: {URL640: srv.URL + "/cover.jpg"}}}
+
+	tests := []struct {
+		name      string
+		existing  string // cover.jpg already in the folder; "" for none
+		wantCover string
+	}{
+		{"keeps the library's cover", "my-cover", "my-cover"},
+		{"saves one where there is none", "", cover.String()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			dir := filepath.Join(tmpDir, "Test Artist", "1999 - Test Album")
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.existing != "" {
+				if err := os.WriteFile(filepath.Join(dir, "cover.jpg"), []byte(tt.existing), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			dl := New(tmpDir, t.TempDir(), 1, player, fetcher, covers, newMockDownloadStore())
+
+			err := dl.Download(context.Background(), Request{TidalAlbumID: 42, Quality: "LOSSLESS", OutputDir: dir, TrackIDs: []int64{2}})
+			if err != nil {
+				t.Fatalf("Download returned unexpected error: %v", err)
+			}
+
+			if got, _ := os.ReadFile(filepath.Join(dir, "cover.jpg")); string(got) != tt.wantCover {
+				t.Errorf("cover.jpg = %q, want %q", got, tt.wantCover)
+			}
+			// The track carries Tidal's cover either way.
+			f, err := flac.ParseFile(filepath.Join(dir, "02 - Song Two.flac"))
+			if err != nil {
+				t.Fatalf("parsing track: %v", err)
+			}
+			embedded := false
+			for _, block := range f.Meta {
+				embedded = embedded || block.Type == flac.Picture
+			}
+			if !embedded {
+				t.Error("track has no embedded cover")
+			}
+		})
+	}
+}
+
+func TestDownload_ReplaceSwapsAndKeepsOldCopy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("hi-res"))
+	}))
+	defer srv.Close()
+
+	b64Manifest := encodeBTSManifest(srv.URL)
+	player := &mockPlayer{
+		playbacks: map[int64]*hifi.Playback{
+			1: {TrackID: 1, ManifestMimeType: manifest.MimeTypeBTS, Manifest: b64Manifest},
+		},
+	}
+	fetcher := &mockAlbumFetcher{
+		albums: map[int64]*hifi.AlbumDetail{
+			42: {
+				Album:  hifi.Album{ID: 42, Title: "Test Album", Artist: hifi.ArtistRef{ID: 1, Name: "Test Artist"}},
+				Tracks: []hifi.Track{{ID: 1, Title: "Song One", TrackNumber: 1}},
+			},
+		},
+	}
+
+	tmpDir := t.TempDir()
+	trash := filepath.Join(tmpDir, ".trash")
+	existing := filepath.Join(tmpDir, "Test Artist", "Test Album (CD)")
+	if err := os.MkdirAll(existing, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(existing, "01 Song One.flac"), []byte("cd"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store := newMockDownloadStore()
+	dl := New(tmpDir, trash, 1, player, fetcher, noCoverFetcher(), store)
+	err := dl.Download(context.Background(), Request{
+		TidalAlbumID: 42,
+		Quality:      "HI_RES_LOSSLESS",
+		Replace:      existing,
+		Naming:       library.TrackNaming{Width: 2, Separator: " "},
+	})
+	if err != nil {
+		t.Fatalf("Download returned unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(existing, "01 Song One.flac"))
+	if err != nil || string(data) != "hi-res" {
+		t.Errorf("replaced track = %q, %v; want new download", data, err)
+	}
+
+	old, err := filepath.Glob(filepath.Join(trash, "*", "Test Artist", "Test Album (CD)", "01 Song One.flac"))
+	if err != nil || len(old) != 1 {
+		t.Fatalf("old copy in trash = %v, %v; want one file", old, err)
+	}
+	if data, _ := os.ReadFile(old[0]); string(data) != "cd" {
+		t.Errorf("trashed track = %q, want old contents", data)
+	}
+
+	if staged, _ := os.ReadDir(filepath.Join(tmpDir, stagingFolder)); len(staged) != 0 {
+		t.Errorf("staging folder not cleaned up: %v", staged)
+	}
+}
+
+func TestDownload_ReplaceFailureKeepsExistingCopy(t *testing.T) {
+	fetcher := &mockAlbumFetcher{
+		albums: map[int64]*hifi.AlbumDetail{
+			42: {
+				Album:  hifi.Album{ID: 42, Title: "Test Album", Artist: hifi.ArtistRef{ID: 1, Name: "Test Artist"}},
+				Tracks: []hifi.Track{{ID: 1, Title: "Song One", TrackNumber: 1}},
+			},
+		},
+	}
+	player := &mockPlayer{err: fmt.Errorf("stream unavailable")}
+
+	tmpDir := t.TempDir()
+	trash := filepath.Join(tmpDir, ".trash")
+	existing := filepath.Join(tmpDir, "Test Artist", "Test Album")
+	if err := os.MkdirAll(existing, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(existing, "01 - Song One.flac"), []byte("cd"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store := newMockDownloadStore()
+	dl := New(tmpDir, trash, 1, player, fetcher, noCoverFetcher(), store)
+	if err := dl.Download(context.Background(), Request{TidalAlbumID: 42, Quality: "HI_RES_LOSSLESS", Replace: existing}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	if data, err := os.ReadFile(filepath.Join(existing, "01 - Song One.flac")); err != nil || string(data) != "cd" {
+		t.Errorf("existing track = %q, %v; want it untouched", data, err)
+	}
+	if _, err := os.Stat(trash); !os.IsNotExist(err) {
+		t.Errorf("trash should not be created, stat err = %v", err)
+	}
+	if staged, _ := os.ReadDir(filepath.Join(tmpDir, stagingFolder)); len(staged) != 0 {
+		t.Errorf("staging folder not cleaned up: %v", staged)
+	}
+	if len(store.failed) != 1 {
+		t.Errorf("expected 1 failure, got %d", len(store.failed))
+	}
+}
+
+func TestDownload_Supersede(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("fresh"))
+	}))
+	defer srv.Close()
+
+	fetcher := &mockAlbumFetcher{
+		albums: map[int64]*hifi.AlbumDetail{
+			42: {
+				Album:  hifi.Album{ID: 42, Title: "Test Album", Artist: hifi.ArtistRef{ID: 1, Name: "Test Artist"}},
+				Tracks: []hifi.Track{{ID: 1, Title: "Song One", TrackNumber: 1}, {ID: 2, Title: "Song Two", TrackNumber: 2}},
+			},
+		},
+	}
+	working := &mockPlayer{
+		playbacks: map[int64]*hifi.Playback{
+			2: {TrackID: 2, ManifestMimeType: manifest.MimeTypeBTS, Manifest: encodeBTSManifest(srv.URL)},
+		},
+	}
+
+	for _, tt := range []struct {
+		name        string
+		player      *mockPlayer
+		wantErr     bool
+		wantCalled  bool
+		wantContent string
+	}{
+		{"download succeeds", working, false, true, "fresh"},
+		{"download fails", &mockPlayer{err: fmt.Errorf("stream unavailable")}, true, false, "corrupt"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			albumDir := filepath.Join(tmpDir, "Test Artist", "Test Album")
+			if err := os.MkdirAll(albumDir, 0o750); err != nil {
+				t.Fatal(err)
+			}
+			track := filepath.Join(albumDir, "02 - Song Two.flac")
+			if err := os.WriteFile(track, []byte("corrupt"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			// Supersede stands in for moving the corrupt copy to the trash;
+			// the new track must not have arrived before it is called.
+			called := false
+			supersede := func(context.Context) error {
+				called = true
+				data, err := os.ReadFile(track)
+				if err != nil || string(data) != "corrupt" {
+					t.Errorf("track when superseding = %q, %v; want the old copy", data, err)
+				}
+				return os.Remove(track)
+			}
+
+			dl := New(tmpDir, filepath.Join(tmpDir, ".trash"), 1, tt.player, fetcher, noCoverFetcher(), newMockDownloadStore())
+			err := dl.Download(context.Background(), Request{
+				TidalAlbumID: 42,
+				Quality:      "LOSSLESS",
+				OutputDir:    albumDir,
+				TrackIDs:     []int64{2},
+				Supersede:    supersede,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Download error = %v, want error %v", err, tt.wantErr)
+			}
+			if called != tt.wantCalled {
+				t.Errorf("Supersede called = %v, want %v", called, tt.wantCalled)
+			}
+			if data, err := os.ReadFile(track); err != nil || string(data) != tt.wantContent {
+				t.Errorf("track = %q, %v; want %q", data, err, tt.wantContent)
+			}
+			if staged, _ := os.ReadDir(filepath.Join(tmpDir, stagingFolder)); len(staged) != 0 {
+				t.Errorf("staging folder not cleaned up: %v", staged)
+			}
+		})
+	}
+}
+
 func TestDownload_AlbumFetchError(t *testing.T) {
 	fetcher := &mockAlbumFetcher{err: fmt.Errorf("tidal API down")}
 	player := &mockPlayer{}
 	store := newMockDownloadStore()
 
-	dl := New(t.TempDir(), 3, player, fetcher, noCoverFetcher(), store)
+	dl := New(t.TempDir(), t.TempDir(), 3, player, fetcher, noCoverFetcher(), store)
 
 	err := dl.Download(context.Background(), Request{
 		TidalAlbumID: 99,
@@ -311,7 +632,7 @@ func TestDownload_TrackPlaybackError(t *testing.T) {
 	player := &mockPlayer{err: fmt.Errorf("playback service unavailable")}
 	store := newMockDownloadStore()
 
-	dl := New(t.TempDir(), 3, player, fetcher, noCoverFetcher(), store)
+	dl := New(t.TempDir(), t.TempDir(), 3, player, fetcher, noCoverFetcher(), store)
 
 	err := dl.Download(context.Background(), Request{
 		TidalAlbumID: 10,
@@ -374,7 +695,7 @@ func TestDownload_HTTPError(t *testing.T) {
 	}
 
 	store := newMockDownloadStore()
-	dl := New(t.TempDir(), 3, player, fetcher, noCoverFetcher(), store)
+	dl := New(t.TempDir(), t.TempDir(), 3, player, fetcher, noCoverFetcher(), store)
 
 	err := dl.Download(context.Background(), Request{
 		TidalAlbumID: 20,
@@ -435,7 +756,7 @@ func TestDownloadAsync_Concurrency(t *testing.T) {
 	fetcher := &mockAlbumFetcher{albums: albums}
 	store := newMockDownloadStore()
 
-	dl := New(t.TempDir(), 2, player, fetcher, noCoverFetcher(), store)
+	dl := New(t.TempDir(), t.TempDir(), 2, player, fetcher, noCoverFetcher(), store)
 
 	for i := int64(1); i <= 3; i++ {
 		dl.DownloadAsync(context.Background(), Request{
@@ -444,6 +765,15 @@ func TestDownloadAsync_Concurrency(t *testing.T) {
 		})
 	}
 
+	// Queued requests wait for a worker rather than each getting a
+	// goroutine of their own.
+	dl.mu.Lock()
+	workers, queued := dl.workers, len(dl.queue)
+	dl.mu.Unlock()
+	if workers != 2 || queued < 1 {
+		t.Errorf("workers = %d with %d queued, want 2 with the third still queued", workers, queued)
+	}
+
 	// Poll for completion since DownloadAsync is fire-and-forget.
 	deadline := time.After(10 * time.Second)
 	for {
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strconv"
 
+	"github.com/MattHbrook/Crescendo/internal/library"
 	flac "github.com/go-flac/go-flac/v2"
 	"github.com/go-flac/flacpicture/v2"
 	"github.com/go-flac/flacvorbis/v2"
@@ -96,8 +97,7 @@ func tagFLAC(path string, meta trackMeta) error {
 }
 
 // downloadCover fetches cover art from the given URL and returns the raw bytes.
-// It also saves the image as cover.jpg in albumDir.
-func downloadCover(ctx context.Context, coverURL, albumDir string) ([]byte, error) {
+func downloadCover(ctx context.Context, coverURL string) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, coverURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating cover request: %w", err)
@@ -118,11 +118,19 @@ func downloadCover(ctx context.Context, coverURL, albumDir string) ([]byte, erro
 		return nil, fmt.Errorf("reading cover data: %w", err)
 	}
 
-	// Save cover.jpg to album directory.
+	return data, nil
+}
+
+// saveCover saves cover art as cover.jpg in albumDir, unless the folder
+// already has album art (see library.FindCover): tracks added to an album
+// in the library leave the art the user has alone.
+func saveCover(albumDir string, data []byte) error {
+	if _, ok := library.FindCover(albumDir); ok {
+		return nil
+	}
 	coverPath := filepath.Join(albumDir, "cover.jpg")
 	if err := os.WriteFile(coverPath, data, 0o644); err != nil { //nolint:gosec // non-sensitive file
-		return nil, fmt.Errorf("saving cover.jpg: %w", err)
+		return fmt.Errorf("saving cover.jpg: %w", err)
 	}
-
-	return data, nil
+	return nil
 }
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/MattHbrook/Crescendo/internal/audio"
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/library"
+	"github.com/go-chi/chi/v5"
+)
+
+// LibraryArtist renders a local artist folder: its albums as indexed by the
+// last scan, with their quality and size, and a link to the Tidal artist
+// when one is mapped.
+func (h *Handler) LibraryArtist(w http.ResponseWriter, r *http.Request) {
+	folder := r.URL.Query().Get("folder")
+	if folder == "" {
+		h.renderError(w, http.StatusBadRequest, "No artist folder given")
+		return
+	}
+
+	ctx := r.Context()
+	mapping, err := h.store.GetArtistMapping(ctx, folder)
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load artist mapping")
+		return
+	}
+	albums, err := h.store.ListAlbumsForArtist(ctx, folder)
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load library albums")
+		return
+	}
+	if mapping == nil && len(albums) == 0 {
+		h.renderError(w, http.StatusNotFound, "Artist folder not found in the library")
+		return
+	}
+
+	summaries := make(map[int64]library.TrackSummary, len(albums))
+	for _, a := range albums {
+		tracks, err := h.store.ListTracksForAlbum(ctx, a.ID)
+		if err != nil {
+			h.renderError(w, http.StatusInternalServerError, "Failed to load library tracks")
+			return
+		}
+		summaries[a.ID] = library.SummarizeTracks(tracks)
+	}
+
+	var rule db.WatchRule
+	if mapping != nil && mapping.TidalID != nil {
+		rules, err := h.store.ListWatchRules(ctx)
+		if err != nil {
+			h.renderError(w, http.StatusInternalServerError, "Failed to load watch rules")
+			return
+		}
+		rule = rules[*mapping.TidalID]
+	}
+
+	h.render(w, "library_artist", map[string]any{
+		"Title":     folder,
+		"Folder":    folder,
+		"Mapping":   mapping,
+		"Albums":    albums,
+		"Summaries": summaries,
+		"WatchRule": rule,
+	})
+}
+
+// LibraryAlbum renders a local album: its track list from the library index
+// with per-track quality and size, the cover art from disk and a link to the
+// linked Tidal album. Whether there is any cover art is left to the browser
+// to find out from LibraryAlbumCover.
+func (h *Handler) LibraryAlbum(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.renderError(w, http.StatusBadRequest, "Invalid album ID")
+		return
+	}
+
+	ctx := r.Context()
+	album, err := h.store.GetLibraryAlbum(ctx, id)
+	if errors.Is(err, db.ErrAlbumNotFound) {
+		h.renderError(w, http.StatusNotFound, "Album not found in the library")
+		return
+	}
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load library album")
+		return
+	}
+	tracks, err := h.store.ListTracksForAlbum(ctx, id)
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load library tracks")
+		return
+	}
+
+	h.render(w, "library_album", map[string]any{
+		"Title":   album.AlbumFolder,
+		"Album":   album,
+		"Tracks":  tracks,
+		"Summary": library.SummarizeTracks(tracks),
+	})
+}
+
+// LibraryAlbumCover serves a local album's cover art (see albumCover). An
+// embedded picture is only served as one of coverTypes.
+func (h *Handler) LibraryAlbumCover(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid album ID", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	album, err := h.store.GetLibraryAlbum(ctx, id)
+	if errors.Is(err, db.ErrAlbumNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tracks, err := h.store.ListTracksForAlbum(ctx, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	file, data, mime := albumCover(album, tracks)
+	switch {
+	case file != "":
+		http.ServeFile(w, r, file)
+	case data != nil:
+		contentType, ok := coverContentType(mime, data)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(data)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// coverTypes are the image types embedded cover art is served as.
+var coverTypes = []string{"image/jpeg", "image/png", "image/webp"}
+
+// coverContentType returns the Content-Type to serve embedded cover art
+// with: its declared MIME type if that is one of coverTypes, otherwise the
+// type its data sniffs as if that is. ok is false for anything else, as the
+// MIME type comes from the file and the data may be anything.
+func coverContentType(declared string, data []byte) (contentType string, ok bool) {
+	declared = strings.ToLower(strings.TrimSpace(declared))
+	if declared == "image/jpg" {
+		declared = "image/jpeg"
+	}
+	if slices.Contains(coverTypes, declared) {
+		return declared, true
+	}
+	if sniffed := http.DetectContentType(data); slices.Contains(coverTypes, sniffed) {
+		return sniffed, true
+	}
+	return "", false
+}
+
+// albumCover finds a local album's cover art: the path of an image file in
+// its folder or, failing that, the picture embedded in its first FLAC track
+// with its MIME type. All results are empty when the album has no cover.
+func albumCover(album *db.LibraryAlbum, tracks []db.LibraryTrack) (file string, data []byte, mime string) {
+	if path, ok := library.FindCover(album.Path); ok {
+		return path, nil, ""
+	}
+	for _, t := range tracks {
+		if t.Format != audio.FormatFLAC {
+			continue
+		}
+		if data, mime, err := library.EmbeddedCover(t.Path); err == nil {
+			return "", data, mime
+		}
+		break
+	}
+	return "", nil, ""
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+)
+
+// localRadiohead returns a store with two Radiohead albums whose folders live
+// under dir; only OK Computer has a cover file.
+func localRadiohead(t *testing.T, dir string) *mockStore {
+	t.Helper()
+	okComputer := filepath.Join(dir, "Radiohead", "OK Computer")
+	kidA := filepath.Join(dir, "Radiohead", "Kid A")
+	for _, d := range []string{okComputer, kidA} {
+		if err := os.MkdirAll(d, 0o750); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(okComputer, "Folder.JPG"), []byte("jpeg"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tidalID := int64(100)
+	return &mockStore{
+		artists: []db.ArtistMapping{{FolderName: "Radiohead"}},
+		libAlbums: map[int64]*db.LibraryAlbum{
+			7: {ID: 7, ArtistFolder: "Radiohead", AlbumFolder: "OK Computer", Path: okComputer, TidalAlbumID: &tidalID},
+			8: {ID: 8, ArtistFolder: "Radiohead", AlbumFolder: "Kid A", Path: kidA},
+		},
+		libTracks: map[int64][]db.LibraryTrack{
+			7: {
+				{AlbumID: 7, Filename: "01 - Airbag.flac", Format: "flac", BitDepth: 24, SampleRate: 96000},
+				{AlbumID: 7, Filename: "02 - Paranoid Android.flac", Format: "flac", BitDepth: 16, SampleRate: 44100},
+			},
+			8: {{AlbumID: 8, Filename: "01 - Everything.mp3", Format: "mp3", SampleRate: 44100}},
+		},
+	}
+}
+
+func TestLibraryArtist(t *testing.T) {
+	h := newTestHandler(t, localRadiohead(t, t.TempDir()), &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	t.Run("lists albums with quality", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.LibraryArtist(rec, httptest.NewRequest(http.MethodGet, "/library/artist?folder=Radiohead", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+		if body, want := rec.Body.String(), "Kid A:LOSSY;OK Computer:LOSSLESS;"; !strings.Contains(body, want) {
+			t.Errorf("body = %q, want it to contain %q", body, want)
+		}
+	})
+
+	t.Run("unknown folder returns 404", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.LibraryArtist(rec, httptest.NewRequest(http.MethodGet, "/library/artist?folder=Nobody", nil))
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("expected status 404, got %d", rec.Code)
+		}
+	})
+}
+
+func TestLibraryAlbum(t *testing.T) {
+	h := newTestHandler(t, localRadiohead(t, t.TempDir()), &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	tests := []struct {
+		id       string
+		wantCode int
+		wantBody string
+	}{
+		{"7", http.StatusOK, "OK Computer|LOSSLESS|01 - Airbag.flac;02 - Paranoid Android.flac;"},
+		{"8", http.StatusOK, "Kid A|LOSSY|01 - Everything.mp3;"},
+		{"9", http.StatusNotFound, ""},
+		{"abc", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/library/album/"+tt.id, nil)
+			req = chiContextID(req, tt.id)
+			rec := httptest.NewRecorder()
+			h.LibraryAlbum(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d", tt.wantCode, rec.Code)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestLibraryAlbumCover(t *testing.T) {
+	h := newTestHandler(t, localRadiohead(t, t.TempDir()), &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	serve := func(id string) *httptest.ResponseRecorder {
+		req := chiContextID(httptest.NewRequest(http.MethodGet, "/library/album/"+id+"/cover", nil), id)
+		rec := httptest.NewRecorder()
+		h.LibraryAlbumCover(rec, req)
+		return rec
+	}
+
+	if rec := serve("7"); rec.Code != http.StatusOK || rec.Body.String() != "jpeg" {
+		t.Errorf("cover for album 7 = %d %q, want the folder image", rec.Code, rec.Body.String())
+	} else if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+	}
+	if rec := serve("8"); rec.Code != http.StatusNotFound {
+		t.Errorf("cover for album 8 status = %d, want 404", rec.Code)
+	}
+}
+
+func TestCoverContentType(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	tests := []struct {
+		declared string
+		data     []byte
+		want     string
+		wantOK   bool
+	}{
+		{"image/jpeg", nil, "image/jpeg", true},
+		{"IMAGE/JPG", nil, "image/jpeg", true},
+		{"image/webp", nil, "image/webp", true},
+		{"text/html", png, "image/png", true},
+		{"", png, "image/png", true},
+		{"text/html", []byte("<html><script>alert(1)</script></html>"), "", false},
+		{"image/svg+xml", []byte("<svg></svg>"), "", false},
+	}
+	for _, tt := range tests {
+		got, ok := coverContentType(tt.declared, tt.data)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("coverContentType(%q, %q) = %q, %v, want %q, %v", tt.declared, tt.data, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/downloader"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+	"github.com/MattHbrook/Crescendo/internal/library"
+)
+
+// Completeness renders the report of library albums missing tracks relative
+// to their linked Tidal album. Tracks added since the last check are left
+// out, so completed albums drop off without waiting for a new check.
+func (h *Handler) Completeness(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	incomplete, err := h.store.ListIncompleteAlbums(ctx)
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load completeness report")
+		return
+	}
+
+	albums := incomplete[:0]
+	for _, a := range incomplete {
+		local, err := h.store.ListTracksForAlbum(ctx, a.Album.ID)
+		if err != nil {
+			h.renderError(w, http.StatusInternalServerError, "Failed to load library tracks")
+			return
+		}
+		a.Missing = stillMissing(a.Missing, local)
+		if len(a.Missing) > 0 {
+			albums = append(albums, a)
+		}
+	}
+
+	lastCheck, err := h.store.LastCompletenessCheck(ctx)
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load completeness report")
+		return
+	}
+
+	h.render(w, "completeness", map[string]any{
+		"Title":     "Album Completeness",
+		"Albums":    albums,
+		"LastCheck": lastCheck,
+		"Running":   h.completeness.Running(),
+	})
+}
+
+// StartCompletenessCheck starts a background completeness check and
+// redirects back to the report. A check already in progress is not an
+// error; the report shows it as running.
+func (h *Handler) StartCompletenessCheck(w http.ResponseWriter, r *http.Request) {
+	if err := h.completeness.Start(r.Context()); err != nil && !errors.Is(err, library.ErrCheckInProgress) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/library/completeness", http.StatusSeeOther)
+}
+
+// DownloadMissing downloads the tracks a library album is missing into its
+// folder, named like the tracks already there.
+func (h *Handler) DownloadMissing(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	albumID, err := strconv.ParseInt(r.FormValue("album_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid album ID", http.StatusBadRequest)
+		return
+	}
+
+	album, err := h.store.GetLibraryAlbum(ctx, albumID)
+	if errors.Is(err, db.ErrAlbumNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if album.TidalAlbumID == nil {
+		http.Error(w, "Album is not linked to Tidal", http.StatusConflict)
+		return
+	}
+
+	missing, err := h.store.ListMissingTracks(ctx, albumID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	local, err := h.store.ListTracksForAlbum(ctx, albumID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	missing = stillMissing(missing, local)
+	if len(missing) == 0 {
+		http.Error(w, "No tracks missing", http.StatusConflict)
+		return
+	}
+
+	req := downloader.Request{
+		TidalAlbumID: *album.TidalAlbumID,
+		Quality:      h.quality,
+		OutputDir:    album.Path,
+		Naming:       trackNaming(local),
+	}
+	for _, m := range missing {
+		req.TrackIDs = append(req.TrackIDs, m.TidalTrackID)
+	}
+	h.downloader.DownloadAsync(ctx, req)
+
+	h.renderPartial(w, "download_status", "download_status", map[string]any{
+		"ArtistName": album.ArtistFolder,
+		"AlbumTitle": album.AlbumFolder,
+	})
+}
+
+// stillMissing drops recorded missing tracks that have since appeared among
+// the album's local tracks.
+func stillMissing(missing []db.MissingTrack, local []db.LibraryTrack) []db.MissingTrack {
+	remote := make([]hifi.Track, 0, len(missing))
+	for _, m := range missing {
+		remote = append(remote, hifi.Track{ID: m.TidalTrackID, Title: m.Title, TrackNumber: m.TrackNumber, VolumeNumber: m.DiscNumber})
+	}
+
+	still := make(map[int64]bool, len(missing))
+	for _, t := range library.MissingTracks(remote, local) {
+		still[t.ID] = true
+	}
+
+	kept := make([]db.MissingTrack, 0, len(still))
+	for _, m := range missing {
+		if still[m.TidalTrackID] {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+// trackNaming returns the filename pattern of an album's local tracks.
+func trackNaming(local []db.LibraryTrack) library.TrackNaming {
+	names := make([]string, 0, len(local))
+	for _, t := range local {
+		names = append(names, t.Filename)
+	}
+	return library.DetectTrackNaming(names)
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+)
+
+func incompleteOKComputer() *mockStore {
+	tidalID := int64(100)
+	album := db.LibraryAlbum{ID: 7, ArtistFolder: "Radiohead", AlbumFolder: "OK Computer", Path: "/music/Radiohead/OK Computer", TidalAlbumID: &tidalID}
+	return &mockStore{
+		libAlbums: map[int64]*db.LibraryAlbum{7: &album},
+		incomplete: []db.IncompleteAlbum{{
+			Album:          album,
+			ExpectedTracks: 3,
+			Missing: []db.MissingTrack{
+				{AlbumID: 7, TidalTrackID: 2, TrackNumber: 2, Title: "Paranoid Android"},
+				{AlbumID: 7, TidalTrackID: 3, TrackNumber: 3, Title: "Subterranean Homesick Alien"},
+			},
+		}},
+		libTracks: map[int64][]db.LibraryTrack{
+			7: {{AlbumID: 7, Filename: "1. Airbag.flac", TrackNumber: 1}},
+		},
+	}
+}
+
+func TestCompleteness(t *testing.T) {
+	t.Run("lists missing tracks", func(t *testing.T) {
+		h := newTestHandler(t, incompleteOKComputer(), &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		rec := httptest.NewRecorder()
+		h.Completeness(rec, httptest.NewRequest(http.MethodGet, "/library/completeness", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+		if body := rec.Body.String(); !strings.Contains(body, "OK Computer:2,3,;") {
+			t.Errorf("body = %q, want tracks 2 and 3 missing", body)
+		}
+	})
+
+	t.Run("omits tracks added since the check", func(t *testing.T) {
+		store := incompleteOKComputer()
+		store.libTracks[7] = append(store.libTracks[7], db.LibraryTrack{AlbumID: 7, Filename: "2. Paranoid Android.flac", TrackNumber: 2})
+		h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		rec := httptest.NewRecorder()
+		h.Completeness(rec, httptest.NewRequest(http.MethodGet, "/library/completeness", nil))
+
+		if body := rec.Body.String(); !strings.Contains(body, "OK Computer:3,;") {
+			t.Errorf("body = %q, want only track 3 missing", body)
+		}
+	})
+}
+
+func TestStartCompletenessCheck(t *testing.T) {
+	h := newTestHandler(t, &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+	checker := &mockCompleteness{}
+	h.completeness = checker
+
+	rec := httptest.NewRecorder()
+	h.StartCompletenessCheck(rec, httptest.NewRequest(http.MethodPost, "/library/completeness", nil))
+
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected status 303, got %d", rec.Code)
+	}
+	if !checker.started {
+		t.Error("expected the check to be started")
+	}
+}
+
+func TestDownloadMissing(t *testing.T) {
+	post := func(t *testing.T, store *mockStore, dl *mockDownloader, albumID string) *httptest.ResponseRecorder {
+		t.Helper()
+		h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, dl, &mockDiscovery{})
+
+		form := url.Values{}
+		form.Set("album_id", albumID)
+		req := httptest.NewRequest(http.MethodPost, "/library/completeness/download", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+
+		h.DownloadMissing(rec, req)
+		return rec
+	}
+
+	t.Run("queues missing tracks with folder naming", func(t *testing.T) {
+		dl := &mockDownloader{}
+		rec := post(t, incompleteOKComputer(), dl, "7")
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		got := dl.lastReq
+		if got.TidalAlbumID != 100 || got.OutputDir != "/music/Radiohead/OK Computer" {
+			t.Errorf("request = %+v, want album 100 into library folder", got)
+		}
+		if len(got.TrackIDs) != 2 || got.TrackIDs[0] != 2 || got.TrackIDs[1] != 3 {
+			t.Errorf("TrackIDs = %v, want [2 3]", got.TrackIDs)
+		}
+		if name := got.Naming.Filename(2, "Paranoid Android"); name != "2. Paranoid Android.flac" {
+			t.Errorf("Naming produces %q, want %q", name, "2. Paranoid Android.flac")
+		}
+	})
+
+	t.Run("unknown album returns 404", func(t *testing.T) {
+		dl := &mockDownloader{}
+		rec := post(t, &mockStore{}, dl, "9")
+
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("expected status 404, got %d", rec.Code)
+		}
+		if dl.called {
+			t.Error("DownloadAsync should not be called")
+		}
+	})
+
+	t.Run("nothing missing returns 409", func(t *testing.T) {
+		store := incompleteOKComputer()
+		store.incomplete[0].Missing = nil
+		rec := post(t, store, &mockDownloader{}, "7")
+
+		if rec.Code != http.StatusConflict {
+			t.Fatalf("expected status 409, got %d", rec.Code)
+		}
+	})
+}
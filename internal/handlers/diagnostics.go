@@ -0,0 +1,31 @@
+package handlers
+
+import "net/http"
+
+// Diagnostics renders how Crescendo is getting on with hifi-api: which of
+// its backends are up, whether requests are getting through, and how well
+// responses are being cached.
+func (h *Handler) Diagnostics(w http.ResponseWriter, r *http.Request) {
+	h.render(w, "diagnostics", map[string]any{
+		"Title":  "Diagnostics",
+		"Status": h.hifi.Status(),
+		"Cache":  h.hifi.CacheStats(),
+	})
+}
+
+// ClearCache drops every cached hifi-api response and redirects back to the
+// diagnostics page.
+func (h *Handler) ClearCache(w http.ResponseWriter, r *http.Request) {
+	if err := h.hifi.ClearCache(r.Context()); err != nil {
+		http.Error(w, "Failed to clear cache", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/diagnostics", http.StatusSeeOther)
+}
+
+// CheckHealth checks that the hifi-api backends are up and redirects back
+// to the diagnostics page.
+func (h *Handler) CheckHealth(w http.ResponseWriter, r *http.Request) {
+	h.hifi.CheckHealth(r.Context())
+	http.Redirect(w, r, "/diagnostics", http.StatusSeeOther)
+}
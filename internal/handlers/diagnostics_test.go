@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+)
+
+func TestDiagnostics(t *testing.T) {
+	hf := &mockHiFi{
+		status: hifi.Status{
+			Backends: []hifi.BackendStatus{
+				{Backend: hifi.Backend{URL: "http://hifi-us:8000"}, Breaker: hifi.BreakerClosed},
+				{Backend: hifi.Backend{URL: "http://hifi-gb:8000"}, Breaker: hifi.BreakerOpen},
+			},
+			Retries: 2,
+		},
+		cacheStats: hifi.CacheStats{Entries: 40, Capacity: 1000, Hits: 3, Misses: 1},
+	}
+	h := newTestHandler(t, &mockStore{}, hf, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	rec := httptest.NewRecorder()
+	h.Diagnostics(rec, httptest.NewRequest(http.MethodGet, "/diagnostics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if body, want := rec.Body.String(), "http://hifi-us:8000:closed;http://hifi-gb:8000:open;2|40/1000 75%"; !strings.Contains(body, want) {
+		t.Errorf("body = %q, want it to contain %q", body, want)
+	}
+}
+
+func TestClearCache(t *testing.T) {
+	t.Run("clears and redirects", func(t *testing.T) {
+		hf := &mockHiFi{}
+		h := newTestHandler(t, &mockStore{}, hf, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		rec := httptest.NewRecorder()
+		h.ClearCache(rec, httptest.NewRequest(http.MethodPost, "/diagnostics/cache", nil))
+
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d", rec.Code)
+		}
+		if loc := rec.Header().Get("Location"); loc != "/diagnostics" {
+			t.Errorf("Location = %q, want /diagnostics", loc)
+		}
+		if !hf.cleared {
+			t.Error("cache was not cleared")
+		}
+	})
+
+	t.Run("store error returns 500", func(t *testing.T) {
+		hf := &mockHiFi{errClear: errors.New("db down")}
+		h := newTestHandler(t, &mockStore{}, hf, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		rec := httptest.NewRecorder()
+		h.ClearCache(rec, httptest.NewRequest(http.MethodPost, "/diagnostics/cache", nil))
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("expected status 500, got %d", rec.Code)
+		}
+	})
+}
+
+func TestCheckHealth(t *testing.T) {
+	hf := &mockHiFi{}
+	h := newTestHandler(t, &mockStore{}, hf, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	rec := httptest.NewRecorder()
+	h.CheckHealth(rec, httptest.NewRequest(http.MethodPost, "/diagnostics/health", nil))
+
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected status 303, got %d", rec.Code)
+	}
+	if loc := rec.Header().Get("Location"); loc != "/diagnostics" {
+		t.Errorf("Location = %q, want /diagnostics", loc)
+	}
+	if !hf.checked {
+		t.Error("backends were not health-checked")
+	}
+}
+
+func TestHiFiUnavailable(t *testing.T) {
+	hf := &mockHiFi{
+		status:    hifi.Status{Backends: []hifi.BackendStatus{{Breaker: hifi.BreakerOpen}}},
+		errAlbDet: fmt.Errorf("hifi: get album: %w", hifi.ErrUnavailable),
+	}
+	h := newTestHandler(t, &mockStore{}, hf, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	t.Run("pages warn while hifi-api is unavailable", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.Home(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if body := rec.Body.String(); !strings.Contains(body, "hifi-api unavailable|") {
+			t.Errorf("body = %q, want the unavailable banner", body)
+		}
+	})
+
+	t.Run("failed requests say so", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.Album(rec, chiContextID(httptest.NewRequest(http.MethodGet, "/album/1", nil), "1"))
+
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("expected status 503, got %d", rec.Code)
+		}
+	})
+
+	t.Run("partials say so", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.SimilarAlbums(rec, chiContextID(httptest.NewRequest(http.MethodGet, "/album/1/similar", nil), "1"))
+
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("expected status 503, got %d", rec.Code)
+		}
+		if body := rec.Body.String(); !strings.Contains(body, "hifi-api unavailable") {
+			t.Errorf("body = %q, want it to say hifi-api is unavailable", body)
+		}
+	})
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/discovery"
+)
+
+// runsPerPage is how many past discovery runs the history page lists.
+const runsPerPage = 20
+
+// Discover renders the discovery page with the latest saved album
+// recommendations, or those of the past run given by the run query
+// parameter. With none saved yet it starts the first refresh.
+func (h *Handler) Discover(w http.ResponseWriter, r *http.Request) {
+	var id int64
+	if v := r.URL.Query().Get("run"); v != "" {
+		var err error
+		if id, err = strconv.ParseInt(v, 10, 64); err != nil || id <= 0 {
+			h.renderError(w, http.StatusBadRequest, "Invalid run ID")
+			return
+		}
+	}
+
+	snap, err := h.discovery.Snapshot(r.Context(), id)
+	switch {
+	case errors.Is(err, discovery.ErrNoSnapshot):
+		if err := h.discovery.Start(r.Context()); err != nil && !errors.Is(err, discovery.ErrRefreshInProgress) {
+			h.renderError(w, http.StatusInternalServerError, "Failed to generate recommendations")
+			return
+		}
+	case errors.Is(err, db.ErrDiscoveryRunNotFound):
+		h.renderError(w, http.StatusNotFound, "Recommendations not found")
+		return
+	case err != nil:
+		h.renderError(w, http.StatusInternalServerError, "Failed to load recommendations")
+		return
+	}
+
+	data := map[string]any{
+		"Title":      "Discover",
+		"Snapshot":   snap,
+		"Past":       id != 0,
+		"Refreshing": h.discovery.Running(),
+		"Next":       r.URL.RequestURI(),
+	}
+	if snap != nil {
+		data["Recommendations"] = snap.Recommendations
+	}
+	h.render(w, "discover", data)
+}
+
+// RefreshDiscover starts generating a fresh set of recommendations in the
+// background and redirects back to the discovery page, which shows the
+// previous set until the new one is saved.
+func (h *Handler) RefreshDiscover(w http.ResponseWriter, r *http.Request) {
+	if err := h.discovery.Start(r.Context()); err != nil && !errors.Is(err, discovery.ErrRefreshInProgress) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/discover", http.StatusSeeOther)
+}
+
+// DiscoverFrom renders recommendations similar to a single seed, given by
+// the query parameters: the Tidal album_id for an album, or artist_id for
+// an artist, with its name. They are generated on the spot and not saved.
+func (h *Handler) DiscoverFrom(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var seed discovery.Seed
+	var err error
+	if v := q.Get("artist_id"); v != "" {
+		if seed.ArtistID, err = strconv.ParseInt(v, 10, 64); err != nil || seed.ArtistID <= 0 {
+			h.renderError(w, http.StatusBadRequest, "Invalid artist ID")
+			return
+		}
+	}
+	if v := q.Get("album_id"); v != "" {
+		if seed.AlbumID, err = strconv.ParseInt(v, 10, 64); err != nil || seed.AlbumID <= 0 {
+			h.renderError(w, http.StatusBadRequest, "Invalid album ID")
+			return
+		}
+	}
+	if seed.ArtistID == 0 && seed.AlbumID == 0 {
+		h.renderError(w, http.StatusBadRequest, "Choose an artist or album to discover from")
+		return
+	}
+	seed.Name = q.Get("name")
+	if seed.Name == "" {
+		seed.Name = "this artist"
+		if seed.AlbumID != 0 {
+			seed.Name = "this album"
+		}
+	}
+
+	recs, err := h.discovery.DiscoverFrom(r.Context(), seed, discovery.SnapshotSize)
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to generate recommendations")
+		return
+	}
+
+	h.render(w, "discover", map[string]any{
+		"Title":           "Discover: " + seed.Name,
+		"From":            seed.Name,
+		"Recommendations": recs,
+		"Next":            r.URL.RequestURI(),
+	})
+}
+
+// DiscoverSeeds renders the library artists discovery starts from, with
+// their weights and the user's favourites and pins.
+func (h *Handler) DiscoverSeeds(w http.ResponseWriter, r *http.Request) {
+	seeds, err := h.discovery.SeedCandidates(r.Context())
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load seed artists")
+		return
+	}
+
+	// Each unpinned artist's chance of being the first pick.
+	var total float64
+	for _, s := range seeds {
+		if !s.Pinned {
+			total += s.Weight
+		}
+	}
+	chances := make(map[string]float64, len(seeds))
+	for _, s := range seeds {
+		if !s.Pinned {
+			chances[s.FolderName] = s.Weight / total
+		}
+	}
+
+	h.render(w, "discover_seeds", map[string]any{
+		"Title":      "Discovery seeds",
+		"Seeds":      seeds,
+		"Chances":    chances,
+		"RecentDays": discovery.RecentDays,
+	})
+}
+
+// UpdateSeedPreference marks the artist folder in the form as a favourite
+// and/or pinned seed, or neither, then redirects to the local page given
+// as next, or the seeds page.
+func (h *Handler) UpdateSeedPreference(w http.ResponseWriter, r *http.Request) {
+	folder := r.FormValue("folder")
+	if folder == "" {
+		http.Error(w, "Missing folder", http.StatusBadRequest)
+		return
+	}
+	if err := h.store.SetSeedPreference(r.Context(), folder, r.FormValue("favourite") != "", r.FormValue("pinned") != ""); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	next := r.FormValue("next")
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") {
+		next = "/discover/seeds"
+	}
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+// DiscoverHistory renders a page of past discovery runs, newest first. The
+// page query parameter counts from 1.
+func (h *Handler) DiscoverHistory(w http.ResponseWriter, r *http.Request) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	total, err := h.store.CountDiscoveryRuns(r.Context())
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load past recommendations")
+		return
+	}
+	runs, err := h.store.ListDiscoveryRuns(r.Context(), runsPerPage, (page-1)*runsPerPage)
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load past recommendations")
+		return
+	}
+
+	data := map[string]any{
+		"Title": "Past recommendations",
+		"Runs":  runs,
+		"Page":  page,
+		"Total": total,
+	}
+	if page > 1 {
+		data["PrevPage"] = page - 1
+	}
+	if page*runsPerPage < total {
+		data["NextPage"] = page + 1
+	}
+	h.render(w, "discover_history", data)
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/discovery"
+)
+
+func TestDiscover(t *testing.T) {
+	snapshot := &discovery.Snapshot{
+		ID:        7,
+		CreatedAt: "2026-10-18T06:00:00Z",
+		Seeds:     []string{"Muse"},
+		Recommendations: []discovery.Recommendation{
+			{AlbumID: 300, AlbumTitle: "In Rainbows", ArtistName: "Radiohead", SeedArtist: "Muse", Seeds: []string{"Muse"}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		disc     *mockDiscovery
+		query    string
+		code     int
+		want     string
+		starting bool
+	}{
+		{"latest snapshot", &mockDiscovery{snapshot: snapshot}, "", http.StatusOK, "In Rainbows;|past=false|refreshing=false", false},
+		{"past run", &mockDiscovery{snapshot: snapshot}, "?run=7", http.StatusOK, "In Rainbows;|past=true", false},
+		{"unknown run", &mockDiscovery{snapshot: snapshot}, "?run=8", http.StatusNotFound, "", false},
+		{"invalid run", &mockDiscovery{snapshot: snapshot}, "?run=x", http.StatusBadRequest, "", false},
+		{"nothing saved starts a refresh", &mockDiscovery{running: true}, "", http.StatusOK, "|past=false|refreshing=true", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, tt.disc)
+
+			rec := httptest.NewRecorder()
+			h.Discover(rec, httptest.NewRequest(http.MethodGet, "/discover"+tt.query, nil))
+
+			if rec.Code != tt.code {
+				t.Fatalf("expected status %d, got %d", tt.code, rec.Code)
+			}
+			if body := rec.Body.String(); !strings.Contains(body, tt.want) {
+				t.Errorf("body = %q, want it to contain %q", body, tt.want)
+			}
+			if tt.disc.started != tt.starting {
+				t.Errorf("refresh started = %v, want %v", tt.disc.started, tt.starting)
+			}
+		})
+	}
+}
+
+func TestRefreshDiscover(t *testing.T) {
+	disc := &mockDiscovery{}
+	h := newTestHandler(t, &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, disc)
+
+	rec := httptest.NewRecorder()
+	h.RefreshDiscover(rec, httptest.NewRequest(http.MethodPost, "/discover/refresh", nil))
+
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected status 303, got %d", rec.Code)
+	}
+	if loc := rec.Header().Get("Location"); loc != "/discover" {
+		t.Errorf("redirect = %q, want /discover", loc)
+	}
+	if !disc.started {
+		t.Error("refresh not started")
+	}
+}
+
+func TestDiscoverHistory(t *testing.T) {
+	store := &mockStore{}
+	for id := int64(45); id > 0; id-- {
+		store.runs = append(store.runs, db.DiscoveryRun{ID: id})
+	}
+	h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "26;||2"},
+		{"?page=2", "6;|1|3"},
+		{"?page=3", "1;|2|<"},
+		{"?page=x", "45;"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.DiscoverHistory(rec, httptest.NewRequest(http.MethodGet, "/discover/history"+tt.query, nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", tt.query, rec.Code)
+		}
+		body := rec.Body.String()
+		if !strings.Contains(body, tt.want) {
+			t.Errorf("%s: body = %q, want it to contain %q", tt.query, body, tt.want)
+		}
+		if n := strings.Count(body, ";"); n > runsPerPage {
+			t.Errorf("%s: listed %d runs, want at most %d", tt.query, n, runsPerPage)
+		}
+	}
+}
+
+func TestDiscoverFrom(t *testing.T) {
+	tests := []struct {
+		query string
+		code  int
+		seed  discovery.Seed
+	}{
+		{"?artist_id=10&name=Portishead", http.StatusOK, discovery.Seed{Name: "Portishead", ArtistID: 10}},
+		{"?album_id=500", http.StatusOK, discovery.Seed{Name: "this album", AlbumID: 500}},
+		{"?artist_id=x", http.StatusBadRequest, discovery.Seed{}},
+		{"", http.StatusBadRequest, discovery.Seed{}},
+	}
+	for _, tt := range tests {
+		disc := &mockDiscovery{recs: []discovery.Recommendation{{AlbumID: 1001, AlbumTitle: "Maxinquaye"}}}
+		h := newTestHandler(t, &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, disc)
+
+		rec := httptest.NewRecorder()
+		h.DiscoverFrom(rec, httptest.NewRequest(http.MethodGet, "/discover/from"+tt.query, nil))
+
+		if rec.Code != tt.code {
+			t.Fatalf("%s: expected status %d, got %d", tt.query, tt.code, rec.Code)
+		}
+		if disc.seed != tt.seed {
+			t.Errorf("%s: seed = %+v, want %+v", tt.query, disc.seed, tt.seed)
+		}
+		if tt.code == http.StatusOK {
+			if body, want := rec.Body.String(), "Maxinquaye;|past=|refreshing=|from="+tt.seed.Name; !strings.Contains(body, want) {
+				t.Errorf("%s: body = %q, want it to contain %q", tt.query, body, want)
+			}
+		}
+	}
+}
+
+func TestDiscoverSeeds(t *testing.T) {
+	disc := &mockDiscovery{seeds: []discovery.WeightedSeed{
+		{SeedCandidate: db.SeedCandidate{FolderName: "Portishead", Pinned: true}, Weight: 2},
+		{SeedCandidate: db.SeedCandidate{FolderName: "bjork", Favourite: true}, Weight: 3},
+		{SeedCandidate: db.SeedCandidate{FolderName: "stray"}, Weight: 1},
+	}}
+	h := newTestHandler(t, &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, disc)
+
+	rec := httptest.NewRecorder()
+	h.DiscoverSeeds(rec, httptest.NewRequest(http.MethodGet, "/discover/seeds", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if body, want := rec.Body.String(), "Portishead:pinned;bjork:75%;stray:25%;"; !strings.Contains(body, want) {
+		t.Errorf("body = %q, want it to contain %q", body, want)
+	}
+}
+
+func TestUpdateSeedPreference(t *testing.T) {
+	tests := []struct {
+		form string
+		code int
+		next string
+		want [2]bool
+	}{
+		{"folder=bjork&favourite=on", http.StatusSeeOther, "/discover/seeds", [2]bool{true, false}},
+		{"folder=bjork&pinned=on&next=/library/artist%3Ffolder%3Dbjork", http.StatusSeeOther, "/library/artist?folder=bjork", [2]bool{false, true}},
+		{"folder=bjork&next=//example.com", http.StatusSeeOther, "/discover/seeds", [2]bool{false, false}},
+		{"favourite=on", http.StatusBadRequest, "", [2]bool{}},
+	}
+	for _, tt := range tests {
+		store := &mockStore{}
+		h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		req := httptest.NewRequest(http.MethodPost, "/discover/seeds", strings.NewReader(tt.form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.UpdateSeedPreference(rec, req)
+
+		if rec.Code != tt.code {
+			t.Fatalf("%s: expected status %d, got %d", tt.form, tt.code, rec.Code)
+		}
+		if loc := rec.Header().Get("Location"); loc != tt.next {
+			t.Errorf("%s: redirect = %q, want %q", tt.form, loc, tt.next)
+		}
+		if got := store.seedPrefs["bjork"]; got != tt.want {
+			t.Errorf("%s: preference = %v, want %v", tt.form, got, tt.want)
+		}
+	}
+}
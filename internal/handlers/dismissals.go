@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/discovery"
+)
+
+// Dismiss records feedback on a recommendation and redirects back to the
+// discovery page it came from. The action is "album" to dismiss the album,
+// "snooze" to hide it for discovery.SnoozeDays, or "artist" to never
+// recommend the artist again.
+func (h *Handler) Dismiss(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	kind, idField, title, snooze := db.DismissAlbum, "album_id", r.FormValue("album_title"), 0
+	switch r.FormValue("action") {
+	case "album":
+	case "snooze":
+		snooze = discovery.SnoozeDays
+	case "artist":
+		kind, idField, title = db.DismissArtist, "artist_id", r.FormValue("artist_name")
+	default:
+		http.Error(w, "Unknown action", http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.FormValue(idField), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid "+kind+" ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.AddDismissal(r.Context(), kind, id, title, r.FormValue("artist_name"), snooze); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	next := r.FormValue("next")
+	if !strings.HasPrefix(next, "/discover") {
+		next = "/discover"
+	}
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+// Dismissals renders the albums and artists kept out of recommendations,
+// with a button to undo each.
+func (h *Handler) Dismissals(w http.ResponseWriter, r *http.Request) {
+	dismissals, err := h.store.ListDismissals(r.Context())
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load dismissals")
+		return
+	}
+
+	h.render(w, "dismissals", map[string]any{
+		"Title":      "Dismissed recommendations",
+		"Dismissals": dismissals,
+	})
+}
+
+// Undismiss removes a dismissal so its album or artist may be recommended
+// again, then redirects back to the dismissals page.
+func (h *Handler) Undismiss(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid dismissal ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.store.DeleteDismissal(r.Context(), id)
+	if errors.Is(err, db.ErrDismissalNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/discover/dismissed", http.StatusSeeOther)
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+)
+
+func TestDismiss(t *testing.T) {
+	post := func(t *testing.T, store *mockStore, form url.Values) *httptest.ResponseRecorder {
+		t.Helper()
+		h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		req := httptest.NewRequest(http.MethodPost, "/discover/dismiss", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+
+		h.Dismiss(rec, req)
+		return rec
+	}
+	recommendation := func(action string) url.Values {
+		return url.Values{
+			"action":      {action},
+			"album_id":    {"100"},
+			"album_title": {"Dummy"},
+			"artist_id":   {"10"},
+			"artist_name": {"Portishead"},
+		}
+	}
+
+	tests := []struct {
+		action string
+		want   db.Dismissal
+		snooze bool
+	}{
+		{"album", db.Dismissal{Kind: db.DismissAlbum, TidalID: 100, Title: "Dummy", ArtistName: "Portishead"}, false},
+		{"snooze", db.Dismissal{Kind: db.DismissAlbum, TidalID: 100, Title: "Dummy", ArtistName: "Portishead"}, true},
+		{"artist", db.Dismissal{Kind: db.DismissArtist, TidalID: 10, Title: "Portishead", ArtistName: "Portishead"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			store := &mockStore{}
+			rec := post(t, store, recommendation(tt.action))
+
+			if rec.Code != http.StatusSeeOther {
+				t.Fatalf("expected status 303, got %d", rec.Code)
+			}
+			if loc := rec.Header().Get("Location"); loc != "/discover" {
+				t.Errorf("redirect = %q, want /discover", loc)
+			}
+			if len(store.added) != 1 {
+				t.Fatalf("recorded %d dismissals, want 1", len(store.added))
+			}
+			got := store.added[0]
+			if (got.SnoozedUntil != nil) != tt.snooze {
+				t.Errorf("snoozed = %v, want %v", got.SnoozedUntil != nil, tt.snooze)
+			}
+			got.SnoozedUntil = nil
+			if got != tt.want {
+				t.Errorf("dismissal = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("redirects back to the artists page", func(t *testing.T) {
+		form := recommendation("artist")
+		form.Set("next", "/discover/artists?hops=1")
+		rec := post(t, &mockStore{}, form)
+
+		if loc := rec.Header().Get("Location"); loc != "/discover/artists?hops=1" {
+			t.Errorf("redirect = %q, want /discover/artists?hops=1", loc)
+		}
+	})
+
+	t.Run("ignores redirects off the discover pages", func(t *testing.T) {
+		form := recommendation("album")
+		form.Set("next", "https://example.com/")
+		rec := post(t, &mockStore{}, form)
+
+		if loc := rec.Header().Get("Location"); loc != "/discover" {
+			t.Errorf("redirect = %q, want /discover", loc)
+		}
+	})
+
+	t.Run("unknown action returns 400", func(t *testing.T) {
+		store := &mockStore{}
+		rec := post(t, store, recommendation("forget"))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got %d", rec.Code)
+		}
+		if store.added != nil {
+			t.Error("nothing should be dismissed")
+		}
+	})
+
+	t.Run("missing artist returns 400", func(t *testing.T) {
+		form := recommendation("artist")
+		form.Set("artist_id", "0")
+		rec := post(t, &mockStore{}, form)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got %d", rec.Code)
+		}
+	})
+}
+
+func TestDismissals(t *testing.T) {
+	store := &mockStore{dismissals: []db.Dismissal{
+		{ID: 1, Kind: db.DismissAlbum, TidalID: 100, Title: "Dummy"},
+		{ID: 2, Kind: db.DismissArtist, TidalID: 11, Title: "Muse"},
+	}}
+	h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	rec := httptest.NewRecorder()
+	h.Dismissals(rec, httptest.NewRequest(http.MethodGet, "/discover/dismissed", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "album:Dummy;artist:Muse;") {
+		t.Errorf("body = %q, want both dismissals", body)
+	}
+}
+
+func TestUndismiss(t *testing.T) {
+	post := func(t *testing.T, store *mockStore, id string) *httptest.ResponseRecorder {
+		t.Helper()
+		h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		form := url.Values{"id": {id}}
+		req := httptest.NewRequest(http.MethodPost, "/discover/dismissed", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+
+		h.Undismiss(rec, req)
+		return rec
+	}
+
+	t.Run("removes the dismissal", func(t *testing.T) {
+		store := &mockStore{dismissals: []db.Dismissal{{ID: 1}, {ID: 2}}}
+		rec := post(t, store, "1")
+
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d", rec.Code)
+		}
+		if len(store.dismissals) != 1 || store.dismissals[0].ID != 2 {
+			t.Errorf("dismissals = %+v, want only 2 left", store.dismissals)
+		}
+	})
+
+	t.Run("unknown dismissal returns 404", func(t *testing.T) {
+		rec := post(t, &mockStore{}, "7")
+
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("expected status 404, got %d", rec.Code)
+		}
+	})
+
+	t.Run("invalid ID returns 400", func(t *testing.T) {
+		rec := post(t, &mockStore{}, "abc")
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got %d", rec.Code)
+		}
+	})
+}
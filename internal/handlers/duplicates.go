@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/library"
+)
+
+// Duplicates renders the report of library albums that look like copies of
+// the same release, best copy first.
+func (h *Handler) Duplicates(w http.ResponseWriter, r *http.Request) {
+	candidates, err := h.store.ListDuplicateCandidates(r.Context())
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load duplicates report")
+		return
+	}
+
+	h.render(w, "duplicates", map[string]any{
+		"Title":  "Duplicate Albums",
+		"Groups": library.FindDuplicates(candidates),
+	})
+}
+
+// ResolveDuplicates merges the selected albums (album_id, repeatable) into
+// the kept one (keep) when action is "merge", or moves them to the trash when
+// action is "delete", then redirects back to the report. The kept album is
+// never deleted.
+func (h *Handler) ResolveDuplicates(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	keepID, err := strconv.ParseInt(r.FormValue("keep"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid album to keep", http.StatusBadRequest)
+		return
+	}
+	var ids []int64
+	for _, v := range r.Form["album_id"] {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid album ID", http.StatusBadRequest)
+			return
+		}
+		if id != keepID {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		http.Error(w, "No duplicates selected", http.StatusBadRequest)
+		return
+	}
+
+	switch r.FormValue("action") {
+	case "merge":
+		_, err = h.dedupe.Merge(r.Context(), keepID, ids)
+	case "delete":
+		err = h.dedupe.Delete(r.Context(), ids)
+	default:
+		http.Error(w, "Unknown action", http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, db.ErrAlbumNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, library.ErrMergeMismatch) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/library/duplicates", http.StatusSeeOther)
+}
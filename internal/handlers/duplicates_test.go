@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/library"
+)
+
+func TestDuplicates(t *testing.T) {
+	store := &mockStore{
+		duplicates: []db.DuplicateCandidate{
+			{Album: db.LibraryAlbum{ID: 1, ArtistFolder: "AC_DC", AlbumFolder: "Back in Black"}, Holding: db.AlbumHolding{LibraryAlbumID: 1, MinBitDepth: 16, MinSampleRate: 44100}},
+			{Album: db.LibraryAlbum{ID: 2, ArtistFolder: "AC-DC", AlbumFolder: "Back in Black"}, Holding: db.AlbumHolding{LibraryAlbumID: 2, MinBitDepth: 24, MinSampleRate: 96000}},
+			{Album: db.LibraryAlbum{ID: 3, ArtistFolder: "Radiohead", AlbumFolder: "Kid A"}},
+		},
+	}
+	h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	rec := httptest.NewRecorder()
+	h.Duplicates(rec, httptest.NewRequest(http.MethodGet, "/library/duplicates", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "2,1,name,;") {
+		t.Errorf("body = %q, want the hi-res copy first in one name group", body)
+	}
+}
+
+func TestResolveDuplicates(t *testing.T) {
+	post := func(t *testing.T, dedupe *mockDeduper, form url.Values) *httptest.ResponseRecorder {
+		t.Helper()
+		h := newTestHandler(t, &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+		h.dedupe = dedupe
+
+		req := httptest.NewRequest(http.MethodPost, "/library/duplicates", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.ResolveDuplicates(rec, req)
+		return rec
+	}
+
+	t.Run("merge", func(t *testing.T) {
+		dedupe := &mockDeduper{}
+		rec := post(t, dedupe, url.Values{"action": {"merge"}, "keep": {"2"}, "album_id": {"1", "2", "3"}})
+
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if dedupe.kept != 2 || !slices.Equal(dedupe.merged, []int64{1, 3}) {
+			t.Errorf("merged %v into %d, want [1 3] into 2", dedupe.merged, dedupe.kept)
+		}
+	})
+
+	t.Run("delete never removes the kept album", func(t *testing.T) {
+		dedupe := &mockDeduper{}
+		rec := post(t, dedupe, url.Values{"action": {"delete"}, "keep": {"2"}, "album_id": {"1", "2"}})
+
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if !slices.Equal(dedupe.deleted, []int64{1}) {
+			t.Errorf("deleted = %v, want [1]", dedupe.deleted)
+		}
+	})
+
+	t.Run("missing album returns 404", func(t *testing.T) {
+		rec := post(t, &mockDeduper{err: db.ErrAlbumNotFound}, url.Values{"action": {"delete"}, "keep": {"2"}, "album_id": {"9"}})
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("expected status 404, got %d", rec.Code)
+		}
+	})
+
+	t.Run("mismatched quality returns 409", func(t *testing.T) {
+		err := fmt.Errorf("%w: 01.mp3 is mp3", library.ErrMergeMismatch)
+		rec := post(t, &mockDeduper{err: err}, url.Values{"action": {"merge"}, "keep": {"2"}, "album_id": {"1"}})
+		if rec.Code != http.StatusConflict {
+			t.Fatalf("expected status 409, got %d", rec.Code)
+		}
+	})
+
+	t.Run("only the kept album selected returns 400", func(t *testing.T) {
+		dedupe := &mockDeduper{}
+		rec := post(t, dedupe, url.Values{"action": {"delete"}, "keep": {"2"}, "album_id": {"2"}})
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got %d", rec.Code)
+		}
+		if dedupe.deleted != nil {
+			t.Errorf("deleted = %v, want nothing", dedupe.deleted)
+		}
+	})
+}
@@ -3,9 +3,11 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io/fs"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
@@ -15,6 +17,7 @@ import (
 	"github.com/MattHbrook/Crescendo/internal/downloader"
 	"github.com/MattHbrook/Crescendo/internal/hifi"
 	"github.com/MattHbrook/Crescendo/internal/library"
+	"github.com/MattHbrook/Crescendo/internal/releases"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -24,9 +27,45 @@ import (
 
 // HandlerStore is the subset of db.Store used by HTTP handlers.
 type HandlerStore interface {
-	ListArtistMappings(ctx context.Context) ([]db.ArtistMapping, error)
+	GetArtistMapping(ctx context.Context, folderName string) (*db.ArtistMapping, error)
+	ListArtistFolders(ctx context.Context) ([]db.ArtistMapping, error)
+	SetArtistMapping(ctx context.Context, folderName string, tidalID int64, tidalName, pictureURL string) error
+	IgnoreArtist(ctx context.Context, folderName string) error
+	UnlockArtistMapping(ctx context.Context, folderName string) error
 	GetActiveDownloads(ctx context.Context) ([]db.Download, error)
 	GetDownloadHistory(ctx context.Context, limit int) ([]db.Download, error)
+	GetScan(ctx context.Context, id int64) (*db.Scan, error)
+	GetLatestScan(ctx context.Context) (*db.Scan, error)
+	ListScanErrors(ctx context.Context, scanID int64) ([]string, error)
+	ListLibraryFormats(ctx context.Context) ([]db.FormatCount, error)
+	ListArtistFoldersByFormat(ctx context.Context, format string) ([]string, error)
+	ListArtistReviews(ctx context.Context) ([]db.ArtistReview, error)
+	CountArtistReviews(ctx context.Context) (int, error)
+	AcceptArtistCandidate(ctx context.Context, folderName string, tidalID int64) error
+	GetAlbumHoldings(ctx context.Context, tidalAlbumIDs []int64) (map[int64]db.AlbumHolding, error)
+	ListTracksForAlbum(ctx context.Context, albumID int64) ([]db.LibraryTrack, error)
+	GetLibraryAlbum(ctx context.Context, id int64) (*db.LibraryAlbum, error)
+	ListIncompleteAlbums(ctx context.Context) ([]db.IncompleteAlbum, error)
+	ListMissingTracks(ctx context.Context, albumID int64) ([]db.MissingTrack, error)
+	LastCompletenessCheck(ctx context.Context) (string, error)
+	ListLinkedAlbumQualities(ctx context.Context) ([]db.LinkedAlbumQuality, error)
+	ListDuplicateCandidates(ctx context.Context) ([]db.DuplicateCandidate, error)
+	ListAlbumsForArtist(ctx context.Context, artistFolder string) ([]db.LibraryAlbum, error)
+	GetLibraryStats(ctx context.Context, topArtists int) (*db.LibraryStats, error)
+	GetVerificationSummary(ctx context.Context, maxAgeDays int) (*db.VerificationSummary, error)
+	ListCorruptTracks(ctx context.Context) ([]db.CorruptTrack, error)
+	ListPlaylists(ctx context.Context) ([]db.Playlist, error)
+	AddDismissal(ctx context.Context, kind string, tidalID int64, title, artistName string, snoozeDays int) error
+	ListDismissals(ctx context.Context) ([]db.Dismissal, error)
+	DeleteDismissal(ctx context.Context, id int64) error
+	ListNewReleases(ctx context.Context, limit int) ([]db.Release, error)
+	LastReleaseCheck(ctx context.Context) (string, error)
+	ListWatchRules(ctx context.Context) (map[int64]db.WatchRule, error)
+	SetWatchRule(ctx context.Context, rule db.WatchRule) error
+	DeleteWatchRule(ctx context.Context, artistTidalID int64) error
+	ListDiscoveryRuns(ctx context.Context, limit, offset int) ([]db.DiscoveryRun, error)
+	CountDiscoveryRuns(ctx context.Context) (int, error)
+	SetSeedPreference(ctx context.Context, folderName string, favourite, pinned bool) error
 }
 
 // HandlerHiFi is the subset of hifi.Client used by HTTP handlers.
@@ -35,11 +74,16 @@ type HandlerHiFi interface {
 	SearchAlbums(ctx context.Context, query string, limit, offset int) (*hifi.SearchResult[hifi.Album], error)
 	GetArtistAlbums(ctx context.Context, id int64) ([]hifi.Album, error)
 	GetAlbum(ctx context.Context, id int64) (*hifi.AlbumDetail, error)
+	GetSimilarAlbums(ctx context.Context, id int64) ([]hifi.SimilarAlbum, error)
+	CacheStats() hifi.CacheStats
+	ClearCache(ctx context.Context) error
+	Status() hifi.Status
+	CheckHealth(ctx context.Context)
 }
 
-// HandlerScanner is the subset of library.Scanner used by HTTP handlers.
+// HandlerScanner is the subset of library.ScanManager used by HTTP handlers.
 type HandlerScanner interface {
-	Scan(ctx context.Context) (*library.ScanResult, error)
+	Start(ctx context.Context) (int64, error)
 }
 
 // HandlerDownloader is the subset of downloader.Downloader used by HTTP handlers.
@@ -49,7 +93,46 @@ type HandlerDownloader interface {
 
 // HandlerDiscovery is the subset of discovery.Engine used by HTTP handlers.
 type HandlerDiscovery interface {
-	Discover(ctx context.Context, seedCount, maxResults int) ([]discovery.Recommendation, error)
+	Snapshot(ctx context.Context, id int64) (*discovery.Snapshot, error)
+	Start(ctx context.Context) error
+	Running() bool
+	DiscoverFrom(ctx context.Context, seed discovery.Seed, maxResults int) ([]discovery.Recommendation, error)
+	SeedCandidates(ctx context.Context) ([]discovery.WeightedSeed, error)
+	ArtistSnapshot(ctx context.Context, maxHops int) ([]discovery.ArtistRecommendation, error)
+}
+
+// HandlerCompleteness is the subset of library.CompletenessChecker used by
+// HTTP handlers.
+type HandlerCompleteness interface {
+	Start(ctx context.Context) error
+	Running() bool
+}
+
+// HandlerDeduper is the subset of library.Deduper used by HTTP handlers.
+type HandlerDeduper interface {
+	Merge(ctx context.Context, keepID int64, ids []int64) (int, error)
+	Delete(ctx context.Context, ids []int64) error
+}
+
+// HandlerVerifier is the subset of library.Verifier used by HTTP handlers.
+type HandlerVerifier interface {
+	Start(ctx context.Context) error
+	Running() bool
+	Discard(ctx context.Context, tracks []db.LibraryTrack) error
+}
+
+// HandlerPlaylists is the subset of playlist.Syncer used by HTTP handlers.
+type HandlerPlaylists interface {
+	Start(ctx context.Context, id string) error
+	StartRefresh(ctx context.Context) error
+	Running() bool
+}
+
+// HandlerReleases is the subset of releases.Monitor used by HTTP handlers.
+type HandlerReleases interface {
+	Start(ctx context.Context) error
+	Running() bool
+	Preview(ctx context.Context, rule db.WatchRule) ([]releases.Decision, error)
 }
 
 // ---------------------------------------------------------------------------
@@ -58,11 +141,36 @@ type HandlerDiscovery interface {
 
 var funcMap = template.FuncMap{
 	"replace": strings.ReplaceAll,
+	"upper":   strings.ToUpper,
+	"percent": func(f float64) string {
+		return fmt.Sprintf("%.0f%%", f*100)
+	},
+	"holdingQuality": library.HoldingQuality,
+	"formatSize": func(bytes int64) string {
+		switch {
+		case bytes >= 1e12:
+			return fmt.Sprintf("%.2f TB", float64(bytes)/1e12)
+		case bytes >= 1e9:
+			return fmt.Sprintf("%.1f GB", float64(bytes)/1e9)
+		default:
+			return fmt.Sprintf("%.1f MB", float64(bytes)/1e6)
+		}
+	},
+	"kHz": func(hz int) string {
+		return strconv.FormatFloat(float64(hz)/1000, 'f', -1, 64) + " kHz"
+	},
+	"round": func(f float64) int {
+		return int(math.Round(f))
+	},
 	"formatDuration": func(seconds int) string {
 		m := seconds / 60
 		s := seconds % 60
 		return fmt.Sprintf("%d:%02d", m, s)
 	},
+	"formatHours": func(seconds float64) string {
+		m := int(math.Round(seconds / 60))
+		return fmt.Sprintf("%d h %02d min", m/60, m%60)
+	},
 	"deref": func(v any) any {
 		switch val := v.(type) {
 		case *string:
@@ -87,13 +195,18 @@ var funcMap = template.FuncMap{
 
 // Handler holds all dependencies needed by HTTP handlers.
 type Handler struct {
-	templates  map[string]*template.Template
-	store      HandlerStore
-	hifi       HandlerHiFi
-	scanner    HandlerScanner
-	downloader HandlerDownloader
-	discovery  HandlerDiscovery
-	quality    string // default download quality from config
+	templates    map[string]*template.Template
+	store        HandlerStore
+	hifi         HandlerHiFi
+	scanner      HandlerScanner
+	downloader   HandlerDownloader
+	discovery    HandlerDiscovery
+	completeness HandlerCompleteness
+	dedupe       HandlerDeduper
+	verifier     HandlerVerifier
+	playlists    HandlerPlaylists
+	releases     HandlerReleases
+	quality      string // default download quality from config
 }
 
 // New creates a Handler, parsing all HTML templates from the given filesystem.
@@ -105,6 +218,11 @@ func New(
 	scanner HandlerScanner,
 	dl HandlerDownloader,
 	disc HandlerDiscovery,
+	checker HandlerCompleteness,
+	dedupe HandlerDeduper,
+	verifier HandlerVerifier,
+	playlists HandlerPlaylists,
+	releases HandlerReleases,
 	quality string,
 ) (*Handler, error) {
 	// Parse layout as the base template that every page clones.
@@ -118,16 +236,35 @@ func New(
 	// We clone the base for each page so the "content" definitions don't
 	// collide across pages.
 	pages := map[string]string{
-		"home":            "home.html",
-		"search":          "search.html",
-		"search_results":  "search_results.html",
-		"artist":          "artist.html",
-		"album":           "album.html",
-		"downloads":       "downloads.html",
-		"discover":        "discover.html",
-		"library":         "library.html",
-		"download_status": "download_status.html",
-		"error":           "error.html",
+		"home":              "home.html",
+		"search":            "search.html",
+		"search_results":    "search_results.html",
+		"artist":            "artist.html",
+		"album":             "album.html",
+		"downloads":         "downloads.html",
+		"discover":          "discover.html",
+		"discover_history":  "discover_history.html",
+		"discover_seeds":    "discover_seeds.html",
+		"discover_artists":  "discover_artists.html",
+		"dismissals":        "dismissals.html",
+		"library":           "library.html",
+		"review":            "review.html",
+		"mapping":           "mapping.html",
+		"completeness":      "completeness.html",
+		"upgrades":          "upgrades.html",
+		"duplicates":        "duplicates.html",
+		"library_artist":    "library_artist.html",
+		"library_album":     "library_album.html",
+		"stats":             "stats.html",
+		"integrity":         "integrity.html",
+		"playlists":         "playlists.html",
+		"releases":          "releases.html",
+		"watchlist":         "watchlist.html",
+		"watchlist_rule":    "watchlist_rule.html",
+		"watchlist_preview": "watchlist_preview.html",
+		"diagnostics":       "diagnostics.html",
+		"download_status":   "download_status.html",
+		"error":             "error.html",
 	}
 
 	tmpl := make(map[string]*template.Template, len(pages))
@@ -140,13 +277,18 @@ func New(
 	}
 
 	return &Handler{
-		templates:  tmpl,
-		store:      store,
-		hifi:       hifi,
-		scanner:    scanner,
-		downloader: dl,
-		discovery:  disc,
-		quality:    quality,
+		templates:    tmpl,
+		store:        store,
+		hifi:         hifi,
+		scanner:      scanner,
+		downloader:   dl,
+		discovery:    disc,
+		completeness: checker,
+		dedupe:       dedupe,
+		verifier:     verifier,
+		playlists:    playlists,
+		releases:     releases,
+		quality:      quality,
 	}, nil
 }
 
@@ -154,13 +296,18 @@ func New(
 // Template rendering helpers
 // ---------------------------------------------------------------------------
 
-// render executes a full-page template (layout + content block).
+// render executes a full-page template (layout + content block). Page data
+// maps gain HiFiUnavailable, for the layout to warn while hifi-api isn't
+// being asked.
 func (h *Handler) render(w http.ResponseWriter, page string, data any) {
 	t, ok := h.templates[page]
 	if !ok {
 		http.Error(w, "template not found", http.StatusInternalServerError)
 		return
 	}
+	if m, ok := data.(map[string]any); ok {
+		m["HiFiUnavailable"] = !h.hifi.Status().Available()
+	}
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	if err := t.ExecuteTemplate(w, "layout", data); err != nil {
 		http.Error(w, "template error", http.StatusInternalServerError)
@@ -181,6 +328,16 @@ func (h *Handler) renderPartial(w http.ResponseWriter, page, block string, data
 	}
 }
 
+// hifiFailure returns the status and message to report a failed hifi-api
+// request with: the given ones, unless the client has stopped asking every
+// hifi-api backend after too many failures, which is reported as such.
+func hifiFailure(err error, status int, msg string) (int, string) {
+	if errors.Is(err, hifi.ErrUnavailable) {
+		return http.StatusServiceUnavailable, "hifi-api unavailable, try again shortly"
+	}
+	return status, msg
+}
+
 // renderError renders a full error page with the given HTTP status and message.
 func (h *Handler) renderError(w http.ResponseWriter, status int, msg string) {
 	w.WriteHeader(status)
@@ -201,11 +358,55 @@ func (h *Handler) Routes(r chi.Router) {
 	r.Get("/search/results", h.SearchResults)
 	r.Get("/artist/{id}", h.Artist)
 	r.Get("/album/{id}", h.Album)
+	r.Get("/album/{id}/similar", h.SimilarAlbums)
 	r.Get("/downloads", h.Downloads)
 	r.Get("/discover", h.Discover)
+	r.Post("/discover/refresh", h.RefreshDiscover)
+	r.Get("/discover/history", h.DiscoverHistory)
+	r.Get("/discover/from", h.DiscoverFrom)
+	r.Get("/discover/seeds", h.DiscoverSeeds)
+	r.Post("/discover/seeds", h.UpdateSeedPreference)
+	r.Get("/discover/artists", h.DiscoverArtists)
+	r.Post("/discover/dismiss", h.Dismiss)
+	r.Get("/discover/dismissed", h.Dismissals)
+	r.Post("/discover/dismissed", h.Undismiss)
 	r.Get("/library", h.Library)
 	r.Post("/download", h.StartDownload)
 	r.Post("/scan", h.StartScan)
+	r.Get("/scan/{id}", h.ScanStatus)
+	r.Get("/library/scan-status", h.LibraryScanStatus)
+	r.Get("/library/artist", h.LibraryArtist)
+	r.Get("/library/album/{id}", h.LibraryAlbum)
+	r.Get("/library/album/{id}/cover", h.LibraryAlbumCover)
+	r.Get("/library/review", h.Review)
+	r.Post("/library/review", h.ResolveReview)
+	r.Get("/library/mapping", h.MappingEditor)
+	r.Post("/library/mapping", h.UpdateMapping)
+	r.Get("/library/completeness", h.Completeness)
+	r.Post("/library/completeness", h.StartCompletenessCheck)
+	r.Post("/library/completeness/download", h.DownloadMissing)
+	r.Get("/library/upgrades", h.Upgrades)
+	r.Post("/library/upgrades", h.QueueUpgrades)
+	r.Get("/library/stats", h.Stats)
+	r.Get("/library/stats.json", h.StatsJSON)
+	r.Get("/library/duplicates", h.Duplicates)
+	r.Post("/library/duplicates", h.ResolveDuplicates)
+	r.Get("/library/integrity", h.Integrity)
+	r.Post("/library/integrity", h.StartVerification)
+	r.Post("/library/integrity/download", h.RedownloadCorrupt)
+	r.Get("/playlists", h.Playlists)
+	r.Post("/playlists", h.AddPlaylist)
+	r.Post("/playlists/refresh", h.RefreshPlaylists)
+	r.Get("/releases", h.Releases)
+	r.Post("/releases", h.StartReleaseCheck)
+	r.Get("/watchlist", h.Watchlist)
+	r.Post("/watchlist", h.UpdateWatchRule)
+	r.Get("/watchlist/rule", h.EditWatchRule)
+	r.Get("/watchlist/preview", h.PreviewWatchRule)
+	r.Post("/watchlist/remove", h.RemoveWatchRule)
+	r.Get("/diagnostics", h.Diagnostics)
+	r.Post("/diagnostics/cache", h.ClearCache)
+	r.Post("/diagnostics/health", h.CheckHealth)
 }
 
 // ---------------------------------------------------------------------------
@@ -237,15 +438,24 @@ func (h *Handler) SearchResults(w http.ResponseWriter, r *http.Request) {
 	switch searchType {
 	case "albums":
 		result, err := h.hifi.SearchAlbums(ctx, q, 20, 0)
+		if err != nil {
+			status, msg := hifiFailure(err, http.StatusInternalServerError, err.Error())
+			http.Error(w, msg, status)
+			return
+		}
+		ownership, err := h.albumOwnership(ctx, result.Items)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 		data["Albums"] = result.Items
+		data["Ownership"] = ownership
+		data["Quality"] = h.quality
 	default: // "artists" or anything else
 		result, err := h.hifi.SearchArtists(ctx, q, 20, 0)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			status, msg := hifiFailure(err, http.StatusInternalServerError, err.Error())
+			http.Error(w, msg, status)
 			return
 		}
 		data["Artists"] = result.Items
@@ -266,7 +476,14 @@ func (h *Handler) Artist(w http.ResponseWriter, r *http.Request) {
 
 	albums, err := h.hifi.GetArtistAlbums(r.Context(), id)
 	if err != nil {
-		h.renderError(w, http.StatusInternalServerError, "Failed to load artist")
+		status, msg := hifiFailure(err, http.StatusInternalServerError, "Failed to load artist")
+		h.renderError(w, status, msg)
+		return
+	}
+
+	ownership, err := h.albumOwnership(r.Context(), albums)
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load library albums")
 		return
 	}
 
@@ -278,9 +495,11 @@ func (h *Handler) Artist(w http.ResponseWriter, r *http.Request) {
 	}
 
 	h.render(w, "artist", map[string]any{
-		"Title":  artistName,
-		"Artist": map[string]any{"Name": artistName, "ID": id},
-		"Albums": albums,
+		"Title":     artistName,
+		"Artist":    map[string]any{"Name": artistName, "ID": id},
+		"Albums":    albums,
+		"Ownership": ownership,
+		"Quality":   h.quality,
 	})
 }
 
@@ -295,15 +514,78 @@ func (h *Handler) Album(w http.ResponseWriter, r *http.Request) {
 
 	detail, err := h.hifi.GetAlbum(r.Context(), id)
 	if err != nil {
-		h.renderError(w, http.StatusInternalServerError, "Failed to load album")
+		status, msg := hifiFailure(err, http.StatusInternalServerError, "Failed to load album")
+		h.renderError(w, status, msg)
+		return
+	}
+
+	ownership, err := h.albumOwnership(r.Context(), []hifi.Album{detail.Album})
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load library albums")
 		return
 	}
 
 	h.render(w, "album", map[string]any{
-		"Title":   detail.Title,
-		"Album":   detail.Album,
-		"Tracks":  detail.Tracks,
-		"Quality": h.quality,
+		"Title":     detail.Title,
+		"Album":     detail.Album,
+		"Tracks":    detail.Tracks,
+		"Quality":   h.quality,
+		"Ownership": ownership[detail.ID],
+	})
+}
+
+// SimilarAlbums renders the "more like this" section of an album page: the
+// albums Tidal finds similar, less those dismissed from recommendations,
+// with ownership badges and download buttons.
+func (h *Handler) SimilarAlbums(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid album ID", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	similar, err := h.hifi.GetSimilarAlbums(ctx, id)
+	if err != nil {
+		status, msg := hifiFailure(err, http.StatusInternalServerError, err.Error())
+		http.Error(w, msg, status)
+		return
+	}
+	dismissals, err := h.store.ListDismissals(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	skipAlbums, skipArtists := make(map[int64]bool), make(map[int64]bool)
+	for _, d := range dismissals {
+		if d.Kind == db.DismissArtist {
+			skipArtists[d.TidalID] = true
+		} else {
+			skipAlbums[d.TidalID] = true
+		}
+	}
+
+	albums := make([]hifi.Album, 0, len(similar))
+	for _, s := range similar {
+		a := s.AsAlbum()
+		if a.ID == id || skipAlbums[a.ID] || skipArtists[a.Artist.ID] {
+			continue
+		}
+		albums = append(albums, a)
+	}
+
+	ownership, err := h.albumOwnership(ctx, albums)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.renderPartial(w, "album", "similar_albums", map[string]any{
+		"AlbumID":    id,
+		"AlbumTitle": r.URL.Query().Get("title"),
+		"Albums":     albums,
+		"Ownership":  ownership,
+		"Quality":    h.quality,
 	})
 }
 
@@ -330,32 +612,132 @@ func (h *Handler) Downloads(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// Discover renders the discovery page with album recommendations.
-func (h *Handler) Discover(w http.ResponseWriter, r *http.Request) {
-	recs, err := h.discovery.Discover(r.Context(), 5, 20)
-	if err != nil {
-		h.renderError(w, http.StatusInternalServerError, "Failed to generate recommendations")
+// DiscoverArtists renders the artists-you-might-like page: newcomers found
+// by walking the similar-artist graph out from the library, as saved by
+// the last discovery refresh. The hops query parameter limits the walk to
+// direct neighbours (1) or their neighbours too (2, the default). If no
+// refresh has run yet, one is started.
+func (h *Handler) DiscoverArtists(w http.ResponseWriter, r *http.Request) {
+	hops := 2
+	if r.URL.Query().Get("hops") == "1" {
+		hops = 1
+	}
+
+	artists, err := h.discovery.ArtistSnapshot(r.Context(), hops)
+	switch {
+	case errors.Is(err, discovery.ErrNoSnapshot):
+		if err := h.discovery.Start(r.Context()); err != nil && !errors.Is(err, discovery.ErrRefreshInProgress) {
+			h.renderError(w, http.StatusInternalServerError, "Failed to generate recommendations")
+			return
+		}
+	case err != nil:
+		h.renderError(w, http.StatusInternalServerError, "Failed to load recommendations")
 		return
 	}
 
-	h.render(w, "discover", map[string]any{
-		"Title":           "Discover",
-		"Recommendations": recs,
+	h.render(w, "discover_artists", map[string]any{
+		"Title":      "Artists you might like",
+		"Artists":    artists,
+		"Hops":       hops,
+		"Refreshing": h.discovery.Running(),
 	})
 }
 
-// Library renders the library page with all mapped artists.
+// Library renders the library page with every artist folder and its Tidal
+// mapping, optionally filtered by audio format, and the result of the most
+// recent scan.
 func (h *Handler) Library(w http.ResponseWriter, r *http.Request) {
-	artists, err := h.store.ListArtistMappings(r.Context())
+	artists, err := h.store.ListArtistFolders(r.Context())
 	if err != nil {
 		h.renderError(w, http.StatusInternalServerError, "Failed to load library")
 		return
 	}
 
-	h.render(w, "library", map[string]any{
-		"Title":   "Library",
-		"Artists": artists,
-	})
+	formats, err := h.store.ListLibraryFormats(r.Context())
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load library formats")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" {
+		folders, err := h.store.ListArtistFoldersByFormat(r.Context(), format)
+		if err != nil {
+			h.renderError(w, http.StatusInternalServerError, "Failed to load library")
+			return
+		}
+		artists = filterArtistsByFolder(artists, folders)
+	}
+
+	pending, err := h.store.CountArtistReviews(r.Context())
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load review queue")
+		return
+	}
+
+	data, err := h.scanStatusData(r.Context())
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load scan status")
+		return
+	}
+	data["Title"] = "Library"
+	data["PendingReviews"] = pending
+	data["Artists"] = artists
+	data["Formats"] = formats
+	data["Format"] = format
+
+	h.render(w, "library", data)
+}
+
+// filterArtistsByFolder keeps only the mappings whose folder name appears in
+// folders, preserving their order.
+func filterArtistsByFolder(artists []db.ArtistMapping, folders []string) []db.ArtistMapping {
+	wanted := make(map[string]bool, len(folders))
+	for _, f := range folders {
+		wanted[f] = true
+	}
+
+	filtered := make([]db.ArtistMapping, 0, len(folders))
+	for _, a := range artists {
+		if wanted[a.FolderName] {
+			filtered = append(filtered, a)
+		}
+	}
+	return filtered
+}
+
+// LibraryScanStatus returns an HTMX partial describing the most recent scan.
+// While a scan is running the partial polls itself for updates.
+func (h *Handler) LibraryScanStatus(w http.ResponseWriter, r *http.Request) {
+	data, err := h.scanStatusData(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.renderPartial(w, "library", "scan_status", data)
+}
+
+// scanStatusData loads the latest scan and its error messages for the
+// library page's scan section.
+func (h *Handler) scanStatusData(ctx context.Context) (map[string]any, error) {
+	scan, err := h.store.GetLatestScan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var scanErrors []string
+	if scan != nil && scan.ErrorCount > 0 {
+		scanErrors, err = h.store.ListScanErrors(ctx, scan.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return map[string]any{
+		"LastScan":   scan,
+		"ScanErrors": scanErrors,
+	}, nil
 }
 
 // ---------------------------------------------------------------------------
@@ -363,7 +745,8 @@ func (h *Handler) Library(w http.ResponseWriter, r *http.Request) {
 // ---------------------------------------------------------------------------
 
 // StartDownload kicks off an async album download and returns an HTMX partial
-// confirming the request.
+// confirming the request. With mode=complete only the tracks missing from
+// the library copy are downloaded, into that copy's folder.
 func (h *Handler) StartDownload(w http.ResponseWriter, r *http.Request) {
 	albumIDStr := r.FormValue("album_id")
 	quality := r.FormValue("quality")
@@ -380,14 +763,28 @@ func (h *Handler) StartDownload(w http.ResponseWriter, r *http.Request) {
 	// Fetch album info so we can show a meaningful status message.
 	detail, err := h.hifi.GetAlbum(r.Context(), albumID)
 	if err != nil {
-		http.Error(w, "Failed to fetch album", http.StatusInternalServerError)
+		status, msg := hifiFailure(err, http.StatusInternalServerError, "Failed to fetch album")
+		http.Error(w, msg, status)
 		return
 	}
 
-	h.downloader.DownloadAsync(r.Context(), downloader.Request{
+	req := downloader.Request{
 		TidalAlbumID: albumID,
 		Quality:      quality,
-	})
+	}
+	var status int
+	switch r.FormValue("mode") {
+	case "complete":
+		req, status, err = h.completeRequest(r.Context(), detail, quality)
+	case "upgrade":
+		req, status, err = h.upgradeRequest(r.Context(), detail, quality)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	h.downloader.DownloadAsync(r.Context(), req)
 
 	h.renderPartial(w, "download_status", "download_status", map[string]any{
 		"ArtistName": detail.Artist.Name,
@@ -395,19 +792,75 @@ func (h *Handler) StartDownload(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// StartScan triggers a library scan and returns the result as JSON.
+// StartScan starts a background library scan. JSON clients receive the new
+// scan ID with 202 Accepted (or 409 Conflict with the running scan's ID if
+// one is already in progress); HTMX requests receive the scan status partial.
 func (h *Handler) StartScan(w http.ResponseWriter, r *http.Request) {
-	result, err := h.scanner.Scan(r.Context())
+	id, err := h.scanner.Start(r.Context())
+	if err != nil && !errors.Is(err, library.ErrScanInProgress) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		h.LibraryScanStatus(w, r)
+		return
+	}
+
+	status := http.StatusAccepted
+	body := map[string]any{"scan_id": id, "status": "running"}
+	if err != nil {
+		status = http.StatusConflict
+		body["error"] = err.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// ScanStatus returns the progress and results of a scan as JSON, including
+// every error message it recorded.
+func (h *Handler) ScanStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid scan ID", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	scan, err := h.store.GetScan(ctx, id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if scan == nil {
+		http.Error(w, "Scan not found", http.StatusNotFound)
+		return
+	}
+
+	scanErrors, err := h.store.ListScanErrors(ctx, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if scanErrors == nil {
+		scanErrors = []string{}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(map[string]int{
-		"artists_found":   result.ArtistsFound,
-		"albums_found":    result.AlbumsFound,
-		"artists_matched": result.ArtistsMatched,
-		"errors":          len(result.Errors),
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"scan_id":           scan.ID,
+		"status":            scan.Status,
+		"artists_total":     scan.ArtistsTotal,
+		"artists_processed": scan.ArtistsProcessed,
+		"artists_found":     scan.ArtistsFound,
+		"albums_found":      scan.AlbumsFound,
+		"artists_matched":   scan.ArtistsMatched,
+		"error_count":       scan.ErrorCount,
+		"error":             scan.Error,
+		"errors":            scanErrors,
+		"started_at":        scan.StartedAt,
+		"completed_at":      scan.CompletedAt,
 	})
 }
@@ -3,12 +3,15 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/fs"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -17,6 +20,7 @@ import (
 	"github.com/MattHbrook/Crescendo/internal/downloader"
 	"github.com/MattHbrook/Crescendo/internal/hifi"
 	"github.com/MattHbrook/Crescendo/internal/library"
+	"github.com/MattHbrook/Crescendo/internal/releases"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -25,18 +29,104 @@ import (
 // ---------------------------------------------------------------------------
 
 type mockStore struct {
-	artists   []db.ArtistMapping
-	active    []db.Download
-	history   []db.Download
-	errList   error
-	errActive error
-	errHist   error
+	artists    []db.ArtistMapping
+	active     []db.Download
+	history    []db.Download
+	scans      map[int64]*db.Scan
+	scanErrors map[int64][]string
+	formats    []db.FormatCount
+	byFormat   map[string][]string
+	reviews    []db.ArtistReview
+	accepted   map[string]int64
+	mapped     map[string]int64
+	ignored    []string
+	unlocked   []string
+	holdings   map[int64]db.AlbumHolding
+	libTracks  map[int64][]db.LibraryTrack // key is library album ID
+	libAlbums  map[int64]*db.LibraryAlbum
+	incomplete []db.IncompleteAlbum
+	qualities  []db.LinkedAlbumQuality
+	duplicates []db.DuplicateCandidate
+	stats      *db.LibraryStats
+	verified   *db.VerificationSummary
+	corrupt    []db.CorruptTrack
+	dismissals []db.Dismissal
+	releases   []db.Release
+	rules      map[int64]db.WatchRule
+	runs       []db.DiscoveryRun
+	seedPrefs  map[string][2]bool // folder -> favourite, pinned
+	added      []db.Dismissal     // recorded by AddDismissal
+	playlists  []db.Playlist
+	errList    error
+	errActive  error
+	errHist    error
 }
 
-func (m *mockStore) ListArtistMappings(_ context.Context) ([]db.ArtistMapping, error) {
+func (m *mockStore) GetArtistMapping(_ context.Context, folderName string) (*db.ArtistMapping, error) {
+	for i := range m.artists {
+		if m.artists[i].FolderName == folderName {
+			return &m.artists[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockStore) ListArtistFolders(_ context.Context) ([]db.ArtistMapping, error) {
 	return m.artists, m.errList
 }
 
+func (m *mockStore) SetArtistMapping(_ context.Context, folderName string, tidalID int64, _, _ string) error {
+	if m.mapped == nil {
+		m.mapped = make(map[string]int64)
+	}
+	m.mapped[folderName] = tidalID
+	return nil
+}
+
+func (m *mockStore) IgnoreArtist(_ context.Context, folderName string) error {
+	m.ignored = append(m.ignored, folderName)
+	return nil
+}
+
+func (m *mockStore) UnlockArtistMapping(_ context.Context, folderName string) error {
+	m.unlocked = append(m.unlocked, folderName)
+	return nil
+}
+
+func (m *mockStore) ListLibraryFormats(_ context.Context) ([]db.FormatCount, error) {
+	return m.formats, nil
+}
+
+func (m *mockStore) ListArtistFoldersByFormat(_ context.Context, format string) ([]string, error) {
+	return m.byFormat[format], nil
+}
+
+func (m *mockStore) ListArtistReviews(_ context.Context) ([]db.ArtistReview, error) {
+	return m.reviews, nil
+}
+
+func (m *mockStore) CountArtistReviews(_ context.Context) (int, error) {
+	return len(m.reviews), nil
+}
+
+func (m *mockStore) AcceptArtistCandidate(_ context.Context, folderName string, tidalID int64) error {
+	for _, r := range m.reviews {
+		if r.FolderName != folderName {
+			continue
+		}
+		for _, c := range r.Candidates {
+			if c.TidalID == tidalID {
+				if m.accepted == nil {
+					m.accepted = make(map[string]int64)
+				}
+				m.accepted[folderName] = tidalID
+				return nil
+			}
+		}
+	}
+	return db.ErrCandidateNotFound
+}
+
 func (m *mockStore) GetActiveDownloads(_ context.Context) ([]db.Download, error) {
 	return m.active, m.errActive
 }
@@ -45,15 +135,191 @@ func (m *mockStore) GetDownloadHistory(_ context.Context, _ int) ([]db.Download,
 	return m.history, m.errHist
 }
 
+func (m *mockStore) GetScan(_ context.Context, id int64) (*db.Scan, error) {
+	return m.scans[id], nil
+}
+
+func (m *mockStore) GetLatestScan(_ context.Context) (*db.Scan, error) {
+	var latest *db.Scan
+	for _, s := range m.scans {
+		if latest == nil || s.ID > latest.ID {
+			latest = s
+		}
+	}
+	return latest, nil
+}
+
+func (m *mockStore) ListScanErrors(_ context.Context, scanID int64) ([]string, error) {
+	return m.scanErrors[scanID], nil
+}
+
+func (m *mockStore) GetAlbumHoldings(_ context.Context, ids []int64) (map[int64]db.AlbumHolding, error) {
+	holdings := make(map[int64]db.AlbumHolding)
+	for _, id := range ids {
+		if h, ok := m.holdings[id]; ok {
+			holdings[id] = h
+		}
+	}
+	return holdings, nil
+}
+
+func (m *mockStore) ListTracksForAlbum(_ context.Context, albumID int64) ([]db.LibraryTrack, error) {
+	return m.libTracks[albumID], nil
+}
+
+func (m *mockStore) GetLibraryAlbum(_ context.Context, id int64) (*db.LibraryAlbum, error) {
+	if a, ok := m.libAlbums[id]; ok {
+		return a, nil
+	}
+	return nil, db.ErrAlbumNotFound
+}
+
+func (m *mockStore) ListIncompleteAlbums(_ context.Context) ([]db.IncompleteAlbum, error) {
+	return m.incomplete, nil
+}
+
+func (m *mockStore) ListMissingTracks(_ context.Context, albumID int64) ([]db.MissingTrack, error) {
+	for _, a := range m.incomplete {
+		if a.Album.ID == albumID {
+			return a.Missing, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockStore) LastCompletenessCheck(_ context.Context) (string, error) {
+	return "", nil
+}
+
+func (m *mockStore) ListLinkedAlbumQualities(_ context.Context) ([]db.LinkedAlbumQuality, error) {
+	return m.qualities, nil
+}
+
+func (m *mockStore) ListAlbumsForArtist(_ context.Context, artistFolder string) ([]db.LibraryAlbum, error) {
+	var albums []db.LibraryAlbum
+	for _, a := range m.libAlbums {
+		if a.ArtistFolder == artistFolder {
+			albums = append(albums, *a)
+		}
+	}
+	slices.SortFunc(albums, func(a, b db.LibraryAlbum) int { return strings.Compare(a.AlbumFolder, b.AlbumFolder) })
+	return albums, nil
+}
+
+func (m *mockStore) ListDuplicateCandidates(_ context.Context) ([]db.DuplicateCandidate, error) {
+	return m.duplicates, nil
+}
+
+func (m *mockStore) GetLibraryStats(_ context.Context, topArtists int) (*db.LibraryStats, error) {
+	if m.errList != nil {
+		return nil, m.errList
+	}
+	if m.stats == nil {
+		return &db.LibraryStats{}, nil
+	}
+	st := *m.stats
+	st.TopArtists = st.TopArtists[:min(topArtists, len(st.TopArtists))]
+	return &st, nil
+}
+
+func (m *mockStore) GetVerificationSummary(_ context.Context, _ int) (*db.VerificationSummary, error) {
+	if m.verified == nil {
+		return &db.VerificationSummary{Corrupt: len(m.corrupt)}, nil
+	}
+	return m.verified, nil
+}
+
+func (m *mockStore) ListCorruptTracks(_ context.Context) ([]db.CorruptTrack, error) {
+	return m.corrupt, nil
+}
+
+func (m *mockStore) ListPlaylists(_ context.Context) ([]db.Playlist, error) {
+	return m.playlists, nil
+}
+
+func (m *mockStore) AddDismissal(_ context.Context, kind string, tidalID int64, title, artistName string, snoozeDays int) error {
+	d := db.Dismissal{Kind: kind, TidalID: tidalID, Title: title, ArtistName: artistName}
+	if snoozeDays > 0 {
+		until := fmt.Sprintf("+%d days", snoozeDays)
+		d.SnoozedUntil = &until
+	}
+	m.added = append(m.added, d)
+	return nil
+}
+
+func (m *mockStore) ListNewReleases(_ context.Context, _ int) ([]db.Release, error) {
+	return m.releases, nil
+}
+
+func (m *mockStore) LastReleaseCheck(_ context.Context) (string, error) {
+	return "", nil
+}
+
+func (m *mockStore) ListWatchRules(_ context.Context) (map[int64]db.WatchRule, error) {
+	return m.rules, nil
+}
+
+func (m *mockStore) SetWatchRule(_ context.Context, rule db.WatchRule) error {
+	if m.rules == nil {
+		m.rules = make(map[int64]db.WatchRule)
+	}
+	m.rules[rule.ArtistTidalID] = rule
+	return nil
+}
+
+func (m *mockStore) ListDiscoveryRuns(_ context.Context, limit, offset int) ([]db.DiscoveryRun, error) {
+	if offset >= len(m.runs) {
+		return nil, nil
+	}
+	return m.runs[offset:min(offset+limit, len(m.runs))], nil
+}
+
+func (m *mockStore) CountDiscoveryRuns(_ context.Context) (int, error) {
+	return len(m.runs), nil
+}
+
+func (m *mockStore) SetSeedPreference(_ context.Context, folderName string, favourite, pinned bool) error {
+	if m.seedPrefs == nil {
+		m.seedPrefs = make(map[string][2]bool)
+	}
+	m.seedPrefs[folderName] = [2]bool{favourite, pinned}
+	return nil
+}
+
+func (m *mockStore) DeleteWatchRule(_ context.Context, artistTidalID int64) error {
+	delete(m.rules, artistTidalID)
+	return nil
+}
+
+func (m *mockStore) ListDismissals(_ context.Context) ([]db.Dismissal, error) {
+	return m.dismissals, nil
+}
+
+func (m *mockStore) DeleteDismissal(_ context.Context, id int64) error {
+	for i, d := range m.dismissals {
+		if d.ID == id {
+			m.dismissals = slices.Delete(m.dismissals, i, i+1)
+			return nil
+		}
+	}
+	return db.ErrDismissalNotFound
+}
+
 type mockHiFi struct {
 	artists      *hifi.SearchResult[hifi.Artist]
 	albums       *hifi.SearchResult[hifi.Album]
 	artistAlbums []hifi.Album
 	albumDetail  *hifi.AlbumDetail
+	similar      []hifi.SimilarAlbum
+	cacheStats   hifi.CacheStats
+	status       hifi.Status
+	cleared      bool
+	checked      bool
 	errArtists   error
 	errAlbums    error
 	errArtAlb    error
 	errAlbDet    error
+	errClear     error
 }
 
 func (m *mockHiFi) SearchArtists(_ context.Context, _ string, _, _ int) (*hifi.SearchResult[hifi.Artist], error) {
@@ -72,34 +338,200 @@ func (m *mockHiFi) GetAlbum(_ context.Context, _ int64) (*hifi.AlbumDetail, erro
 	return m.albumDetail, m.errAlbDet
 }
 
+func (m *mockHiFi) GetSimilarAlbums(_ context.Context, _ int64) ([]hifi.SimilarAlbum, error) {
+	return m.similar, m.errAlbDet
+}
+
+func (m *mockHiFi) CacheStats() hifi.CacheStats {
+	return m.cacheStats
+}
+
+func (m *mockHiFi) Status() hifi.Status {
+	return m.status
+}
+
+func (m *mockHiFi) CheckHealth(_ context.Context) {
+	m.checked = true
+}
+
+func (m *mockHiFi) ClearCache(_ context.Context) error {
+	m.cleared = m.errClear == nil
+	return m.errClear
+}
+
 type mockScanner struct {
-	result *library.ScanResult
+	id     int64
 	err    error
+	called bool
 }
 
-func (m *mockScanner) Scan(_ context.Context) (*library.ScanResult, error) {
-	return m.result, m.err
+func (m *mockScanner) Start(_ context.Context) (int64, error) {
+	m.called = true
+	return m.id, m.err
 }
 
 type mockDownloader struct {
 	lastReq downloader.Request
+	reqs    []downloader.Request
 	called  bool
 }
 
 func (m *mockDownloader) DownloadAsync(_ context.Context, req downloader.Request) {
 	m.lastReq = req
+	m.reqs = append(m.reqs, req)
 	m.called = true
 }
 
 type mockDiscovery struct {
-	recs []discovery.Recommendation
-	err  error
+	snapshot *discovery.Snapshot // returned for every run; nil for none saved
+	recs     []discovery.Recommendation
+	seed     discovery.Seed // recorded by DiscoverFrom
+	seeds    []discovery.WeightedSeed
+	artists  []discovery.ArtistRecommendation
+	hops     int
+	running  bool
+	started  bool
+	err      error
 }
 
-func (m *mockDiscovery) Discover(_ context.Context, _, _ int) ([]discovery.Recommendation, error) {
+func (m *mockDiscovery) Snapshot(_ context.Context, id int64) (*discovery.Snapshot, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.snapshot == nil {
+		return nil, discovery.ErrNoSnapshot
+	}
+	if id != 0 && id != m.snapshot.ID {
+		return nil, db.ErrDiscoveryRunNotFound
+	}
+	return m.snapshot, nil
+}
+
+func (m *mockDiscovery) Start(_ context.Context) error {
+	m.started = true
+	return nil
+}
+
+func (m *mockDiscovery) Running() bool {
+	return m.running
+}
+
+func (m *mockDiscovery) DiscoverFrom(_ context.Context, seed discovery.Seed, _ int) ([]discovery.Recommendation, error) {
+	m.seed = seed
 	return m.recs, m.err
 }
 
+func (m *mockDiscovery) SeedCandidates(_ context.Context) ([]discovery.WeightedSeed, error) {
+	return m.seeds, m.err
+}
+
+func (m *mockDiscovery) ArtistSnapshot(_ context.Context, maxHops int) ([]discovery.ArtistRecommendation, error) {
+	m.hops = maxHops
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.artists == nil {
+		return nil, discovery.ErrNoSnapshot
+	}
+	return m.artists, nil
+}
+
+type mockCompleteness struct {
+	running bool
+	started bool
+	err     error
+}
+
+func (m *mockCompleteness) Start(_ context.Context) error {
+	m.started = true
+	return m.err
+}
+
+func (m *mockCompleteness) Running() bool {
+	return m.running
+}
+
+type mockDeduper struct {
+	kept    int64
+	merged  []int64
+	deleted []int64
+	err     error
+}
+
+func (m *mockDeduper) Merge(_ context.Context, keepID int64, ids []int64) (int, error) {
+	m.kept, m.merged = keepID, ids
+	return len(ids), m.err
+}
+
+func (m *mockDeduper) Delete(_ context.Context, ids []int64) error {
+	m.deleted = ids
+	return m.err
+}
+
+type mockVerifier struct {
+	running   bool
+	started   bool
+	discarded []db.LibraryTrack
+	err       error
+}
+
+func (m *mockVerifier) Start(_ context.Context) error {
+	m.started = true
+	return m.err
+}
+
+func (m *mockVerifier) Running() bool {
+	return m.running
+}
+
+func (m *mockVerifier) Discard(_ context.Context, tracks []db.LibraryTrack) error {
+	m.discarded = tracks
+	return m.err
+}
+
+type mockPlaylists struct {
+	running   bool
+	started   []string
+	refreshed bool
+	err       error
+}
+
+func (m *mockPlaylists) Start(_ context.Context, id string) error {
+	m.started = append(m.started, id)
+	return m.err
+}
+
+func (m *mockPlaylists) StartRefresh(_ context.Context) error {
+	m.refreshed = true
+	return m.err
+}
+
+func (m *mockPlaylists) Running() bool {
+	return m.running
+}
+
+type mockReleases struct {
+	running   bool
+	started   bool
+	err       error
+	decisions []releases.Decision
+	previewed db.WatchRule
+}
+
+func (m *mockReleases) Start(_ context.Context) error {
+	m.started = true
+	return m.err
+}
+
+func (m *mockReleases) Running() bool {
+	return m.running
+}
+
+func (m *mockReleases) Preview(_ context.Context, rule db.WatchRule) ([]releases.Decision, error) {
+	m.previewed = rule
+	return m.decisions, m.err
+}
+
 // ---------------------------------------------------------------------------
 // Template setup helper
 // ---------------------------------------------------------------------------
@@ -113,17 +545,38 @@ func writeTemplates(t *testing.T) fs.FS {
 	dir := t.TempDir()
 
 	files := map[string]string{
-		"layout.html": `{{define "layout"}}<!DOCTYPE html><html><body>{{template "content" .}}</body></html>{{end}}`,
+		"layout.html": `{{define "layout"}}<!DOCTYPE html><html><body>{{if .HiFiUnavailable}}hifi-api unavailable|{{end}}{{template "content" .}}</body></html>{{end}}`,
 		"home.html":   `{{define "content"}}ok{{end}}`,
 		"search.html": `{{define "content"}}ok{{end}}`,
 		"search_results.html": `{{define "search_results"}}results{{end}}
 {{define "content"}}search results{{end}}`,
-		"artist.html":    `{{define "content"}}ok{{end}}`,
-		"album.html":     `{{define "content"}}ok{{end}}`,
-		"downloads.html": `{{define "content"}}ok{{end}}`,
-		"discover.html":  `{{define "content"}}ok{{end}}`,
-		"library.html":   `{{define "content"}}ok{{end}}`,
-		"error.html":     `{{define "content"}}ok{{end}}`,
+		"artist.html": `{{define "content"}}{{range .Albums}}{{.ID}}{{$o := index $.Ownership .ID}}{{if $o.State}}({{$o.State}}){{end}};{{end}}{{end}}`,
+		"album.html": `{{define "content"}}ok{{end}}
+{{define "similar_albums"}}{{range .Albums}}{{.ID}}{{$o := index $.Ownership .ID}}{{if $o.State}}({{$o.State}}){{end}};{{end}}{{end}}`,
+		"downloads.html":         `{{define "content"}}ok{{end}}`,
+		"discover.html":          `{{define "content"}}{{range .Recommendations}}{{.AlbumTitle}};{{end}}|past={{.Past}}|refreshing={{.Refreshing}}|from={{.From}}{{end}}`,
+		"discover_seeds.html":    `{{define "content"}}{{range .Seeds}}{{.FolderName}}:{{if .Pinned}}pinned{{else}}{{percent (index $.Chances .FolderName)}}{{end}};{{end}}{{end}}`,
+		"discover_history.html":  `{{define "content"}}{{range .Runs}}{{.ID}};{{end}}|{{.PrevPage}}|{{.NextPage}}{{end}}`,
+		"releases.html":          `{{define "content"}}{{range .Releases}}{{.Title}}{{if index $.Held .TidalAlbumID}}(held){{end}};{{end}}{{end}}`,
+		"watchlist.html":         `{{define "content"}}{{range .Rules}}{{.ArtistName}}:{{.AutoDownload}};{{end}}{{end}}`,
+		"watchlist_rule.html":    `{{define "content"}}{{.Rule.ArtistName}}|{{.Rule.Quality}}|{{.Watched}}{{end}}`,
+		"watchlist_preview.html": `{{define "content"}}{{range .Decisions}}{{.Album.Title}}:{{.Skip}};{{end}}|{{.Downloads}}{{end}}`,
+		"dismissals.html":        `{{define "content"}}{{range .Dismissals}}{{.Kind}}:{{.Title}};{{end}}{{end}}`,
+		"discover_artists.html":  `{{define "content"}}{{range .Artists}}{{.Name}}:{{len .Albums}};{{end}}|refreshing={{.Refreshing}}{{end}}`,
+		"library.html": `{{define "content"}}{{range .Artists}}{{.FolderName}};{{end}}{{end}}
+{{define "scan_status"}}scan{{end}}`,
+		"review.html":         `{{define "content"}}{{range .Reviews}}{{.FolderName}}:{{len .Candidates}};{{end}}{{end}}`,
+		"mapping.html":        `{{define "content"}}{{.Folder}}|{{.Query}}|{{range .Results}}{{.Name}};{{end}}{{end}}`,
+		"completeness.html":   `{{define "content"}}{{range .Albums}}{{.Album.AlbumFolder}}:{{range .Missing}}{{.TrackNumber}},{{end}};{{end}}{{end}}`,
+		"library_artist.html": `{{define "content"}}{{range .Albums}}{{.AlbumFolder}}:{{(index $.Summaries .ID).Quality}};{{end}}|auto={{.WatchRule.AutoDownload}}{{end}}`,
+		"library_album.html":  `{{define "content"}}{{.Album.AlbumFolder}}|{{.Summary.Quality}}|{{range .Tracks}}{{.Filename}};{{end}}{{end}}`,
+		"duplicates.html":     `{{define "content"}}{{range .Groups}}{{range .Albums}}{{.Album.ID}},{{end}}{{range .Reasons}}{{.}},{{end}};{{end}}{{end}}`,
+		"stats.html":          `{{define "content"}}{{.Stats.Albums}} albums|{{.MaxMonthly}}|{{.MaxArtist}}|{{range .Stats.TopArtists}}{{.ArtistFolder}};{{end}}{{end}}`,
+		"integrity.html":      `{{define "content"}}{{.Summary.Corrupt}} corrupt|{{range .Albums}}{{.Album.AlbumFolder}}:{{range .Tracks}}{{.Track.Filename}},{{end}};{{end}}{{end}}`,
+		"playlists.html":      `{{define "content"}}{{range .Playlists}}{{.Title}}:{{.LocalTracks}}/{{.TotalTracks}};{{end}}{{end}}`,
+		"upgrades.html":       `{{define "content"}}{{range .Upgrades}}{{.Album.AlbumFolder}}:{{.LocalQuality}}>{{.UpgradeQuality}};{{end}}{{end}}`,
+		"diagnostics.html":    `{{define "content"}}{{range .Status.Backends}}{{.URL}}:{{.Breaker}};{{end}}{{.Status.Retries}}|{{with .Cache}}{{.Entries}}/{{.Capacity}} {{percent .HitRate}}{{end}}{{end}}`,
+		"error.html":          `{{define "content"}}ok{{end}}`,
 		"download_status.html": `{{define "download_status"}}queued{{end}}
 {{define "content"}}download status{{end}}`,
 	}
@@ -144,7 +597,7 @@ func newTestHandler(t *testing.T, store HandlerStore, hf HandlerHiFi, scanner Ha
 
 	tmplFS := writeTemplates(t)
 
-	h, err := New(tmplFS, store, hf, scanner, dl, disc, "LOSSLESS")
+	h, err := New(tmplFS, store, hf, scanner, dl, disc, &mockCompleteness{}, &mockDeduper{}, &mockVerifier{}, &mockPlaylists{}, &mockReleases{}, "LOSSLESS")
 	if err != nil {
 		t.Fatalf("creating handler: %v", err)
 	}
@@ -165,7 +618,7 @@ func chiContextID(r *http.Request, value string) *http.Request {
 
 func TestNew(t *testing.T) {
 	t.Run("fails with bad template dir", func(t *testing.T) {
-		_, err := New(os.DirFS("/no/such/dir"), &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{}, "LOSSLESS")
+		_, err := New(os.DirFS("/no/such/dir"), &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{}, &mockCompleteness{}, &mockDeduper{}, &mockVerifier{}, &mockPlaylists{}, &mockReleases{}, "LOSSLESS")
 		if err == nil {
 			t.Fatal("expected error, got nil")
 		}
@@ -174,7 +627,7 @@ func TestNew(t *testing.T) {
 	t.Run("succeeds with valid template dir", func(t *testing.T) {
 		tmplFS := writeTemplates(t)
 
-		h, err := New(tmplFS, &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{}, "LOSSLESS")
+		h, err := New(tmplFS, &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{}, &mockCompleteness{}, &mockDeduper{}, &mockVerifier{}, &mockPlaylists{}, &mockReleases{}, "LOSSLESS")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -348,6 +801,49 @@ func TestAlbum(t *testing.T) {
 	})
 }
 
+func TestSimilarAlbums(t *testing.T) {
+	t.Run("lists similar albums with ownership", func(t *testing.T) {
+		hf := &mockHiFi{similar: []hifi.SimilarAlbum{
+			{ID: 100, Title: "OK Computer"},
+			{ID: 200, Title: "Kid A", Artists: []hifi.ArtistRef{{ID: 1, Name: "Radiohead"}}},
+			{ID: 300, Title: "Showbiz", Artists: []hifi.ArtistRef{{ID: 2, Name: "Muse"}}},
+			{ID: 400, Title: "Mezzanine", Artists: []hifi.ArtistRef{{ID: 3, Name: "Massive Attack"}}},
+			{ID: 500, Title: "Dummy", Artists: []hifi.ArtistRef{{ID: 4, Name: "Portishead"}}},
+		}}
+		store := &mockStore{
+			holdings: map[int64]db.AlbumHolding{200: {TidalAlbumID: 200, TrackCount: 10}},
+			dismissals: []db.Dismissal{
+				{Kind: db.DismissArtist, TidalID: 2},
+				{Kind: db.DismissAlbum, TidalID: 400},
+			},
+		}
+		h := newTestHandler(t, store, hf, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		req := chiContextID(httptest.NewRequest(http.MethodGet, "/album/100/similar", nil), "100")
+		rec := httptest.NewRecorder()
+		h.SimilarAlbums(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+		if body, want := rec.Body.String(), "200(owned);500;"; body != want {
+			t.Errorf("body = %q, want %q", body, want)
+		}
+	})
+
+	t.Run("invalid ID returns 400", func(t *testing.T) {
+		h := newTestHandler(t, &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		req := chiContextID(httptest.NewRequest(http.MethodGet, "/album/xyz/similar", nil), "xyz")
+		rec := httptest.NewRecorder()
+		h.SimilarAlbums(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got %d", rec.Code)
+		}
+	})
+}
+
 func TestDownloads(t *testing.T) {
 	t.Run("returns 200", func(t *testing.T) {
 		store := &mockStore{
@@ -371,30 +867,75 @@ func TestDownloads(t *testing.T) {
 	})
 }
 
-func TestDiscover(t *testing.T) {
-	t.Run("returns 200", func(t *testing.T) {
-		disc := &mockDiscovery{
-			recs: []discovery.Recommendation{
-				{
-					AlbumID:     300,
-					AlbumTitle:  "In Rainbows",
-					ArtistName:  "Radiohead",
-					Cover:       "cover-uuid",
-					ReleaseDate: "2007-10-10",
-					SeedArtist:  "Muse",
-				},
-			},
+func TestDiscoverArtists(t *testing.T) {
+	disc := &mockDiscovery{
+		artists: []discovery.ArtistRecommendation{
+			{ArtistID: 10, Name: "Portishead", Hops: 1, Seeds: []string{"Radiohead", "Massive Attack"}, Albums: []hifi.Album{{ID: 100, Title: "Dummy"}}},
+			{ArtistID: 15, Name: "Beth Gibbons", Hops: 2, Seeds: []string{"Radiohead"}},
+		},
+	}
+
+	tests := []struct {
+		query    string
+		wantHops int
+	}{
+		{"", 2},
+		{"?hops=1", 1},
+		{"?hops=2", 2},
+		{"?hops=7", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			h := newTestHandler(t, &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, disc)
+
+			req := httptest.NewRequest(http.MethodGet, "/discover/artists"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			h.DiscoverArtists(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", rec.Code)
+			}
+			if disc.hops != tt.wantHops {
+				t.Errorf("maxHops = %d, want %d", disc.hops, tt.wantHops)
+			}
+			if body := rec.Body.String(); !strings.Contains(body, "Portishead:1;Beth Gibbons:0;") {
+				t.Errorf("body = %q, want both artists", body)
+			}
+		})
+	}
+
+	t.Run("error returns 500", func(t *testing.T) {
+		h := newTestHandler(t, &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{err: errors.New("boom")})
+
+		req := httptest.NewRequest(http.MethodGet, "/discover/artists", nil)
+		rec := httptest.NewRecorder()
+
+		h.DiscoverArtists(rec, req)
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("expected status 500, got %d", rec.Code)
 		}
+	})
+
+	t.Run("nothing saved starts a refresh", func(t *testing.T) {
+		disc := &mockDiscovery{running: true}
 		h := newTestHandler(t, &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, disc)
 
-		req := httptest.NewRequest(http.MethodGet, "/discover", nil)
+		req := httptest.NewRequest(http.MethodGet, "/discover/artists", nil)
 		rec := httptest.NewRecorder()
 
-		h.Discover(rec, req)
+		h.DiscoverArtists(rec, req)
 
 		if rec.Code != http.StatusOK {
 			t.Fatalf("expected status 200, got %d", rec.Code)
 		}
+		if !disc.started {
+			t.Error("no refresh started")
+		}
+		if body := rec.Body.String(); !strings.Contains(body, "refreshing=true") {
+			t.Errorf("body = %q, want the refresh shown", body)
+		}
 	})
 }
 
@@ -418,6 +959,35 @@ func TestLibrary(t *testing.T) {
 			t.Fatalf("expected status 200, got %d", rec.Code)
 		}
 	})
+
+	t.Run("filters by format", func(t *testing.T) {
+		store := &mockStore{
+			artists: []db.ArtistMapping{
+				{ID: 1, FolderName: "Björk"},
+				{ID: 2, FolderName: "Radiohead"},
+				{ID: 3, FolderName: "Aphex Twin"},
+			},
+			formats:  []db.FormatCount{{Format: "flac", Tracks: 20}, {Format: "alac", Tracks: 11}},
+			byFormat: map[string][]string{"alac": {"Björk"}},
+		}
+		h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		req := httptest.NewRequest(http.MethodGet, "/library?format=alac", nil)
+		rec := httptest.NewRecorder()
+
+		h.Library(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+		body := rec.Body.String()
+		if !strings.Contains(body, "Björk;") {
+			t.Errorf("expected Björk in filtered library, got %q", body)
+		}
+		if strings.Contains(body, "Radiohead") || strings.Contains(body, "Aphex Twin") {
+			t.Errorf("expected only ALAC artists, got %q", body)
+		}
+	})
 }
 
 func TestStartDownload(t *testing.T) {
@@ -519,18 +1089,65 @@ func TestStartDownload(t *testing.T) {
 }
 
 func TestStartScan(t *testing.T) {
-	t.Run("returns 200 with JSON", func(t *testing.T) {
-		scanner := &mockScanner{
-			result: &library.ScanResult{
-				ArtistsFound:   10,
-				AlbumsFound:    25,
-				ArtistsMatched: 8,
-				Errors:         []string{"some error"},
-			},
+	t.Run("returns 202 with scan ID", func(t *testing.T) {
+		scanner := &mockScanner{id: 7}
+		h := newTestHandler(t, &mockStore{}, &mockHiFi{}, scanner, &mockDownloader{}, &mockDiscovery{})
+
+		req := httptest.NewRequest(http.MethodPost, "/scan", nil)
+		rec := httptest.NewRecorder()
+
+		h.StartScan(rec, req)
+
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("expected status 202, got %d", rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Fatalf("expected application/json content type, got %q", ct)
+		}
+		if !scanner.called {
+			t.Fatal("expected Start to be called")
+		}
+
+		var got map[string]any
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode JSON body: %v", err)
+		}
+		if got["scan_id"] != float64(7) {
+			t.Fatalf("scan_id = %v, want 7", got["scan_id"])
+		}
+		if got["status"] != "running" {
+			t.Fatalf("status = %v, want running", got["status"])
+		}
+	})
+
+	t.Run("returns 409 when a scan is running", func(t *testing.T) {
+		scanner := &mockScanner{id: 3, err: library.ErrScanInProgress}
+		h := newTestHandler(t, &mockStore{}, &mockHiFi{}, scanner, &mockDownloader{}, &mockDiscovery{})
+
+		req := httptest.NewRequest(http.MethodPost, "/scan", nil)
+		rec := httptest.NewRecorder()
+
+		h.StartScan(rec, req)
+
+		if rec.Code != http.StatusConflict {
+			t.Fatalf("expected status 409, got %d", rec.Code)
+		}
+
+		var got map[string]any
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode JSON body: %v", err)
+		}
+		if got["scan_id"] != float64(3) {
+			t.Fatalf("scan_id = %v, want running scan 3", got["scan_id"])
 		}
+	})
+
+	t.Run("HTMX request returns status partial", func(t *testing.T) {
+		scanner := &mockScanner{id: 1}
 		h := newTestHandler(t, &mockStore{}, &mockHiFi{}, scanner, &mockDownloader{}, &mockDiscovery{})
 
 		req := httptest.NewRequest(http.MethodPost, "/scan", nil)
+		req.Header.Set("HX-Request", "true")
 		rec := httptest.NewRecorder()
 
 		h.StartScan(rec, req)
@@ -538,24 +1155,81 @@ func TestStartScan(t *testing.T) {
 		if rec.Code != http.StatusOK {
 			t.Fatalf("expected status 200, got %d", rec.Code)
 		}
-		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
-			t.Fatalf("expected application/json content type, got %q", ct)
+		if ct := rec.Header().Get("Content-Type"); !strings.Contains(ct, "text/html") {
+			t.Fatalf("expected text/html content type, got %q", ct)
 		}
+	})
+}
 
-		var got map[string]int
+func TestScanStatus(t *testing.T) {
+	t.Run("returns progress and errors", func(t *testing.T) {
+		store := &mockStore{
+			scans: map[int64]*db.Scan{
+				5: {ID: 5, Status: "complete", ArtistsTotal: 10, ArtistsProcessed: 10, ArtistsFound: 10, AlbumsFound: 25, ArtistsMatched: 8, ErrorCount: 2},
+			},
+			scanErrors: map[int64][]string{
+				5: {"no Tidal match found for artist X", "reading artist directory Y: denied"},
+			},
+		}
+		h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		req := httptest.NewRequest(http.MethodGet, "/scan/5", nil)
+		req = chiContextID(req, "5")
+		rec := httptest.NewRecorder()
+
+		h.ScanStatus(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+
+		var got struct {
+			Status      string   `json:"status"`
+			AlbumsFound int      `json:"albums_found"`
+			Errors      []string `json:"errors"`
+		}
 		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
 			t.Fatalf("failed to decode JSON body: %v", err)
 		}
-		want := map[string]int{
-			"artists_found":   10,
-			"albums_found":    25,
-			"artists_matched": 8,
-			"errors":          1,
+		if got.Status != "complete" || got.AlbumsFound != 25 {
+			t.Fatalf("got %+v, want complete scan with 25 albums", got)
 		}
-		for k, v := range want {
-			if got[k] != v {
-				t.Fatalf("key %q: got %d, want %d", k, got[k], v)
-			}
+		if len(got.Errors) != 2 {
+			t.Fatalf("errors = %v, want 2 messages", got.Errors)
+		}
+	})
+
+	t.Run("unknown scan returns 404", func(t *testing.T) {
+		h := newTestHandler(t, &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		req := httptest.NewRequest(http.MethodGet, "/scan/99", nil)
+		req = chiContextID(req, "99")
+		rec := httptest.NewRecorder()
+
+		h.ScanStatus(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("expected status 404, got %d", rec.Code)
+		}
+	})
+}
+
+func TestLibraryScanStatus(t *testing.T) {
+	t.Run("returns 200", func(t *testing.T) {
+		store := &mockStore{
+			scans: map[int64]*db.Scan{
+				1: {ID: 1, Status: "running", ArtistsTotal: 10, ArtistsProcessed: 4},
+			},
+		}
+		h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		req := httptest.NewRequest(http.MethodGet, "/library/scan-status", nil)
+		rec := httptest.NewRecorder()
+
+		h.LibraryScanStatus(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
 		}
 	})
 }
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/downloader"
+	"github.com/MattHbrook/Crescendo/internal/library"
+)
+
+// Integrity renders the library integrity report: how many FLAC tracks have
+// been verified and the tracks whose audio failed verification, grouped by
+// album.
+func (h *Handler) Integrity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	summary, err := h.store.GetVerificationSummary(ctx, library.ReverifyAfterDays)
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load integrity report")
+		return
+	}
+	corrupt, err := h.store.ListCorruptTracks(ctx)
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load integrity report")
+		return
+	}
+
+	// Tracks arrive ordered by album, so each album's tracks are adjacent.
+	var albums []map[string]any
+	for i := 0; i < len(corrupt); {
+		j := i + 1
+		for j < len(corrupt) && corrupt[j].Album.ID == corrupt[i].Album.ID {
+			j++
+		}
+		albums = append(albums, map[string]any{"Album": corrupt[i].Album, "Tracks": corrupt[i:j]})
+		i = j
+	}
+
+	h.render(w, "integrity", map[string]any{
+		"Title":   "Library Integrity",
+		"Summary": summary,
+		"Albums":  albums,
+		"Running": h.verifier.Running(),
+	})
+}
+
+// StartVerification starts a background verification of the library's FLAC
+// tracks and redirects back to the report. A verification already in
+// progress is not an error; the report shows it as running.
+func (h *Handler) StartVerification(w http.ResponseWriter, r *http.Request) {
+	if err := h.verifier.Start(r.Context()); err != nil && !errors.Is(err, library.ErrVerifyInProgress) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/library/integrity", http.StatusSeeOther)
+}
+
+// RedownloadCorrupt replaces the corrupt tracks of a Tidal-linked library
+// album: those with a counterpart on Tidal are downloaded again, named like
+// the tracks in the album folder, and only once the download has succeeded
+// are the corrupt copies moved to the trash to make way for them.
+func (h *Handler) RedownloadCorrupt(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	albumID, err := strconv.ParseInt(r.FormValue("album_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid album ID", http.StatusBadRequest)
+		return
+	}
+
+	album, err := h.store.GetLibraryAlbum(ctx, albumID)
+	if errors.Is(err, db.ErrAlbumNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if album.TidalAlbumID == nil {
+		http.Error(w, "Album is not linked to Tidal", http.StatusConflict)
+		return
+	}
+
+	corrupt, err := h.store.ListCorruptTracks(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	local, err := h.store.ListTracksForAlbum(ctx, albumID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	detail, err := h.hifi.GetAlbum(ctx, *album.TidalAlbumID)
+	if err != nil {
+		status, msg := hifiFailure(err, http.StatusInternalServerError, "Failed to fetch album")
+		http.Error(w, msg, status)
+		return
+	}
+
+	req := downloader.Request{
+		TidalAlbumID: *album.TidalAlbumID,
+		Quality:      h.quality,
+		OutputDir:    album.Path,
+		Naming:       trackNaming(local),
+	}
+	var discard []db.LibraryTrack
+	for _, c := range corrupt {
+		if c.Album.ID != albumID {
+			continue
+		}
+		// A corrupt track with no counterpart on Tidal is kept: a damaged
+		// copy beats none.
+		matching := library.MatchingTracks(detail.Tracks, []db.LibraryTrack{c.Track})
+		if len(matching) == 0 {
+			continue
+		}
+		discard = append(discard, c.Track)
+		req.TrackIDs = append(req.TrackIDs, matching[0].ID)
+	}
+	if len(discard) == 0 {
+		http.Error(w, "No corrupt tracks to re-download", http.StatusConflict)
+		return
+	}
+
+	req.Supersede = func(ctx context.Context) error {
+		return h.verifier.Discard(ctx, discard)
+	}
+	h.downloader.DownloadAsync(ctx, req)
+
+	h.renderPartial(w, "download_status", "download_status", map[string]any{
+		"ArtistName": album.ArtistFolder,
+		"AlbumTitle": album.AlbumFolder,
+	})
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+)
+
+func corruptOKComputer() *mockStore {
+	tidalID := int64(100)
+	okc := db.LibraryAlbum{ID: 7, ArtistFolder: "Radiohead", AlbumFolder: "OK Computer", Path: "/music/Radiohead/OK Computer", TidalAlbumID: &tidalID}
+	kida := db.LibraryAlbum{ID: 8, ArtistFolder: "Radiohead", AlbumFolder: "Kid A", Path: "/music/Radiohead/Kid A"}
+	airbag := db.LibraryTrack{ID: 70, AlbumID: 7, Filename: "1. Airbag.flac", TrackNumber: 1}
+	android := db.LibraryTrack{ID: 71, AlbumID: 7, Filename: "2. Paranoid Android.flac", TrackNumber: 2}
+	bonus := db.LibraryTrack{ID: 72, AlbumID: 7, Filename: "Bonus.flac"}
+	return &mockStore{
+		libAlbums: map[int64]*db.LibraryAlbum{7: &okc, 8: &kida},
+		libTracks: map[int64][]db.LibraryTrack{7: {airbag, android, bonus}},
+		corrupt: []db.CorruptTrack{
+			{Track: db.LibraryTrack{ID: 80, AlbumID: 8, Filename: "01 Everything.flac"}, Album: kida, Error: "audio: corrupt audio data"},
+			{Track: android, Album: okc, Error: "audio: corrupt audio data"},
+			{Track: bonus, Album: okc, Error: "audio: corrupt audio data"},
+		},
+	}
+}
+
+func TestIntegrity(t *testing.T) {
+	h := newTestHandler(t, corruptOKComputer(), &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	rec := httptest.NewRecorder()
+	h.Integrity(rec, httptest.NewRequest(http.MethodGet, "/library/integrity", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	want := "3 corrupt|Kid A:01 Everything.flac,;OK Computer:2. Paranoid Android.flac,Bonus.flac,;"
+	if body := rec.Body.String(); !strings.Contains(body, want) {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+}
+
+func TestStartVerification(t *testing.T) {
+	h := newTestHandler(t, &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+	verifier := &mockVerifier{}
+	h.verifier = verifier
+
+	rec := httptest.NewRecorder()
+	h.StartVerification(rec, httptest.NewRequest(http.MethodPost, "/library/integrity", nil))
+
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected status 303, got %d", rec.Code)
+	}
+	if !verifier.started {
+		t.Error("expected a verification to be started")
+	}
+}
+
+func TestRedownloadCorrupt(t *testing.T) {
+	detail := &hifi.AlbumDetail{Tracks: []hifi.Track{
+		{ID: 1, TrackNumber: 1, Title: "Airbag"},
+		{ID: 2, TrackNumber: 2, Title: "Paranoid Android"},
+	}}
+	post := func(t *testing.T, store *mockStore, verifier *mockVerifier, dl *mockDownloader, albumID string) *httptest.ResponseRecorder {
+		t.Helper()
+		h := newTestHandler(t, store, &mockHiFi{albumDetail: detail}, &mockScanner{}, dl, &mockDiscovery{})
+		h.verifier = verifier
+
+		form := url.Values{}
+		form.Set("album_id", albumID)
+		req := httptest.NewRequest(http.MethodPost, "/library/integrity/download", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+
+		h.RedownloadCorrupt(rec, req)
+		return rec
+	}
+
+	t.Run("replaces corrupt tracks found on Tidal", func(t *testing.T) {
+		verifier, dl := &mockVerifier{}, &mockDownloader{}
+		rec := post(t, corruptOKComputer(), verifier, dl, "7")
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		got := dl.lastReq
+		if verifier.discarded != nil || got.Supersede == nil {
+			t.Fatalf("discarded = %+v before the download, want it left to Supersede", verifier.discarded)
+		}
+		if err := got.Supersede(context.Background()); err != nil {
+			t.Fatalf("Supersede: %v", err)
+		}
+		if len(verifier.discarded) != 1 || verifier.discarded[0].ID != 71 {
+			t.Errorf("discarded = %+v, want only track 71", verifier.discarded)
+		}
+		if got.TidalAlbumID != 100 || got.OutputDir != "/music/Radiohead/OK Computer" {
+			t.Errorf("request = %+v, want album 100 into library folder", got)
+		}
+		if len(got.TrackIDs) != 1 || got.TrackIDs[0] != 2 {
+			t.Errorf("TrackIDs = %v, want [2]", got.TrackIDs)
+		}
+		if name := got.Naming.Filename(2, "Paranoid Android"); name != "2. Paranoid Android.flac" {
+			t.Errorf("Naming produces %q, want %q", name, "2. Paranoid Android.flac")
+		}
+	})
+
+	t.Run("unlinked album returns 409", func(t *testing.T) {
+		verifier, dl := &mockVerifier{}, &mockDownloader{}
+		rec := post(t, corruptOKComputer(), verifier, dl, "8")
+
+		if rec.Code != http.StatusConflict {
+			t.Fatalf("expected status 409, got %d", rec.Code)
+		}
+		if verifier.discarded != nil || dl.called {
+			t.Error("nothing should be discarded or downloaded")
+		}
+	})
+
+	t.Run("album without corrupt tracks returns 409", func(t *testing.T) {
+		store := corruptOKComputer()
+		store.corrupt = store.corrupt[:1]
+		verifier, dl := &mockVerifier{}, &mockDownloader{}
+		rec := post(t, store, verifier, dl, "7")
+
+		if rec.Code != http.StatusConflict {
+			t.Fatalf("expected status 409, got %d", rec.Code)
+		}
+		if verifier.discarded != nil || dl.called {
+			t.Error("nothing should be discarded or downloaded")
+		}
+	})
+
+	t.Run("unknown album returns 404", func(t *testing.T) {
+		rec := post(t, &mockStore{}, &mockVerifier{}, &mockDownloader{}, "9")
+
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("expected status 404, got %d", rec.Code)
+		}
+	})
+
+	t.Run("invalid album ID returns 400", func(t *testing.T) {
+		rec := post(t, &mockStore{}, &mockVerifier{}, &mockDownloader{}, "abc")
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got %d", rec.Code)
+		}
+	})
+}
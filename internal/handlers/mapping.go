@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// MappingEditor renders the editor for one artist folder's Tidal mapping:
+// the current mapping and Tidal search results to choose from. The search
+// query defaults to the folder name.
+func (h *Handler) MappingEditor(w http.ResponseWriter, r *http.Request) {
+	folder := r.URL.Query().Get("folder")
+	if folder == "" {
+		h.renderError(w, http.StatusBadRequest, "No artist folder given")
+		return
+	}
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		q = folder
+	}
+
+	ctx := r.Context()
+	mapping, err := h.store.GetArtistMapping(ctx, folder)
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load artist mapping")
+		return
+	}
+
+	result, err := h.hifi.SearchArtists(ctx, q, 10, 0)
+	if err != nil {
+		status, msg := hifiFailure(err, http.StatusBadGateway, "Failed to search Tidal")
+		h.renderError(w, status, msg)
+		return
+	}
+
+	h.render(w, "mapping", map[string]any{
+		"Title":   "Edit " + folder,
+		"Folder":  folder,
+		"Query":   q,
+		"Mapping": mapping,
+		"Results": result.Items,
+	})
+}
+
+// UpdateMapping applies a manual change to a folder's mapping. The form
+// field "action" selects the change:
+//
+//   - "assign": map to the artist in tidal_id/tidal_name/picture and lock it
+//   - "ignore": mark the folder as having no Tidal equivalent
+//   - "unlock": let the next scan resolve the folder again
+//
+// On success the client is redirected back to the library page.
+func (h *Handler) UpdateMapping(w http.ResponseWriter, r *http.Request) {
+	folder := r.FormValue("folder")
+	if folder == "" {
+		http.Error(w, "folder required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	var err error
+	switch r.FormValue("action") {
+	case "assign":
+		tidalID, parseErr := strconv.ParseInt(r.FormValue("tidal_id"), 10, 64)
+		if parseErr != nil {
+			http.Error(w, "Invalid Tidal ID", http.StatusBadRequest)
+			return
+		}
+		name := r.FormValue("tidal_name")
+		if name == "" {
+			http.Error(w, "tidal_name required", http.StatusBadRequest)
+			return
+		}
+		err = h.store.SetArtistMapping(ctx, folder, tidalID, name, r.FormValue("picture"))
+	case "ignore":
+		err = h.store.IgnoreArtist(ctx, folder)
+	case "unlock":
+		err = h.store.UnlockArtistMapping(ctx, folder)
+	default:
+		http.Error(w, "Unknown action", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/library", http.StatusSeeOther)
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+)
+
+func postMapping(h *Handler, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/library/mapping", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.UpdateMapping(rec, req)
+	return rec
+}
+
+func TestMappingEditor(t *testing.T) {
+	t.Run("searches folder name by default", func(t *testing.T) {
+		hf := &mockHiFi{
+			artists: &hifi.SearchResult[hifi.Artist]{
+				Items: []hifi.Artist{{ID: 1, Name: "Nirvana"}, {ID: 2, Name: "Nirvana (UK)"}},
+			},
+		}
+		h := newTestHandler(t, &mockStore{}, hf, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		req := httptest.NewRequest(http.MethodGet, "/library/mapping?folder=Nirvana", nil)
+		rec := httptest.NewRecorder()
+
+		h.MappingEditor(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+		if body := rec.Body.String(); !strings.Contains(body, "Nirvana|Nirvana|Nirvana;Nirvana (UK);") {
+			t.Errorf("unexpected body %q", body)
+		}
+	})
+
+	t.Run("missing folder returns 400", func(t *testing.T) {
+		h := newTestHandler(t, &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		req := httptest.NewRequest(http.MethodGet, "/library/mapping", nil)
+		rec := httptest.NewRecorder()
+
+		h.MappingEditor(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got %d", rec.Code)
+		}
+	})
+
+	t.Run("search failure returns 502", func(t *testing.T) {
+		hf := &mockHiFi{errArtists: errors.New("upstream down")}
+		h := newTestHandler(t, &mockStore{}, hf, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		req := httptest.NewRequest(http.MethodGet, "/library/mapping?folder=Nirvana&q=nirvana+uk", nil)
+		rec := httptest.NewRecorder()
+
+		h.MappingEditor(rec, req)
+
+		if rec.Code != http.StatusBadGateway {
+			t.Fatalf("expected status 502, got %d", rec.Code)
+		}
+	})
+}
+
+func TestUpdateMapping(t *testing.T) {
+	t.Run("assign", func(t *testing.T) {
+		store := &mockStore{}
+		h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		rec := postMapping(h, url.Values{"folder": {"Nirvana"}, "action": {"assign"}, "tidal_id": {"2"}, "tidal_name": {"Nirvana"}})
+
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d", rec.Code)
+		}
+		if store.mapped["Nirvana"] != 2 {
+			t.Errorf("mapped = %v, want Nirvana -> 2", store.mapped)
+		}
+	})
+
+	t.Run("ignore", func(t *testing.T) {
+		store := &mockStore{}
+		h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		rec := postMapping(h, url.Values{"folder": {"Bootlegs"}, "action": {"ignore"}})
+
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d", rec.Code)
+		}
+		if len(store.ignored) != 1 || store.ignored[0] != "Bootlegs" {
+			t.Errorf("ignored = %v, want [Bootlegs]", store.ignored)
+		}
+	})
+
+	t.Run("unlock", func(t *testing.T) {
+		store := &mockStore{}
+		h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		rec := postMapping(h, url.Values{"folder": {"Nirvana"}, "action": {"unlock"}})
+
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d", rec.Code)
+		}
+		if len(store.unlocked) != 1 {
+			t.Errorf("unlocked = %v, want [Nirvana]", store.unlocked)
+		}
+	})
+
+	t.Run("bad input returns 400", func(t *testing.T) {
+		h := newTestHandler(t, &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		cases := []url.Values{
+			{"action": {"ignore"}},
+			{"folder": {"Nirvana"}, "action": {"assign"}, "tidal_id": {"x"}, "tidal_name": {"Nirvana"}},
+			{"folder": {"Nirvana"}, "action": {"assign"}, "tidal_id": {"2"}},
+			{"folder": {"Nirvana"}, "action": {"delete"}},
+		}
+		for _, form := range cases {
+			if rec := postMapping(h, form); rec.Code != http.StatusBadRequest {
+				t.Errorf("form %v: expected status 400, got %d", form, rec.Code)
+			}
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/MattHbrook/Crescendo/internal/downloader"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+	"github.com/MattHbrook/Crescendo/internal/library"
+)
+
+// albumOwnership returns the ownership state of each album, keyed by Tidal
+// album ID, for the badges and download buttons on album listings.
+func (h *Handler) albumOwnership(ctx context.Context, albums []hifi.Album) (map[int64]library.Ownership, error) {
+	ids := make([]int64, 0, len(albums))
+	for _, a := range albums {
+		ids = append(ids, a.ID)
+	}
+
+	holdings, err := h.store.GetAlbumHoldings(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	ownership := make(map[int64]library.Ownership, len(albums))
+	for _, a := range albums {
+		if holding, ok := holdings[a.ID]; ok {
+			ownership[a.ID] = library.AlbumOwnership(a, &holding, h.quality)
+		}
+	}
+	return ownership, nil
+}
+
+// completeRequest builds a download request for the tracks missing from the
+// library copy of an album, written into that copy's folder and named like
+// the tracks already there.
+func (h *Handler) completeRequest(ctx context.Context, detail *hifi.AlbumDetail, quality string) (downloader.Request, int, error) {
+	holdings, err := h.store.GetAlbumHoldings(ctx, []int64{detail.ID})
+	if err != nil {
+		return downloader.Request{}, http.StatusInternalServerError, err
+	}
+	holding, ok := holdings[detail.ID]
+	if !ok || holding.LibraryAlbumID == 0 {
+		return downloader.Request{}, http.StatusConflict, fmt.Errorf("no library copy of %q to complete", detail.Title)
+	}
+
+	local, err := h.store.ListTracksForAlbum(ctx, holding.LibraryAlbumID)
+	if err != nil {
+		return downloader.Request{}, http.StatusInternalServerError, err
+	}
+
+	missing := library.MissingTracks(detail.Tracks, local)
+	if len(missing) == 0 {
+		return downloader.Request{}, http.StatusConflict, fmt.Errorf("no tracks missing from %q", detail.Title)
+	}
+
+	req := downloader.Request{
+		TidalAlbumID: detail.ID,
+		Quality:      quality,
+		OutputDir:    holding.Path,
+		Naming:       trackNaming(local),
+	}
+	for _, t := range missing {
+		req.TrackIDs = append(req.TrackIDs, t.ID)
+	}
+	return req, http.StatusOK, nil
+}
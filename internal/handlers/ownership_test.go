@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+)
+
+func TestArtist_Ownership(t *testing.T) {
+	hf := &mockHiFi{
+		artistAlbums: []hifi.Album{
+			{ID: 100, Title: "OK Computer", NumberOfTracks: 12, AudioQuality: "LOSSLESS"},
+			{ID: 101, Title: "Kid A", NumberOfTracks: 10, AudioQuality: "LOSSLESS"},
+			{ID: 102, Title: "Amnesiac", NumberOfTracks: 11, AudioQuality: "LOSSLESS"},
+			{ID: 103, Title: "In Rainbows", NumberOfTracks: 10, AudioQuality: "LOSSLESS"},
+		},
+	}
+	store := &mockStore{
+		holdings: map[int64]db.AlbumHolding{
+			101: {TidalAlbumID: 101, LibraryAlbumID: 1, TrackCount: 10, MinBitDepth: 16, MinSampleRate: 44100},
+			102: {TidalAlbumID: 102, LibraryAlbumID: 2, TrackCount: 7, MinBitDepth: 16, MinSampleRate: 44100},
+			103: {TidalAlbumID: 103, LibraryAlbumID: 3, TrackCount: 10, LossyTracks: 10},
+		},
+	}
+	h := newTestHandler(t, store, hf, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	req := httptest.NewRequest(http.MethodGet, "/artist/1", nil)
+	req = chiContextID(req, "1")
+	rec := httptest.NewRecorder()
+
+	h.Artist(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	want := "100;101(owned);102(partial);103(upgradable);"
+	if body := rec.Body.String(); !strings.Contains(body, want) {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+}
+
+func TestStartDownload_CompleteMissingTracks(t *testing.T) {
+	detail := &hifi.AlbumDetail{
+		Album: hifi.Album{ID: 100, Title: "OK Computer", NumberOfTracks: 3, Artist: hifi.ArtistRef{ID: 1, Name: "Radiohead"}},
+		Tracks: []hifi.Track{
+			{ID: 1, Title: "Airbag", TrackNumber: 1},
+			{ID: 2, Title: "Paranoid Android", TrackNumber: 2},
+			{ID: 3, Title: "Subterranean Homesick Alien", TrackNumber: 3},
+		},
+	}
+
+	postComplete := func(t *testing.T, store *mockStore, dl *mockDownloader) *httptest.ResponseRecorder {
+		t.Helper()
+		h := newTestHandler(t, store, &mockHiFi{albumDetail: detail}, &mockScanner{}, dl, &mockDiscovery{})
+
+		form := url.Values{}
+		form.Set("album_id", "100")
+		form.Set("mode", "complete")
+
+		req := httptest.NewRequest(http.MethodPost, "/download", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+
+		h.StartDownload(rec, req)
+		return rec
+	}
+
+	t.Run("downloads missing tracks into library folder", func(t *testing.T) {
+		dl := &mockDownloader{}
+		store := &mockStore{
+			holdings: map[int64]db.AlbumHolding{
+				100: {TidalAlbumID: 100, LibraryAlbumID: 7, Path: "/music/Radiohead/1997 - OK Computer", TrackCount: 1},
+			},
+			libTracks: map[int64][]db.LibraryTrack{
+				7: {{AlbumID: 7, Filename: "01 - Airbag.flac", TrackNumber: 1}},
+			},
+		}
+
+		rec := postComplete(t, store, dl)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if dl.lastReq.OutputDir != "/music/Radiohead/1997 - OK Computer" {
+			t.Errorf("OutputDir = %q, want library folder", dl.lastReq.OutputDir)
+		}
+		if got := dl.lastReq.TrackIDs; len(got) != 2 || got[0] != 2 || got[1] != 3 {
+			t.Errorf("TrackIDs = %v, want [2 3]", got)
+		}
+	})
+
+	t.Run("no library copy returns 409", func(t *testing.T) {
+		dl := &mockDownloader{}
+		rec := postComplete(t, &mockStore{}, dl)
+
+		if rec.Code != http.StatusConflict {
+			t.Fatalf("expected status 409, got %d", rec.Code)
+		}
+		if dl.called {
+			t.Error("DownloadAsync should not be called")
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+	"github.com/MattHbrook/Crescendo/internal/playlist"
+)
+
+// Playlists renders the Tidal playlists kept in sync with M3U files in the
+// library, with a form to add another.
+func (h *Handler) Playlists(w http.ResponseWriter, r *http.Request) {
+	playlists, err := h.store.ListPlaylists(r.Context())
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load playlists")
+		return
+	}
+
+	h.render(w, "playlists", map[string]any{
+		"Title":     "Playlists",
+		"Playlists": playlists,
+		"Running":   h.playlists.Running(),
+	})
+}
+
+// AddPlaylist adds the Tidal playlist given by ID or URL and starts syncing
+// it in the background, then redirects back to the playlists page. If
+// another sync is running the playlist is added and synced by the next
+// refresh.
+func (h *Handler) AddPlaylist(w http.ResponseWriter, r *http.Request) {
+	id, err := hifi.ParsePlaylistID(r.FormValue("playlist"))
+	if err != nil {
+		h.renderError(w, http.StatusBadRequest, "Enter a Tidal playlist URL or ID")
+		return
+	}
+	if err := h.playlists.Start(r.Context(), id); err != nil && !errors.Is(err, playlist.ErrSyncInProgress) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/playlists", http.StatusSeeOther)
+}
+
+// RefreshPlaylists starts a background refresh of every playlist that has
+// changed on Tidal and redirects back to the playlists page. A sync already
+// in progress is not an error; the page shows it as running.
+func (h *Handler) RefreshPlaylists(w http.ResponseWriter, r *http.Request) {
+	if err := h.playlists.StartRefresh(r.Context()); err != nil && !errors.Is(err, playlist.ErrSyncInProgress) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/playlists", http.StatusSeeOther)
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/playlist"
+)
+
+func TestPlaylists(t *testing.T) {
+	synced := "2024-05-02 09:00:00"
+	store := &mockStore{playlists: []db.Playlist{
+		{TidalUUID: "36ea71a8-445e-41a4-82ab-6628c581535d", Title: "Road Trip", TotalTracks: 12, LocalTracks: 11, SyncedAt: &synced},
+	}}
+	h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	rec := httptest.NewRecorder()
+	h.Playlists(rec, httptest.NewRequest(http.MethodGet, "/playlists", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "Road Trip:11/12;") {
+		t.Errorf("body = %q, want Road Trip with 11 of 12 tracks", body)
+	}
+}
+
+func TestAddPlaylist(t *testing.T) {
+	post := func(t *testing.T, syncer *mockPlaylists, value string) *httptest.ResponseRecorder {
+		t.Helper()
+		h := newTestHandler(t, &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+		h.playlists = syncer
+
+		form := url.Values{}
+		form.Set("playlist", value)
+		req := httptest.NewRequest(http.MethodPost, "/playlists", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+
+		h.AddPlaylist(rec, req)
+		return rec
+	}
+
+	t.Run("starts a sync of the playlist in the URL", func(t *testing.T) {
+		syncer := &mockPlaylists{}
+		rec := post(t, syncer, "https://tidal.com/browse/playlist/36ea71a8-445e-41a4-82ab-6628c581535d")
+
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d", rec.Code)
+		}
+		if len(syncer.started) != 1 || syncer.started[0] != "36ea71a8-445e-41a4-82ab-6628c581535d" {
+			t.Errorf("started %v, want the playlist UUID", syncer.started)
+		}
+	})
+
+	t.Run("sync in progress still redirects", func(t *testing.T) {
+		rec := post(t, &mockPlaylists{err: playlist.ErrSyncInProgress}, "36ea71a8-445e-41a4-82ab-6628c581535d")
+
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d", rec.Code)
+		}
+	})
+
+	t.Run("invalid playlist returns 400", func(t *testing.T) {
+		syncer := &mockPlaylists{}
+		rec := post(t, syncer, "https://tidal.com/browse/album/77640617")
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got %d", rec.Code)
+		}
+		if syncer.started != nil {
+			t.Error("no sync should be started")
+		}
+	})
+}
+
+func TestRefreshPlaylists(t *testing.T) {
+	h := newTestHandler(t, &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+	syncer := &mockPlaylists{}
+	h.playlists = syncer
+
+	rec := httptest.NewRecorder()
+	h.RefreshPlaylists(rec, httptest.NewRequest(http.MethodPost, "/playlists/refresh", nil))
+
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected status 303, got %d", rec.Code)
+	}
+	if !syncer.refreshed {
+		t.Error("expected a refresh to be started")
+	}
+}
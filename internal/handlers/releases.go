@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/MattHbrook/Crescendo/internal/releases"
+)
+
+// Releases renders the new releases found for library and watchlist
+// artists, latest first, with whether each is already held.
+func (h *Handler) Releases(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	fresh, err := h.store.ListNewReleases(ctx, 100)
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load new releases")
+		return
+	}
+	lastCheck, err := h.store.LastReleaseCheck(ctx)
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load new releases")
+		return
+	}
+
+	ids := make([]int64, 0, len(fresh))
+	for _, rel := range fresh {
+		ids = append(ids, rel.TidalAlbumID)
+	}
+	holdings, err := h.store.GetAlbumHoldings(ctx, ids)
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load album ownership")
+		return
+	}
+
+	held := make(map[int64]bool, len(holdings))
+	for id := range holdings {
+		held[id] = true
+	}
+
+	h.render(w, "releases", map[string]any{
+		"Title":     "New Releases",
+		"Releases":  fresh,
+		"Held":      held,
+		"LastCheck": lastCheck,
+		"Running":   h.releases.Running(),
+	})
+}
+
+// StartReleaseCheck starts a background check for new releases and
+// redirects back to the new releases page. A check already in progress is
+// not an error; the page shows it as running.
+func (h *Handler) StartReleaseCheck(w http.ResponseWriter, r *http.Request) {
+	if err := h.releases.Start(r.Context()); err != nil && !errors.Is(err, releases.ErrCheckInProgress) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/releases", http.StatusSeeOther)
+}
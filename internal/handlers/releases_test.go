@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/releases"
+)
+
+func TestReleases(t *testing.T) {
+	store := &mockStore{
+		releases: []db.Release{
+			{TidalAlbumID: 200, ArtistTidalID: 20, ArtistName: "Beth Gibbons", Title: "Lives Outgrown", IsNew: true},
+			{TidalAlbumID: 103, ArtistTidalID: 10, ArtistName: "Portishead", Title: "Fourth", IsNew: true},
+		},
+		holdings: map[int64]db.AlbumHolding{103: {TidalAlbumID: 103}},
+	}
+	h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	rec := httptest.NewRecorder()
+	h.Releases(rec, httptest.NewRequest(http.MethodGet, "/releases", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if body, want := rec.Body.String(), "Lives Outgrown;Fourth(held);"; !strings.Contains(body, want) {
+		t.Errorf("body = %q, want it to contain %q", body, want)
+	}
+}
+
+func TestStartReleaseCheck(t *testing.T) {
+	for _, startErr := range []error{nil, releases.ErrCheckInProgress} {
+		monitor := &mockReleases{err: startErr}
+		h := newTestHandler(t, &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+		h.releases = monitor
+
+		rec := httptest.NewRecorder()
+		h.StartReleaseCheck(rec, httptest.NewRequest(http.MethodPost, "/releases", nil))
+
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("Start error %v: expected status 303, got %d", startErr, rec.Code)
+		}
+		if !monitor.started {
+			t.Errorf("Start error %v: check not started", startErr)
+		}
+	}
+}
+
+func TestLibraryArtist_WatchRule(t *testing.T) {
+	store := localRadiohead(t, t.TempDir())
+	tidalID := int64(100)
+	store.artists[0].TidalID = &tidalID
+	store.rules = map[int64]db.WatchRule{100: {ArtistTidalID: 100, AutoDownload: true}}
+	h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	rec := httptest.NewRecorder()
+	h.LibraryArtist(rec, httptest.NewRequest(http.MethodGet, "/library/artist?folder=Radiohead", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "|auto=true") {
+		t.Errorf("body = %q, want auto-download on", body)
+	}
+}
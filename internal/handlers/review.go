@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+)
+
+// Review renders the queue of artist folders whose Tidal match was too
+// ambiguous to accept automatically, with the scored candidates for each.
+func (h *Handler) Review(w http.ResponseWriter, r *http.Request) {
+	reviews, err := h.store.ListArtistReviews(r.Context())
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load review queue")
+		return
+	}
+
+	h.render(w, "review", map[string]any{
+		"Title":   "Review Matches",
+		"Reviews": reviews,
+	})
+}
+
+// ResolveReview accepts one candidate for a folder (form fields "folder" and
+// "tidal_id") or, when "action" is "dismiss", marks the folder as having no
+// Tidal equivalent so later scans don't queue it again; that can be undone
+// from the artist mapping page. HTMX requests receive an empty body so the
+// resolved entry is swapped out; others are redirected back to the queue.
+func (h *Handler) ResolveReview(w http.ResponseWriter, r *http.Request) {
+	folder := r.FormValue("folder")
+	if folder == "" {
+		http.Error(w, "folder required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if r.FormValue("action") == "dismiss" {
+		if err := h.store.IgnoreArtist(ctx, folder); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		tidalID, err := strconv.ParseInt(r.FormValue("tidal_id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid Tidal ID", http.StatusBadRequest)
+			return
+		}
+		err = h.store.AcceptArtistCandidate(ctx, folder, tidalID)
+		if errors.Is(err, db.ErrCandidateNotFound) {
+			http.Error(w, "Candidate not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	http.Redirect(w, r, "/library/review", http.StatusSeeOther)
+}
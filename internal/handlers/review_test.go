@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+)
+
+func reviewStore() *mockStore {
+	return &mockStore{
+		reviews: []db.ArtistReview{
+			{FolderName: "Genesis", Candidates: []db.ArtistCandidate{
+				{TidalID: 1, TidalName: "Genesis", Score: 0.88},
+				{TidalID: 2, TidalName: "Genesis", Score: 0.82},
+			}},
+		},
+	}
+}
+
+func postReview(h *Handler, form url.Values, htmx bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/library/review", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if htmx {
+		req.Header.Set("HX-Request", "true")
+	}
+	rec := httptest.NewRecorder()
+	h.ResolveReview(rec, req)
+	return rec
+}
+
+func TestReview(t *testing.T) {
+	h := newTestHandler(t, reviewStore(), &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	req := httptest.NewRequest(http.MethodGet, "/library/review", nil)
+	rec := httptest.NewRecorder()
+
+	h.Review(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "Genesis:2;") {
+		t.Errorf("expected Genesis with 2 candidates, got %q", rec.Body.String())
+	}
+}
+
+func TestResolveReview(t *testing.T) {
+	t.Run("accept redirects", func(t *testing.T) {
+		store := reviewStore()
+		h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		rec := postReview(h, url.Values{"folder": {"Genesis"}, "tidal_id": {"2"}}, false)
+
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d", rec.Code)
+		}
+		if store.accepted["Genesis"] != 2 {
+			t.Errorf("accepted = %v, want Genesis -> 2", store.accepted)
+		}
+	})
+
+	t.Run("accept via HTMX returns empty body", func(t *testing.T) {
+		store := reviewStore()
+		h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		rec := postReview(h, url.Values{"folder": {"Genesis"}, "tidal_id": {"1"}}, true)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+		if rec.Body.Len() != 0 {
+			t.Errorf("expected empty body, got %q", rec.Body.String())
+		}
+	})
+
+	t.Run("unknown candidate returns 404", func(t *testing.T) {
+		h := newTestHandler(t, reviewStore(), &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		rec := postReview(h, url.Values{"folder": {"Genesis"}, "tidal_id": {"99"}}, false)
+
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("expected status 404, got %d", rec.Code)
+		}
+	})
+
+	t.Run("dismiss ignores the folder", func(t *testing.T) {
+		store := reviewStore()
+		h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		rec := postReview(h, url.Values{"folder": {"Genesis"}, "action": {"dismiss"}}, false)
+
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d", rec.Code)
+		}
+		if len(store.ignored) != 1 || store.ignored[0] != "Genesis" {
+			t.Errorf("ignored = %v, want [Genesis]", store.ignored)
+		}
+	})
+
+	t.Run("bad input returns 400", func(t *testing.T) {
+		h := newTestHandler(t, reviewStore(), &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		if rec := postReview(h, url.Values{"tidal_id": {"1"}}, false); rec.Code != http.StatusBadRequest {
+			t.Errorf("missing folder: expected status 400, got %d", rec.Code)
+		}
+		if rec := postReview(h, url.Values{"folder": {"Genesis"}, "tidal_id": {"abc"}}, false); rec.Code != http.StatusBadRequest {
+			t.Errorf("bad tidal_id: expected status 400, got %d", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+)
+
+// statsTopArtists is how many artists the statistics rank by album count.
+const statsTopArtists = 10
+
+// Stats renders the library statistics dashboard.
+func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
+	st, err := h.store.GetLibraryStats(r.Context(), statsTopArtists)
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load library statistics")
+		return
+	}
+
+	// Bars are scaled against the busiest month and the artist with the most
+	// albums.
+	maxMonthly, maxArtist := 0, 0
+	for _, m := range st.Downloads {
+		maxMonthly = max(maxMonthly, m.Albums)
+	}
+	for _, a := range st.TopArtists {
+		maxArtist = max(maxArtist, a.Albums)
+	}
+
+	h.render(w, "stats", map[string]any{
+		"Title":      "Library Statistics",
+		"Stats":      st,
+		"MaxMonthly": maxMonthly,
+		"MaxArtist":  maxArtist,
+	})
+}
+
+// StatsJSON returns the library statistics as JSON.
+func (h *Handler) StatsJSON(w http.ResponseWriter, r *http.Request) {
+	st, err := h.store.GetLibraryStats(r.Context(), statsTopArtists)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(statsBody(st))
+}
+
+// statsBody converts library statistics to the JSON response shape. Lists are
+// always present, empty rather than null.
+func statsBody(st *db.LibraryStats) map[string]any {
+	formats := make([]map[string]any, 0, len(st.Formats))
+	for _, f := range st.Formats {
+		formats = append(formats, map[string]any{"format": f.Format, "tracks": f.Tracks})
+	}
+	resolutions := make([]map[string]any, 0, len(st.Resolutions))
+	for _, r := range st.Resolutions {
+		resolutions = append(resolutions, map[string]any{
+			"bit_depth":   r.BitDepth,
+			"sample_rate": r.SampleRate,
+			"tracks":      r.Tracks,
+		})
+	}
+	downloads := make([]map[string]any, 0, len(st.Downloads))
+	for _, m := range st.Downloads {
+		downloads = append(downloads, map[string]any{"month": m.Month, "albums": m.Albums, "tracks": m.Tracks})
+	}
+	artists := make([]map[string]any, 0, len(st.TopArtists))
+	for _, a := range st.TopArtists {
+		artists = append(artists, map[string]any{"artist": a.ArtistFolder, "albums": a.Albums, "tracks": a.Tracks})
+	}
+
+	return map[string]any{
+		"artists":             st.Artists,
+		"albums":              st.Albums,
+		"tracks":              st.Tracks,
+		"duration_seconds":    st.Duration,
+		"size_bytes":          st.SizeBytes,
+		"formats":             formats,
+		"resolutions":         resolutions,
+		"downloads_per_month": downloads,
+		"top_artists":         artists,
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+)
+
+func testStats() *db.LibraryStats {
+	return &db.LibraryStats{
+		Artists:     2,
+		Albums:      3,
+		Tracks:      30,
+		Duration:    7200,
+		SizeBytes:   2_500_000_000,
+		Formats:     []db.FormatCount{{Format: "flac", Tracks: 20}, {Format: "mp3", Tracks: 10}},
+		Resolutions: []db.ResolutionCount{{BitDepth: 16, SampleRate: 44100, Tracks: 20}, {SampleRate: 44100, Tracks: 10}},
+		Downloads:   []db.MonthlyDownloads{{Month: "2026-08", Albums: 1, Tracks: 10}, {Month: "2026-09", Albums: 4, Tracks: 40}},
+		TopArtists:  []db.ArtistCount{{ArtistFolder: "Radiohead", Albums: 2, Tracks: 20}, {ArtistFolder: "Portishead", Albums: 1, Tracks: 10}},
+	}
+}
+
+func TestStats(t *testing.T) {
+	h := newTestHandler(t, &mockStore{stats: testStats()}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	rec := httptest.NewRecorder()
+	h.Stats(rec, httptest.NewRequest(http.MethodGet, "/library/stats", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if body, want := rec.Body.String(), "3 albums|4|2|Radiohead;Portishead;"; !strings.Contains(body, want) {
+		t.Errorf("body = %q, want it to contain %q", body, want)
+	}
+}
+
+func TestStats_StoreError(t *testing.T) {
+	h := newTestHandler(t, &mockStore{errList: errors.New("db down")}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	rec := httptest.NewRecorder()
+	h.Stats(rec, httptest.NewRequest(http.MethodGet, "/library/stats", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Stats status = %d, want 500", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.StatsJSON(rec, httptest.NewRequest(http.MethodGet, "/library/stats.json", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("StatsJSON status = %d, want 500", rec.Code)
+	}
+}
+
+func TestStatsJSON(t *testing.T) {
+	h := newTestHandler(t, &mockStore{stats: testStats()}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	rec := httptest.NewRecorder()
+	h.StatsJSON(rec, httptest.NewRequest(http.MethodGet, "/library/stats.json", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	var body struct {
+		Albums      int     `json:"albums"`
+		Duration    float64 `json:"duration_seconds"`
+		SizeBytes   int64   `json:"size_bytes"`
+		Resolutions []struct {
+			BitDepth   int `json:"bit_depth"`
+			SampleRate int `json:"sample_rate"`
+			Tracks     int `json:"tracks"`
+		} `json:"resolutions"`
+		Downloads []struct {
+			Month  string `json:"month"`
+			Albums int    `json:"albums"`
+		} `json:"downloads_per_month"`
+		TopArtists []struct {
+			Artist string `json:"artist"`
+			Albums int    `json:"albums"`
+		} `json:"top_artists"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Albums != 3 || body.Duration != 7200 || body.SizeBytes != 2_500_000_000 {
+		t.Errorf("totals = %+v", body)
+	}
+	if len(body.Resolutions) != 2 || body.Resolutions[0].BitDepth != 16 || body.Resolutions[1].BitDepth != 0 {
+		t.Errorf("resolutions = %+v", body.Resolutions)
+	}
+	if len(body.Downloads) != 2 || body.Downloads[1].Month != "2026-09" || body.Downloads[1].Albums != 4 {
+		t.Errorf("downloads = %+v", body.Downloads)
+	}
+	if len(body.TopArtists) != 2 || body.TopArtists[0].Artist != "Radiohead" {
+		t.Errorf("top artists = %+v", body.TopArtists)
+	}
+}
+
+func TestStatsJSON_EmptyListsNotNull(t *testing.T) {
+	h := newTestHandler(t, &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	rec := httptest.NewRecorder()
+	h.StatsJSON(rec, httptest.NewRequest(http.MethodGet, "/library/stats.json", nil))
+
+	for _, key := range []string{`"formats":[]`, `"resolutions":[]`, `"downloads_per_month":[]`, `"top_artists":[]`} {
+		if !strings.Contains(rec.Body.String(), key) {
+			t.Errorf("body %s missing %s", rec.Body.String(), key)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/MattHbrook/Crescendo/internal/downloader"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+	"github.com/MattHbrook/Crescendo/internal/library"
+)
+
+// Upgrades renders the report of linked library albums that Tidal offers in
+// better quality than the copy on disk.
+func (h *Handler) Upgrades(w http.ResponseWriter, r *http.Request) {
+	albums, err := h.store.ListLinkedAlbumQualities(r.Context())
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load upgrade report")
+		return
+	}
+
+	h.render(w, "upgrades", map[string]any{
+		"Title":    "Quality Upgrades",
+		"Upgrades": library.Upgrades(albums),
+	})
+}
+
+// QueueUpgrades queues replacement downloads for the selected upgrade
+// candidates (album_id, repeatable) and redirects to the downloads page. Each
+// album is fetched in the quality Tidal offers and swapped in over the
+// existing folder once complete, with the old copy moved to the trash.
+func (h *Handler) QueueUpgrades(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	selected := make(map[int64]bool, len(r.Form["album_id"]))
+	for _, v := range r.Form["album_id"] {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid album ID", http.StatusBadRequest)
+			return
+		}
+		selected[id] = true
+	}
+	if len(selected) == 0 {
+		http.Error(w, "No albums selected", http.StatusBadRequest)
+		return
+	}
+
+	albums, err := h.store.ListLinkedAlbumQualities(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var reqs []downloader.Request
+	for _, u := range library.Upgrades(albums) {
+		if !selected[u.Album.Holding.LibraryAlbumID] {
+			continue
+		}
+		local, err := h.store.ListTracksForAlbum(ctx, u.Album.Holding.LibraryAlbumID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		reqs = append(reqs, downloader.Request{
+			TidalAlbumID: u.Album.Holding.TidalAlbumID,
+			Quality:      u.UpgradeQuality,
+			Replace:      u.Album.Holding.Path,
+			Naming:       trackNaming(local),
+		})
+	}
+	if len(reqs) == 0 {
+		http.Error(w, "None of the selected albums can be upgraded", http.StatusConflict)
+		return
+	}
+
+	for _, req := range reqs {
+		h.downloader.DownloadAsync(ctx, req)
+	}
+	http.Redirect(w, r, "/downloads", http.StatusSeeOther)
+}
+
+// upgradeRequest builds a download request that replaces the copy we hold of
+// an album with one in the given quality, named like the tracks already
+// there.
+func (h *Handler) upgradeRequest(ctx context.Context, detail *hifi.AlbumDetail, quality string) (downloader.Request, int, error) {
+	holdings, err := h.store.GetAlbumHoldings(ctx, []int64{detail.ID})
+	if err != nil {
+		return downloader.Request{}, http.StatusInternalServerError, err
+	}
+	holding, ok := holdings[detail.ID]
+	if !ok || holding.Path == "" {
+		return downloader.Request{}, http.StatusConflict, fmt.Errorf("no copy of %q to upgrade", detail.Title)
+	}
+
+	req := downloader.Request{
+		TidalAlbumID: detail.ID,
+		Quality:      quality,
+		Replace:      holding.Path,
+	}
+	if holding.LibraryAlbumID != 0 {
+		local, err := h.store.ListTracksForAlbum(ctx, holding.LibraryAlbumID)
+		if err != nil {
+			return downloader.Request{}, http.StatusInternalServerError, err
+		}
+		req.Naming = trackNaming(local)
+	}
+	return req, http.StatusOK, nil
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+	"github.com/MattHbrook/Crescendo/internal/library"
+)
+
+func upgradableRadiohead() *mockStore {
+	return &mockStore{
+		qualities: []db.LinkedAlbumQuality{
+			{
+				ArtistFolder: "Radiohead", AlbumFolder: "Kid A", TidalQuality: library.QualityHiRes,
+				Holding: db.AlbumHolding{TidalAlbumID: 200, LibraryAlbumID: 8, Path: "/music/Radiohead/Kid A", MinBitDepth: 24, MinSampleRate: 96000},
+			},
+			{
+				ArtistFolder: "Radiohead", AlbumFolder: "OK Computer", TidalQuality: library.QualityHiRes,
+				Holding: db.AlbumHolding{TidalAlbumID: 100, LibraryAlbumID: 7, Path: "/music/Radiohead/OK Computer", MinBitDepth: 16, MinSampleRate: 44100},
+			},
+			{
+				ArtistFolder: "Radiohead", AlbumFolder: "Pablo Honey", TidalQuality: library.QualityLossless,
+				Holding: db.AlbumHolding{TidalAlbumID: 300, LibraryAlbumID: 9, Path: "/music/Radiohead/Pablo Honey", MinSampleRate: 44100, LossyTracks: 12},
+			},
+		},
+		libTracks: map[int64][]db.LibraryTrack{
+			7: {{AlbumID: 7, Filename: "1. Airbag.flac", TrackNumber: 1}},
+		},
+	}
+}
+
+func TestUpgrades(t *testing.T) {
+	h := newTestHandler(t, upgradableRadiohead(), &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	req := httptest.NewRequest(http.MethodGet, "/library/upgrades", nil)
+	rec := httptest.NewRecorder()
+	h.Upgrades(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	want := "OK Computer:LOSSLESS>HI_RES_LOSSLESS;Pablo Honey:LOSSY>LOSSLESS;"
+	if body := rec.Body.String(); !strings.Contains(body, want) {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+}
+
+func TestQueueUpgrades(t *testing.T) {
+	post := func(t *testing.T, dl *mockDownloader, ids ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		h := newTestHandler(t, upgradableRadiohead(), &mockHiFi{}, &mockScanner{}, dl, &mockDiscovery{})
+
+		form := url.Values{"album_id": ids}
+		req := httptest.NewRequest(http.MethodPost, "/library/upgrades", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.QueueUpgrades(rec, req)
+		return rec
+	}
+
+	t.Run("queues replacement downloads for selected candidates", func(t *testing.T) {
+		dl := &mockDownloader{}
+		rec := post(t, dl, "7", "8")
+
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if len(dl.reqs) != 1 {
+			t.Fatalf("queued %d downloads, want 1 (Kid A is already hi-res): %+v", len(dl.reqs), dl.reqs)
+		}
+		got := dl.reqs[0]
+		if got.TidalAlbumID != 100 || got.Quality != library.QualityHiRes || got.Replace != "/music/Radiohead/OK Computer" {
+			t.Errorf("request = %+v, want album 100 in hi-res replacing the library folder", got)
+		}
+		if got.Naming != (library.TrackNaming{Width: 1, Separator: ". "}) {
+			t.Errorf("Naming = %+v, want the existing \"1. \" pattern", got.Naming)
+		}
+	})
+
+	t.Run("nothing upgradable returns 409", func(t *testing.T) {
+		dl := &mockDownloader{}
+		if rec := post(t, dl, "8"); rec.Code != http.StatusConflict {
+			t.Fatalf("expected status 409, got %d", rec.Code)
+		}
+		if dl.called {
+			t.Error("DownloadAsync should not be called")
+		}
+	})
+
+	t.Run("invalid album ID returns 400", func(t *testing.T) {
+		if rec := post(t, &mockDownloader{}, "abc"); rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got %d", rec.Code)
+		}
+	})
+}
+
+func TestStartDownload_Upgrade(t *testing.T) {
+	detail := &hifi.AlbumDetail{
+		Album: hifi.Album{ID: 100, Title: "OK Computer", Artist: hifi.ArtistRef{ID: 1, Name: "Radiohead"}},
+	}
+	store := &mockStore{
+		holdings: map[int64]db.AlbumHolding{
+			100: {TidalAlbumID: 100, LibraryAlbumID: 7, Path: "/music/Radiohead/OK Computer", TrackCount: 12},
+		},
+	}
+	dl := &mockDownloader{}
+	h := newTestHandler(t, store, &mockHiFi{albumDetail: detail}, &mockScanner{}, dl, &mockDiscovery{})
+
+	form := url.Values{"album_id": {"100"}, "quality": {library.QualityHiRes}, "mode": {"upgrade"}}
+	req := httptest.NewRequest(http.MethodPost, "/download", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.StartDownload(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if dl.lastReq.Replace != "/music/Radiohead/OK Computer" || dl.lastReq.Quality != library.QualityHiRes {
+		t.Errorf("request = %+v, want hi-res replacement of the library folder", dl.lastReq)
+	}
+}
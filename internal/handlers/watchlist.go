@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"cmp"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+)
+
+// Watchlist renders the artists whose new releases are monitored by rule,
+// library artists among them, ordered by name.
+func (h *Handler) Watchlist(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.store.ListWatchRules(r.Context())
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load watchlist")
+		return
+	}
+
+	list := make([]db.WatchRule, 0, len(rules))
+	for _, rule := range rules {
+		list = append(list, rule)
+	}
+	slices.SortFunc(list, func(a, b db.WatchRule) int {
+		return cmp.Or(cmp.Compare(strings.ToLower(a.ArtistName), strings.ToLower(b.ArtistName)), cmp.Compare(a.ArtistTidalID, b.ArtistTidalID))
+	})
+
+	h.render(w, "watchlist", map[string]any{
+		"Title": "Watchlist",
+		"Rules": list,
+	})
+}
+
+// EditWatchRule renders the watch rule editor for the Tidal artist given by
+// the artist_id and artist_name query parameters, filled in from the
+// artist's rule if they are on the watchlist.
+func (h *Handler) EditWatchRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.URL.Query().Get("artist_id"), 10, 64)
+	if err != nil || id <= 0 {
+		h.renderError(w, http.StatusBadRequest, "Invalid artist ID")
+		return
+	}
+
+	rules, err := h.store.ListWatchRules(r.Context())
+	if err != nil {
+		h.renderError(w, http.StatusInternalServerError, "Failed to load watchlist")
+		return
+	}
+	rule, watched := rules[id]
+	if !watched {
+		rule = db.WatchRule{ArtistTidalID: id, ArtistName: r.URL.Query().Get("artist_name")}
+	}
+
+	h.render(w, "watchlist_rule", map[string]any{
+		"Title":   "Watch " + rule.ArtistName,
+		"Rule":    rule,
+		"Watched": watched,
+	})
+}
+
+// UpdateWatchRule adds the artist in the form to the watchlist, or updates
+// their rule, then redirects to the local page given as next, or the
+// watchlist.
+func (h *Handler) UpdateWatchRule(w http.ResponseWriter, r *http.Request) {
+	rule, err := watchRuleFromForm(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.store.SetWatchRule(r.Context(), rule); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	next := r.FormValue("next")
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") {
+		next = "/watchlist"
+	}
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+// RemoveWatchRule takes an artist off the watchlist and redirects back to
+// it. Library artists are still monitored, without auto-download.
+func (h *Handler) RemoveWatchRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.FormValue("artist_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid artist ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.store.DeleteWatchRule(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/watchlist", http.StatusSeeOther)
+}
+
+// PreviewWatchRule renders a dry run of the rule in the query parameters,
+// saved or not, over the artist's discography: which albums it would
+// download as new releases and why it would skip the others.
+func (h *Handler) PreviewWatchRule(w http.ResponseWriter, r *http.Request) {
+	rule, err := watchRuleFromForm(r)
+	if err != nil {
+		h.renderError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	decisions, err := h.releases.Preview(r.Context(), rule)
+	if err != nil {
+		status, msg := hifiFailure(err, http.StatusInternalServerError, "Failed to load artist albums")
+		h.renderError(w, status, msg)
+		return
+	}
+	downloads := 0
+	for _, d := range decisions {
+		if d.Skip == "" {
+			downloads++
+		}
+	}
+
+	h.render(w, "watchlist_preview", map[string]any{
+		"Title":     "Preview: " + rule.ArtistName,
+		"Rule":      rule,
+		"Decisions": decisions,
+		"Downloads": downloads,
+	})
+}
+
+// watchRuleFromForm reads a watch rule from the request's form or query
+// parameters. Checkboxes count as set when present.
+func watchRuleFromForm(r *http.Request) (db.WatchRule, error) {
+	id, err := strconv.ParseInt(r.FormValue("artist_id"), 10, 64)
+	if err != nil || id <= 0 {
+		return db.WatchRule{}, errors.New("Invalid artist ID")
+	}
+	quality := r.FormValue("quality")
+	if quality != "" && quality != "LOSSLESS" && quality != "HI_RES_LOSSLESS" {
+		return db.WatchRule{}, errors.New("Invalid quality")
+	}
+
+	return db.WatchRule{
+		ArtistTidalID: id,
+		ArtistName:    r.FormValue("artist_name"),
+		AutoDownload:  r.FormValue("auto_download") != "",
+		Quality:       quality,
+		SkipSingles:   r.FormValue("skip_singles") != "",
+		SkipLive:      r.FormValue("skip_live") != "",
+	}, nil
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+	"github.com/MattHbrook/Crescendo/internal/releases"
+)
+
+func TestWatchlist(t *testing.T) {
+	store := &mockStore{rules: map[int64]db.WatchRule{
+		20: {ArtistTidalID: 20, ArtistName: "portishead", AutoDownload: true},
+		10: {ArtistTidalID: 10, ArtistName: "Beth Gibbons"},
+		30: {ArtistTidalID: 30, ArtistName: "Adrian Utley", AutoDownload: true},
+	}}
+	h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	rec := httptest.NewRecorder()
+	h.Watchlist(rec, httptest.NewRequest(http.MethodGet, "/watchlist", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if body, want := rec.Body.String(), "Adrian Utley:true;Beth Gibbons:false;portishead:true;"; !strings.Contains(body, want) {
+		t.Errorf("body = %q, want it to contain %q", body, want)
+	}
+}
+
+func TestEditWatchRule(t *testing.T) {
+	store := &mockStore{rules: map[int64]db.WatchRule{
+		10: {ArtistTidalID: 10, ArtistName: "Portishead", Quality: "HI_RES_LOSSLESS"},
+	}}
+	h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	tests := []struct {
+		query string
+		code  int
+		want  string
+	}{
+		{"artist_id=10&artist_name=Ignored", http.StatusOK, "Portishead|HI_RES_LOSSLESS|true"},
+		{"artist_id=20&artist_name=Beth+Gibbons", http.StatusOK, "Beth Gibbons||false"},
+		{"artist_id=x", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.EditWatchRule(rec, httptest.NewRequest(http.MethodGet, "/watchlist/rule?"+tt.query, nil))
+
+		if rec.Code != tt.code {
+			t.Fatalf("%s: expected status %d, got %d", tt.query, tt.code, rec.Code)
+		}
+		if body := rec.Body.String(); !strings.Contains(body, tt.want) {
+			t.Errorf("%s: body = %q, want it to contain %q", tt.query, body, tt.want)
+		}
+	}
+}
+
+func TestUpdateWatchRule(t *testing.T) {
+	post := func(t *testing.T, store *mockStore, form url.Values) *httptest.ResponseRecorder {
+		t.Helper()
+		h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+		req := httptest.NewRequest(http.MethodPost, "/watchlist", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+
+		h.UpdateWatchRule(rec, req)
+		return rec
+	}
+
+	t.Run("turns on auto-download", func(t *testing.T) {
+		store := &mockStore{}
+		rec := post(t, store, url.Values{
+			"artist_id":     {"10"},
+			"artist_name":   {"Portishead"},
+			"auto_download": {"on"},
+			"quality":       {"HI_RES_LOSSLESS"},
+			"skip_singles":  {"on"},
+			"next":          {"/library/artist?folder=Portishead"},
+		})
+
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d", rec.Code)
+		}
+		if loc := rec.Header().Get("Location"); loc != "/library/artist?folder=Portishead" {
+			t.Errorf("redirect = %q, want the artist page", loc)
+		}
+		want := db.WatchRule{ArtistTidalID: 10, ArtistName: "Portishead", AutoDownload: true, Quality: "HI_RES_LOSSLESS", SkipSingles: true}
+		if got := store.rules[10]; got != want {
+			t.Errorf("rule = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("turns off auto-download", func(t *testing.T) {
+		store := &mockStore{rules: map[int64]db.WatchRule{10: {ArtistTidalID: 10, AutoDownload: true}}}
+		rec := post(t, store, url.Values{"artist_id": {"10"}, "next": {"//example.com"}})
+
+		if loc := rec.Header().Get("Location"); loc != "/watchlist" {
+			t.Errorf("redirect = %q, want /watchlist", loc)
+		}
+		if store.rules[10].AutoDownload {
+			t.Error("auto-download still on")
+		}
+	})
+
+	for name, form := range map[string]url.Values{
+		"invalid artist":  {"artist_id": {"x"}},
+		"invalid quality": {"artist_id": {"10"}, "quality": {"MP3"}},
+	} {
+		t.Run(name+" returns 400", func(t *testing.T) {
+			store := &mockStore{}
+			rec := post(t, store, form)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected status 400, got %d", rec.Code)
+			}
+			if len(store.rules) != 0 {
+				t.Error("no rule should be set")
+			}
+		})
+	}
+}
+
+func TestRemoveWatchRule(t *testing.T) {
+	store := &mockStore{rules: map[int64]db.WatchRule{
+		10: {ArtistTidalID: 10},
+		20: {ArtistTidalID: 20},
+	}}
+	h := newTestHandler(t, store, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+
+	req := httptest.NewRequest(http.MethodPost, "/watchlist/remove", strings.NewReader("artist_id=10"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.RemoveWatchRule(rec, req)
+
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected status 303, got %d", rec.Code)
+	}
+	if _, ok := store.rules[10]; ok {
+		t.Error("rule 10 still on the watchlist")
+	}
+	if _, ok := store.rules[20]; !ok {
+		t.Error("rule 20 removed")
+	}
+}
+
+func TestPreviewWatchRule(t *testing.T) {
+	monitor := &mockReleases{decisions: []releases.Decision{
+		{Album: hifi.Album{ID: 1, Title: "Dummy"}, Skip: releases.SkipHeld, IsHeld: true},
+		{Album: hifi.Album{ID: 2, Title: "Roads"}, Skip: releases.SkipSingle},
+		{Album: hifi.Album{ID: 3, Title: "Third"}},
+	}}
+	h := newTestHandler(t, &mockStore{}, &mockHiFi{}, &mockScanner{}, &mockDownloader{}, &mockDiscovery{})
+	h.releases = monitor
+
+	rec := httptest.NewRecorder()
+	h.PreviewWatchRule(rec, httptest.NewRequest(http.MethodGet, "/watchlist/preview?artist_id=10&artist_name=Portishead&auto_download=on&skip_singles=on", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if body, want := rec.Body.String(), "Dummy:in library;Roads:single;Third:;|1"; !strings.Contains(body, want) {
+		t.Errorf("body = %q, want it to contain %q", body, want)
+	}
+	want := db.WatchRule{ArtistTidalID: 10, ArtistName: "Portishead", AutoDownload: true, SkipSingles: true}
+	if monitor.previewed != want {
+		t.Errorf("previewed rule = %+v, want %+v", monitor.previewed, want)
+	}
+}
@@ -0,0 +1,155 @@
+package hifi
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backend is a hifi-api instance. Instances in different regions can see
+// different Tidal catalogues.
+type Backend struct {
+	URL    string
+	Region string // "" if not known
+}
+
+// healthPath is requested to check that a backend is up; hifi-api answers
+// it with its version.
+const healthPath = "/"
+
+// healthTimeout bounds a health check.
+const healthTimeout = 5 * time.Second
+
+// backend is a Backend and what the client has learnt of its health.
+type backend struct {
+	Backend
+	breaker *breaker // nil to never fail fast
+
+	mu      sync.Mutex
+	checked time.Time // zero until first health-checked
+	down    bool      // the last health check failed
+	latency time.Duration
+	lastErr string
+}
+
+// BackendStatus describes a backend and how it is doing.
+type BackendStatus struct {
+	Backend
+	Breaker   string        // BreakerClosed, BreakerOpen or BreakerHalfOpen
+	Failures  int           // failed requests in a row
+	RetryAt   time.Time     // when an open breaker lets a request through again
+	CheckedAt time.Time     // zero if never health-checked
+	Down      bool          // the last health check failed
+	Latency   time.Duration // of the last health check
+	Error     string        // why the last health check failed
+}
+
+// Available reports whether requests are being let through to the backend.
+func (s BackendStatus) Available() bool {
+	return s.Breaker != BreakerOpen
+}
+
+// status returns the backend's status.
+func (b *backend) status() BackendStatus {
+	status := BackendStatus{Backend: b.Backend, Breaker: BreakerClosed}
+	if b.breaker != nil {
+		status.Breaker, status.Failures, status.RetryAt = b.breaker.status()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status.CheckedAt = b.checked
+	status.Down = b.down
+	status.Latency = b.latency
+	status.Error = b.lastErr
+	return status
+}
+
+// isDown reports whether the backend failed its last health check.
+func (b *backend) isDown() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.down
+}
+
+// candidates returns the backends to try a request on, in order: those in
+// the preferred region before the others, each as configured, and any that
+// failed their last health check after the rest, as a last resort.
+func (c *Client) candidates() []*backend {
+	rank := func(b *backend) int {
+		r := 0
+		if b.isDown() {
+			r += 2
+		}
+		if c.region != "" && !strings.EqualFold(b.Region, c.region) {
+			r++
+		}
+		return r
+	}
+
+	ordered := slices.Clone(c.backends)
+	slices.SortStableFunc(ordered, func(a, b *backend) int {
+		return cmp.Compare(rank(a), rank(b))
+	})
+	return ordered
+}
+
+// CheckHealth asks every backend whether it is up, all at once, and
+// records the answers for requests to be routed by.
+func (c *Client) CheckHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, b := range c.backends {
+		wg.Go(func() { c.checkBackend(ctx, b) })
+	}
+	wg.Wait()
+}
+
+// checkBackend health-checks a backend, logging when it goes down or
+// comes back up.
+func (c *Client) checkBackend(ctx context.Context, b *backend) {
+	ctx, cancel := context.WithTimeout(ctx, healthTimeout)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.do(ctx, b, healthPath, nil)
+	latency := time.Since(start)
+
+	b.mu.Lock()
+	wasDown := b.down
+	b.checked = time.Now()
+	b.down = err != nil
+	b.latency = latency
+	b.lastErr = ""
+	if err != nil {
+		b.lastErr = err.Error()
+	}
+	b.mu.Unlock()
+
+	switch {
+	case err != nil && !wasDown:
+		c.logger.Printf("backend %s is down: %v", b.URL, err)
+	case err == nil && wasDown:
+		c.logger.Printf("backend %s is back up", b.URL)
+	}
+}
+
+// Run health-checks the backends every interval until ctx is cancelled,
+// starting straight away.
+func (c *Client) Run(ctx context.Context, interval time.Duration) {
+	c.CheckHealth(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.CheckHealth(ctx)
+		}
+	}
+}
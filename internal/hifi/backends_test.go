@@ -0,0 +1,151 @@
+package hifi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testBackend is an httptest.Server answering every request with status,
+// and with an empty album when that is 200, counting the requests it serves.
+type testBackend struct {
+	*httptest.Server
+	status   atomic.Int64
+	requests atomic.Int64
+}
+
+func newTestBackend(t *testing.T, status int) *testBackend {
+	t.Helper()
+	b := &testBackend{}
+	b.status.Store(int64(status))
+	b.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		b.requests.Add(1)
+		status := int(b.status.Load())
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			_, _ = w.Write([]byte(`{"data": {"id": 1, "title": "Dummy", "items": []}}`))
+		}
+	}))
+	t.Cleanup(b.Close)
+	return b
+}
+
+func TestClient_failover(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("passes failed requests on to the next backend", func(t *testing.T) {
+		down, up := newTestBackend(t, http.StatusBadGateway), newTestBackend(t, http.StatusOK)
+		c := NewFailoverClient([]Backend{{URL: down.URL}, {URL: up.URL}})
+
+		if _, err := c.GetAlbum(ctx, 1); err != nil {
+			t.Fatalf("GetAlbum: %v", err)
+		}
+		if down.requests.Load() != 1 || up.requests.Load() != 1 {
+			t.Errorf("requests = %d and %d, want 1 each", down.requests.Load(), up.requests.Load())
+		}
+		if n := c.Status().Failovers; n != 1 {
+			t.Errorf("Failovers = %d, want 1", n)
+		}
+	})
+
+	t.Run("does not fail over an answer", func(t *testing.T) {
+		first, second := newTestBackend(t, http.StatusNotFound), newTestBackend(t, http.StatusOK)
+		c := NewFailoverClient([]Backend{{URL: first.URL}, {URL: second.URL}})
+
+		if _, err := c.GetAlbum(ctx, 1); err == nil {
+			t.Fatal("expected the 404, got nil")
+		}
+		if n := second.requests.Load(); n != 0 {
+			t.Errorf("second backend requests = %d, want 0", n)
+		}
+	})
+
+	t.Run("tries the preferred region first", func(t *testing.T) {
+		us, gb := newTestBackend(t, http.StatusOK), newTestBackend(t, http.StatusOK)
+		c := NewFailoverClient([]Backend{{URL: us.URL, Region: "US"}, {URL: gb.URL, Region: "GB"}})
+		c.SetRegion("gb")
+
+		if _, err := c.GetAlbum(ctx, 1); err != nil {
+			t.Fatalf("GetAlbum: %v", err)
+		}
+		if us.requests.Load() != 0 || gb.requests.Load() != 1 {
+			t.Errorf("requests = %d (US) and %d (GB), want 0 and 1", us.requests.Load(), gb.requests.Load())
+		}
+	})
+
+	t.Run("passes over backends with open breakers", func(t *testing.T) {
+		first, second := newTestBackend(t, http.StatusInternalServerError), newTestBackend(t, http.StatusOK)
+		c := NewFailoverClient([]Backend{{URL: first.URL}, {URL: second.URL}})
+		c.SetBreaker(1, time.Hour)
+
+		for range 3 {
+			if _, err := c.GetAlbum(ctx, 1); err != nil {
+				t.Fatalf("GetAlbum: %v", err)
+			}
+		}
+		if first.requests.Load() != 1 || second.requests.Load() != 3 {
+			t.Errorf("requests = %d and %d, want 1 and 3", first.requests.Load(), second.requests.Load())
+		}
+
+		second.status.Store(http.StatusInternalServerError)
+		_, _ = c.GetAlbum(ctx, 1)
+		if _, err := c.GetAlbum(ctx, 1); !errors.Is(err, ErrUnavailable) {
+			t.Errorf("err = %v with every breaker open, want ErrUnavailable", err)
+		}
+		if c.Status().Available() {
+			t.Error("Status().Available() = true with every breaker open")
+		}
+	})
+}
+
+func TestClient_CheckHealth(t *testing.T) {
+	ctx := context.Background()
+	first, second := newTestBackend(t, http.StatusServiceUnavailable), newTestBackend(t, http.StatusOK)
+	c := NewFailoverClient([]Backend{{URL: first.URL}, {URL: second.URL}})
+
+	c.CheckHealth(ctx)
+	status := c.Status()
+	if !status.Backends[0].Down || status.Backends[0].Error == "" || status.Backends[0].CheckedAt.IsZero() {
+		t.Errorf("first backend status = %+v, want it down with the error", status.Backends[0])
+	}
+	if status.Backends[1].Down {
+		t.Errorf("second backend status = %+v, want it up", status.Backends[1])
+	}
+
+	// The backend that is down is tried last, though it is first in line.
+	first.status.Store(http.StatusOK)
+	first.requests.Store(0)
+	if _, err := c.GetAlbum(ctx, 1); err != nil {
+		t.Fatalf("GetAlbum: %v", err)
+	}
+	if n := first.requests.Load(); n != 0 {
+		t.Errorf("requests to the backend that is down = %d, want 0", n)
+	}
+
+	c.CheckHealth(ctx)
+	if status := c.Status(); status.Backends[0].Down || status.Backends[0].Error != "" {
+		t.Errorf("first backend status = %+v, want it back up", status.Backends[0])
+	}
+}
+
+func TestStatus_Available(t *testing.T) {
+	tests := []struct {
+		name     string
+		backends []BackendStatus
+		want     bool
+	}{
+		{"no backends", nil, true},
+		{"one closed", []BackendStatus{{Breaker: BreakerOpen}, {Breaker: BreakerClosed}}, true},
+		{"one half-open", []BackendStatus{{Breaker: BreakerHalfOpen}, {Breaker: BreakerOpen}}, true},
+		{"all open", []BackendStatus{{Breaker: BreakerOpen}, {Breaker: BreakerOpen}}, false},
+	}
+	for _, tt := range tests {
+		if got := (Status{Backends: tt.backends}).Available(); got != tt.want {
+			t.Errorf("%s: Available() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
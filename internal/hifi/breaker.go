@@ -0,0 +1,117 @@
+package hifi
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrUnavailable is returned, without hifi-api being asked, while the
+// circuit breakers of all the client's backends are open after too many
+// failed requests in a row.
+var ErrUnavailable = errors.New("hifi-api unavailable")
+
+// Circuit breaker states.
+const (
+	BreakerClosed   = "closed"    // requests go through
+	BreakerOpen     = "open"      // requests fail fast with ErrUnavailable
+	BreakerHalfOpen = "half-open" // one trial request decides which of the two follows
+)
+
+// breaker is a circuit breaker. After threshold failed requests in a row it
+// opens, failing requests fast for cooldown. Then a single trial request
+// is let through: if it succeeds the breaker closes, otherwise it opens
+// again.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int       // failed requests in a row
+	openedAt time.Time // zero while closed
+	trial    bool      // a trial request is under way
+}
+
+// newBreaker creates a closed circuit breaker.
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// state returns the breaker's state. An open breaker whose cooldown has
+// passed is half-open.
+func (b *breaker) state() string {
+	switch {
+	case b.openedAt.IsZero():
+		return BreakerClosed
+	case b.now().Sub(b.openedAt) < b.cooldown:
+		return BreakerOpen
+	default:
+		return BreakerHalfOpen
+	}
+}
+
+// ticket is handed to a request the breaker lets through, for done to
+// record its outcome against.
+type ticket struct {
+	trial bool // the request is the half-open breaker's trial
+}
+
+// allow reports whether a request may go through, claiming the trial if
+// the breaker is half-open. Every allowed request must be followed by a
+// call to done with the ticket returned.
+func (b *breaker) allow() (ticket, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state() {
+	case BreakerOpen:
+		return ticket{}, ErrUnavailable
+	case BreakerHalfOpen:
+		if b.trial {
+			return ticket{}, ErrUnavailable
+		}
+		b.trial = true
+		return ticket{trial: true}, nil
+	}
+	return ticket{}, nil
+}
+
+// done records how an allowed request went: whether hifi-api failed it, or
+// neither, as when the caller gave up first. Once the breaker has opened
+// only its trial decides what follows, so requests let through before
+// then are not counted.
+func (b *breaker) done(t ticket, failed, aborted bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if t.trial {
+		b.trial = false
+	} else if !b.openedAt.IsZero() {
+		return
+	}
+	switch {
+	case aborted:
+	case failed:
+		b.failures++
+		if t.trial || b.failures >= b.threshold {
+			b.openedAt = b.now()
+		}
+	default:
+		b.failures = 0
+		b.openedAt = time.Time{}
+	}
+}
+
+// status returns the breaker's state, its count of failed requests in a
+// row and, while it is open, when it will let a trial request through.
+func (b *breaker) status() (state string, failures int, retryAt time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state = b.state()
+	if state == BreakerOpen {
+		retryAt = b.openedAt.Add(b.cooldown)
+	}
+	return state, b.failures, retryAt
+}
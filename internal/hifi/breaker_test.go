@@ -0,0 +1,132 @@
+package hifi
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newBreaker(3, time.Minute)
+	b.now = func() time.Time { return now }
+
+	// request runs a request through the breaker, failing it or not.
+	request := func(fail bool) error {
+		tk, err := b.allow()
+		if err != nil {
+			return err
+		}
+		b.done(tk, fail, false)
+		return nil
+	}
+
+	for range 2 {
+		_ = request(true)
+	}
+	_ = request(false)
+	for range 2 {
+		_ = request(true)
+	}
+	if state, failures, _ := b.status(); state != BreakerClosed || failures != 2 {
+		t.Fatalf("state = %s with %d failures, want closed with 2: a success resets the count", state, failures)
+	}
+
+	_ = request(true)
+	if state, _, retryAt := b.status(); state != BreakerOpen || !retryAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("state = %s retrying at %v, want open until %v", state, retryAt, now.Add(time.Minute))
+	}
+	if err := request(false); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("open breaker allowed a request: %v", err)
+	}
+
+	// After the cooldown a single trial goes through; failing it reopens.
+	now = now.Add(time.Minute)
+	trial, err := b.allow()
+	if err != nil {
+		t.Fatalf("half-open breaker refused the trial: %v", err)
+	}
+	if _, err := b.allow(); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("half-open breaker allowed a second request: %v", err)
+	}
+	b.done(trial, true, false)
+	if state, _, _ := b.status(); state != BreakerOpen {
+		t.Fatalf("state after failed trial = %s, want open", state)
+	}
+
+	// An aborted trial decides nothing; a successful one closes.
+	now = now.Add(time.Minute)
+	if trial, err = b.allow(); err != nil {
+		t.Fatalf("trial refused: %v", err)
+	}
+	b.done(trial, false, true)
+	if state, _, _ := b.status(); state != BreakerHalfOpen {
+		t.Fatalf("state after aborted trial = %s, want half-open", state)
+	}
+	if err := request(false); err != nil {
+		t.Fatalf("trial refused: %v", err)
+	}
+	if state, failures, _ := b.status(); state != BreakerClosed || failures != 0 {
+		t.Fatalf("state = %s with %d failures, want closed with 0", state, failures)
+	}
+}
+
+func TestBreaker_lateRequest(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newBreaker(1, time.Minute)
+	b.now = func() time.Time { return now }
+
+	// Two requests go through while closed; the first to fail opens the
+	// breaker and the other finishes while the trial is in flight.
+	late, _ := b.allow()
+	first, _ := b.allow()
+	b.done(first, true, false)
+	now = now.Add(time.Minute)
+	trial, err := b.allow()
+	if err != nil {
+		t.Fatalf("half-open breaker refused the trial: %v", err)
+	}
+
+	b.done(late, false, false)
+	if state, _, _ := b.status(); state != BreakerHalfOpen {
+		t.Fatalf("state after a late success = %s, want half-open: only the trial may close it", state)
+	}
+	if _, err := b.allow(); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("a late request freed the trial: %v", err)
+	}
+
+	b.done(trial, true, false)
+	if state, _, _ := b.status(); state != BreakerOpen {
+		t.Fatalf("state after failed trial = %s, want open", state)
+	}
+}
+
+func TestClient_breaker(t *testing.T) {
+	var requests atomic.Int64
+	srv := newFlakyServer(t, "", &requests, 500, 500, 500)
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	c.SetRetries(1, time.Millisecond)
+	c.SetBreaker(1, time.Hour)
+
+	// The breaker opens on the first failure, leaving the retry nowhere to
+	// go: the request is refused, with the server error as the cause.
+	_, err := c.GetAlbum(context.Background(), 1)
+	var serr *statusError
+	if !errors.Is(err, ErrUnavailable) || !errors.As(err, &serr) || serr.code != 500 {
+		t.Fatalf("first request err = %v, want ErrUnavailable after the server error", err)
+	}
+	_, err = c.GetAlbum(context.Background(), 1)
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("second request err = %v, want ErrUnavailable", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("requests = %d, want 1: the open breaker should fail fast, retries included", n)
+	}
+	if status := c.Status(); status.Available() || status.Backends[0].Breaker != BreakerOpen {
+		t.Errorf("status = %+v, want an open breaker", status)
+	}
+}
@@ -0,0 +1,312 @@
+package hifi
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"log"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// CacheTTLs is how long responses from each hifi-api endpoint stay fresh.
+// Endpoints not listed, such as playback manifests with their expiring
+// URLs and playlists that must be synced as they are, are never cached.
+var CacheTTLs = map[string]time.Duration{
+	"/search/":         10 * time.Minute,
+	"/artist/":         6 * time.Hour,
+	"/album/":          24 * time.Hour,
+	"/artist/similar/": 24 * time.Hour,
+	"/album/similar/":  24 * time.Hour,
+	"/cover/":          7 * 24 * time.Hour,
+}
+
+// CachePruneInterval is how often Cache.Run prunes the store. Stored
+// responses expire over hours to days, so pruning hourly keeps it small.
+const CachePruneInterval = time.Hour
+
+// CacheStore persists cached responses so they survive a restart.
+// db.Store satisfies it.
+type CacheStore interface {
+	LoadHiFiCacheEntry(ctx context.Context, key string) ([]byte, time.Time, error)
+	SaveHiFiCacheEntry(ctx context.Context, key string, body []byte, fetchedAt time.Time) error
+	DeleteHiFiCacheEntries(ctx context.Context, before time.Time) (int64, error)
+}
+
+// CacheStats counts how the cache has answered requests since it was created.
+type CacheStats struct {
+	Entries   int   // responses held in memory
+	Capacity  int   // responses that fit in memory
+	Hits      int64 // fresh responses served from the cache
+	StaleHits int64 // expired responses served while being refetched
+	Misses    int64 // responses fetched from hifi-api
+	Evictions int64 // responses dropped to make room
+}
+
+// HitRate returns the fraction of cacheable requests answered from the
+// cache, stale or not.
+func (s CacheStats) HitRate() float64 {
+	served := s.Hits + s.StaleHits
+	if served+s.Misses == 0 {
+		return 0
+	}
+	return float64(served) / float64(served+s.Misses)
+}
+
+// Cache holds hifi-api response bodies keyed by endpoint and query
+// parameters, in memory with the least recently used dropped first and,
+// given a CacheStore, on disk behind it.
+//
+// A response is fresh for its endpoint's TTL. For as long again it is
+// stale: still served, while a background request refetches it.
+type Cache struct {
+	store    CacheStore // nil for a memory-only cache
+	capacity int
+	ttls     map[string]time.Duration
+	now      func() time.Time
+	logger   *log.Logger
+
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List // of *cacheEntry, most recently used first
+	refreshing map[string]bool
+	stats      CacheStats
+	wg         sync.WaitGroup // background refetches
+}
+
+// cacheEntry is a response body and when it was fetched.
+type cacheEntry struct {
+	key       string
+	body      []byte
+	fetchedAt time.Time
+}
+
+// freshKey marks a context whose requests must not be answered from the cache.
+type freshKey struct{}
+
+// NewCache creates a cache holding up to capacity responses in memory. The
+// store may be nil to keep nothing across restarts.
+func NewCache(capacity int, store CacheStore) *Cache {
+	return &Cache{
+		store:      store,
+		capacity:   capacity,
+		ttls:       CacheTTLs,
+		now:        time.Now,
+		logger:     log.New(os.Stderr, "[hifi] ", log.LstdFlags),
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		refreshing: make(map[string]bool),
+	}
+}
+
+// Fresh returns a context whose requests skip cached responses, for callers
+// such as the release monitor that must see hifi-api as it is now. What
+// they fetch is still cached for everyone else.
+func Fresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, freshKey{}, true)
+}
+
+// Stats returns the cache's counters.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = c.order.Len()
+	stats.Capacity = c.capacity
+	return stats
+}
+
+// Clear drops every cached response, from memory and the store.
+func (c *Cache) Clear(ctx context.Context) error {
+	c.mu.Lock()
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+	c.mu.Unlock()
+
+	if c.store == nil {
+		return nil
+	}
+	_, err := c.store.DeleteHiFiCacheEntries(ctx, time.Time{})
+	return err
+}
+
+// Prune removes stored responses too old to be served even when stale and
+// returns how many were removed.
+func (c *Cache) Prune(ctx context.Context) (int64, error) {
+	if c.store == nil {
+		return 0, nil
+	}
+	var longest time.Duration
+	for _, ttl := range c.ttls {
+		longest = max(longest, ttl)
+	}
+	return c.store.DeleteHiFiCacheEntries(ctx, c.now().Add(-2*longest))
+}
+
+// Run prunes the store every interval until ctx is cancelled, starting
+// straight away.
+func (c *Cache) Run(ctx context.Context, interval time.Duration) {
+	c.prune(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.prune(ctx)
+		}
+	}
+}
+
+// prune prunes the store, logging the outcome.
+func (c *Cache) prune(ctx context.Context) {
+	n, err := c.Prune(ctx)
+	switch {
+	case err != nil:
+		c.logger.Printf("pruning cached responses: %v", err)
+	case n > 0:
+		c.logger.Printf("pruned %d expired response(s)", n)
+	}
+}
+
+// get returns the response body for the endpoint and parameters, from the
+// cache if it holds one that is fresh or stale, otherwise from fetch.
+func (c *Cache) get(ctx context.Context, path string, params url.Values, fetch func(context.Context) ([]byte, error)) ([]byte, error) {
+	ttl := c.ttls[path]
+	if ttl <= 0 || c.capacity <= 0 {
+		return fetch(ctx)
+	}
+	key := path + "?" + params.Encode()
+
+	if fresh, _ := ctx.Value(freshKey{}).(bool); !fresh {
+		if e := c.lookup(ctx, key); e != nil {
+			age := c.now().Sub(e.fetchedAt)
+			switch {
+			case age < ttl:
+				c.count(&c.stats.Hits)
+				return e.body, nil
+			case age < 2*ttl:
+				c.count(&c.stats.StaleHits)
+				c.revalidate(ctx, key, fetch)
+				return e.body, nil
+			}
+		}
+	}
+
+	c.count(&c.stats.Misses)
+	body, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.put(ctx, key, body)
+	return body, nil
+}
+
+// lookup returns the cached entry for key, loading it from the store into
+// memory if need be, or nil if there is none.
+func (c *Cache) lookup(ctx context.Context, key string) *cacheEntry {
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		// Read the entry before unlocking: remember replaces it in place.
+		e := el.Value.(*cacheEntry)
+		c.mu.Unlock()
+		return e
+	}
+	c.mu.Unlock()
+
+	if c.store == nil {
+		return nil
+	}
+	body, fetchedAt, err := c.store.LoadHiFiCacheEntry(ctx, key)
+	if err != nil {
+		c.logger.Printf("loading cached %s: %v", key, err)
+		return nil
+	}
+	if body == nil {
+		return nil
+	}
+	e := &cacheEntry{key: key, body: body, fetchedAt: fetchedAt}
+	c.remember(e)
+	return e
+}
+
+// put caches a freshly fetched response body, in memory and the store.
+// Bodies that are not valid JSON are left out, so a broken response is
+// never served twice.
+func (c *Cache) put(ctx context.Context, key string, body []byte) {
+	if !json.Valid(body) {
+		return
+	}
+	e := &cacheEntry{key: key, body: body, fetchedAt: c.now()}
+	c.remember(e)
+
+	if c.store == nil {
+		return
+	}
+	if err := c.store.SaveHiFiCacheEntry(context.WithoutCancel(ctx), key, body, e.fetchedAt); err != nil {
+		c.logger.Printf("storing %s: %v", key, err)
+	}
+}
+
+// remember adds an entry to memory as the most recently used, replacing any
+// with the same key and evicting the least recently used beyond capacity.
+func (c *Cache) remember(e *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[e.key]; ok {
+		el.Value = e
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[e.key] = c.order.PushFront(e)
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+		c.stats.Evictions++
+	}
+}
+
+// revalidate refetches a stale response in the background, unless a
+// refetch of it is already under way.
+func (c *Cache) revalidate(ctx context.Context, key string, fetch func(context.Context) ([]byte, error)) {
+	c.mu.Lock()
+	if c.refreshing[key] {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing[key] = true
+	c.mu.Unlock()
+
+	ctx = context.WithoutCancel(ctx)
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer func() {
+			c.mu.Lock()
+			delete(c.refreshing, key)
+			c.mu.Unlock()
+		}()
+
+		body, err := fetch(ctx)
+		if err != nil {
+			c.logger.Printf("refetching %s: %v", key, err)
+			return
+		}
+		c.put(ctx, key, body)
+	}()
+}
+
+// count increments one of the cache's counters.
+func (c *Cache) count(n *int64) {
+	c.mu.Lock()
+	*n++
+	c.mu.Unlock()
+}
@@ -0,0 +1,339 @@
+package hifi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memoryCacheStore is a CacheStore backed by a map.
+type memoryCacheStore struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+func (s *memoryCacheStore) LoadHiFiCacheEntry(_ context.Context, key string) ([]byte, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.entries[key]
+	return e.body, e.fetchedAt, nil
+}
+
+func (s *memoryCacheStore) SaveHiFiCacheEntry(_ context.Context, key string, body []byte, fetchedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.entries == nil {
+		s.entries = make(map[string]cacheEntry)
+	}
+	s.entries[key] = cacheEntry{key: key, body: body, fetchedAt: fetchedAt}
+	return nil
+}
+
+func (s *memoryCacheStore) DeleteHiFiCacheEntries(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for key, e := range s.entries {
+		if before.IsZero() || e.fetchedAt.Before(before) {
+			delete(s.entries, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// newVersionServer creates an httptest.Server answering album requests with
+// the album's ID and the server's current version as its title, counting
+// the requests it serves.
+func newVersionServer(t *testing.T, version *atomic.Int64, requests *atomic.Int64) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"data": {"id": %s, "title": "v%d", "items": []}}`, r.URL.Query().Get("id"), version.Load())
+	}))
+}
+
+// newCachedClient returns a client for srv with a cache whose clock is the
+// returned pointer.
+func newCachedClient(srv *httptest.Server, capacity int, store CacheStore) (*Client, *Cache, *time.Time) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewCache(capacity, store)
+	cache.now = func() time.Time { return now }
+	c := NewClient(srv.URL)
+	c.SetCache(cache)
+	return c, cache, &now
+}
+
+// albumTitle fetches an album and returns its title.
+func albumTitle(t *testing.T, ctx context.Context, c *Client, id int64) string {
+	t.Helper()
+	detail, err := c.GetAlbum(ctx, id)
+	if err != nil {
+		t.Fatalf("GetAlbum(%d): %v", id, err)
+	}
+	return detail.Title
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("serves repeated requests from memory", func(t *testing.T) {
+		var version, requests atomic.Int64
+		srv := newVersionServer(t, &version, &requests)
+		defer srv.Close()
+		c, cache, _ := newCachedClient(srv, 10, nil)
+
+		albumTitle(t, ctx, c, 1)
+		version.Add(1)
+		if got := albumTitle(t, ctx, c, 1); got != "v0" {
+			t.Errorf("title = %q, want the cached v0", got)
+		}
+		if got := albumTitle(t, ctx, c, 2); got != "v1" {
+			t.Errorf("other album title = %q, want v1", got)
+		}
+		if n := requests.Load(); n != 2 {
+			t.Errorf("requests = %d, want 2", n)
+		}
+
+		stats := cache.Stats()
+		if stats.Hits != 1 || stats.Misses != 2 || stats.Entries != 2 {
+			t.Errorf("stats = %+v, want 1 hit, 2 misses, 2 entries", stats)
+		}
+	})
+
+	t.Run("serves stale responses while refetching", func(t *testing.T) {
+		var version, requests atomic.Int64
+		srv := newVersionServer(t, &version, &requests)
+		defer srv.Close()
+		c, cache, now := newCachedClient(srv, 10, nil)
+
+		albumTitle(t, ctx, c, 1)
+		version.Add(1)
+		*now = now.Add(CacheTTLs["/album/"] + time.Minute)
+
+		if got := albumTitle(t, ctx, c, 1); got != "v0" {
+			t.Errorf("stale title = %q, want v0", got)
+		}
+		cache.wg.Wait()
+		if got := albumTitle(t, ctx, c, 1); got != "v1" {
+			t.Errorf("refetched title = %q, want v1", got)
+		}
+		if n := requests.Load(); n != 2 {
+			t.Errorf("requests = %d, want 2", n)
+		}
+		if stats := cache.Stats(); stats.StaleHits != 1 || stats.Hits != 1 {
+			t.Errorf("stats = %+v, want 1 stale hit and 1 hit", stats)
+		}
+	})
+
+	t.Run("refetches responses too old to serve", func(t *testing.T) {
+		var version, requests atomic.Int64
+		srv := newVersionServer(t, &version, &requests)
+		defer srv.Close()
+		c, _, now := newCachedClient(srv, 10, nil)
+
+		albumTitle(t, ctx, c, 1)
+		version.Add(1)
+		*now = now.Add(2 * CacheTTLs["/album/"])
+
+		if got := albumTitle(t, ctx, c, 1); got != "v1" {
+			t.Errorf("title = %q, want v1", got)
+		}
+	})
+
+	t.Run("evicts the least recently used", func(t *testing.T) {
+		var version, requests atomic.Int64
+		srv := newVersionServer(t, &version, &requests)
+		defer srv.Close()
+		c, cache, _ := newCachedClient(srv, 2, nil)
+
+		albumTitle(t, ctx, c, 1)
+		albumTitle(t, ctx, c, 2)
+		albumTitle(t, ctx, c, 1) // 2 is now the least recently used
+		albumTitle(t, ctx, c, 3)
+		version.Add(1)
+
+		if got := albumTitle(t, ctx, c, 1); got != "v0" {
+			t.Errorf("album 1 title = %q, want the cached v0", got)
+		}
+		if got := albumTitle(t, ctx, c, 2); got != "v1" {
+			t.Errorf("album 2 title = %q, want the refetched v1", got)
+		}
+		if stats := cache.Stats(); stats.Evictions != 2 || stats.Entries != 2 {
+			t.Errorf("stats = %+v, want 2 evictions and 2 entries", stats)
+		}
+	})
+
+	t.Run("fresh context skips the cache", func(t *testing.T) {
+		var version, requests atomic.Int64
+		srv := newVersionServer(t, &version, &requests)
+		defer srv.Close()
+		c, _, _ := newCachedClient(srv, 10, nil)
+
+		albumTitle(t, ctx, c, 1)
+		version.Add(1)
+		if got := albumTitle(t, Fresh(ctx), c, 1); got != "v1" {
+			t.Errorf("fresh title = %q, want v1", got)
+		}
+		version.Add(1)
+		if got := albumTitle(t, ctx, c, 1); got != "v1" {
+			t.Errorf("title after fresh request = %q, want the cached v1", got)
+		}
+	})
+
+	t.Run("never caches uncached endpoints", func(t *testing.T) {
+		var requests atomic.Int64
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			requests.Add(1)
+			_, _ = w.Write([]byte(`{"data": {"trackId": 1, "manifest": ""}}`))
+		}))
+		defer srv.Close()
+		c, _, _ := newCachedClient(srv, 10, nil)
+
+		for range 2 {
+			if _, err := c.GetTrackPlayback(ctx, 1, "LOSSLESS"); err != nil {
+				t.Fatalf("GetTrackPlayback: %v", err)
+			}
+		}
+		if n := requests.Load(); n != 2 {
+			t.Errorf("requests = %d, want 2", n)
+		}
+	})
+
+	t.Run("does not cache invalid JSON", func(t *testing.T) {
+		var requests atomic.Int64
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			requests.Add(1)
+			_, _ = w.Write([]byte(`{not valid json`))
+		}))
+		defer srv.Close()
+		c, _, _ := newCachedClient(srv, 10, nil)
+
+		for range 2 {
+			if _, err := c.GetAlbum(ctx, 1); err == nil {
+				t.Fatal("expected decode error, got nil")
+			}
+		}
+		if n := requests.Load(); n != 2 {
+			t.Errorf("requests = %d, want 2", n)
+		}
+	})
+
+	t.Run("persists responses across caches", func(t *testing.T) {
+		var version, requests atomic.Int64
+		srv := newVersionServer(t, &version, &requests)
+		defer srv.Close()
+		store := &memoryCacheStore{}
+
+		c, _, _ := newCachedClient(srv, 10, store)
+		albumTitle(t, ctx, c, 1)
+		version.Add(1)
+
+		c, cache, _ := newCachedClient(srv, 10, store)
+		if got := albumTitle(t, ctx, c, 1); got != "v0" {
+			t.Errorf("title = %q, want the stored v0", got)
+		}
+		if n := requests.Load(); n != 1 {
+			t.Errorf("requests = %d, want 1", n)
+		}
+
+		if err := cache.Clear(ctx); err != nil {
+			t.Fatalf("Clear: %v", err)
+		}
+		if got := albumTitle(t, ctx, c, 1); got != "v1" {
+			t.Errorf("title after clear = %q, want v1", got)
+		}
+	})
+
+	t.Run("prunes stored responses too old to serve", func(t *testing.T) {
+		store := &memoryCacheStore{}
+		cache := NewCache(10, store)
+		now := time.Now()
+		_ = store.SaveHiFiCacheEntry(ctx, "old", []byte(`{}`), now.Add(-15*24*time.Hour))
+		_ = store.SaveHiFiCacheEntry(ctx, "new", []byte(`{}`), now.Add(-time.Hour))
+
+		n, err := cache.Prune(ctx)
+		if err != nil {
+			t.Fatalf("Prune: %v", err)
+		}
+		if n != 1 {
+			t.Errorf("pruned %d entries, want 1", n)
+		}
+	})
+
+	t.Run("keeps pruning while running", func(t *testing.T) {
+		store := &memoryCacheStore{}
+		cache := NewCache(10, store)
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			cache.Run(runCtx, time.Millisecond)
+			close(done)
+		}()
+
+		// Entries saved while Run is going are pruned too, not only those
+		// stored before it started.
+		_ = store.SaveHiFiCacheEntry(ctx, "old", []byte(`{}`), time.Now().Add(-15*24*time.Hour))
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			store.mu.Lock()
+			n := len(store.entries)
+			store.mu.Unlock()
+			if n == 0 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("expired entry was never pruned")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+		<-done
+	})
+
+	// Meant for -race: lookups must not read an entry that put is
+	// replacing.
+	t.Run("serves lookups while the entry is replaced", func(t *testing.T) {
+		cache := NewCache(10, nil)
+		fetch := func(context.Context) ([]byte, error) { return []byte(`{}`), nil }
+
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Go(func() {
+				for range 100 {
+					if _, err := cache.get(ctx, "/album/", nil, fetch); err != nil {
+						t.Errorf("get: %v", err)
+					}
+				}
+			})
+			wg.Go(func() {
+				for range 100 {
+					cache.put(ctx, "/album/?", []byte(`{}`))
+				}
+			})
+		}
+		wg.Wait()
+	})
+}
+
+func TestCacheStats_HitRate(t *testing.T) {
+	tests := []struct {
+		stats CacheStats
+		want  float64
+	}{
+		{CacheStats{}, 0},
+		{CacheStats{Hits: 2, StaleHits: 1, Misses: 1}, 0.75},
+		{CacheStats{Misses: 3}, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.stats.HitRate(); got != tt.want {
+			t.Errorf("%+v.HitRate() = %v, want %v", tt.stats, got, tt.want)
+		}
+	}
+}
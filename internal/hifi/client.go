@@ -4,45 +4,263 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"net/url"
+	"os"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
-// Client is an HTTP client for the hifi-api (Tidal proxy).
+// Client is an HTTP client for the hifi-api (Tidal proxy). It can spread
+// its requests over several hifi-api instances, failing over from one to
+// the next.
 type Client struct {
-	baseURL    string
+	backends   []*backend
+	region     string // region whose backends are tried first, "" for none
 	httpClient *http.Client
+	cache      *Cache        // nil to always ask hifi-api
+	retries    int           // times a failed request is retried
+	backoff    time.Duration // wait before the first retry, doubled for each one after
+	limiter    *limiter      // nil for no rate limit
+	retried    atomic.Int64  // requests repeated so far
+	failovers  atomic.Int64  // requests passed on to another backend so far
+	logger     *log.Logger
 }
 
 // NewClient creates a new hifi-api client pointed at the given base URL.
+// It makes a single attempt at each request, as fast as it is asked to.
 func NewClient(baseURL string) *Client {
-	return &Client{
-		baseURL: baseURL,
+	return NewFailoverClient([]Backend{{URL: baseURL}})
+}
+
+// NewFailoverClient creates a hifi-api client that sends each request to
+// the first of the backends that is up, passing it on to the next if that
+// one fails it.
+func NewFailoverClient(backends []Backend) *Client {
+	c := &Client{
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		logger: log.New(os.Stderr, "[hifi] ", log.LstdFlags),
+	}
+	for _, b := range backends {
+		c.backends = append(c.backends, &backend{Backend: b})
+	}
+	return c
+}
+
+// SetCache makes the client answer repeated requests from the given cache.
+// It must be called before the client is first used.
+func (c *Client) SetCache(cache *Cache) {
+	c.cache = cache
+}
+
+// SetRetries makes the client retry requests failed by a network error, a
+// 5xx status or 429 Too Many Requests up to retries times, waiting backoff
+// before the first retry and twice as long before each one after, or as
+// long as a Retry-After header asks. It must be called before the client
+// is first used.
+func (c *Client) SetRetries(retries int, backoff time.Duration) {
+	c.retries = retries
+	c.backoff = backoff
+}
+
+// SetRateLimit holds requests back so that no more than rate a second are
+// made on average, in bursts of up to burst, however many goroutines share
+// the client. It must be called before the client is first used.
+func (c *Client) SetRateLimit(rate float64, burst int) {
+	c.limiter = newLimiter(rate, burst)
+}
+
+// SetBreaker gives each backend a circuit breaker: once threshold requests
+// to it in a row have failed, it is passed over for cooldown rather than
+// kept being asked while it is down. With every backend passed over,
+// requests fail fast with ErrUnavailable. It must be called before the
+// client is first used.
+func (c *Client) SetBreaker(threshold int, cooldown time.Duration) {
+	for _, b := range c.backends {
+		b.breaker = newBreaker(threshold, cooldown)
+	}
+}
+
+// SetRegion makes the client try the backends in the given region before
+// the others, so that requests see that region's catalogue while one of
+// them is up. It must be called before the client is first used.
+func (c *Client) SetRegion(region string) {
+	c.region = region
+}
+
+// Status describes how the client is getting on with hifi-api.
+type Status struct {
+	Backends  []BackendStatus // in the order they are configured
+	Region    string          // region whose backends are tried first, "" for none
+	Retries   int64           // requests repeated after hifi-api failed them
+	Failovers int64           // requests passed on to another backend
+	Throttled int64           // requests held back by the rate limit
+}
+
+// Available reports whether requests are being let through to hifi-api:
+// whether any backend's circuit breaker is not open. Without backends there
+// is nothing to be unavailable.
+func (s Status) Available() bool {
+	return len(s.Backends) == 0 || slices.ContainsFunc(s.Backends, BackendStatus.Available)
+}
+
+// Status returns the state of the client's backends and its retry,
+// failover and rate limit counters.
+func (c *Client) Status() Status {
+	status := Status{
+		Region:    c.region,
+		Retries:   c.retried.Load(),
+		Failovers: c.failovers.Load(),
+	}
+	for _, b := range c.backends {
+		status.Backends = append(status.Backends, b.status())
+	}
+	if c.limiter != nil {
+		status.Throttled = c.limiter.stats()
+	}
+	return status
+}
+
+// CacheStats returns the counters of the client's cache, all zero if it has
+// none.
+func (c *Client) CacheStats() CacheStats {
+	if c.cache == nil {
+		return CacheStats{}
+	}
+	return c.cache.Stats()
+}
+
+// ClearCache drops every response in the client's cache.
+func (c *Client) ClearCache(ctx context.Context) error {
+	if c.cache == nil {
+		return nil
 	}
+	return c.cache.Clear(ctx)
 }
 
-// get performs a GET request, checks the status code, and decodes the JSON
-// response body into dest.
+// get performs a GET request, through the cache if the client has one, and
+// decodes the JSON response body into dest.
 func (c *Client) get(ctx context.Context, path string, params url.Values, dest any) error {
-	u, err := url.Parse(c.baseURL + path)
+	fetch := func(ctx context.Context) ([]byte, error) {
+		return c.fetch(ctx, path, params)
+	}
+
+	var (
+		body []byte
+		err  error
+	)
+	if c.cache != nil {
+		body, err = c.cache.get(ctx, path, params, fetch)
+	} else {
+		body, err = fetch(ctx)
+	}
 	if err != nil {
-		return fmt.Errorf("hifi: parse url: %w", err)
+		return err
+	}
+
+	if err := json.Unmarshal(body, dest); err != nil {
+		return fmt.Errorf("hifi: decode response: %w", err)
+	}
+
+	return nil
+}
+
+// fetch performs a GET request on the backends in turn until one answers
+// it, retrying the round as the client allows, and returns the response
+// body.
+func (c *Client) fetch(ctx context.Context, path string, params url.Values) ([]byte, error) {
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		tried := 0
+		for _, b := range c.candidates() {
+			var t ticket
+			if b.breaker != nil {
+				var err error
+				if t, err = b.breaker.allow(); err != nil {
+					continue
+				}
+			}
+			if tried > 0 {
+				c.failovers.Add(1)
+			}
+			tried++
+
+			body, err := c.attempt(ctx, b, t, path, params)
+			if !failed(ctx, err) {
+				return body, err
+			}
+			lastErr = err
+		}
+
+		switch {
+		case tried == 0 && lastErr == nil:
+			return nil, fmt.Errorf("hifi: %s: %w", path, ErrUnavailable)
+		case tried == 0:
+			return nil, fmt.Errorf("hifi: %s: %w (after %w)", path, ErrUnavailable, lastErr)
+		case attempt >= c.retries:
+			return nil, lastErr
+		}
+
+		delay := retryDelay(c.backoff, attempt, lastErr)
+		if delay > maxRetryWait {
+			return nil, lastErr
+		}
+		c.retried.Add(1)
+		if serr := sleep(ctx, delay); serr != nil {
+			return nil, fmt.Errorf("hifi: wait to retry: %w (after %w)", serr, lastErr)
+		}
+	}
+}
+
+// attempt performs a GET request on one backend within the rate limit,
+// recording how it went with the backend's circuit breaker against the
+// ticket it let the request through with.
+func (c *Client) attempt(ctx context.Context, b *backend, t ticket, path string, params url.Values) ([]byte, error) {
+	var (
+		body []byte
+		err  error
+	)
+	if c.limiter != nil {
+		err = c.limiter.wait(ctx)
+		if err != nil {
+			err = fmt.Errorf("hifi: wait for rate limit: %w", err)
+		}
+	}
+	if err == nil {
+		body, err = c.do(ctx, b, path, params)
+	}
+
+	if b.breaker != nil {
+		b.breaker.done(t, failed(ctx, err), ctx.Err() != nil)
+	}
+	return body, err
+}
+
+// do performs a single GET request on a backend, checks the status code,
+// and returns the response body.
+func (c *Client) do(ctx context.Context, b *backend, path string, params url.Values) (body []byte, err error) {
+	u, err := url.Parse(b.URL + path)
+	if err != nil {
+		return nil, fmt.Errorf("hifi: parse url: %w", err)
 	}
 	u.RawQuery = params.Encode()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
-		return fmt.Errorf("hifi: create request: %w", err)
+		return nil, fmt.Errorf("hifi: create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req) //nolint:gosec // baseURL is set by trusted config, not user input
+	resp, err := c.httpClient.Do(req) //nolint:gosec // backend URLs are set by trusted config, not user input
 	if err != nil {
-		return fmt.Errorf("hifi: do request: %w", err)
+		return nil, fmt.Errorf("hifi: do request: %w", err)
 	}
 	defer func() {
 		if cerr := resp.Body.Close(); cerr != nil && err == nil {
@@ -51,14 +269,19 @@ func (c *Client) get(ctx context.Context, path string, params url.Values, dest a
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("hifi: unexpected status %d for %s", resp.StatusCode, path)
+		return nil, &statusError{
+			code:       resp.StatusCode,
+			path:       path,
+			retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
 	}
 
-	if err = json.NewDecoder(resp.Body).Decode(dest); err != nil {
-		return fmt.Errorf("hifi: decode response: %w", err)
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("hifi: read response: %w", err)
 	}
 
-	return nil
+	return body, nil
 }
 
 // SearchArtists searches for artists by name.
@@ -136,6 +359,52 @@ func (c *Client) GetAlbum(ctx context.Context, id int64) (*AlbumDetail, error) {
 	}, nil
 }
 
+// playlistPageSize is the number of playlist items requested per page.
+const playlistPageSize = 100
+
+// GetPlaylist returns a playlist with its tracks, paging through the items.
+// Videos in the playlist are left out.
+func (c *Client) GetPlaylist(ctx context.Context, id string) (*PlaylistDetail, error) {
+	var detail PlaylistDetail
+	for offset := 0; ; offset += playlistPageSize {
+		params := url.Values{
+			"id":     {id},
+			"limit":  {strconv.Itoa(playlistPageSize)},
+			"offset": {strconv.Itoa(offset)},
+		}
+
+		var resp playlistResponse
+		if err := c.get(ctx, "/playlist/", params, &resp); err != nil {
+			return nil, fmt.Errorf("hifi: get playlist: %w", err)
+		}
+
+		if offset == 0 {
+			detail.Playlist = resp.Playlist
+		}
+		for _, item := range resp.Items {
+			if item.Type == "track" {
+				detail.Tracks = append(detail.Tracks, item.Item)
+			}
+		}
+		if len(resp.Items) < playlistPageSize || offset+len(resp.Items) >= detail.NumberOfTracks {
+			return &detail, nil
+		}
+	}
+}
+
+// playlistID matches a Tidal playlist UUID, on its own or in a playlist URL
+// such as https://tidal.com/browse/playlist/<uuid>.
+var playlistID = regexp.MustCompile(`(?i)(?:^|/playlist/)([0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})(?:$|[/?#])`)
+
+// ParsePlaylistID extracts the playlist UUID from a Tidal playlist ID or URL.
+func ParsePlaylistID(s string) (string, error) {
+	m := playlistID.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return "", fmt.Errorf("hifi: %q is not a Tidal playlist ID or URL", s)
+	}
+	return strings.ToLower(m[1]), nil
+}
+
 // GetSimilarArtists returns artists similar to the given artist.
 func (c *Client) GetSimilarArtists(ctx context.Context, id int64) ([]SimilarArtist, error) {
 	params := url.Values{
@@ -2,8 +2,10 @@ package hifi
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -147,7 +149,7 @@ func TestGetArtistAlbums(t *testing.T) {
 			"items": [
 				{"id": 100, "title": "Album One", "artist": {"id": 8812, "name": "Coldplay"}},
 				{"id": 200, "title": "Album Two", "artist": {"id": 8812, "name": "Coldplay"}},
-				{"id": 300, "title": "Album Three", "artist": {"id": 8812, "name": "Coldplay"}}
+				{"id": 300, "title": "Album Three", "type": "SINGLE", "artist": {"id": 8812, "name": "Coldplay"}}
 			]
 		}
 	}`
@@ -170,6 +172,9 @@ func TestGetArtistAlbums(t *testing.T) {
 	if albums[0].Title != "Album One" {
 		t.Errorf("albums[0].Title = %q, want %q", albums[0].Title, "Album One")
 	}
+	if albums[2].Type != "SINGLE" {
+		t.Errorf("albums[2].Type = %q, want %q", albums[2].Type, "SINGLE")
+	}
 }
 
 func TestGetAlbum(t *testing.T) {
@@ -249,6 +254,82 @@ func TestGetAlbum(t *testing.T) {
 	}
 }
 
+func TestGetPlaylist(t *testing.T) {
+	// 101 tracks and a video split over two pages.
+	var offsets []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("id"); got != "36ea71a8-445e-41a4-82ab-6628c581535d" {
+			t.Errorf("id = %q, want the playlist UUID", got)
+		}
+		offset := r.URL.Query().Get("offset")
+		offsets = append(offsets, offset)
+
+		var items []string
+		switch offset {
+		case "0":
+			items = append(items, `{"item": {"id": 1, "title": "Video"}, "type": "video"}`)
+			for i := 1; i < 100; i++ {
+				items = append(items, fmt.Sprintf(`{"item": {"id": %d, "title": "Track %d", "album": {"id": 500, "title": "Album"}}, "type": "track"}`, 1000+i, i))
+			}
+		case "100":
+			items = append(items,
+				`{"item": {"id": 1100, "title": "Track 100", "album": {"id": 501, "title": "Other"}}, "type": "track"}`,
+				`{"item": {"id": 1101, "title": "Track 101", "album": {"id": 501, "title": "Other"}}, "type": "track"}`)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{
+			"playlist": {"uuid": "36ea71a8-445e-41a4-82ab-6628c581535d", "title": "Road Trip", "numberOfTracks": 102, "lastUpdated": "2024-05-01T10:00:00.000+0000"},
+			"items": [%s]
+		}`, strings.Join(items, ","))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	detail, err := c.GetPlaylist(context.Background(), "36ea71a8-445e-41a4-82ab-6628c581535d")
+	if err != nil {
+		t.Fatalf("GetPlaylist returned error: %v", err)
+	}
+
+	if detail.Title != "Road Trip" || detail.LastUpdated != "2024-05-01T10:00:00.000+0000" {
+		t.Errorf("playlist = %+v, want Road Trip updated 2024-05-01", detail.Playlist)
+	}
+	if strings.Join(offsets, ",") != "0,100" {
+		t.Errorf("requested offsets %v, want [0 100]", offsets)
+	}
+	if got, want := len(detail.Tracks), 101; got != want {
+		t.Fatalf("track count = %d, want %d", got, want)
+	}
+	if first := detail.Tracks[0]; first.ID != 1001 || first.Album.ID != 500 {
+		t.Errorf("Tracks[0] = %+v, want track 1001 on album 500", first)
+	}
+	if last := detail.Tracks[100]; last.ID != 1101 || last.Album.Title != "Other" {
+		t.Errorf("Tracks[100] = %+v, want track 1101 on album Other", last)
+	}
+}
+
+func TestParsePlaylistID(t *testing.T) {
+	const id = "36ea71a8-445e-41a4-82ab-6628c581535d"
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: id, want: id},
+		{in: "  36EA71A8-445E-41A4-82AB-6628C581535D ", want: id},
+		{in: "https://tidal.com/browse/playlist/" + id, want: id},
+		{in: "https://listen.tidal.com/playlist/" + id + "?u", want: id},
+		{in: "https://tidal.com/browse/album/" + id, wantErr: true},
+		{in: "77640617", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePlaylistID(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParsePlaylistID(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
 func TestGetSimilarArtists(t *testing.T) {
 	const fixture = `{
 		"artists": [
@@ -423,6 +504,13 @@ func TestClient_HTTP_error(t *testing.T) {
 				return err
 			},
 		},
+		{
+			name: "GetPlaylist",
+			call: func(c *Client) error {
+				_, err := c.GetPlaylist(context.Background(), "36ea71a8-445e-41a4-82ab-6628c581535d")
+				return err
+			},
+		},
 		{
 			name: "GetSimilarArtists",
 			call: func(c *Client) error {
@@ -499,6 +587,13 @@ func TestClient_invalid_JSON(t *testing.T) {
 				return err
 			},
 		},
+		{
+			name: "GetPlaylist",
+			call: func(c *Client) error {
+				_, err := c.GetPlaylist(context.Background(), "36ea71a8-445e-41a4-82ab-6628c581535d")
+				return err
+			},
+		},
 		{
 			name: "GetSimilarArtists",
 			call: func(c *Client) error {
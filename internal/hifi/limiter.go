@@ -0,0 +1,71 @@
+package hifi
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// limiter is a token bucket: it lets through rate requests a second on
+// average, in bursts of up to burst. Requests over the limit reserve a
+// token ahead of time and wait for it, so they go through in arrival order.
+type limiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu        sync.Mutex
+	tokens    float64 // negative while requests are waiting
+	last      time.Time
+	throttled int64 // requests that had to wait
+}
+
+// newLimiter creates a limiter with a full bucket.
+func newLimiter(rate float64, burst int) *limiter {
+	return &limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		now:    time.Now,
+		tokens: float64(burst),
+	}
+}
+
+// wait takes a token, blocking until one is available or ctx is done.
+func (l *limiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+		l.throttled++
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		// Hand the reserved token back to those still waiting.
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// stats returns the number of requests that have had to wait.
+func (l *limiter) stats() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.throttled
+}
@@ -0,0 +1,63 @@
+package hifi
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	t.Run("lets bursts through and holds back the rest", func(t *testing.T) {
+		now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		l := newLimiter(1000, 2)
+		l.now = func() time.Time { return now } // no tokens are refilled
+
+		start := time.Now()
+		var wg sync.WaitGroup
+		for range 5 {
+			wg.Go(func() {
+				if err := l.wait(context.Background()); err != nil {
+					t.Errorf("wait: %v", err)
+				}
+			})
+		}
+		wg.Wait()
+
+		if n := l.stats(); n != 3 {
+			t.Errorf("throttled = %d, want 3", n)
+		}
+		if elapsed := time.Since(start); elapsed < 3*time.Millisecond {
+			t.Errorf("elapsed = %s, want at least 3ms for the last of three waiting requests", elapsed)
+		}
+	})
+
+	t.Run("refills over time up to the burst", func(t *testing.T) {
+		now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		l := newLimiter(1, 2)
+		l.now = func() time.Time { return now }
+
+		for range 2 {
+			_ = l.wait(context.Background())
+		}
+		now = now.Add(time.Hour)
+		for range 2 {
+			_ = l.wait(context.Background())
+		}
+		if n := l.stats(); n != 0 {
+			t.Errorf("throttled = %d, want 0", n)
+		}
+	})
+
+	t.Run("gives up with the context", func(t *testing.T) {
+		l := newLimiter(0.001, 1)
+		_ = l.wait(context.Background())
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		if err := l.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("err = %v, want deadline exceeded", err)
+		}
+	})
+}
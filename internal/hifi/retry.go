@@ -0,0 +1,87 @@
+package hifi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxRetryWait caps how long a retry waits. A request asked to come back
+// later than that fails instead of holding up its caller.
+const maxRetryWait = 30 * time.Second
+
+// statusError is a response from hifi-api with an unexpected status code.
+type statusError struct {
+	code       int
+	path       string
+	retryAfter time.Duration // from the Retry-After header, 0 if none
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("hifi: unexpected status %d for %s", e.code, e.path)
+}
+
+// failed reports whether err means hifi-api is struggling: a network error,
+// a 5xx status or being told to slow down. Other statuses, such as 404 for
+// an unknown album, are answers like any other, and a cancelled request
+// says nothing about hifi-api.
+func failed(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code == http.StatusTooManyRequests || se.code >= 500
+	}
+	return true
+}
+
+// parseRetryAfter returns how long a Retry-After header value, in seconds
+// or as an HTTP date, asks to wait, or 0 if it can't be parsed.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		return max(0, time.Duration(secs)*time.Second)
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(0, t.Sub(now))
+	}
+	return 0
+}
+
+// retryDelay returns how long to wait before retrying after the given
+// attempt, counted from 0: the backoff doubled for every attempt, with
+// jitter so that callers don't retry in step, or as long as hifi-api asked
+// if that is longer.
+func retryDelay(backoff time.Duration, attempt int, err error) time.Duration {
+	// Double step by step rather than shifting by attempt, which overflows
+	// for large attempts.
+	d := max(min(backoff, maxRetryWait), 0)
+	for i := 0; i < attempt && d < maxRetryWait; i++ {
+		d = min(2*d, maxRetryWait)
+	}
+	d = d/2 + rand.N(d/2+1) //nolint:gosec // jitter needs no cryptographic randomness
+	var se *statusError
+	if errors.As(err, &se) {
+		d = max(d, se.retryAfter)
+	}
+	return d
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
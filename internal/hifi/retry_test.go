@@ -0,0 +1,133 @@
+package hifi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFlakyServer creates an httptest.Server that answers the first len(fails)
+// requests with those statuses, with the given Retry-After header if any,
+// and every request after with an empty album, counting the requests it
+// serves.
+func newFlakyServer(t *testing.T, retryAfter string, requests *atomic.Int64, fails ...int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := int(requests.Add(1))
+		if n <= len(fails) {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(fails[n-1])
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"id": 1, "title": "Dummy", "items": []}}`))
+	}))
+}
+
+func TestClient_retries(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		retryAfter string
+		fails      []int
+		wantErr    bool
+		wantReqs   int64
+	}{
+		{name: "succeeds after 5xx and 429", fails: []int{http.StatusBadGateway, http.StatusTooManyRequests}, wantReqs: 3},
+		{name: "gives up after the last retry", fails: []int{500, 500, 500, 500}, wantErr: true, wantReqs: 3},
+		{name: "does not retry 404", fails: []int{http.StatusNotFound}, wantErr: true, wantReqs: 1},
+		{name: "honours a short Retry-After", retryAfter: "0", fails: []int{http.StatusServiceUnavailable}, wantReqs: 2},
+		{name: "fails on a long Retry-After", retryAfter: "3600", fails: []int{http.StatusTooManyRequests}, wantErr: true, wantReqs: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int64
+			srv := newFlakyServer(t, tt.retryAfter, &requests, tt.fails...)
+			defer srv.Close()
+
+			c := NewClient(srv.URL)
+			c.SetRetries(2, time.Millisecond)
+			_, err := c.GetAlbum(ctx, 1)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error: %v", err, tt.wantErr)
+			}
+			if n := requests.Load(); n != tt.wantReqs {
+				t.Errorf("requests = %d, want %d", n, tt.wantReqs)
+			}
+			if got, want := c.Status().Retries, tt.wantReqs-1; got != want {
+				t.Errorf("Status().Retries = %d, want %d", got, want)
+			}
+		})
+	}
+
+	t.Run("stops waiting when the context is done", func(t *testing.T) {
+		var requests atomic.Int64
+		srv := newFlakyServer(t, "20", &requests, 500, 500)
+		defer srv.Close()
+
+		c := NewClient(srv.URL)
+		c.SetRetries(2, time.Millisecond)
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		if _, err := c.GetAlbum(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("err = %v, want deadline exceeded", err)
+		}
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"-5", 0},
+		{"Thu, 01 Jan 2026 12:00:30 GMT", 30 * time.Second},
+		{"Thu, 01 Jan 2026 11:00:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	for attempt := range 4 {
+		full := 100 * time.Millisecond << attempt
+		for range 20 {
+			if d := retryDelay(100*time.Millisecond, attempt, errors.New("boom")); d < full/2 || d > full {
+				t.Fatalf("attempt %d delay = %s, want between %s and %s", attempt, d, full/2, full)
+			}
+		}
+	}
+
+	if d := retryDelay(time.Hour, 3, nil); d > maxRetryWait {
+		t.Errorf("delay = %s, want at most %s", d, maxRetryWait)
+	}
+
+	// However many attempts, the delay neither overflows nor exceeds the cap.
+	for _, attempt := range []int{40, 63, 64, 1000} {
+		if d := retryDelay(500*time.Millisecond, attempt, nil); d < maxRetryWait/2 || d > maxRetryWait {
+			t.Errorf("attempt %d delay = %s, want between %s and %s", attempt, d, maxRetryWait/2, maxRetryWait)
+		}
+	}
+	if d := retryDelay(-time.Second, 2, nil); d != 0 {
+		t.Errorf("delay for a negative backoff = %s, want 0", d)
+	}
+
+	err := &statusError{code: http.StatusTooManyRequests, retryAfter: 5 * time.Second}
+	if d := retryDelay(time.Millisecond, 0, err); d != 5*time.Second {
+		t.Errorf("delay = %s, want the 5s asked for", d)
+	}
+}
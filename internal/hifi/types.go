@@ -18,6 +18,7 @@ type Album struct {
 	NumberOfVolumes int           `json:"numberOfVolumes"`
 	AudioQuality    string        `json:"audioQuality"`
 	Explicit        bool          `json:"explicit"`
+	Type            string        `json:"type"` // "ALBUM", "EP" or "SINGLE"
 	Artist          ArtistRef     `json:"artist"`
 	Artists         []ArtistRef   `json:"artists"`
 	MediaMetadata   MediaMetadata `json:"mediaMetadata"`
@@ -46,6 +47,24 @@ type Track struct {
 	Explicit     bool        `json:"explicit"`
 	Artist       ArtistRef   `json:"artist"`
 	Artists      []ArtistRef `json:"artists"`
+	Album        AlbumRef    `json:"album"`
+}
+
+// AlbumRef is a lightweight album reference embedded in tracks.
+type AlbumRef struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	Cover string `json:"cover"` // UUID for image URL construction
+}
+
+// Playlist represents a Tidal playlist.
+type Playlist struct {
+	UUID           string `json:"uuid"`
+	Title          string `json:"title"`
+	Description    string `json:"description"`
+	NumberOfTracks int    `json:"numberOfTracks"`
+	LastUpdated    string `json:"lastUpdated"` // changes whenever the playlist is edited
+	SquareImage    string `json:"squareImage"` // UUID for image URL construction
 }
 
 // Playback holds track playback info including the manifest.
@@ -85,6 +104,23 @@ type SimilarAlbum struct {
 	MediaTags   []string    `json:"mediaTags"`
 }
 
+// AsAlbum returns the similar album as an Album, with the fields the V2 API
+// provides. The track count is not among them.
+func (a SimilarAlbum) AsAlbum() Album {
+	album := Album{
+		ID:            a.ID,
+		Title:         a.Title,
+		Cover:         a.Cover,
+		ReleaseDate:   a.ReleaseDate,
+		Artists:       a.Artists,
+		MediaMetadata: MediaMetadata{Tags: a.MediaTags},
+	}
+	if len(a.Artists) > 0 {
+		album.Artist = a.Artists[0]
+	}
+	return album
+}
+
 // SearchResult holds paginated search results.
 type SearchResult[T any] struct {
 	Items []T
@@ -97,6 +133,12 @@ type AlbumDetail struct {
 	Tracks []Track
 }
 
+// PlaylistDetail holds a playlist with its tracks.
+type PlaylistDetail struct {
+	Playlist
+	Tracks []Track
+}
+
 // --- JSON response envelopes (unexported, used only for unmarshaling) ---
 
 type searchArtistsResponse struct {
@@ -133,6 +175,14 @@ type albumDetailResponse struct {
 	} `json:"data"`
 }
 
+type playlistResponse struct {
+	Playlist Playlist `json:"playlist"`
+	Items    []struct {
+		Item Track  `json:"item"`
+		Type string `json:"type"`
+	} `json:"items"`
+}
+
 type similarArtistsResponse struct {
 	Artists []SimilarArtist `json:"artists"`
 }
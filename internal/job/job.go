@@ -0,0 +1,60 @@
+// Package job runs background jobs one at a time, detached from the
+// request that started them.
+package job
+
+import (
+	"context"
+	"sync"
+)
+
+// Runner runs one job at a time in the background. The zero value is
+// ready to use; embed it to give a type Running and Wait.
+type Runner struct {
+	mu   sync.Mutex
+	done chan struct{} // non-nil while a job is running
+}
+
+// Start runs fn in the background, detached from ctx's cancellation, and
+// reports whether it did: it returns false, without running fn, if a job
+// is already running.
+func (r *Runner) Start(ctx context.Context, fn func(ctx context.Context)) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.done != nil {
+		return false
+	}
+	done := make(chan struct{})
+	r.done = done
+
+	go func() {
+		defer func() {
+			r.mu.Lock()
+			r.done = nil
+			r.mu.Unlock()
+			close(done)
+		}()
+		fn(context.WithoutCancel(ctx))
+	}()
+
+	return true
+}
+
+// Running reports whether a job is in progress.
+func (r *Runner) Running() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.done != nil
+}
+
+// Wait blocks until the running job (if any) has finished. It is mainly
+// useful in tests and during shutdown.
+func (r *Runner) Wait() {
+	r.mu.Lock()
+	done := r.done
+	r.mu.Unlock()
+
+	if done != nil {
+		<-done
+	}
+}
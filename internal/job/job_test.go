@@ -0,0 +1,45 @@
+package job
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRunner(t *testing.T) {
+	var r Runner
+	if r.Running() {
+		t.Fatal("zero Runner is running")
+	}
+	r.Wait() // returns straight away when idle
+
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	var jobErr error
+	if !r.Start(ctx, func(ctx context.Context) {
+		<-release
+		jobErr = ctx.Err()
+	}) {
+		t.Fatal("Start refused the first job")
+	}
+	if !r.Running() {
+		t.Error("Running = false while a job is in progress")
+	}
+	if r.Start(ctx, func(context.Context) { t.Error("second job ran") }) {
+		t.Error("Start accepted a job while one was running")
+	}
+
+	// Cancelling the starting context does not reach the job.
+	cancel()
+	close(release)
+	r.Wait()
+	if jobErr != nil {
+		t.Errorf("job context err = %v, want it detached from the caller's", jobErr)
+	}
+	if r.Running() {
+		t.Error("Running = true after Wait")
+	}
+	if !r.Start(context.Background(), func(context.Context) {}) {
+		t.Error("Start refused a job after the last finished")
+	}
+	r.Wait()
+}
@@ -0,0 +1,135 @@
+package library
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+)
+
+// Album link thresholds. A local album is linked to the best-scoring album
+// in its artist's Tidal discography only when the overall score reaches
+// AlbumLinkScore and the titles alone are at least minAlbumTitleScore alike,
+// so a matching track count and year cannot carry a different title.
+const (
+	AlbumLinkScore     = 0.75
+	minAlbumTitleScore = 0.8
+)
+
+// Album score weights. When the local album has no recognisable year the
+// year weight is shared out as a neutral half score.
+const (
+	weightAlbumTitle  = 0.7
+	weightAlbumTracks = 0.2
+	weightAlbumYear   = 0.1
+)
+
+// albumYear matches a plausible release year anywhere in a folder name.
+var albumYear = regexp.MustCompile(`\b(19|20)\d{2}\b`)
+
+// localAlbum is the evidence gathered about an album folder during a scan.
+type localAlbum struct {
+	Folder     string
+	Tag        string // album tag shared by its tracks, if any
+	TrackCount int
+}
+
+// AlbumMatch is the best Tidal album found for a local album.
+type AlbumMatch struct {
+	Album      hifi.Album
+	Score      float64
+	TitleScore float64
+}
+
+// folderYear returns the first plausible year in an album folder name, or 0.
+func folderYear(folder string) int {
+	m := albumYear.FindString(folder)
+	if m == "" {
+		return 0
+	}
+	year, _ := strconv.Atoi(m)
+	return year
+}
+
+// releaseYear returns the year of a Tidal YYYY-MM-DD release date, or 0.
+func releaseYear(date string) int {
+	if len(date) < 4 {
+		return 0
+	}
+	year, err := strconv.Atoi(date[:4])
+	if err != nil {
+		return 0
+	}
+	return year
+}
+
+// albumTitleSimilarity compares a local album against a Tidal title using
+// the better of its folder name and its album tag.
+func albumTitleSimilarity(local localAlbum, title string) float64 {
+	t := normalizeAlbumTitle(title)
+	if t == "" {
+		return 0
+	}
+	best := 0.0
+	for _, candidate := range []string{local.Folder, local.Tag} {
+		c := normalizeAlbumTitle(candidate)
+		if c == "" {
+			continue
+		}
+		best = max(best, similarity(c, t))
+	}
+	return best
+}
+
+// trackCountSimilarity is 1 for equal track counts, falling off with the
+// relative difference. Unknown remote counts score a neutral half.
+func trackCountSimilarity(local, remote int) float64 {
+	if remote <= 0 || local <= 0 {
+		return 0.5
+	}
+	diff := max(local-remote, remote-local)
+	return 1 - float64(diff)/float64(max(local, remote))
+}
+
+// yearSimilarity is 1 for the same year, 0.5 for an adjacent year (reissues
+// and late-December releases) or when either year is unknown, and 0
+// otherwise.
+func yearSimilarity(local, remote int) float64 {
+	switch {
+	case local == 0 || remote == 0:
+		return 0.5
+	case local == remote:
+		return 1
+	case local-remote == 1 || remote-local == 1:
+		return 0.5
+	default:
+		return 0
+	}
+}
+
+// scoreAlbumMatch combines title, track count and year similarity into a
+// single score in [0, 1].
+func scoreAlbumMatch(local localAlbum, remote hifi.Album) AlbumMatch {
+	m := AlbumMatch{
+		Album:      remote,
+		TitleScore: albumTitleSimilarity(local, remote.Title),
+	}
+	m.Score = weightAlbumTitle*m.TitleScore +
+		weightAlbumTracks*trackCountSimilarity(local.TrackCount, remote.NumberOfTracks) +
+		weightAlbumYear*yearSimilarity(folderYear(local.Folder), releaseYear(remote.ReleaseDate))
+	return m
+}
+
+// bestAlbumMatch returns the highest-scoring remote album for a local album
+// and whether it is good enough to link.
+func bestAlbumMatch(local localAlbum, remote []hifi.Album) (AlbumMatch, bool) {
+	var best AlbumMatch
+	for _, r := range remote {
+		m := scoreAlbumMatch(local, r)
+		if m.Score > best.Score {
+			best = m
+		}
+	}
+	ok := best.Score >= AlbumLinkScore && best.TitleScore >= minAlbumTitleScore
+	return best, ok
+}
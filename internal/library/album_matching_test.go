@@ -0,0 +1,98 @@
+package library
+
+import (
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+)
+
+func TestFolderYear(t *testing.T) {
+	tests := []struct {
+		folder string
+		want   int
+	}{
+		{"1997 - OK Computer", 1997},
+		{"OK Computer (1997)", 1997},
+		{"[2011] Bon Iver", 2011},
+		{"OK Computer", 0},
+		{"Album 12345", 0},
+	}
+	for _, tt := range tests {
+		if got := folderYear(tt.folder); got != tt.want {
+			t.Errorf("folderYear(%q) = %d, want %d", tt.folder, got, tt.want)
+		}
+	}
+}
+
+func TestBestAlbumMatch(t *testing.T) {
+	discography := []hifi.Album{
+		{ID: 1, Title: "OK Computer", ReleaseDate: "1997-05-21", NumberOfTracks: 12},
+		{ID: 2, Title: "OK Computer OKNOTOK 1997 2017", ReleaseDate: "2017-06-23", NumberOfTracks: 23},
+		{ID: 3, Title: "Kid A", ReleaseDate: "2000-10-02", NumberOfTracks: 10},
+		{ID: 4, Title: "Amnesiac", ReleaseDate: "2001-06-05", NumberOfTracks: 11},
+	}
+
+	tests := []struct {
+		name   string
+		local  localAlbum
+		wantID int64
+		wantOK bool
+	}{
+		{
+			name:   "year prefixed folder",
+			local:  localAlbum{Folder: "1997 - OK Computer", TrackCount: 12},
+			wantID: 1,
+			wantOK: true,
+		},
+		{
+			name:   "qualifier in brackets",
+			local:  localAlbum{Folder: "Kid A (24-96 FLAC)", TrackCount: 10},
+			wantID: 3,
+			wantOK: true,
+		},
+		{
+			name:   "folder name unusable but tag matches",
+			local:  localAlbum{Folder: "CD1", Tag: "Amnesiac", TrackCount: 11},
+			wantID: 4,
+			wantOK: true,
+		},
+		{
+			name:   "track count alone is not enough",
+			local:  localAlbum{Folder: "Hail to the Thief", TrackCount: 10},
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := bestAlbumMatch(tt.local, discography)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v (best %q scored %.2f), want %v", ok, got.Album.Title, got.Score, tt.wantOK)
+			}
+			if ok && got.Album.ID != tt.wantID {
+				t.Errorf("matched album %d (%q), want %d", got.Album.ID, got.Album.Title, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestScoreAlbumMatch_PrefersMatchingTrackCount(t *testing.T) {
+	local := localAlbum{Folder: "Blonde", TrackCount: 17}
+	standard := scoreAlbumMatch(local, hifi.Album{Title: "Blonde", NumberOfTracks: 17})
+	other := scoreAlbumMatch(local, hifi.Album{Title: "Blond", NumberOfTracks: 9})
+	if standard.Score <= other.Score {
+		t.Errorf("standard edition scored %.2f, other %.2f; want standard higher", standard.Score, other.Score)
+	}
+}
+
+func TestAlbumTag(t *testing.T) {
+	a, b := "Amnesiac", "Amnesiac (Bonus)"
+	tracks := []db.LibraryTrack{{Album: &a}, {Album: &b}, {Album: &a}, {}}
+	if got := albumTag(tracks); got != "Amnesiac" {
+		t.Errorf("albumTag() = %q, want %q", got, "Amnesiac")
+	}
+	if got := albumTag(nil); got != "" {
+		t.Errorf("albumTag(nil) = %q, want empty", got)
+	}
+}
@@ -0,0 +1,145 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+	"github.com/MattHbrook/Crescendo/internal/job"
+)
+
+// ErrCheckInProgress is returned by CompletenessChecker.Start when a check is
+// already running.
+var ErrCheckInProgress = errors.New("library: a completeness check is already running")
+
+// CompletenessStore is the subset of db.Store needed by the completeness
+// checker.
+type CompletenessStore interface {
+	ListLinkedLibraryAlbums(ctx context.Context) ([]db.LibraryAlbum, error)
+	ListTracksForAlbum(ctx context.Context, albumID int64) ([]db.LibraryTrack, error)
+	RecordAlbumCompleteness(ctx context.Context, albumID, tidalAlbumID int64, expected int, missing []db.MissingTrack) error
+	SetTidalAlbumQuality(ctx context.Context, tidalAlbumID int64, quality string) error
+}
+
+// AlbumFetcher is the subset of hifi.Client needed to fetch track lists.
+type AlbumFetcher interface {
+	GetAlbum(ctx context.Context, id int64) (*hifi.AlbumDetail, error)
+}
+
+// CompletenessResult holds aggregate statistics from a completeness check.
+type CompletenessResult struct {
+	AlbumsChecked    int
+	AlbumsIncomplete int
+	TracksMissing    int
+	Errors           []string
+}
+
+// CompletenessChecker compares every Tidal-linked library album with the
+// Tidal track list and records the tracks it is missing, refreshing the
+// album's Tidal quality on the way. Checks run in the background, one at a
+// time.
+type CompletenessChecker struct {
+	store  CompletenessStore
+	albums AlbumFetcher
+	logger *log.Logger
+	job.Runner
+}
+
+// NewCompletenessChecker creates a CompletenessChecker that reads albums from
+// store and track lists from albums.
+func NewCompletenessChecker(store CompletenessStore, albums AlbumFetcher) *CompletenessChecker {
+	return &CompletenessChecker{
+		store:  store,
+		albums: albums,
+		logger: log.New(os.Stderr, "[completeness] ", log.LstdFlags),
+	}
+}
+
+// Start runs a check in the background, detached from ctx's cancellation. It
+// returns ErrCheckInProgress if a check is already running.
+func (c *CompletenessChecker) Start(ctx context.Context) error {
+	if !c.Runner.Start(ctx, func(ctx context.Context) {
+		result, err := c.Check(ctx)
+		if err != nil {
+			c.logger.Printf("check failed: %v", err)
+			return
+		}
+		c.logger.Printf("check complete: %d albums, %d incomplete, %d tracks missing, %d errors",
+			result.AlbumsChecked, result.AlbumsIncomplete, result.TracksMissing, len(result.Errors))
+	}) {
+		return ErrCheckInProgress
+	}
+	return nil
+}
+
+// Check compares every linked library album with its Tidal track list and
+// records the result. It returns an error only if the albums cannot be
+// listed; per-album errors are collected in CompletenessResult.Errors.
+func (c *CompletenessChecker) Check(ctx context.Context) (*CompletenessResult, error) {
+	albums, err := c.store.ListLinkedLibraryAlbums(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("library: listing linked albums: %w", err)
+	}
+
+	result := &CompletenessResult{}
+	for _, a := range albums {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		missing, expected, err := c.checkAlbum(ctx, a)
+		if err != nil {
+			msg := fmt.Sprintf("checking %s/%s: %v", a.ArtistFolder, a.AlbumFolder, err)
+			c.logger.Println(msg)
+			result.Errors = append(result.Errors, msg)
+			continue
+		}
+
+		if err := c.store.RecordAlbumCompleteness(ctx, a.ID, *a.TidalAlbumID, expected, missing); err != nil {
+			msg := fmt.Sprintf("recording completeness of %s/%s: %v", a.ArtistFolder, a.AlbumFolder, err)
+			c.logger.Println(msg)
+			result.Errors = append(result.Errors, msg)
+			continue
+		}
+
+		result.AlbumsChecked++
+		if len(missing) > 0 {
+			result.AlbumsIncomplete++
+			result.TracksMissing += len(missing)
+		}
+	}
+	return result, nil
+}
+
+// checkAlbum returns the Tidal tracks missing from one library album and
+// the number of tracks the Tidal album has.
+func (c *CompletenessChecker) checkAlbum(ctx context.Context, a db.LibraryAlbum) ([]db.MissingTrack, int, error) {
+	detail, err := c.albums.GetAlbum(ctx, *a.TidalAlbumID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("fetching Tidal album %d: %w", *a.TidalAlbumID, err)
+	}
+	if err := c.store.SetTidalAlbumQuality(ctx, *a.TidalAlbumID, AvailableQuality(detail.Album)); err != nil {
+		c.logger.Printf("recording quality of Tidal album %d: %v", *a.TidalAlbumID, err)
+	}
+
+	local, err := c.store.ListTracksForAlbum(ctx, a.ID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("listing tracks: %w", err)
+	}
+
+	var missing []db.MissingTrack
+	for _, t := range MissingTracks(detail.Tracks, local) {
+		missing = append(missing, db.MissingTrack{
+			AlbumID:      a.ID,
+			TidalTrackID: t.ID,
+			DiscNumber:   t.VolumeNumber,
+			TrackNumber:  t.TrackNumber,
+			Title:        t.Title,
+		})
+	}
+	return missing, len(detail.Tracks), nil
+}
@@ -0,0 +1,136 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+)
+
+type mockCompletenessStore struct {
+	albums   []db.LibraryAlbum
+	tracks   map[int64][]db.LibraryTrack
+	recorded map[int64][]db.MissingTrack
+	expected map[int64]int
+	quality  map[int64]string // keyed by Tidal album ID
+	block    chan struct{}    // if non-nil, listing waits for it to close
+}
+
+func (m *mockCompletenessStore) ListLinkedLibraryAlbums(_ context.Context) ([]db.LibraryAlbum, error) {
+	if m.block != nil {
+		<-m.block
+	}
+	return m.albums, nil
+}
+
+func (m *mockCompletenessStore) ListTracksForAlbum(_ context.Context, albumID int64) ([]db.LibraryTrack, error) {
+	return m.tracks[albumID], nil
+}
+
+func (m *mockCompletenessStore) RecordAlbumCompleteness(_ context.Context, albumID, _ int64, expected int, missing []db.MissingTrack) error {
+	if m.recorded == nil {
+		m.recorded = make(map[int64][]db.MissingTrack)
+		m.expected = make(map[int64]int)
+	}
+	m.recorded[albumID] = missing
+	m.expected[albumID] = expected
+	return nil
+}
+
+func (m *mockCompletenessStore) SetTidalAlbumQuality(_ context.Context, tidalAlbumID int64, quality string) error {
+	if m.quality == nil {
+		m.quality = make(map[int64]string)
+	}
+	m.quality[tidalAlbumID] = quality
+	return nil
+}
+
+type mockAlbumFetcher struct {
+	albums map[int64]*hifi.AlbumDetail
+}
+
+func (m *mockAlbumFetcher) GetAlbum(_ context.Context, id int64) (*hifi.AlbumDetail, error) {
+	if a, ok := m.albums[id]; ok {
+		return a, nil
+	}
+	return nil, errors.New("album not found")
+}
+
+func TestCompletenessChecker_Check(t *testing.T) {
+	okComputer, kidA, gone := int64(100), int64(200), int64(300)
+	store := &mockCompletenessStore{
+		albums: []db.LibraryAlbum{
+			{ID: 1, ArtistFolder: "Radiohead", AlbumFolder: "OK Computer", TidalAlbumID: &okComputer},
+			{ID: 2, ArtistFolder: "Radiohead", AlbumFolder: "Kid A", TidalAlbumID: &kidA},
+			{ID: 3, ArtistFolder: "Radiohead", AlbumFolder: "Withdrawn", TidalAlbumID: &gone},
+		},
+		tracks: map[int64][]db.LibraryTrack{
+			1: {{Filename: "01 - Airbag.flac", TrackNumber: 1}},
+			2: {{Filename: "01 - Everything.flac", TrackNumber: 1}, {Filename: "02 - Kid A.flac", TrackNumber: 2}},
+		},
+	}
+	fetcher := &mockAlbumFetcher{albums: map[int64]*hifi.AlbumDetail{
+		100: {Album: hifi.Album{AudioQuality: QualityHiRes}, Tracks: []hifi.Track{
+			{ID: 11, Title: "Airbag", TrackNumber: 1},
+			{ID: 12, Title: "Paranoid Android", TrackNumber: 2},
+			{ID: 13, Title: "Subterranean Homesick Alien", TrackNumber: 3},
+		}},
+		200: {Tracks: []hifi.Track{
+			{ID: 21, Title: "Everything in Its Right Place", TrackNumber: 1},
+			{ID: 22, Title: "Kid A", TrackNumber: 2},
+		}},
+	}}
+
+	result, err := NewCompletenessChecker(store, fetcher).Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check() returned unexpected error: %v", err)
+	}
+
+	if result.AlbumsChecked != 2 || result.AlbumsIncomplete != 1 || result.TracksMissing != 2 {
+		t.Errorf("result = %+v, want 2 checked, 1 incomplete, 2 missing", result)
+	}
+	if len(result.Errors) != 1 {
+		t.Errorf("Errors = %v, want 1 for the unavailable album", result.Errors)
+	}
+
+	missing := store.recorded[1]
+	if len(missing) != 2 || missing[0].TidalTrackID != 12 || missing[1].TidalTrackID != 13 {
+		t.Errorf("missing for album 1 = %+v, want tracks 12 and 13", missing)
+	}
+	if store.expected[1] != 3 {
+		t.Errorf("expected tracks for album 1 = %d, want 3", store.expected[1])
+	}
+	if got, ok := store.recorded[2]; !ok || len(got) != 0 {
+		t.Errorf("album 2 recorded %v (recorded=%v), want complete", got, ok)
+	}
+	if store.quality[100] != QualityHiRes || store.quality[200] != QualityLossy {
+		t.Errorf("recorded qualities = %v, want 100 hi-res and 200 lossy", store.quality)
+	}
+}
+
+func TestCompletenessChecker_Start(t *testing.T) {
+	store := &mockCompletenessStore{block: make(chan struct{})}
+	checker := NewCompletenessChecker(store, &mockAlbumFetcher{})
+
+	if err := checker.Start(context.Background()); err != nil {
+		t.Fatalf("Start() returned unexpected error: %v", err)
+	}
+	if !checker.Running() {
+		t.Error("Running() = false while a check is in progress")
+	}
+	if err := checker.Start(context.Background()); !errors.Is(err, ErrCheckInProgress) {
+		t.Errorf("Start() while running = %v, want ErrCheckInProgress", err)
+	}
+
+	close(store.block)
+	checker.Wait()
+	if checker.Running() {
+		t.Error("Running() = true after Wait")
+	}
+	if err := checker.Start(context.Background()); err != nil {
+		t.Fatalf("second Start() returned unexpected error: %v", err)
+	}
+	checker.Wait()
+}
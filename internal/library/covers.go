@@ -0,0 +1,83 @@
+package library
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-flac/flacpicture/v2"
+	flac "github.com/go-flac/go-flac/v2"
+)
+
+// ErrNoCover is returned by EmbeddedCover when a file has no picture.
+var ErrNoCover = errors.New("library: no embedded cover art")
+
+var (
+	// coverNames are the conventional album art filenames, without
+	// extension, in order of preference.
+	coverNames = []string{"cover", "folder", "front", "album", "albumart"}
+	// coverExts are the image formats served as album art.
+	coverExts = []string{".jpg", ".jpeg", ".png"}
+)
+
+// FindCover returns the path of the album art image in dir: a file with a
+// conventional name such as cover.jpg or folder.png (any case), otherwise the
+// first image file. ok is false when dir holds no image.
+func FindCover(dir string) (path string, ok bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+
+	best, bestRank := "", len(coverNames)+1
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || !slices.Contains(coverExts, ext) {
+			continue
+		}
+		rank := slices.Index(coverNames, strings.ToLower(strings.TrimSuffix(e.Name(), filepath.Ext(e.Name()))))
+		if rank < 0 {
+			rank = len(coverNames) // any other image
+		}
+		if rank < bestRank {
+			best, bestRank = filepath.Join(dir, e.Name()), rank
+		}
+	}
+	return best, best != ""
+}
+
+// EmbeddedCover returns the picture embedded in a FLAC file, preferring the
+// front cover, and its MIME type. It returns ErrNoCover when there is none.
+func EmbeddedCover(path string) ([]byte, string, error) {
+	f, err := os.Open(path) //nolint:gosec // path comes from the library index
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	meta, err := flac.ParseMetadata(f)
+	if err != nil {
+		return nil, "", fmt.Errorf("library: reading FLAC metadata of %s: %w", path, err)
+	}
+
+	var found *flacpicture.MetadataBlockPicture
+	for _, block := range meta.Meta {
+		if block.Type != flac.Picture {
+			continue
+		}
+		pic, err := flacpicture.ParseFromMetaDataBlock(*block)
+		if err != nil {
+			continue
+		}
+		if found == nil || pic.PictureType == flacpicture.PictureTypeFrontCover {
+			found = pic
+		}
+	}
+	if found == nil {
+		return nil, "", ErrNoCover
+	}
+	return found.ImageData, found.MIME, nil
+}
@@ -0,0 +1,47 @@
+package library
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindCover(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{"conventional name preferred", []string{"scan.png", "Folder.JPG", "cover.jpg"}, "cover.jpg"},
+		{"any image as fallback", []string{"01 - Airbag.flac", "scan.png"}, "scan.png"},
+		{"no image", []string{"01 - Airbag.flac", "cover.txt"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, f := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, f), nil, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, ok := FindCover(dir)
+			if ok != (tt.want != "") {
+				t.Fatalf("FindCover() ok = %v, want %v", ok, tt.want != "")
+			}
+			if ok && filepath.Base(got) != tt.want {
+				t.Errorf("FindCover() = %q, want %q", filepath.Base(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbeddedCover_NotFLAC(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "track.flac")
+	if err := os.WriteFile(path, []byte("not a flac"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := EmbeddedCover(path); err == nil || errors.Is(err, ErrNoCover) {
+		t.Errorf("EmbeddedCover() error = %v, want a parse error", err)
+	}
+}
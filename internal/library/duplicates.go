@@ -0,0 +1,386 @@
+package library
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+)
+
+// DuplicateReason is a piece of evidence that two library albums are copies
+// of the same release.
+type DuplicateReason string
+
+// Duplicate reasons, from folder names to audio content.
+const (
+	SameName      DuplicateReason = "name"      // same normalised artist and album folder
+	SameTidal     DuplicateReason = "tidal"     // linked to the same Tidal album
+	SameDurations DuplicateReason = "durations" // same track durations
+)
+
+const (
+	// minFingerprintTracks is the fewest tracks an album needs for its
+	// durations alone to mark it as a duplicate; singles and short EPs
+	// collide too easily.
+	minFingerprintTracks = 4
+	// durationTolerance is how far apart, in seconds, two rips of the same
+	// track may be.
+	durationTolerance = 2.0
+)
+
+// DuplicateGroup is a set of library albums that look like copies of one
+// release, with the evidence that links them.
+type DuplicateGroup struct {
+	Albums  []db.DuplicateCandidate // best copy first (see betterCopy)
+	Reasons []DuplicateReason
+}
+
+// FindDuplicates groups albums that share a normalised artist and album
+// folder name, a Tidal album link or a duration fingerprint. Evidence is
+// transitive: if A matches B by name and B matches C by durations, all three
+// form one group. Groups are returned in the order of their first album.
+func FindDuplicates(albums []db.DuplicateCandidate) []DuplicateGroup {
+	parent := make([]int, len(albums))
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	type edge struct {
+		a, b   int
+		reason DuplicateReason
+	}
+	var edges []edge
+	link := func(buckets map[string][]int, reason DuplicateReason) {
+		for _, members := range buckets {
+			for _, m := range members[1:] {
+				edges = append(edges, edge{members[0], m, reason})
+			}
+		}
+	}
+
+	byName := make(map[string][]int)
+	byTidal := make(map[string][]int)
+	byLength := make(map[int][]int)
+	for i, a := range albums {
+		byName[duplicateNameKey(a.Album)] = append(byName[duplicateNameKey(a.Album)], i)
+		if a.Album.TidalAlbumID != nil {
+			key := fmt.Sprint(*a.Album.TidalAlbumID)
+			byTidal[key] = append(byTidal[key], i)
+		}
+		if fingerprintable(a.Durations) {
+			byLength[len(a.Durations)] = append(byLength[len(a.Durations)], i)
+		}
+	}
+	link(byName, SameName)
+	link(byTidal, SameTidal)
+	for _, members := range byLength {
+		for x, i := range members {
+			for _, j := range members[x+1:] {
+				if sameDurations(albums[i].Durations, albums[j].Durations) {
+					edges = append(edges, edge{i, j, SameDurations})
+				}
+			}
+		}
+	}
+
+	for _, e := range edges {
+		parent[find(e.a)] = find(e.b)
+	}
+
+	members := make(map[int][]int)
+	reasons := make(map[int][]DuplicateReason)
+	for i := range albums {
+		root := find(i)
+		members[root] = append(members[root], i)
+	}
+	for _, e := range edges {
+		root := find(e.a)
+		if !slices.Contains(reasons[root], e.reason) {
+			reasons[root] = append(reasons[root], e.reason)
+		}
+	}
+
+	var roots []int
+	for root, m := range members {
+		if len(m) > 1 {
+			roots = append(roots, root)
+		}
+	}
+	slices.SortFunc(roots, func(a, b int) int { return cmp.Compare(members[a][0], members[b][0]) })
+
+	groups := make([]DuplicateGroup, 0, len(roots))
+	for _, root := range roots {
+		g := DuplicateGroup{Reasons: reasons[root]}
+		for _, i := range members[root] {
+			g.Albums = append(g.Albums, albums[i])
+		}
+		slices.SortStableFunc(g.Albums, func(a, b db.DuplicateCandidate) int {
+			if betterCopy(a, b) {
+				return -1
+			}
+			if betterCopy(b, a) {
+				return 1
+			}
+			return 0
+		})
+		slices.SortFunc(g.Reasons, func(a, b DuplicateReason) int { return strings.Compare(string(a), string(b)) })
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+// duplicateNameKey reduces an album's artist and album folders to a key
+// that ignores spelling differences such as "AC_DC" and "AC-DC", year
+// prefixes and bracketed qualifiers.
+func duplicateNameKey(a db.LibraryAlbum) string {
+	artist := strings.ReplaceAll(normalizeName(a.ArtistFolder), " ", "")
+	album := strings.ReplaceAll(normalizeAlbumTitle(a.AlbumFolder), " ", "")
+	return artist + "/" + album
+}
+
+// fingerprintable reports whether an album has enough tracks with known
+// durations to be matched on durations alone.
+func fingerprintable(durations []float64) bool {
+	if len(durations) < minFingerprintTracks {
+		return false
+	}
+	return !slices.ContainsFunc(durations, func(d float64) bool { return d <= 0 })
+}
+
+// sameDurations reports whether two equally long duration lists match track
+// for track within durationTolerance.
+func sameDurations(a, b []float64) bool {
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > durationTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// betterCopy reports whether a is a better copy to keep than b: higher
+// quality first, then more tracks, higher bit depth and sample rate, larger
+// files and finally the older library entry.
+func betterCopy(a, b db.DuplicateCandidate) bool {
+	if qa, qb := qualityRank(HoldingQuality(a.Holding)), qualityRank(HoldingQuality(b.Holding)); qa != qb {
+		return qa > qb
+	}
+	if a.Album.TrackCount != b.Album.TrackCount {
+		return a.Album.TrackCount > b.Album.TrackCount
+	}
+	if a.Holding.MinBitDepth != b.Holding.MinBitDepth {
+		return a.Holding.MinBitDepth > b.Holding.MinBitDepth
+	}
+	if a.Holding.MinSampleRate != b.Holding.MinSampleRate {
+		return a.Holding.MinSampleRate > b.Holding.MinSampleRate
+	}
+	if a.SizeBytes != b.SizeBytes {
+		return a.SizeBytes > b.SizeBytes
+	}
+	return a.Album.ID < b.Album.ID
+}
+
+// DedupeStore is the subset of db.Store needed to resolve duplicates.
+type DedupeStore interface {
+	GetLibraryAlbum(ctx context.Context, id int64) (*db.LibraryAlbum, error)
+	ListTracksForAlbum(ctx context.Context, albumID int64) ([]db.LibraryTrack, error)
+	DeleteLibraryAlbum(ctx context.Context, id int64) error
+	UpsertLibraryAlbum(ctx context.Context, artistFolder, albumFolder string, trackCount int, path string) error
+	SyncLibraryTracks(ctx context.Context, artistFolder, albumFolder string, tracks []db.LibraryTrack) error
+}
+
+// Deduper resolves duplicate albums by merging them into one copy or
+// deleting them. Folders it removes are moved to the trash (see
+// MoveToTrash), never deleted outright.
+type Deduper struct {
+	musicPath string
+	trashPath string
+	store     DedupeStore
+	logger    *log.Logger
+}
+
+// NewDeduper creates a Deduper for the library at musicPath that keeps
+// removed folders under trashPath.
+func NewDeduper(musicPath, trashPath string, store DedupeStore) *Deduper {
+	return &Deduper{
+		musicPath: musicPath,
+		trashPath: trashPath,
+		store:     store,
+		logger:    log.New(os.Stderr, "[dedupe] ", log.LstdFlags),
+	}
+}
+
+// Delete moves the folders of the given albums to the trash and removes
+// them from the library.
+func (d *Deduper) Delete(ctx context.Context, ids []int64) error {
+	for _, id := range ids {
+		album, err := d.store.GetLibraryAlbum(ctx, id)
+		if err != nil {
+			return fmt.Errorf("library: loading album %d: %w", id, err)
+		}
+		if err := d.trash(ctx, album); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ErrMergeMismatch is returned by Merge when a track it would move differs
+// in format or bit depth from the kept album's tracks, so that merging would
+// leave the album mixed.
+var ErrMergeMismatch = errors.New("library: tracks differ in quality from the kept album")
+
+// trackMove is a track Merge moves into the kept album's folder.
+type trackMove struct {
+	from, to string
+}
+
+// Merge moves the tracks of each duplicate that keepID lacks into keepID's
+// folder, then rescans the kept album and trashes the duplicates. Tracks are
+// matched by disc and track number or by title, as MissingTracks does; a
+// track whose filename is already taken stays behind and goes to the trash
+// with its folder. Nothing is moved if any track to be moved is of another
+// format or bit depth than the kept album (ErrMergeMismatch), and if a move
+// fails those already made are undone. It returns the number of tracks
+// moved.
+func (d *Deduper) Merge(ctx context.Context, keepID int64, ids []int64) (int, error) {
+	keep, err := d.store.GetLibraryAlbum(ctx, keepID)
+	if err != nil {
+		return 0, fmt.Errorf("library: loading album %d: %w", keepID, err)
+	}
+	local, err := d.store.ListTracksForAlbum(ctx, keepID)
+	if err != nil {
+		return 0, fmt.Errorf("library: listing tracks of album %d: %w", keepID, err)
+	}
+	have := newTrackIndex(local)
+
+	var dups []*db.LibraryAlbum
+	var moves []trackMove
+	taken := make(map[string]bool)
+	for _, id := range ids {
+		if id == keepID {
+			continue
+		}
+		dup, err := d.store.GetLibraryAlbum(ctx, id)
+		if err != nil {
+			return 0, fmt.Errorf("library: loading album %d: %w", id, err)
+		}
+		if filepath.Clean(dup.Path) == filepath.Clean(keep.Path) {
+			return 0, fmt.Errorf("library: albums %d and %d share folder %s", keepID, id, keep.Path)
+		}
+		dups = append(dups, dup)
+
+		tracks, err := d.store.ListTracksForAlbum(ctx, id)
+		if err != nil {
+			return 0, fmt.Errorf("library: listing tracks of album %d: %w", id, err)
+		}
+		for _, t := range tracks {
+			if have.has(trackPosition{max(t.DiscNumber, 1), t.TrackNumber}, localTrackTitle(t)) {
+				continue
+			}
+			dest := filepath.Join(keep.Path, t.Filename)
+			if _, err := os.Lstat(dest); err == nil || taken[dest] {
+				continue
+			}
+			if len(local) > 0 && !sameTrackQuality(local[0], t) {
+				return 0, fmt.Errorf("%w: %s is %s, %s is %s", ErrMergeMismatch,
+					t.Path, trackQualityLabel(t), keep.Path, trackQualityLabel(local[0]))
+			}
+			moves = append(moves, trackMove{from: t.Path, to: dest})
+			taken[dest] = true
+			have.add(t)
+		}
+	}
+
+	for i, m := range moves {
+		if err := os.Rename(m.from, m.to); err != nil {
+			d.undoMoves(moves[:i])
+			return 0, fmt.Errorf("library: moving %s into %s: %w", m.from, keep.Path, err)
+		}
+	}
+
+	if len(moves) > 0 {
+		if err := d.rescan(ctx, keep); err != nil {
+			return len(moves), err
+		}
+	}
+	for _, dup := range dups {
+		if err := d.trash(ctx, dup); err != nil {
+			return len(moves), err
+		}
+	}
+	return len(moves), nil
+}
+
+// undoMoves moves tracks back to where they came from after a failed merge,
+// last first.
+func (d *Deduper) undoMoves(moves []trackMove) {
+	for _, m := range slices.Backward(moves) {
+		if err := os.Rename(m.to, m.from); err != nil {
+			d.logger.Printf("restoring %s from %s failed: %v", m.from, m.to, err)
+		}
+	}
+}
+
+// sameTrackQuality reports whether two tracks share a format and bit depth.
+func sameTrackQuality(a, b db.LibraryTrack) bool {
+	return a.Format == b.Format && a.BitDepth == b.BitDepth
+}
+
+// trackQualityLabel describes a track's format and bit depth for errors.
+func trackQualityLabel(t db.LibraryTrack) string {
+	if t.BitDepth == 0 {
+		return t.Format
+	}
+	return fmt.Sprintf("%d-bit %s", t.BitDepth, t.Format)
+}
+
+// trash moves an album's folder to the trash and removes the album from the
+// library. A folder that is already gone is not an error.
+func (d *Deduper) trash(ctx context.Context, album *db.LibraryAlbum) error {
+	trashed, err := MoveToTrash(d.musicPath, d.trashPath, album.Path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("library: moving %s to trash: %w", album.Path, err)
+	}
+	if err := d.store.DeleteLibraryAlbum(ctx, album.ID); err != nil {
+		return fmt.Errorf("library: removing album %d: %w", album.ID, err)
+	}
+	if trashed != "" {
+		d.logger.Printf("moved %s to %s", album.Path, trashed)
+	}
+	return nil
+}
+
+// rescan re-reads an album's tracks from disk after a merge.
+func (d *Deduper) rescan(ctx context.Context, album *db.LibraryAlbum) error {
+	tracks, probeErrs, err := scanAlbumTracks(album.Path)
+	if err != nil {
+		return fmt.Errorf("library: rescanning %s: %w", album.Path, err)
+	}
+	for _, msg := range probeErrs {
+		d.logger.Println(msg)
+	}
+	if err := d.store.UpsertLibraryAlbum(ctx, album.ArtistFolder, album.AlbumFolder, len(tracks), album.Path); err != nil {
+		return fmt.Errorf("library: updating album %d: %w", album.ID, err)
+	}
+	if err := d.store.SyncLibraryTracks(ctx, album.ArtistFolder, album.AlbumFolder, tracks); err != nil {
+		return fmt.Errorf("library: storing tracks of album %d: %w", album.ID, err)
+	}
+	return nil
+}
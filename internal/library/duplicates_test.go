@@ -0,0 +1,275 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	tidalID := int64(100)
+	album := func(id int64, artist, folder string, h db.AlbumHolding, durations ...float64) db.DuplicateCandidate {
+		h.LibraryAlbumID = id
+		return db.DuplicateCandidate{
+			Album:     db.LibraryAlbum{ID: id, ArtistFolder: artist, AlbumFolder: folder, TrackCount: len(durations)},
+			Holding:   h,
+			Durations: durations,
+		}
+	}
+	cd := db.AlbumHolding{MinBitDepth: 16, MinSampleRate: 44100}
+	hiRes := db.AlbumHolding{MinBitDepth: 24, MinSampleRate: 96000}
+	mp3 := db.AlbumHolding{MinSampleRate: 44100, LossyTracks: 4}
+
+	linked := album(6, "Radiohead", "OK Computer (2017 Remaster)", cd, 284, 383, 267, 237)
+	linked.Album.TidalAlbumID = &tidalID
+	otherLink := album(7, "Radiohead", "OKNOTOK", cd)
+	otherLink.Album.TidalAlbumID = &tidalID
+
+	albums := []db.DuplicateCandidate{
+		album(1, "AC_DC", "Back in Black", cd, 255, 210, 253, 222),
+		album(2, "AC-DC", "1980 - Back in Black [FLAC 24-96]", hiRes, 255, 210, 253, 222),
+		album(3, "Unknown Artist", "Rip 2", mp3, 255.5, 211, 252, 223),
+		album(4, "Radiohead", "Kid A", cd, 100, 200, 300, 400),
+		album(5, "Radiohead", "Single", cd, 100),
+		linked,
+		otherLink,
+		album(8, "Someone", "Single", cd, 100),
+	}
+
+	groups := FindDuplicates(albums)
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2: %+v", len(groups), groups)
+	}
+
+	var ids []int64
+	for _, a := range groups[0].Albums {
+		ids = append(ids, a.Album.ID)
+	}
+	if !slices.Equal(ids, []int64{2, 1, 3}) {
+		t.Errorf("group 0 albums = %v, want [2 1 3] (hi-res first, mp3 last)", ids)
+	}
+	if !slices.Equal(groups[0].Reasons, []DuplicateReason{SameDurations, SameName}) {
+		t.Errorf("group 0 reasons = %v, want durations and name", groups[0].Reasons)
+	}
+
+	ids = ids[:0]
+	for _, a := range groups[1].Albums {
+		ids = append(ids, a.Album.ID)
+	}
+	if !slices.Equal(ids, []int64{6, 7}) {
+		t.Errorf("group 1 albums = %v, want [6 7] (more tracks first)", ids)
+	}
+	if !slices.Equal(groups[1].Reasons, []DuplicateReason{SameTidal}) {
+		t.Errorf("group 1 reasons = %v, want tidal", groups[1].Reasons)
+	}
+}
+
+type mockDedupeStore struct {
+	albums  map[int64]*db.LibraryAlbum
+	tracks  map[int64][]db.LibraryTrack
+	deleted []int64
+	synced  []db.LibraryTrack
+}
+
+func (m *mockDedupeStore) GetLibraryAlbum(_ context.Context, id int64) (*db.LibraryAlbum, error) {
+	a, ok := m.albums[id]
+	if !ok {
+		return nil, db.ErrAlbumNotFound
+	}
+	return a, nil
+}
+
+func (m *mockDedupeStore) ListTracksForAlbum(_ context.Context, albumID int64) ([]db.LibraryTrack, error) {
+	return m.tracks[albumID], nil
+}
+
+func (m *mockDedupeStore) DeleteLibraryAlbum(_ context.Context, id int64) error {
+	m.deleted = append(m.deleted, id)
+	delete(m.albums, id)
+	return nil
+}
+
+func (m *mockDedupeStore) UpsertLibraryAlbum(_ context.Context, _, _ string, _ int, _ string) error {
+	return nil
+}
+
+func (m *mockDedupeStore) SyncLibraryTracks(_ context.Context, _, _ string, tracks []db.LibraryTrack) error {
+	m.synced = tracks
+	return nil
+}
+
+// writeAlbum creates an album folder under root with the given files.
+func writeAlbum(t *testing.T, root, artist, album string, files ...string) string {
+	t.Helper()
+	dir := filepath.Join(root, artist, album)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(dir, f), []byte(f), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDeduper_Merge(t *testing.T) {
+	root := t.TempDir()
+	trash := filepath.Join(root, ".trash")
+	keepDir := writeAlbum(t, root, "AC-DC", "Back in Black", "01 - Hells Bells.flac")
+	dupDir := writeAlbum(t, root, "AC_DC", "Back in Black", "01 Hells Bells.flac", "02 Shoot to Thrill.flac")
+
+	store := &mockDedupeStore{
+		albums: map[int64]*db.LibraryAlbum{
+			1: {ID: 1, ArtistFolder: "AC-DC", AlbumFolder: "Back in Black", Path: keepDir},
+			2: {ID: 2, ArtistFolder: "AC_DC", AlbumFolder: "Back in Black", Path: dupDir},
+		},
+		tracks: map[int64][]db.LibraryTrack{
+			1: {{Filename: "01 - Hells Bells.flac", Path: filepath.Join(keepDir, "01 - Hells Bells.flac"), Format: "flac", BitDepth: 16, TrackNumber: 1}},
+			2: {
+				{Filename: "01 Hells Bells.flac", Path: filepath.Join(dupDir, "01 Hells Bells.flac"), Format: "flac", BitDepth: 16, TrackNumber: 1},
+				{Filename: "02 Shoot to Thrill.flac", Path: filepath.Join(dupDir, "02 Shoot to Thrill.flac"), Format: "flac", BitDepth: 16, TrackNumber: 2},
+			},
+		},
+	}
+
+	moved, err := NewDeduper(root, trash, store).Merge(context.Background(), 1, []int64{1, 2})
+	if err != nil {
+		t.Fatalf("Merge() returned unexpected error: %v", err)
+	}
+	if moved != 1 {
+		t.Errorf("moved = %d, want 1", moved)
+	}
+
+	if _, err := os.Stat(filepath.Join(keepDir, "02 Shoot to Thrill.flac")); err != nil {
+		t.Errorf("missing track not moved into kept album: %v", err)
+	}
+	if _, err := os.Stat(dupDir); !os.IsNotExist(err) {
+		t.Errorf("duplicate folder should be gone, stat err = %v", err)
+	}
+	trashed, _ := filepath.Glob(filepath.Join(trash, "*", "AC_DC", "Back in Black", "01 Hells Bells.flac"))
+	if len(trashed) != 1 {
+		t.Errorf("duplicate track not in trash: %v", trashed)
+	}
+	if !slices.Equal(store.deleted, []int64{2}) {
+		t.Errorf("deleted = %v, want [2]", store.deleted)
+	}
+	if len(store.synced) != 2 {
+		t.Errorf("kept album rescanned with %d tracks, want 2", len(store.synced))
+	}
+}
+
+func TestDeduper_Merge_LeavesEverythingOnFailure(t *testing.T) {
+	keepTrack := db.LibraryTrack{Filename: "01 - Hells Bells.flac", Format: "flac", BitDepth: 24, TrackNumber: 1}
+
+	tests := []struct {
+		name    string
+		tracks  []db.LibraryTrack // of the duplicate, in dupDir
+		missing string            // a track of the duplicate not on disk
+		wantErr error
+	}{
+		{
+			name: "lower bit depth",
+			tracks: []db.LibraryTrack{
+				{Filename: "02 Shoot to Thrill.flac", Format: "flac", BitDepth: 16, TrackNumber: 2},
+			},
+			wantErr: ErrMergeMismatch,
+		},
+		{
+			name: "other format",
+			tracks: []db.LibraryTrack{
+				{Filename: "02 Shoot to Thrill.flac", Format: "flac", BitDepth: 24, TrackNumber: 2},
+				{Filename: "03 What Do You Do for Money Honey.mp3", Format: "mp3", TrackNumber: 3},
+			},
+			wantErr: ErrMergeMismatch,
+		},
+		{
+			name: "failed move",
+			tracks: []db.LibraryTrack{
+				{Filename: "02 Shoot to Thrill.flac", Format: "flac", BitDepth: 24, TrackNumber: 2},
+				{Filename: "03 What Do You Do for Money Honey.flac", Format: "flac", BitDepth: 24, TrackNumber: 3},
+			},
+			missing: "03 What Do You Do for Money Honey.flac",
+			wantErr: fs.ErrNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			keepDir := writeAlbum(t, root, "AC-DC", "Back in Black", keepTrack.Filename)
+			var files []string
+			for _, track := range tt.tracks {
+				if track.Filename != tt.missing {
+					files = append(files, track.Filename)
+				}
+			}
+			dupDir := writeAlbum(t, root, "AC_DC", "Back in Black", files...)
+
+			keep := keepTrack
+			keep.Path = filepath.Join(keepDir, keep.Filename)
+			var dupTracks []db.LibraryTrack
+			for _, track := range tt.tracks {
+				track.Path = filepath.Join(dupDir, track.Filename)
+				dupTracks = append(dupTracks, track)
+			}
+			store := &mockDedupeStore{
+				albums: map[int64]*db.LibraryAlbum{
+					1: {ID: 1, ArtistFolder: "AC-DC", AlbumFolder: "Back in Black", Path: keepDir},
+					2: {ID: 2, ArtistFolder: "AC_DC", AlbumFolder: "Back in Black", Path: dupDir},
+				},
+				tracks: map[int64][]db.LibraryTrack{1: {keep}, 2: dupTracks},
+			}
+
+			moved, err := NewDeduper(root, filepath.Join(root, ".trash"), store).Merge(context.Background(), 1, []int64{2})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Merge() error = %v, want %v", err, tt.wantErr)
+			}
+			if moved != 0 {
+				t.Errorf("moved = %d, want 0", moved)
+			}
+			for _, f := range files {
+				if _, err := os.Stat(filepath.Join(dupDir, f)); err != nil {
+					t.Errorf("%s should still be in the duplicate: %v", f, err)
+				}
+			}
+			if entries, _ := os.ReadDir(keepDir); len(entries) != 1 {
+				t.Errorf("kept album has %d files, want only its own", len(entries))
+			}
+			if store.deleted != nil || store.synced != nil {
+				t.Errorf("deleted %v and synced %v, want the library untouched", store.deleted, store.synced)
+			}
+		})
+	}
+}
+
+func TestDeduper_Delete(t *testing.T) {
+	root := t.TempDir()
+	trash := filepath.Join(root, ".trash")
+	dir := writeAlbum(t, root, "AC_DC", "Back in Black", "01 Hells Bells.mp3")
+
+	store := &mockDedupeStore{
+		albums: map[int64]*db.LibraryAlbum{
+			2: {ID: 2, ArtistFolder: "AC_DC", AlbumFolder: "Back in Black", Path: dir},
+			3: {ID: 3, ArtistFolder: "AC_DC", AlbumFolder: "Gone", Path: filepath.Join(root, "AC_DC", "Gone")},
+		},
+	}
+
+	if err := NewDeduper(root, trash, store).Delete(context.Background(), []int64{2, 3}); err != nil {
+		t.Fatalf("Delete() returned unexpected error: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("album folder should be gone, stat err = %v", err)
+	}
+	if trashed, _ := filepath.Glob(filepath.Join(trash, "*", "AC_DC", "Back in Black")); len(trashed) != 1 {
+		t.Errorf("album folder not in trash: %v", trashed)
+	}
+	if !slices.Equal(store.deleted, []int64{2, 3}) {
+		t.Errorf("deleted = %v, want [2 3] (a missing folder is still forgotten)", store.deleted)
+	}
+}
@@ -0,0 +1,140 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/MattHbrook/Crescendo/internal/job"
+)
+
+// ErrScanInProgress is returned by ScanManager.Start when a scan is already
+// running.
+var ErrScanInProgress = errors.New("library: a scan is already running")
+
+// ScanRunner is the subset of Scanner needed by the scan manager.
+type ScanRunner interface {
+	ScanWithProgress(ctx context.Context, progress ProgressFunc) (*ScanResult, error)
+	ScanArtist(ctx context.Context, artistFolder string) (*ScanResult, error)
+}
+
+// ScanStore persists scan history and progress.
+type ScanStore interface {
+	CreateScan(ctx context.Context) (int64, error)
+	UpdateScanProgress(ctx context.Context, id int64, processed, total int) error
+	CompleteScan(ctx context.Context, id int64, artistsFound, albumsFound, artistsMatched int, errs []string) error
+	FailScan(ctx context.Context, id int64, errMsg string) error
+}
+
+// ScanManager runs library scans as background jobs, recording their
+// progress and results so they outlive the HTTP request that started them.
+// Only one scan runs at a time.
+type ScanManager struct {
+	runner ScanRunner
+	store  ScanStore
+	logger *log.Logger
+	job.Runner
+
+	mu      sync.Mutex // held while starting a scan
+	current int64      // ID of the last scan started
+}
+
+// NewScanManager creates a ScanManager that runs scans with runner and
+// records them in store.
+func NewScanManager(runner ScanRunner, store ScanStore) *ScanManager {
+	return &ScanManager{
+		runner: runner,
+		store:  store,
+		logger: log.New(os.Stderr, "[scan] ", log.LstdFlags),
+	}
+}
+
+// Start records a new scan and runs it in the background, returning the scan
+// ID immediately. The scan is detached from ctx's cancellation so it keeps
+// running after the request that started it has finished. If a scan is
+// already running, Start returns its ID together with ErrScanInProgress.
+func (m *ScanManager) Start(ctx context.Context) (int64, error) {
+	return m.start(ctx, m.runner.ScanWithProgress)
+}
+
+// StartArtists is like Start but rescans only the given artist folders, as
+// the watcher does when they change.
+func (m *ScanManager) StartArtists(ctx context.Context, artists []string) (int64, error) {
+	return m.start(ctx, func(ctx context.Context, progress ProgressFunc) (*ScanResult, error) {
+		return m.rescan(ctx, artists, progress)
+	})
+}
+
+// start records a new scan and runs scan in the background.
+func (m *ScanManager) start(ctx context.Context, scan func(context.Context, ProgressFunc) (*ScanResult, error)) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Running() {
+		return m.current, ErrScanInProgress
+	}
+
+	id, err := m.store.CreateScan(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("library: starting scan: %w", err)
+	}
+	m.current = id
+	// Scans only start under m.mu, so nothing can have beaten this one.
+	m.Runner.Start(ctx, func(ctx context.Context) {
+		m.run(ctx, id, scan)
+	})
+
+	return id, nil
+}
+
+// rescan rescans the given artist folders one after another, adding up
+// their results. A folder that cannot be rescanned is reported among the
+// errors rather than failing the others.
+func (m *ScanManager) rescan(ctx context.Context, artists []string, progress ProgressFunc) (*ScanResult, error) {
+	total := &ScanResult{}
+	for i, artist := range artists {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("scan cancelled: %w", err)
+		}
+		result, err := m.runner.ScanArtist(ctx, artist)
+		if err != nil {
+			total.Errors = append(total.Errors, fmt.Sprintf("rescanning %s: %v", artist, err))
+		} else {
+			total.ArtistsFound += result.ArtistsFound
+			total.AlbumsFound += result.AlbumsFound
+			total.ArtistsMatched += result.ArtistsMatched
+			total.ArtistsQueued += result.ArtistsQueued
+			total.AlbumsLinked += result.AlbumsLinked
+			total.Errors = append(total.Errors, result.Errors...)
+		}
+		progress(i+1, len(artists))
+	}
+	return total, nil
+}
+
+// run executes a scan and records its outcome.
+func (m *ScanManager) run(ctx context.Context, id int64, scan func(context.Context, ProgressFunc) (*ScanResult, error)) {
+	result, err := scan(ctx, func(processed, total int) {
+		if err := m.store.UpdateScanProgress(ctx, id, processed, total); err != nil {
+			m.logger.Printf("scan %d: %v", id, err)
+		}
+	})
+	if err != nil {
+		m.logger.Printf("scan %d failed: %v", id, err)
+		if ferr := m.store.FailScan(ctx, id, err.Error()); ferr != nil {
+			m.logger.Printf("scan %d: %v", id, ferr)
+		}
+		return
+	}
+
+	if err := m.store.CompleteScan(ctx, id, result.ArtistsFound, result.AlbumsFound, result.ArtistsMatched, result.Errors); err != nil {
+		m.logger.Printf("scan %d: %v", id, err)
+		return
+	}
+
+	m.logger.Printf("scan %d complete: %d artists, %d albums, %d matched, %d errors",
+		id, result.ArtistsFound, result.AlbumsFound, result.ArtistsMatched, len(result.Errors))
+}
@@ -0,0 +1,167 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+type mockScanStore struct {
+	mu        sync.Mutex
+	nextID    int64
+	progress  [][2]int
+	completed map[int64][]string
+	failed    map[int64]string
+}
+
+func newMockScanStore() *mockScanStore {
+	return &mockScanStore{
+		completed: make(map[int64][]string),
+		failed:    make(map[int64]string),
+	}
+}
+
+func (m *mockScanStore) CreateScan(_ context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	return m.nextID, nil
+}
+
+func (m *mockScanStore) UpdateScanProgress(_ context.Context, _ int64, processed, total int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.progress = append(m.progress, [2]int{processed, total})
+	return nil
+}
+
+func (m *mockScanStore) CompleteScan(_ context.Context, id int64, _, _, _ int, errs []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.completed[id] = errs
+	return nil
+}
+
+func (m *mockScanStore) FailScan(_ context.Context, id int64, errMsg string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failed[id] = errMsg
+	return nil
+}
+
+// blockingRunner reports two artists of progress and then waits for release
+// before returning.
+type blockingRunner struct {
+	release chan struct{}
+	result  *ScanResult
+	err     error
+}
+
+func (b *blockingRunner) ScanWithProgress(_ context.Context, progress ProgressFunc) (*ScanResult, error) {
+	progress(1, 2)
+	progress(2, 2)
+	<-b.release
+	return b.result, b.err
+}
+
+func (b *blockingRunner) ScanArtist(_ context.Context, artistFolder string) (*ScanResult, error) {
+	if artistFolder == "Broken" {
+		return nil, errors.New("reading artist directory Broken: permission denied")
+	}
+	return &ScanResult{ArtistsFound: 1, AlbumsFound: 2, Errors: []string{"unreadable track in " + artistFolder}}, nil
+}
+
+func TestScanManager_RunsInBackground(t *testing.T) {
+	store := newMockScanStore()
+	runner := &blockingRunner{
+		release: make(chan struct{}),
+		result:  &ScanResult{ArtistsFound: 2, Errors: []string{"no Tidal match found for artist X"}},
+	}
+	mgr := NewScanManager(runner, store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	id, err := mgr.Start(ctx)
+	if err != nil {
+		t.Fatalf("Start() returned unexpected error: %v", err)
+	}
+	// Cancelling the starting request must not abort the scan.
+	cancel()
+
+	again, err := mgr.Start(context.Background())
+	if !errors.Is(err, ErrScanInProgress) {
+		t.Fatalf("second Start() error = %v, want ErrScanInProgress", err)
+	}
+	if again != id {
+		t.Errorf("second Start() ID = %d, want running scan %d", again, id)
+	}
+
+	close(runner.release)
+	mgr.Wait()
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.progress) != 2 || store.progress[1] != [2]int{2, 2} {
+		t.Errorf("progress = %v, want [[1 2] [2 2]]", store.progress)
+	}
+	errs, ok := store.completed[id]
+	if !ok {
+		t.Fatalf("scan %d not completed", id)
+	}
+	if len(errs) != 1 {
+		t.Errorf("recorded errors = %v, want 1", errs)
+	}
+}
+
+func TestScanManager_RecordsFailure(t *testing.T) {
+	store := newMockScanStore()
+	runner := &blockingRunner{
+		release: make(chan struct{}),
+		err:     errors.New("reading music directory /music: no such file"),
+	}
+	close(runner.release)
+	mgr := NewScanManager(runner, store)
+
+	id, err := mgr.Start(context.Background())
+	if err != nil {
+		t.Fatalf("Start() returned unexpected error: %v", err)
+	}
+	mgr.Wait()
+
+	store.mu.Lock()
+	msg := store.failed[id]
+	store.mu.Unlock()
+	if msg == "" {
+		t.Fatalf("scan %d not marked failed", id)
+	}
+
+	// A new scan can start once the previous one has finished.
+	if _, err := mgr.Start(context.Background()); err != nil {
+		t.Fatalf("Start() after failure returned unexpected error: %v", err)
+	}
+	mgr.Wait()
+}
+
+func TestScanManager_StartArtists(t *testing.T) {
+	store := newMockScanStore()
+	mgr := NewScanManager(&blockingRunner{}, store)
+
+	id, err := mgr.StartArtists(context.Background(), []string{"ArtistA", "Broken", "ArtistB"})
+	if err != nil {
+		t.Fatalf("StartArtists() returned unexpected error: %v", err)
+	}
+	mgr.Wait()
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.progress) != 3 || store.progress[2] != [2]int{3, 3} {
+		t.Errorf("progress = %v, want three steps to [3 3]", store.progress)
+	}
+	errs, ok := store.completed[id]
+	if !ok {
+		t.Fatalf("scan %d not completed", id)
+	}
+	if len(errs) != 3 {
+		t.Errorf("recorded errors = %v, want one per artist", errs)
+	}
+}
@@ -0,0 +1,211 @@
+package library
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+)
+
+// Matching thresholds. A candidate is accepted automatically only when it
+// scores at least AutoAcceptScore and leads the runner-up by
+// ambiguityMargin; otherwise every candidate scoring at least
+// minCandidateScore is queued for review.
+const (
+	AutoAcceptScore   = 0.8
+	ambiguityMargin   = 0.1
+	minCandidateScore = 0.3
+
+	// candidateSearchLimit is how many search results are scored.
+	candidateSearchLimit = 10
+	// albumLookupLimit is how many of the best name matches have their
+	// discography fetched for album overlap scoring.
+	albumLookupLimit = 3
+	// minAlbumLookupName is the name similarity below which a candidate's
+	// discography is not worth fetching.
+	minAlbumLookupName = 0.5
+)
+
+// Score weights. Album overlap is strong evidence when both sides have
+// albums to compare; without it the name carries almost all the weight.
+const (
+	weightNameWithAlbums = 0.6
+	weightAlbums         = 0.3
+	weightNameOnly       = 0.9
+	weightPopularity     = 0.1
+)
+
+// Candidate is a scored Tidal artist for a local artist folder.
+type Candidate struct {
+	Artist     hifi.Artist
+	Score      float64
+	NameScore  float64
+	AlbumScore float64 // -1 when no album evidence was available
+}
+
+var (
+	// albumYearPrefix matches "1997 - ", "(1997) " and "[1997] " prefixes.
+	albumYearPrefix = regexp.MustCompile(`^[(\[]?\d{4}[)\]]?(\s*-\s*|\s+)`)
+	// bracketed matches parenthesised or bracketed qualifiers such as
+	// "(Deluxe Edition)" or "[24-96 FLAC]".
+	bracketed = regexp.MustCompile(`\s*[(\[][^)\]]*[)\]]`)
+)
+
+// diacriticFolder strips combining marks after canonical decomposition, so
+// "Björk" and "Bjork" compare equal.
+var diacriticFolder = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// normalizeName reduces an artist or album name to a comparable form:
+// diacritics removed, lower case, "&" spelled out, punctuation dropped,
+// whitespace collapsed and a leading or trailing "The" removed.
+func normalizeName(s string) string {
+	folded, _, err := transform.String(diacriticFolder, s)
+	if err != nil {
+		folded = s
+	}
+	folded = strings.ToLower(folded)
+	folded = strings.ReplaceAll(folded, "&", " and ")
+
+	var b strings.Builder
+	for _, r := range folded {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case r == '\'' || r == '’' || r == '.':
+			// "Guns N' Roses", "R.E.M." — drop without splitting words.
+		default:
+			b.WriteRune(' ')
+		}
+	}
+
+	words := strings.Fields(b.String())
+	if len(words) > 1 && words[0] == "the" {
+		words = words[1:]
+	} else if len(words) > 1 && words[len(words)-1] == "the" {
+		words = words[:len(words)-1] // "Beatles, The"
+	}
+	return strings.Join(words, " ")
+}
+
+// normalizeAlbumTitle strips year prefixes and bracketed qualifiers from an
+// album folder or title before normalising it.
+func normalizeAlbumTitle(s string) string {
+	s = albumYearPrefix.ReplaceAllString(s, "")
+	s = bracketed.ReplaceAllString(s, "")
+	return normalizeName(s)
+}
+
+// nameSimilarity returns a similarity in [0, 1] between two names, taking the
+// better of a word-aware and a space-insensitive comparison so that
+// "AC/DC" matches "ACDC".
+func nameSimilarity(a, b string) float64 {
+	na, nb := normalizeName(a), normalizeName(b)
+	if na == "" || nb == "" {
+		return 0
+	}
+	spaced := similarity(na, nb)
+	compact := similarity(strings.ReplaceAll(na, " ", ""), strings.ReplaceAll(nb, " ", ""))
+	return max(spaced, compact)
+}
+
+// similarity is one minus the normalised Levenshtein distance between a and b.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// albumOverlap returns the fraction of local album folders that match a
+// title in the remote discography.
+func albumOverlap(localAlbums []string, remote []hifi.Album) float64 {
+	if len(localAlbums) == 0 {
+		return 0
+	}
+
+	titles := make([]string, 0, len(remote))
+	for _, a := range remote {
+		titles = append(titles, normalizeAlbumTitle(a.Title))
+	}
+
+	matched := 0
+	for _, local := range localAlbums {
+		l := normalizeAlbumTitle(local)
+		for _, t := range titles {
+			if l == t || (l != "" && t != "" && similarity(l, t) >= 0.9) {
+				matched++
+				break
+			}
+		}
+	}
+	return float64(matched) / float64(len(localAlbums))
+}
+
+// scoreCandidate combines name similarity, album overlap and popularity into
+// a single score in [0, 1]. remoteAlbums is nil when the discography was not
+// fetched; album evidence is then ignored.
+func scoreCandidate(folder string, localAlbums []string, artist hifi.Artist, remoteAlbums []hifi.Album) Candidate {
+	c := Candidate{
+		Artist:     artist,
+		NameScore:  nameSimilarity(folder, artist.Name),
+		AlbumScore: -1,
+	}
+	popularity := float64(min(max(artist.Popularity, 0), 100)) / 100
+
+	if len(localAlbums) > 0 && len(remoteAlbums) > 0 {
+		c.AlbumScore = albumOverlap(localAlbums, remoteAlbums)
+		c.Score = weightNameWithAlbums*c.NameScore + weightAlbums*c.AlbumScore + weightPopularity*popularity
+	} else {
+		c.Score = weightNameOnly*c.NameScore + weightPopularity*popularity
+	}
+	return c
+}
+
+// rankCandidates sorts candidates by descending score, breaking ties by
+// popularity.
+func rankCandidates(candidates []Candidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].Artist.Popularity > candidates[j].Artist.Popularity
+	})
+}
+
+// autoAccept reports whether the best of the ranked candidates is both
+// confident and clearly ahead of the runner-up.
+func autoAccept(ranked []Candidate) bool {
+	if len(ranked) == 0 || ranked[0].Score < AutoAcceptScore {
+		return false
+	}
+	return len(ranked) == 1 || ranked[0].Score-ranked[1].Score >= ambiguityMargin
+}
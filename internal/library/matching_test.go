@@ -0,0 +1,152 @@
+package library
+
+import (
+	"math"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Björk", "bjork"},
+		{"The Beatles", "beatles"},
+		{"Beatles, The", "beatles"},
+		{"The The", "the"},
+		{"Simon & Garfunkel", "simon and garfunkel"},
+		{"Guns N' Roses", "guns n roses"},
+		{"R.E.M.", "rem"},
+		{"AC/DC", "ac dc"},
+		{"  Sigur   Rós ", "sigur ros"},
+		{"Motörhead", "motorhead"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := normalizeName(tt.in); got != tt.want {
+				t.Errorf("normalizeName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeAlbumTitle(t *testing.T) {
+	tests := map[string]string{
+		"1997 - OK Computer":                "ok computer",
+		"(1997) OK Computer":                "ok computer",
+		"OK Computer [24-96]":               "ok computer",
+		"OK Computer (Collector's Edition)": "ok computer",
+		"1984":                              "1984",
+	}
+	for in, want := range tests {
+		if got := normalizeAlbumTitle(in); got != want {
+			t.Errorf("normalizeAlbumTitle(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		min  float64
+		max  float64
+	}{
+		{"Sigur Ros", "Sigur Rós", 1, 1},
+		{"ACDC", "AC/DC", 1, 1},
+		{"Beatles", "The Beatles", 1, 1},
+		{"Nirvana", "Nirvana (UK)", 0.5, 0.8},
+		{"Radiohead", "Portishead", 0, 0.7},
+		{"", "Anything", 0, 0},
+	}
+
+	for _, tt := range tests {
+		got := nameSimilarity(tt.a, tt.b)
+		if got < tt.min || got > tt.max {
+			t.Errorf("nameSimilarity(%q, %q) = %.3f, want in [%.2f, %.2f]", tt.a, tt.b, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+		{"same", "same", 0},
+		{"björk", "bjork", 1},
+	}
+	for _, tt := range tests {
+		if got := levenshtein([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestAlbumOverlap(t *testing.T) {
+	remote := []hifi.Album{{Title: "Nevermind"}, {Title: "In Utero"}, {Title: "Bleach (Deluxe Edition)"}}
+
+	got := albumOverlap([]string{"1991 - Nevermind", "Bleach", "Unplugged in New York"}, remote)
+	if math.Abs(got-2.0/3.0) > 1e-9 {
+		t.Errorf("albumOverlap() = %.3f, want 0.667", got)
+	}
+	if got := albumOverlap(nil, remote); got != 0 {
+		t.Errorf("albumOverlap(nil) = %.3f, want 0", got)
+	}
+}
+
+func TestScoreCandidate(t *testing.T) {
+	artist := hifi.Artist{ID: 1, Name: "Nirvana", Popularity: 80}
+
+	t.Run("name only", func(t *testing.T) {
+		c := scoreCandidate("Nirvana", nil, artist, nil)
+		want := weightNameOnly + weightPopularity*0.8
+		if math.Abs(c.Score-want) > 1e-9 {
+			t.Errorf("Score = %.3f, want %.3f", c.Score, want)
+		}
+		if c.AlbumScore != -1 {
+			t.Errorf("AlbumScore = %.2f, want -1 without album evidence", c.AlbumScore)
+		}
+	})
+
+	t.Run("with album evidence", func(t *testing.T) {
+		c := scoreCandidate("Nirvana", []string{"Nevermind", "Bootleg"}, artist, []hifi.Album{{Title: "Nevermind"}})
+		want := weightNameWithAlbums + weightAlbums*0.5 + weightPopularity*0.8
+		if math.Abs(c.Score-want) > 1e-9 {
+			t.Errorf("Score = %.3f, want %.3f", c.Score, want)
+		}
+		if c.AlbumScore != 0.5 {
+			t.Errorf("AlbumScore = %.2f, want 0.5", c.AlbumScore)
+		}
+	})
+}
+
+func TestAutoAccept(t *testing.T) {
+	tests := []struct {
+		name   string
+		scores []float64
+		want   bool
+	}{
+		{"no candidates", nil, false},
+		{"single confident", []float64{0.9}, true},
+		{"single weak", []float64{0.7}, false},
+		{"clear winner", []float64{0.95, 0.6}, true},
+		{"too close", []float64{0.92, 0.88}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ranked []Candidate
+			for _, s := range tt.scores {
+				ranked = append(ranked, Candidate{Score: s})
+			}
+			if got := autoAccept(ranked); got != tt.want {
+				t.Errorf("autoAccept(%v) = %v, want %v", tt.scores, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package library
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+)
+
+// Audio quality levels, named after Tidal's quality parameters where they
+// exist. QualityLossy covers anything below lossless.
+const (
+	QualityLossy    = "LOSSY"
+	QualityLossless = "LOSSLESS"
+	QualityHiRes    = "HI_RES_LOSSLESS"
+)
+
+// OwnershipState describes how much of a Tidal album we already hold.
+type OwnershipState string
+
+// Ownership states, from nothing held to a complete copy. A partial copy
+// takes precedence over a low-quality one: completing it comes first.
+const (
+	NotOwned   OwnershipState = ""
+	Owned      OwnershipState = "owned"
+	Partial    OwnershipState = "partial"
+	Upgradable OwnershipState = "upgradable"
+)
+
+// Ownership is the ownership state of a Tidal album together with the
+// details needed to describe it.
+type Ownership struct {
+	State       OwnershipState
+	HaveTracks  int
+	TotalTracks int
+	// LocalQuality is the quality of the copy we hold; UpgradeQuality is the
+	// better quality available to download, set only when Upgradable.
+	LocalQuality   string
+	UpgradeQuality string
+}
+
+// qualityRank orders quality levels; unknown levels rank lowest.
+func qualityRank(q string) int {
+	switch q {
+	case QualityHiRes:
+		return 2
+	case QualityLossless:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// HoldingQuality returns the quality of a held album: the quality requested
+// for a download-only holding, otherwise that of its worst library track.
+func HoldingQuality(h db.AlbumHolding) string {
+	switch {
+	case h.LibraryAlbumID == 0 && h.DownloadQuality != "":
+		return h.DownloadQuality
+	case h.LossyTracks > 0:
+		return QualityLossy
+	case h.MinBitDepth > 16 || h.MinSampleRate > 48000:
+		return QualityHiRes
+	default:
+		return QualityLossless
+	}
+}
+
+// TrackSummary aggregates the quality, size and length of an album's local
+// tracks.
+type TrackSummary struct {
+	Quality       string // see HoldingQuality
+	MinBitDepth   int
+	MinSampleRate int
+	SizeBytes     int64
+	Duration      float64 // seconds
+}
+
+// SummarizeTracks summarises a set of local tracks. As with the holdings
+// query, a track with no bit depth counts as lossy.
+func SummarizeTracks(tracks []db.LibraryTrack) TrackSummary {
+	var (
+		s TrackSummary
+		h db.AlbumHolding
+	)
+	for i, t := range tracks {
+		if i == 0 || t.BitDepth < h.MinBitDepth {
+			h.MinBitDepth = t.BitDepth
+		}
+		if i == 0 || t.SampleRate < h.MinSampleRate {
+			h.MinSampleRate = t.SampleRate
+		}
+		if t.BitDepth == 0 {
+			h.LossyTracks++
+		}
+		s.SizeBytes += t.SizeBytes
+		s.Duration += t.Duration
+	}
+	s.Quality = HoldingQuality(h)
+	s.MinBitDepth, s.MinSampleRate = h.MinBitDepth, h.MinSampleRate
+	return s
+}
+
+// AvailableQuality returns the best quality Tidal offers for an album.
+func AvailableQuality(a hifi.Album) string {
+	switch {
+	case a.AudioQuality == QualityHiRes || slices.Contains(a.MediaMetadata.Tags, "HIRES_LOSSLESS"):
+		return QualityHiRes
+	case a.AudioQuality == QualityLossless || a.AudioQuality == "HI_RES" || slices.Contains(a.MediaMetadata.Tags, "LOSSLESS"):
+		return QualityLossless
+	default:
+		return QualityLossy
+	}
+}
+
+// AlbumOwnership classifies a Tidal album against what we hold of it.
+// maxQuality caps the upgrade target at the quality we download in, so a
+// lossless copy is not offered a hi-res upgrade we would not fetch.
+func AlbumOwnership(album hifi.Album, holding *db.AlbumHolding, maxQuality string) Ownership {
+	o := Ownership{TotalTracks: album.NumberOfTracks}
+	if holding == nil {
+		return o
+	}
+
+	o.HaveTracks = holding.TrackCount
+	o.LocalQuality = HoldingQuality(*holding)
+
+	target := AvailableQuality(album)
+	if qualityRank(maxQuality) < qualityRank(target) {
+		target = maxQuality
+	}
+
+	switch {
+	case album.NumberOfTracks > 0 && holding.TrackCount < album.NumberOfTracks:
+		o.State = Partial
+	case qualityRank(o.LocalQuality) < qualityRank(target):
+		o.State = Upgradable
+		o.UpgradeQuality = target
+	default:
+		o.State = Owned
+	}
+	return o
+}
+
+// Upgrade is a linked library album that Tidal offers in better quality
+// than the copy on disk.
+type Upgrade struct {
+	Album          db.LinkedAlbumQuality
+	LocalQuality   string
+	UpgradeQuality string
+}
+
+// Upgrades returns the albums whose recorded Tidal quality beats the quality
+// on disk, in the order given. Unlike AlbumOwnership the target is not
+// capped: the report lists everything Tidal could improve on. Albums whose
+// Tidal quality has not been recorded yet are skipped.
+func Upgrades(albums []db.LinkedAlbumQuality) []Upgrade {
+	var upgrades []Upgrade
+	for _, a := range albums {
+		local := HoldingQuality(a.Holding)
+		if qualityRank(a.TidalQuality) > qualityRank(local) {
+			upgrades = append(upgrades, Upgrade{Album: a, LocalQuality: local, UpgradeQuality: a.TidalQuality})
+		}
+	}
+	return upgrades
+}
+
+// trackPosition identifies a track by disc and track number.
+type trackPosition struct {
+	disc, track int
+}
+
+// leadingTrackNumber matches a "01 - " or "01. " filename prefix.
+var leadingTrackNumber = regexp.MustCompile(`^\d+\s*[-._]?\s*`)
+
+// MissingTracks returns the Tidal tracks of an album that have no
+// counterpart among the local tracks. Tracks are matched by disc and track
+// number when the local file is numbered, otherwise by title (from the tag,
+// or the filename without its number and extension).
+func MissingTracks(remote []hifi.Track, local []db.LibraryTrack) []hifi.Track {
+	have := newTrackIndex(local)
+
+	var missing []hifi.Track
+	for _, t := range remote {
+		if have.has(trackPosition{max(t.VolumeNumber, 1), t.TrackNumber}, normalizeName(t.Title)) {
+			continue
+		}
+		missing = append(missing, t)
+	}
+	return missing
+}
+
+// MatchingTracks returns the Tidal tracks of an album that correspond to
+// the given local tracks, matched as in MissingTracks.
+func MatchingTracks(remote []hifi.Track, local []db.LibraryTrack) []hifi.Track {
+	have := newTrackIndex(local)
+
+	var matching []hifi.Track
+	for _, t := range remote {
+		if have.has(trackPosition{max(t.VolumeNumber, 1), t.TrackNumber}, normalizeName(t.Title)) {
+			matching = append(matching, t)
+		}
+	}
+	return matching
+}
+
+// LocalTrack returns the local track that corresponds to a Tidal track,
+// matched as in MissingTracks. A match by disc and track number is preferred
+// over one by title.
+func LocalTrack(remote hifi.Track, local []db.LibraryTrack) (db.LibraryTrack, bool) {
+	pos := trackPosition{max(remote.VolumeNumber, 1), remote.TrackNumber}
+	for _, t := range local {
+		if pos.track > 0 && t.TrackNumber > 0 && (trackPosition{max(t.DiscNumber, 1), t.TrackNumber}) == pos {
+			return t, true
+		}
+	}
+	if title := normalizeName(remote.Title); title != "" {
+		for _, t := range local {
+			if localTrackTitle(t) == title {
+				return t, true
+			}
+		}
+	}
+	return db.LibraryTrack{}, false
+}
+
+// trackIndex records the positions and normalised titles of local tracks.
+type trackIndex struct {
+	positions map[trackPosition]bool
+	titles    map[string]bool
+}
+
+// newTrackIndex indexes the given local tracks.
+func newTrackIndex(local []db.LibraryTrack) trackIndex {
+	ix := trackIndex{
+		positions: make(map[trackPosition]bool, len(local)),
+		titles:    make(map[string]bool, len(local)),
+	}
+	for _, t := range local {
+		ix.add(t)
+	}
+	return ix
+}
+
+// add indexes a local track by position, when it is numbered, and by title.
+func (ix trackIndex) add(t db.LibraryTrack) {
+	if t.TrackNumber > 0 {
+		ix.positions[trackPosition{max(t.DiscNumber, 1), t.TrackNumber}] = true
+	}
+	if n := localTrackTitle(t); n != "" {
+		ix.titles[n] = true
+	}
+}
+
+// has reports whether a track at pos or with the normalised title is indexed.
+func (ix trackIndex) has(pos trackPosition, title string) bool {
+	return (pos.track > 0 && ix.positions[pos]) || (title != "" && ix.titles[title])
+}
+
+// localTrackTitle returns the normalised title of a local track: its title
+// tag, or its filename without the track number and extension.
+func localTrackTitle(t db.LibraryTrack) string {
+	if t.Title != nil && *t.Title != "" {
+		return normalizeName(*t.Title)
+	}
+	title := strings.TrimSuffix(t.Filename, filepath.Ext(t.Filename))
+	return normalizeName(leadingTrackNumber.ReplaceAllString(title, ""))
+}
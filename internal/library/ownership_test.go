@@ -0,0 +1,176 @@
+package library
+
+import (
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+)
+
+func TestAlbumOwnership(t *testing.T) {
+	lossless := hifi.Album{NumberOfTracks: 10, AudioQuality: QualityLossless}
+	hiRes := hifi.Album{NumberOfTracks: 10, AudioQuality: QualityLossless, MediaMetadata: hifi.MediaMetadata{Tags: []string{"LOSSLESS", "HIRES_LOSSLESS"}}}
+
+	cd := db.AlbumHolding{LibraryAlbumID: 1, TrackCount: 10, MinBitDepth: 16, MinSampleRate: 44100}
+	mp3 := db.AlbumHolding{LibraryAlbumID: 1, TrackCount: 10, LossyTracks: 10}
+	partial := db.AlbumHolding{LibraryAlbumID: 1, TrackCount: 6, LossyTracks: 6}
+	downloaded := db.AlbumHolding{TrackCount: 10, DownloadQuality: QualityLossless}
+
+	tests := []struct {
+		name        string
+		album       hifi.Album
+		holding     *db.AlbumHolding
+		maxQuality  string
+		wantState   OwnershipState
+		wantUpgrade string
+	}{
+		{"not held", lossless, nil, QualityLossless, NotOwned, ""},
+		{"complete lossless copy", lossless, &cd, QualityLossless, Owned, ""},
+		{"lossy copy of lossless album", lossless, &mp3, QualityLossless, Upgradable, QualityLossless},
+		{"partial copy wins over quality", lossless, &partial, QualityLossless, Partial, ""},
+		{"hi-res upgrade capped by download quality", hiRes, &cd, QualityLossless, Owned, ""},
+		{"hi-res upgrade when downloading hi-res", hiRes, &cd, QualityHiRes, Upgradable, QualityHiRes},
+		{"download-only holding", lossless, &downloaded, QualityLossless, Owned, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AlbumOwnership(tt.album, tt.holding, tt.maxQuality)
+			if got.State != tt.wantState {
+				t.Errorf("State = %q, want %q", got.State, tt.wantState)
+			}
+			if got.UpgradeQuality != tt.wantUpgrade {
+				t.Errorf("UpgradeQuality = %q, want %q", got.UpgradeQuality, tt.wantUpgrade)
+			}
+		})
+	}
+}
+
+func TestHoldingQuality(t *testing.T) {
+	tests := []struct {
+		holding db.AlbumHolding
+		want    string
+	}{
+		{db.AlbumHolding{LibraryAlbumID: 1, MinBitDepth: 16, MinSampleRate: 44100}, QualityLossless},
+		{db.AlbumHolding{LibraryAlbumID: 1, MinBitDepth: 24, MinSampleRate: 96000}, QualityHiRes},
+		{db.AlbumHolding{LibraryAlbumID: 1, MinBitDepth: 16, MinSampleRate: 44100, LossyTracks: 1}, QualityLossy},
+		{db.AlbumHolding{DownloadQuality: QualityHiRes}, QualityHiRes},
+	}
+	for _, tt := range tests {
+		if got := HoldingQuality(tt.holding); got != tt.want {
+			t.Errorf("HoldingQuality(%+v) = %q, want %q", tt.holding, got, tt.want)
+		}
+	}
+}
+
+func TestUpgrades(t *testing.T) {
+	cd := db.AlbumHolding{LibraryAlbumID: 1, MinBitDepth: 16, MinSampleRate: 44100}
+	hiRes := db.AlbumHolding{LibraryAlbumID: 2, MinBitDepth: 24, MinSampleRate: 96000}
+	mp3 := db.AlbumHolding{LibraryAlbumID: 3, MinSampleRate: 44100, LossyTracks: 10}
+
+	got := Upgrades([]db.LinkedAlbumQuality{
+		{AlbumFolder: "CD rip", Holding: cd, TidalQuality: QualityHiRes},
+		{AlbumFolder: "Already hi-res", Holding: hiRes, TidalQuality: QualityHiRes},
+		{AlbumFolder: "CD rip, CD on Tidal", Holding: cd, TidalQuality: QualityLossless},
+		{AlbumFolder: "MP3s", Holding: mp3, TidalQuality: QualityLossless},
+		{AlbumFolder: "Not yet checked", Holding: mp3},
+	})
+
+	if len(got) != 2 {
+		t.Fatalf("got %d upgrades, want 2: %+v", len(got), got)
+	}
+	if got[0].Album.AlbumFolder != "CD rip" || got[0].LocalQuality != QualityLossless || got[0].UpgradeQuality != QualityHiRes {
+		t.Errorf("upgrades[0] = %+v, want CD rip lossless -> hi-res", got[0])
+	}
+	if got[1].Album.AlbumFolder != "MP3s" || got[1].LocalQuality != QualityLossy || got[1].UpgradeQuality != QualityLossless {
+		t.Errorf("upgrades[1] = %+v, want MP3s lossy -> lossless", got[1])
+	}
+}
+
+func TestMissingTracks(t *testing.T) {
+	remote := []hifi.Track{
+		{ID: 1, Title: "Airbag", TrackNumber: 1, VolumeNumber: 1},
+		{ID: 2, Title: "Paranoid Android", TrackNumber: 2, VolumeNumber: 1},
+		{ID: 3, Title: "Subterranean Homesick Alien", TrackNumber: 3, VolumeNumber: 1},
+		{ID: 4, Title: "Exit Music (For a Film)", TrackNumber: 4, VolumeNumber: 1},
+	}
+	title := "Paranoid Android"
+	local := []db.LibraryTrack{
+		{Filename: "01 - Airbag.flac", TrackNumber: 1},               // numbered, no disc tag
+		{Filename: "track.flac", Title: &title},                      // untagged number, tagged title
+		{Filename: "04 - Exit Music (For a Film).mp3"},               // filename only
+		{Filename: "99 - Bonus.flac", TrackNumber: 9, DiscNumber: 2}, // not on the album
+	}
+
+	got := MissingTracks(remote, local)
+	if len(got) != 1 || got[0].ID != 3 {
+		ids := make([]int64, 0, len(got))
+		for _, t := range got {
+			ids = append(ids, t.ID)
+		}
+		t.Errorf("MissingTracks() = %v, want [3]", ids)
+	}
+}
+
+func TestSummarizeTracks(t *testing.T) {
+	tracks := []db.LibraryTrack{
+		{BitDepth: 24, SampleRate: 96000, SizeBytes: 3000, Duration: 200},
+		{BitDepth: 16, SampleRate: 44100, SizeBytes: 1000, Duration: 100.5},
+	}
+	got := SummarizeTracks(tracks)
+	want := TrackSummary{Quality: QualityLossless, MinBitDepth: 16, MinSampleRate: 44100, SizeBytes: 4000, Duration: 300.5}
+	if got != want {
+		t.Errorf("SummarizeTracks() = %+v, want %+v", got, want)
+	}
+
+	tracks = append(tracks, db.LibraryTrack{SampleRate: 44100, SizeBytes: 500})
+	if got := SummarizeTracks(tracks); got.Quality != QualityLossy {
+		t.Errorf("SummarizeTracks() with an mp3 = %q, want %q", got.Quality, QualityLossy)
+	}
+}
+
+func TestMatchingTracks(t *testing.T) {
+	remote := []hifi.Track{
+		{ID: 1, Title: "Airbag", TrackNumber: 1, VolumeNumber: 1},
+		{ID: 2, Title: "Paranoid Android", TrackNumber: 2, VolumeNumber: 1},
+		{ID: 3, Title: "Subterranean Homesick Alien", TrackNumber: 3, VolumeNumber: 1},
+	}
+	local := []db.LibraryTrack{
+		{Filename: "02 - Paranoid Android.flac", TrackNumber: 2},
+		{Filename: "Subterranean Homesick Alien.flac"},
+	}
+
+	got := MatchingTracks(remote, local)
+	if len(got) != 2 || got[0].ID != 2 || got[1].ID != 3 {
+		t.Errorf("MatchingTracks() = %+v, want tracks 2 and 3", got)
+	}
+}
+
+func TestLocalTrack(t *testing.T) {
+	title := "Airbag"
+	local := []db.LibraryTrack{
+		{ID: 10, Filename: "Airbag (live).flac", Title: &title},
+		{ID: 11, Filename: "01 - Airbag.flac", TrackNumber: 1},
+		{ID: 12, Filename: "Subterranean Homesick Alien.flac"},
+		{ID: 13, Filename: "2-01 Lucky.flac", TrackNumber: 1, DiscNumber: 2},
+	}
+
+	tests := []struct {
+		name   string
+		remote hifi.Track
+		want   int64 // 0 for no match
+	}{
+		{"position beats title", hifi.Track{Title: "Airbag", TrackNumber: 1, VolumeNumber: 1}, 11},
+		{"by title", hifi.Track{Title: "Subterranean Homesick Alien", TrackNumber: 3}, 12},
+		{"second disc", hifi.Track{Title: "Lucky", TrackNumber: 1, VolumeNumber: 2}, 13},
+		{"no match", hifi.Track{Title: "Karma Police", TrackNumber: 6}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := LocalTrack(tt.remote, local)
+			if ok != (tt.want != 0) || got.ID != tt.want {
+				t.Errorf("LocalTrack() = %d, %v; want %d", got.ID, ok, tt.want)
+			}
+		})
+	}
+}
@@ -7,6 +7,10 @@ import (
 	"strings"
 )
 
+// PlaylistsFolder is the folder in the music root that holds playlist
+// files. Scans and the watcher skip it.
+const PlaylistsFolder = "Playlists"
+
 var illegalChars = regexp.MustCompile(`[/\\:*?"<>|]`)
 var multiUnder = regexp.MustCompile(`_{2,}`)
 
@@ -36,7 +40,62 @@ func AlbumDir(musicRoot, artistName, albumTitle string) string {
 
 // TrackFilename returns a zero-padded, sanitized FLAC filename for a track.
 func TrackFilename(trackNumber int, title string) string {
-	return fmt.Sprintf("%02d - %s.flac", trackNumber, SanitizeName(title))
+	return TrackNaming{}.Filename(trackNumber, title)
+}
+
+// TrackNaming describes how the track files of an album folder are named:
+// the zero-padded width of the track number and the separator before the
+// title. The zero value is the naming used for downloads, "01 - Title.flac".
+type TrackNaming struct {
+	Width     int
+	Separator string
+}
+
+var (
+	// trackNamePrefix captures the number and separator of "01 - Title",
+	// "1. Title", "01_Title" and "01 Title" filenames.
+	trackNamePrefix = regexp.MustCompile(`^(\d{1,3})(\s*[-.]\s*|_|\s+)[^\s\d.-]`)
+	// discTrackPrefix matches "1-01" style disc-and-track numbering, which
+	// TrackNaming does not describe.
+	discTrackPrefix = regexp.MustCompile(`^\d+-\d+`)
+)
+
+// Filename returns a sanitized FLAC filename for a track following n.
+func (n TrackNaming) Filename(trackNumber int, title string) string {
+	width, sep := n.Width, n.Separator
+	if width == 0 {
+		width = 2
+	}
+	if sep == "" {
+		sep = " - "
+	}
+	return fmt.Sprintf("%0*d%s%s.flac", width, trackNumber, sep, SanitizeName(title))
+}
+
+// DetectTrackNaming infers the naming used by the most filenames in an album
+// folder, so tracks added to it match their neighbours. It returns the zero
+// TrackNaming when no filename has a recognisable number prefix.
+func DetectTrackNaming(filenames []string) TrackNaming {
+	counts := make(map[TrackNaming]int)
+	var best TrackNaming
+	for _, name := range filenames {
+		if discTrackPrefix.MatchString(name) {
+			continue
+		}
+		m := trackNamePrefix.FindStringSubmatch(name)
+		if m == nil {
+			continue
+		}
+		n := TrackNaming{Width: len(m[1]), Separator: m[2]}
+		if n.Width > 1 && m[1][0] != '0' {
+			n.Width = 0 // e.g. "12": padding unknown, use the default width
+		}
+		counts[n]++
+		if counts[n] > counts[best] {
+			best = n
+		}
+	}
+	return best
 }
 
 // TrackPath returns the full path for a track file on disk.
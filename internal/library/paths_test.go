@@ -126,6 +126,33 @@ func TestTrackFilename(t *testing.T) {
 	}
 }
 
+func TestDetectTrackNaming(t *testing.T) {
+	tests := []struct {
+		name      string
+		filenames []string
+		want      string // filename produced for track 7, "Song"
+	}{
+		{"no files uses default", nil, "07 - Song.flac"},
+		{"dash separated", []string{"01 - A.flac", "02 - B.flac"}, "07 - Song.flac"},
+		{"dotted unpadded", []string{"1. A.flac", "2. B.mp3", "10. C.flac"}, "7. Song.flac"},
+		{"space separated", []string{"01 A.flac", "02 B.flac"}, "07 Song.flac"},
+		{"underscore", []string{"01_A.flac"}, "07_Song.flac"},
+		{"three digit padding", []string{"001 - A.flac", "002 - B.flac"}, "007 - Song.flac"},
+		{"disc-track names ignored", []string{"1-01 A.flac", "1-02 B.flac"}, "07 - Song.flac"},
+		{"unnumbered names ignored", []string{"A.flac", "cover.jpg"}, "07 - Song.flac"},
+		{"majority wins", []string{"01. A.flac", "02. B.flac", "03 - C.flac"}, "07. Song.flac"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectTrackNaming(tt.filenames).Filename(7, "Song")
+			if got != tt.want {
+				t.Errorf("DetectTrackNaming(%q).Filename(7, \"Song\") = %q, want %q", tt.filenames, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestTrackPath(t *testing.T) {
 	tests := []struct {
 		name    string
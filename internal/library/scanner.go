@@ -6,8 +6,9 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"strings"
+	"sync"
 
+	"github.com/MattHbrook/Crescendo/internal/audio"
 	"github.com/MattHbrook/Crescendo/internal/db"
 	"github.com/MattHbrook/Crescendo/internal/hifi"
 )
@@ -18,11 +19,19 @@ type ArtistStore interface {
 	UpsertArtistMapping(ctx context.Context, folderName string, tidalID int64, tidalName, pictureURL string) error
 	UpsertLibraryAlbum(ctx context.Context, artistFolder, albumFolder string, trackCount int, path string) error
 	DeleteLibraryAlbumsByArtist(ctx context.Context, artistFolder string) error
+	PruneLibraryAlbums(ctx context.Context, artistFolder string, keep []string) error
+	SyncLibraryTracks(ctx context.Context, artistFolder, albumFolder string, tracks []db.LibraryTrack) error
+	ReplaceArtistCandidates(ctx context.Context, folderName string, candidates []db.ArtistCandidate) error
+	DeleteArtistCandidates(ctx context.Context, folderName string) error
+	ListAlbumsForArtist(ctx context.Context, artistFolder string) ([]db.LibraryAlbum, error)
+	LinkLibraryAlbum(ctx context.Context, albumID, tidalAlbumID int64, score float64) error
+	SetTidalAlbumQuality(ctx context.Context, tidalAlbumID int64, quality string) error
 }
 
 // ArtistSearcher is the subset of hifi.Client needed by the scanner.
 type ArtistSearcher interface {
 	SearchArtists(ctx context.Context, query string, limit, offset int) (*hifi.SearchResult[hifi.Artist], error)
+	GetArtistAlbums(ctx context.Context, id int64) ([]hifi.Album, error)
 }
 
 // Scanner walks a music directory and populates the database with artist and
@@ -32,6 +41,7 @@ type Scanner struct {
 	store     ArtistStore
 	searcher  ArtistSearcher
 	logger    *log.Logger
+	mu        sync.Mutex // serialises full and targeted scans
 }
 
 // NewScanner creates a Scanner that will walk musicPath and use the provided
@@ -50,36 +60,88 @@ type ScanResult struct {
 	ArtistsFound   int
 	AlbumsFound    int
 	ArtistsMatched int // successfully resolved to Tidal ID
+	ArtistsQueued  int // ambiguous matches queued for review
+	AlbumsLinked   int // library albums newly linked to a Tidal album
 	Errors         []string
 }
 
+// ProgressFunc receives the number of artist folders processed so far and
+// the total number found in the music directory.
+type ProgressFunc func(processed, total int)
+
 // Scan reads the top-level music directory and processes every artist folder
 // it finds. It returns an error only if the music directory itself cannot be
 // read; all per-artist and per-album errors are collected in ScanResult.Errors.
 func (s *Scanner) Scan(ctx context.Context) (*ScanResult, error) {
+	return s.ScanWithProgress(ctx, nil)
+}
+
+// ScanWithProgress behaves like Scan but calls progress (if non-nil) after
+// each artist folder has been processed.
+func (s *Scanner) ScanWithProgress(ctx context.Context, progress ProgressFunc) (*ScanResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	entries, err := os.ReadDir(s.musicPath)
 	if err != nil {
 		return nil, fmt.Errorf("reading music directory %s: %w", s.musicPath, err)
 	}
 
-	result := &ScanResult{}
-
+	var artists []string
 	for _, entry := range entries {
 		if !entry.IsDir() {
 			continue
 		}
-		if entry.Name() == "Playlists" {
+		if skipArtistFolder(entry.Name()) {
 			continue
 		}
-		s.scanArtist(ctx, entry.Name(), result)
+		artists = append(artists, entry.Name())
+	}
+
+	result := &ScanResult{}
+
+	for i, artist := range artists {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("scan cancelled: %w", err)
+		}
+		s.scanArtist(ctx, artist, result)
+		if progress != nil {
+			progress(i+1, len(artists))
+		}
 	}
 
 	return result, nil
 }
 
-// scanArtist processes a single artist folder: it discovers albums, counts
-// FLAC tracks in each, persists album records, and attempts to resolve the
-// artist to a Tidal ID.
+// ScanArtist rescans a single artist folder, updating its album and track
+// records so that albums removed from disk disappear from the index. If the
+// folder no longer exists its albums are simply deleted. Like Scan, per-album
+// errors are collected in ScanResult.Errors rather than returned.
+func (s *Scanner) ScanArtist(ctx context.Context, artistFolder string) (*ScanResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := &ScanResult{}
+
+	info, err := os.Stat(filepath.Join(s.musicPath, artistFolder))
+	if os.IsNotExist(err) || (err == nil && !info.IsDir()) {
+		if err := s.store.DeleteLibraryAlbumsByArtist(ctx, artistFolder); err != nil {
+			return nil, fmt.Errorf("clearing albums for artist %s: %w", artistFolder, err)
+		}
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading artist directory %s: %w", artistFolder, err)
+	}
+
+	s.scanArtist(ctx, artistFolder, result)
+	return result, nil
+}
+
+// scanArtist processes a single artist folder: it discovers albums, probes
+// the audio tracks in each, persists album and track records, prunes albums
+// no longer on disk, attempts to resolve the artist to a Tidal ID and then
+// links its albums to the artist's Tidal discography.
 func (s *Scanner) scanArtist(ctx context.Context, artistFolder string, result *ScanResult) {
 	result.ArtistsFound++
 
@@ -92,6 +154,8 @@ func (s *Scanner) scanArtist(ctx context.Context, artistFolder string, result *S
 		return
 	}
 
+	var keep []string
+	scanned := make(map[string]localAlbum)
 	for _, entry := range entries {
 		if !entry.IsDir() {
 			continue
@@ -100,23 +164,43 @@ func (s *Scanner) scanArtist(ctx context.Context, artistFolder string, result *S
 		albumFolder := entry.Name()
 		albumPath := filepath.Join(artistPath, albumFolder)
 
-		trackCount, err := countFLACFiles(albumPath)
+		tracks, probeErrs, err := scanAlbumTracks(albumPath)
 		if err != nil {
-			msg := fmt.Sprintf("counting tracks in %s: %v", albumPath, err)
+			msg := fmt.Sprintf("reading tracks in %s: %v", albumPath, err)
 			s.logger.Println(msg)
 			result.Errors = append(result.Errors, msg)
+			keep = append(keep, albumFolder) // transient; don't drop the existing record
 			continue
 		}
+		for _, msg := range probeErrs {
+			s.logger.Println(msg)
+		}
+		result.Errors = append(result.Errors, probeErrs...)
 
-		if trackCount > 0 {
-			if err := s.store.UpsertLibraryAlbum(ctx, artistFolder, albumFolder, trackCount, albumPath); err != nil {
-				msg := fmt.Sprintf("upserting album %s/%s: %v", artistFolder, albumFolder, err)
-				s.logger.Println(msg)
-				result.Errors = append(result.Errors, msg)
-				continue
-			}
-			result.AlbumsFound++
+		if len(tracks) == 0 {
+			continue
+		}
+		keep = append(keep, albumFolder)
+		scanned[albumFolder] = localAlbum{Folder: albumFolder, Tag: albumTag(tracks), TrackCount: len(tracks)}
+
+		if err := s.store.UpsertLibraryAlbum(ctx, artistFolder, albumFolder, len(tracks), albumPath); err != nil {
+			msg := fmt.Sprintf("upserting album %s/%s: %v", artistFolder, albumFolder, err)
+			s.logger.Println(msg)
+			result.Errors = append(result.Errors, msg)
+			continue
 		}
+		if err := s.store.SyncLibraryTracks(ctx, artistFolder, albumFolder, tracks); err != nil {
+			msg := fmt.Sprintf("storing tracks for %s/%s: %v", artistFolder, albumFolder, err)
+			s.logger.Println(msg)
+			result.Errors = append(result.Errors, msg)
+		}
+		result.AlbumsFound++
+	}
+
+	if err := s.store.PruneLibraryAlbums(ctx, artistFolder, keep); err != nil {
+		msg := fmt.Sprintf("pruning albums for %s: %v", artistFolder, err)
+		s.logger.Println(msg)
+		result.Errors = append(result.Errors, msg)
 	}
 
 	// Attempt to resolve the artist's Tidal ID if not already mapped.
@@ -128,65 +212,256 @@ func (s *Scanner) scanArtist(ctx context.Context, artistFolder string, result *S
 		return
 	}
 
-	if mapping != nil && mapping.TidalID != nil {
-		return // already resolved
+	var tidalID int64
+	switch {
+	case mapping != nil && mapping.TidalID != nil:
+		tidalID = *mapping.TidalID
+	case mapping != nil && mapping.Locked:
+		return // ignored by the user
+	default:
+		var ok bool
+		if tidalID, ok = s.resolveArtist(ctx, artistFolder, keep, result); !ok {
+			return
+		}
 	}
 
-	s.resolveArtist(ctx, artistFolder, result)
+	s.linkAlbums(ctx, artistFolder, tidalID, scanned, result)
 }
 
-// resolveArtist searches the HiFi API for the given artist folder name and,
-// if a match is found, persists the mapping. Errors are logged and collected
-// rather than propagated so the scan can continue.
-func (s *Scanner) resolveArtist(ctx context.Context, artistFolder string, result *ScanResult) {
-	searchResult, err := s.searcher.SearchArtists(ctx, artistFolder, 1, 0)
+// resolveArtist searches the HiFi API for the given artist folder name and
+// scores the results (see scoreCandidate). A confident, unambiguous best
+// match is persisted as the artist's mapping and its Tidal ID returned;
+// otherwise the plausible candidates are queued for review. Errors are
+// logged and collected rather than propagated so the scan can continue.
+func (s *Scanner) resolveArtist(ctx context.Context, artistFolder string, localAlbums []string, result *ScanResult) (int64, bool) {
+	searchResult, err := s.searcher.SearchArtists(ctx, artistFolder, candidateSearchLimit, 0)
 	if err != nil {
 		msg := fmt.Sprintf("searching for artist %s: %v", artistFolder, err)
 		s.logger.Println(msg)
 		result.Errors = append(result.Errors, msg)
-		return
+		return 0, false
 	}
 
-	if len(searchResult.Items) == 0 {
+	candidates := s.scoreCandidates(ctx, artistFolder, localAlbums, searchResult.Items)
+	if len(candidates) == 0 {
 		msg := fmt.Sprintf("no Tidal match found for artist %s", artistFolder)
 		s.logger.Println(msg)
 		result.Errors = append(result.Errors, msg)
-		return
+		return 0, false
 	}
 
-	artist := searchResult.Items[0]
+	if !autoAccept(candidates) {
+		s.queueCandidates(ctx, artistFolder, candidates, result)
+		return 0, false
+	}
+
+	artist := candidates[0].Artist
 	if err := s.store.UpsertArtistMapping(ctx, artistFolder, artist.ID, artist.Name, artist.Picture); err != nil {
 		msg := fmt.Sprintf("upserting artist mapping for %s: %v", artistFolder, err)
 		s.logger.Println(msg)
 		result.Errors = append(result.Errors, msg)
-		return
+		return 0, false
+	}
+	if err := s.store.DeleteArtistCandidates(ctx, artistFolder); err != nil {
+		s.logger.Printf("clearing review queue for %s: %v", artistFolder, err)
 	}
 
 	result.ArtistsMatched++
+	return artist.ID, true
+}
+
+// scoreCandidates scores search results against the folder name and, for
+// the best few name matches, against the artist's local album folders. It
+// returns the candidates above minCandidateScore, best first.
+func (s *Scanner) scoreCandidates(ctx context.Context, artistFolder string, localAlbums []string, artists []hifi.Artist) []Candidate {
+	scored := make([]Candidate, 0, len(artists))
+	for _, a := range artists {
+		scored = append(scored, scoreCandidate(artistFolder, localAlbums, a, nil))
+	}
+	rankCandidates(scored)
+
+	if len(localAlbums) > 0 {
+		for i := 0; i < len(scored) && i < albumLookupLimit; i++ {
+			if scored[i].NameScore < minAlbumLookupName {
+				continue
+			}
+			albums, err := s.searcher.GetArtistAlbums(ctx, scored[i].Artist.ID)
+			if err != nil {
+				s.logger.Printf("fetching albums for candidate %d (%s): %v", scored[i].Artist.ID, scored[i].Artist.Name, err)
+				continue
+			}
+			scored[i] = scoreCandidate(artistFolder, localAlbums, scored[i].Artist, albums)
+		}
+		rankCandidates(scored)
+	}
+
+	kept := scored[:0]
+	for _, c := range scored {
+		if c.Score >= minCandidateScore {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// queueCandidates stores ambiguous candidates for manual review.
+func (s *Scanner) queueCandidates(ctx context.Context, artistFolder string, candidates []Candidate, result *ScanResult) {
+	rows := make([]db.ArtistCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		row := db.ArtistCandidate{
+			FolderName: artistFolder,
+			TidalID:    c.Artist.ID,
+			TidalName:  c.Artist.Name,
+			Popularity: c.Artist.Popularity,
+			Score:      c.Score,
+			NameScore:  c.NameScore,
+		}
+		if c.Artist.Picture != "" {
+			row.PictureURL = &c.Artist.Picture
+		}
+		if c.AlbumScore >= 0 {
+			row.AlbumScore = &c.AlbumScore
+		}
+		rows = append(rows, row)
+	}
+
+	if err := s.store.ReplaceArtistCandidates(ctx, artistFolder, rows); err != nil {
+		msg := fmt.Sprintf("queueing review for artist %s: %v", artistFolder, err)
+		s.logger.Println(msg)
+		result.Errors = append(result.Errors, msg)
+		return
+	}
+
+	s.logger.Printf("artist %s needs review: best match %q scored %.2f", artistFolder, candidates[0].Artist.Name, candidates[0].Score)
+	result.ArtistsQueued++
+}
+
+// linkAlbums matches the artist's not-yet-linked library albums against its
+// Tidal discography (see bestAlbumMatch) and stores each confident link,
+// along with the best quality Tidal offers for the album.
+// The discography is only fetched when there is something to link.
+func (s *Scanner) linkAlbums(ctx context.Context, artistFolder string, tidalID int64, scanned map[string]localAlbum, result *ScanResult) {
+	albums, err := s.store.ListAlbumsForArtist(ctx, artistFolder)
+	if err != nil {
+		msg := fmt.Sprintf("listing albums for %s: %v", artistFolder, err)
+		s.logger.Println(msg)
+		result.Errors = append(result.Errors, msg)
+		return
+	}
+
+	var unlinked []db.LibraryAlbum
+	for _, a := range albums {
+		if a.TidalAlbumID == nil {
+			unlinked = append(unlinked, a)
+		}
+	}
+	if len(unlinked) == 0 {
+		return
+	}
+
+	remote, err := s.searcher.GetArtistAlbums(ctx, tidalID)
+	if err != nil {
+		msg := fmt.Sprintf("fetching Tidal albums for %s: %v", artistFolder, err)
+		s.logger.Println(msg)
+		result.Errors = append(result.Errors, msg)
+		return
+	}
+
+	for _, a := range unlinked {
+		local, ok := scanned[a.AlbumFolder]
+		if !ok {
+			local = localAlbum{Folder: a.AlbumFolder, TrackCount: a.TrackCount}
+		}
+
+		match, ok := bestAlbumMatch(local, remote)
+		if !ok {
+			continue
+		}
+		if err := s.store.LinkLibraryAlbum(ctx, a.ID, match.Album.ID, match.Score); err != nil {
+			msg := fmt.Sprintf("linking album %s/%s: %v", artistFolder, a.AlbumFolder, err)
+			s.logger.Println(msg)
+			result.Errors = append(result.Errors, msg)
+			continue
+		}
+		result.AlbumsLinked++
+
+		if err := s.store.SetTidalAlbumQuality(ctx, match.Album.ID, AvailableQuality(match.Album)); err != nil {
+			s.logger.Printf("recording quality of %s/%s: %v", artistFolder, a.AlbumFolder, err)
+		}
+	}
 }
 
-// countFLACFiles returns the number of .flac files (case-insensitive) in the
-// given directory, ignoring macOS resource-fork artifacts (files starting
-// with "._").
-func countFLACFiles(dirPath string) (int, error) {
+// scanAlbumTracks probes every audio file (see audio.IsAudioFile) in the
+// given directory. Files whose headers cannot be read are still returned,
+// with the format implied by their extension, and described in probeErrs.
+// The returned error is non-nil only if the directory itself is unreadable.
+func scanAlbumTracks(dirPath string) (tracks []db.LibraryTrack, probeErrs []string, err error) {
 	entries, err := os.ReadDir(dirPath)
 	if err != nil {
-		return 0, err
+		return nil, nil, err
 	}
 
-	count := 0
 	for _, entry := range entries {
-		if entry.IsDir() {
+		if entry.IsDir() || !audio.IsAudioFile(entry.Name()) {
 			continue
 		}
-		name := entry.Name()
-		if strings.HasPrefix(name, "._") {
+
+		path := filepath.Join(dirPath, entry.Name())
+		track := db.LibraryTrack{
+			Filename: entry.Name(),
+			Path:     path,
+			Format:   audio.FormatFromExtension(entry.Name()),
+		}
+		if fi, err := entry.Info(); err == nil {
+			track.SizeBytes = fi.Size()
+		}
+
+		info, err := audio.Probe(path)
+		if err != nil {
+			probeErrs = append(probeErrs, fmt.Sprintf("probing %s: %v", path, err))
+			tracks = append(tracks, track)
+			continue
+		}
+
+		track.Format = info.Format
+		track.Title = nonEmpty(info.Title)
+		track.Artist = nonEmpty(info.Artist)
+		track.Album = nonEmpty(info.Album)
+		track.TrackNumber = info.TrackNumber
+		track.DiscNumber = info.DiscNumber
+		track.Duration = info.Duration
+		track.SampleRate = info.SampleRate
+		track.BitDepth = info.BitDepth
+		track.Channels = info.Channels
+		track.Bitrate = info.Bitrate
+		tracks = append(tracks, track)
+	}
+
+	return tracks, probeErrs, nil
+}
+
+// albumTag returns the album tag carried by the most tracks, or "" when no
+// track is tagged.
+func albumTag(tracks []db.LibraryTrack) string {
+	counts := make(map[string]int)
+	best := ""
+	for _, t := range tracks {
+		if t.Album == nil || *t.Album == "" {
 			continue
 		}
-		if strings.EqualFold(filepath.Ext(name), ".flac") {
-			count++
+		counts[*t.Album]++
+		if counts[*t.Album] > counts[best] {
+			best = *t.Album
 		}
 	}
+	return best
+}
 
-	return count, nil
+// nonEmpty returns a pointer to s, or nil if s is empty, for nullable tag
+// columns.
+func nonEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/MattHbrook/Crescendo/internal/db"
@@ -16,15 +17,21 @@ import (
 // ---------------------------------------------------------------------------
 
 type albumRecord struct {
+	id           int64
 	artistFolder string
 	albumFolder  string
 	trackCount   int
 	path         string
+	tidalAlbumID *int64
+	tidalQuality string
 }
 
 type mockStore struct {
 	mappings        map[string]*db.ArtistMapping
 	albums          []albumRecord
+	tracks          map[string][]db.LibraryTrack // key is "artist/album"
+	candidates      map[string][]db.ArtistCandidate
+	nextAlbumID     int64
 	upsertArtistErr error
 	getArtistErr    error
 	upsertAlbumErr  error
@@ -58,7 +65,16 @@ func (m *mockStore) UpsertLibraryAlbum(_ context.Context, artistFolder, albumFol
 	if m.upsertAlbumErr != nil {
 		return m.upsertAlbumErr
 	}
+	for i, a := range m.albums {
+		if a.artistFolder == artistFolder && a.albumFolder == albumFolder {
+			m.albums[i].trackCount = trackCount
+			m.albums[i].path = path
+			return nil
+		}
+	}
+	m.nextAlbumID++
 	m.albums = append(m.albums, albumRecord{
+		id:           m.nextAlbumID,
 		artistFolder: artistFolder,
 		albumFolder:  albumFolder,
 		trackCount:   trackCount,
@@ -67,12 +83,88 @@ func (m *mockStore) UpsertLibraryAlbum(_ context.Context, artistFolder, albumFol
 	return nil
 }
 
-func (m *mockStore) DeleteLibraryAlbumsByArtist(_ context.Context, _ string) error {
-	return nil // not used by scanner
+func (m *mockStore) PruneLibraryAlbums(_ context.Context, artistFolder string, keep []string) error {
+	kept := m.albums[:0]
+	for _, a := range m.albums {
+		if a.artistFolder != artistFolder || slices.Contains(keep, a.albumFolder) {
+			kept = append(kept, a)
+		}
+	}
+	m.albums = kept
+	return nil
+}
+
+func (m *mockStore) SyncLibraryTracks(_ context.Context, artistFolder, albumFolder string, tracks []db.LibraryTrack) error {
+	if m.tracks == nil {
+		m.tracks = make(map[string][]db.LibraryTrack)
+	}
+	m.tracks[artistFolder+"/"+albumFolder] = tracks
+	return nil
+}
+
+func (m *mockStore) DeleteLibraryAlbumsByArtist(_ context.Context, artistFolder string) error {
+	kept := m.albums[:0]
+	for _, a := range m.albums {
+		if a.artistFolder != artistFolder {
+			kept = append(kept, a)
+		}
+	}
+	m.albums = kept
+	return nil
+}
+
+func (m *mockStore) ReplaceArtistCandidates(_ context.Context, folderName string, candidates []db.ArtistCandidate) error {
+	if m.candidates == nil {
+		m.candidates = make(map[string][]db.ArtistCandidate)
+	}
+	m.candidates[folderName] = candidates
+	return nil
+}
+
+func (m *mockStore) DeleteArtistCandidates(_ context.Context, folderName string) error {
+	delete(m.candidates, folderName)
+	return nil
+}
+
+func (m *mockStore) ListAlbumsForArtist(_ context.Context, artistFolder string) ([]db.LibraryAlbum, error) {
+	var albums []db.LibraryAlbum
+	for _, a := range m.albums {
+		if a.artistFolder == artistFolder {
+			albums = append(albums, db.LibraryAlbum{
+				ID:           a.id,
+				ArtistFolder: a.artistFolder,
+				AlbumFolder:  a.albumFolder,
+				TrackCount:   a.trackCount,
+				Path:         a.path,
+				TidalAlbumID: a.tidalAlbumID,
+			})
+		}
+	}
+	return albums, nil
+}
+
+func (m *mockStore) LinkLibraryAlbum(_ context.Context, albumID, tidalAlbumID int64, _ float64) error {
+	for i, a := range m.albums {
+		if a.id == albumID {
+			m.albums[i].tidalAlbumID = &tidalAlbumID
+			return nil
+		}
+	}
+	return errors.New("album not found")
+}
+
+func (m *mockStore) SetTidalAlbumQuality(_ context.Context, tidalAlbumID int64, quality string) error {
+	for i, a := range m.albums {
+		if a.tidalAlbumID != nil && *a.tidalAlbumID == tidalAlbumID {
+			m.albums[i].tidalQuality = quality
+		}
+	}
+	return nil
 }
 
 type mockSearcher struct {
 	results   map[string][]hifi.Artist // key is search query
+	albums    map[int64][]hifi.Album   // key is artist ID
 	searchErr error
 }
 
@@ -87,6 +179,10 @@ func (m *mockSearcher) SearchArtists(_ context.Context, query string, _, _ int)
 	return &hifi.SearchResult[hifi.Artist]{Items: items, Total: len(items)}, nil
 }
 
+func (m *mockSearcher) GetArtistAlbums(_ context.Context, id int64) ([]hifi.Album, error) {
+	return m.albums[id], nil
+}
+
 // ---------------------------------------------------------------------------
 // Test helper
 // ---------------------------------------------------------------------------
@@ -287,6 +383,73 @@ func TestScan_SkipsAlreadyMappedArtists(t *testing.T) {
 	}
 }
 
+func TestScan_SkipsIgnoredArtists(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "Local Band", "Demo"), 0o755); err != nil {
+		t.Fatalf("creating directory: %v", err)
+	}
+
+	store := &mockStore{
+		mappings: map[string]*db.ArtistMapping{
+			"Local Band": {FolderName: "Local Band", Locked: true, Ignored: true},
+		},
+	}
+	searcher := &mockSearcher{searchErr: errors.New("searcher should not be called")}
+
+	result, err := NewScanner(root, store, searcher).Scan(context.Background())
+	if err != nil {
+		t.Fatalf("Scan() returned unexpected error: %v", err)
+	}
+	for _, e := range result.Errors {
+		if contains(e, "searcher should not be called") {
+			t.Error("searcher was called for an ignored artist")
+		}
+	}
+}
+
+func TestScanArtist_ResolvesAgainAfterUnlock(t *testing.T) {
+	root := setupMusicDir(t)
+	handle, err := db.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { _ = handle.Close() })
+	if err := db.Migrate(handle); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	store := db.NewStore(handle)
+
+	ctx := context.Background()
+	if err := store.SetArtistMapping(ctx, "ArtistA", 999, "Someone Else", ""); err != nil {
+		t.Fatalf("SetArtistMapping: %v", err)
+	}
+
+	searcher := &mockSearcher{
+		results: map[string][]hifi.Artist{"ArtistA": {{ID: 100, Name: "ArtistA"}}},
+	}
+	scanner := NewScanner(root, store, searcher)
+	if _, err := scanner.ScanArtist(ctx, "ArtistA"); err != nil {
+		t.Fatalf("ScanArtist: %v", err)
+	}
+	if m, _ := store.GetArtistMapping(ctx, "ArtistA"); m == nil || m.TidalID == nil || *m.TidalID != 999 {
+		t.Fatalf("mapping = %+v, want the manual choice kept", m)
+	}
+
+	if err := store.UnlockArtistMapping(ctx, "ArtistA"); err != nil {
+		t.Fatalf("UnlockArtistMapping: %v", err)
+	}
+	result, err := scanner.ScanArtist(ctx, "ArtistA")
+	if err != nil {
+		t.Fatalf("ScanArtist after unlock: %v", err)
+	}
+	if result.ArtistsMatched != 1 {
+		t.Errorf("ArtistsMatched = %d, want 1: the folder should be resolved again", result.ArtistsMatched)
+	}
+	if m, _ := store.GetArtistMapping(ctx, "ArtistA"); m == nil || m.TidalID == nil || *m.TidalID != 100 {
+		t.Errorf("mapping after unlock = %+v, want Tidal ID 100", m)
+	}
+}
+
 func TestScan_InvalidMusicDir(t *testing.T) {
 	store := &mockStore{
 		mappings: make(map[string]*db.ArtistMapping),
@@ -300,7 +463,257 @@ func TestScan_InvalidMusicDir(t *testing.T) {
 	}
 }
 
-func TestCountFLACFiles(t *testing.T) {
+func TestScanWithProgress(t *testing.T) {
+	root := setupMusicDir(t)
+
+	store := &mockStore{
+		mappings: make(map[string]*db.ArtistMapping),
+	}
+	scanner := NewScanner(root, store, &mockSearcher{})
+
+	var calls [][2]int
+	_, err := scanner.ScanWithProgress(context.Background(), func(processed, total int) {
+		calls = append(calls, [2]int{processed, total})
+	})
+	if err != nil {
+		t.Fatalf("ScanWithProgress() returned unexpected error: %v", err)
+	}
+
+	// ArtistA, ArtistB, ArtistC, EmptyArtist (Playlists skipped)
+	want := [][2]int{{1, 4}, {2, 4}, {3, 4}, {4, 4}}
+	if len(calls) != len(want) {
+		t.Fatalf("progress calls = %v, want %v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("progress call %d = %v, want %v", i, calls[i], want[i])
+		}
+	}
+}
+
+func TestScanArtist(t *testing.T) {
+	root := setupMusicDir(t)
+
+	store := &mockStore{
+		mappings: make(map[string]*db.ArtistMapping),
+	}
+	searcher := &mockSearcher{
+		results: map[string][]hifi.Artist{
+			"ArtistA": {{ID: 100, Name: "Artist A"}},
+		},
+	}
+	scanner := NewScanner(root, store, searcher)
+
+	if _, err := scanner.Scan(context.Background()); err != nil {
+		t.Fatalf("Scan() returned unexpected error: %v", err)
+	}
+
+	// Remove one of ArtistA's albums and add a new one.
+	if err := os.RemoveAll(filepath.Join(root, "ArtistA", "Album2")); err != nil {
+		t.Fatalf("removing album: %v", err)
+	}
+	newAlbum := filepath.Join(root, "ArtistA", "Album4")
+	if err := os.MkdirAll(newAlbum, 0o755); err != nil {
+		t.Fatalf("creating album: %v", err)
+	}
+	f, err := os.Create(filepath.Join(newAlbum, "01.flac"))
+	if err != nil {
+		t.Fatalf("creating file: %v", err)
+	}
+	f.Close()
+
+	result, err := scanner.ScanArtist(context.Background(), "ArtistA")
+	if err != nil {
+		t.Fatalf("ScanArtist() returned unexpected error: %v", err)
+	}
+	if result.AlbumsFound != 2 {
+		t.Errorf("AlbumsFound = %d, want 2", result.AlbumsFound)
+	}
+
+	got := make(map[string]bool)
+	for _, a := range store.albums {
+		if a.artistFolder == "ArtistA" {
+			got[a.albumFolder] = true
+		}
+	}
+	if !got["Album1"] || !got["Album4"] || got["Album2"] {
+		t.Errorf("ArtistA albums = %v, want Album1 and Album4 only", got)
+	}
+
+	// ArtistB's albums are untouched by a targeted rescan.
+	foundB := false
+	for _, a := range store.albums {
+		if a.artistFolder == "ArtistB" {
+			foundB = true
+		}
+	}
+	if !foundB {
+		t.Error("ArtistB albums were removed by ArtistA rescan")
+	}
+}
+
+func TestScanArtist_RemovedFolder(t *testing.T) {
+	root := setupMusicDir(t)
+
+	store := &mockStore{
+		mappings: make(map[string]*db.ArtistMapping),
+	}
+	scanner := NewScanner(root, store, &mockSearcher{})
+
+	if _, err := scanner.Scan(context.Background()); err != nil {
+		t.Fatalf("Scan() returned unexpected error: %v", err)
+	}
+	if err := os.RemoveAll(filepath.Join(root, "ArtistB")); err != nil {
+		t.Fatalf("removing artist: %v", err)
+	}
+
+	result, err := scanner.ScanArtist(context.Background(), "ArtistB")
+	if err != nil {
+		t.Fatalf("ScanArtist() returned unexpected error: %v", err)
+	}
+	if result.ArtistsFound != 0 || result.AlbumsFound != 0 {
+		t.Errorf("result = %+v, want empty", result)
+	}
+	for _, a := range store.albums {
+		if a.artistFolder == "ArtistB" {
+			t.Errorf("album %q for removed artist still stored", a.albumFolder)
+		}
+	}
+}
+
+func TestScan_AmbiguousArtistQueuedForReview(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "Genesis", "Selling England by the Pound"), 0o755); err != nil {
+		t.Fatalf("creating directory: %v", err)
+	}
+
+	// Two artists share the name and neither has a discography to compare,
+	// so the scanner cannot tell them apart.
+	store := &mockStore{mappings: make(map[string]*db.ArtistMapping)}
+	searcher := &mockSearcher{
+		results: map[string][]hifi.Artist{
+			"Genesis": {
+				{ID: 1, Name: "Genesis", Popularity: 60},
+				{ID: 2, Name: "Genesis", Popularity: 20},
+				{ID: 3, Name: "Genesis Owusu", Popularity: 40},
+			},
+		},
+	}
+
+	result, err := NewScanner(root, store, searcher).Scan(context.Background())
+	if err != nil {
+		t.Fatalf("Scan() returned unexpected error: %v", err)
+	}
+
+	if result.ArtistsMatched != 0 || result.ArtistsQueued != 1 {
+		t.Errorf("matched/queued = %d/%d, want 0/1", result.ArtistsMatched, result.ArtistsQueued)
+	}
+	if _, ok := store.mappings["Genesis"]; ok {
+		t.Error("ambiguous artist was mapped automatically")
+	}
+	queued := store.candidates["Genesis"]
+	if len(queued) != 3 {
+		t.Fatalf("queued %d candidates, want 3", len(queued))
+	}
+	if queued[0].TidalID != 1 {
+		t.Errorf("best candidate = %d, want 1 (more popular)", queued[0].TidalID)
+	}
+}
+
+func TestScan_AlbumOverlapDisambiguates(t *testing.T) {
+	root := t.TempDir()
+	for _, album := range []string{"1973 - Selling England by the Pound", "The Lamb Lies Down on Broadway [2008 Remaster]"} {
+		if err := os.MkdirAll(filepath.Join(root, "Genesis", album), 0o755); err != nil {
+			t.Fatalf("creating directory: %v", err)
+		}
+		f, err := os.Create(filepath.Join(root, "Genesis", album, "01.flac"))
+		if err != nil {
+			t.Fatalf("creating file: %v", err)
+		}
+		f.Close()
+	}
+
+	store := &mockStore{mappings: make(map[string]*db.ArtistMapping)}
+	searcher := &mockSearcher{
+		results: map[string][]hifi.Artist{
+			"Genesis": {
+				{ID: 2, Name: "Genesis", Popularity: 30},
+				{ID: 1, Name: "Genesis", Popularity: 25},
+			},
+		},
+		albums: map[int64][]hifi.Album{
+			1: {{Title: "Selling England By The Pound"}, {Title: "The Lamb Lies Down On Broadway"}, {Title: "Foxtrot"}},
+			2: {{Title: "Genesis EP"}},
+		},
+	}
+
+	result, err := NewScanner(root, store, searcher).Scan(context.Background())
+	if err != nil {
+		t.Fatalf("Scan() returned unexpected error: %v", err)
+	}
+
+	if result.ArtistsMatched != 1 {
+		t.Fatalf("ArtistsMatched = %d, want 1 (errors: %v)", result.ArtistsMatched, result.Errors)
+	}
+	if got := *store.mappings["Genesis"].TidalID; got != 1 {
+		t.Errorf("Genesis mapped to %d, want 1 (matching discography)", got)
+	}
+}
+
+func TestScan_LinksAlbumsToTidal(t *testing.T) {
+	root := setupMusicDir(t)
+
+	tidalID := int64(10)
+	store := &mockStore{
+		mappings: map[string]*db.ArtistMapping{
+			"ArtistA": {FolderName: "ArtistA", TidalID: &tidalID},
+		},
+	}
+	searcher := &mockSearcher{
+		albums: map[int64][]hifi.Album{
+			10: {
+				{ID: 100, Title: "Album1", NumberOfTracks: 2, AudioQuality: "LOSSLESS", MediaMetadata: hifi.MediaMetadata{Tags: []string{"LOSSLESS", "HIRES_LOSSLESS"}}},
+				{ID: 101, Title: "Something Else Entirely", NumberOfTracks: 1},
+			},
+		},
+	}
+
+	scanner := NewScanner(root, store, searcher)
+	result, err := scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("Scan() returned unexpected error: %v", err)
+	}
+	if result.AlbumsLinked != 1 {
+		t.Errorf("AlbumsLinked = %d, want 1", result.AlbumsLinked)
+	}
+
+	for _, a := range store.albums {
+		switch a.albumFolder {
+		case "Album1":
+			if a.tidalAlbumID == nil || *a.tidalAlbumID != 100 {
+				t.Errorf("Album1 tidalAlbumID = %v, want 100", a.tidalAlbumID)
+			}
+			if a.tidalQuality != QualityHiRes {
+				t.Errorf("Album1 tidalQuality = %q, want %q", a.tidalQuality, QualityHiRes)
+			}
+		default:
+			if a.tidalAlbumID != nil {
+				t.Errorf("%s/%s linked to %d, want unlinked", a.artistFolder, a.albumFolder, *a.tidalAlbumID)
+			}
+		}
+	}
+
+	// A rescan keeps the link and does not count it again.
+	result, err = scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("second Scan() returned unexpected error: %v", err)
+	}
+	if result.AlbumsLinked != 0 {
+		t.Errorf("second scan AlbumsLinked = %d, want 0", result.AlbumsLinked)
+	}
+}
+
+func TestScanAlbumTracks(t *testing.T) {
 	tests := []struct {
 		name  string
 		files []string // filenames to create in the test directory
@@ -308,7 +721,7 @@ func TestCountFLACFiles(t *testing.T) {
 	}{
 		{
 			name:  "mixed file types",
-			files: []string{"song.flac", "cover.jpg", "notes.mp3", "bonus.flac"},
+			files: []string{"song.flac", "cover.jpg", "notes.txt", "bonus.mp3"},
 			want:  2,
 		},
 		{
@@ -317,9 +730,9 @@ func TestCountFLACFiles(t *testing.T) {
 			want:  2,
 		},
 		{
-			name:  "case insensitive flac extension",
-			files: []string{"track.FLAC", "song.Flac", "other.fLaC"},
-			want:  3,
+			name:  "case insensitive extensions",
+			files: []string{"track.FLAC", "song.M4A", "other.Opus", "wv.WV"},
+			want:  4,
 		},
 		{
 			name:  "empty directory",
@@ -339,17 +752,79 @@ func TestCountFLACFiles(t *testing.T) {
 				f.Close()
 			}
 
-			got, err := countFLACFiles(dir)
+			got, probeErrs, err := scanAlbumTracks(dir)
 			if err != nil {
-				t.Fatalf("countFLACFiles() returned unexpected error: %v", err)
+				t.Fatalf("scanAlbumTracks() returned unexpected error: %v", err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("len(scanAlbumTracks()) = %d, want %d", len(got), tt.want)
 			}
-			if got != tt.want {
-				t.Errorf("countFLACFiles() = %d, want %d", got, tt.want)
+			// The files are empty, so every probe fails but each track
+			// still carries the format implied by its extension.
+			if len(probeErrs) != tt.want {
+				t.Errorf("len(probeErrs) = %d, want %d", len(probeErrs), tt.want)
+			}
+			for _, track := range got {
+				if track.Format == "" {
+					t.Errorf("track %q has empty format", track.Filename)
+				}
 			}
 		})
 	}
 }
 
+func TestScanAlbumTracks_ReadsProperties(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "01 - Intro.flac"), minimalFLAC(), 0o644); err != nil {
+		t.Fatalf("writing flac: %v", err)
+	}
+
+	tracks, probeErrs, err := scanAlbumTracks(dir)
+	if err != nil {
+		t.Fatalf("scanAlbumTracks() returned unexpected error: %v", err)
+	}
+	if len(probeErrs) != 0 {
+		t.Errorf("probeErrs = %v, want none", probeErrs)
+	}
+	if len(tracks) != 1 {
+		t.Fatalf("len(tracks) = %d, want 1", len(tracks))
+	}
+
+	got := tracks[0]
+	if got.Format != "flac" || got.SampleRate != 44100 || got.BitDepth != 16 || got.Channels != 2 {
+		t.Errorf("track = %+v, want flac 44100/16/2", got)
+	}
+	if got.Duration != 10 {
+		t.Errorf("Duration = %v, want 10", got.Duration)
+	}
+	if got.Title == nil || *got.Title != "Intro" {
+		t.Errorf("Title = %v, want Intro", got.Title)
+	}
+	if got.SizeBytes == 0 {
+		t.Error("SizeBytes = 0, want file size")
+	}
+}
+
+// minimalFLAC returns a FLAC header with a STREAMINFO block describing 10
+// seconds of 44.1 kHz 16-bit stereo and a TITLE=Intro comment.
+func minimalFLAC() []byte {
+	data := []byte("fLaC")
+	data = append(data, 0x00, 0, 0, 34)
+	si := make([]byte, 34)
+	rate, total := 44100, 441000
+	si[10] = byte(rate >> 12)
+	si[11] = byte(rate >> 4)
+	si[12] = byte(rate&0x0F)<<4 | 1<<1 // 2 channels
+	si[13] = 15 << 4                   // 16 bits per sample
+	si[14], si[15], si[16], si[17] = byte(total>>24), byte(total>>16), byte(total>>8), byte(total)
+	data = append(data, si...)
+
+	comment := []byte{0, 0, 0, 0, 1, 0, 0, 0, 11, 0, 0, 0}
+	comment = append(comment, "TITLE=Intro"...)
+	data = append(data, 0x84, 0, 0, byte(len(comment)))
+	return append(data, comment...)
+}
+
 // contains reports whether s contains substr. Defined here to avoid importing
 // strings in the test file just for this one check.
 func contains(s, substr string) bool {
@@ -0,0 +1,33 @@
+package library
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MoveToTrash moves a file or folder into a timestamped folder under
+// trashPath, keeping its path relative to musicPath (or just its name when it
+// lies outside the music root), and returns where it ended up. It is a
+// rename, so the trash must be on the same filesystem as path. A missing path
+// is reported with an error satisfying errors.Is(err, fs.ErrNotExist).
+func MoveToTrash(musicPath, trashPath, path string) (string, error) {
+	if _, err := os.Lstat(path); err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(musicPath, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		rel = filepath.Base(path)
+	}
+	trashed := filepath.Join(trashPath, time.Now().Format("20060102-150405"), rel)
+	if err := os.MkdirAll(filepath.Dir(trashed), 0o750); err != nil {
+		return "", fmt.Errorf("creating trash directory: %w", err)
+	}
+	if err := os.Rename(path, trashed); err != nil {
+		return "", err
+	}
+	return trashed, nil
+}
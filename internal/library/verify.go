@@ -0,0 +1,139 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+
+	"github.com/MattHbrook/Crescendo/internal/audio"
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/job"
+)
+
+// ErrVerifyInProgress is returned by Verifier.Start when a verification is
+// already running.
+var ErrVerifyInProgress = errors.New("library: a verification is already running")
+
+// ReverifyAfterDays is how long a track's verification stands before the
+// next run checks it again.
+const ReverifyAfterDays = 30
+
+// VerifyStore is the subset of db.Store needed by the verifier.
+type VerifyStore interface {
+	ListTracksDueForVerification(ctx context.Context, maxAgeDays int) ([]db.LibraryTrack, error)
+	RecordTrackVerification(ctx context.Context, trackID, sizeBytes int64, verifyErr string) error
+	DeleteLibraryTrack(ctx context.Context, id int64) error
+}
+
+// VerifyResult holds aggregate statistics from a verification run.
+type VerifyResult struct {
+	TracksVerified int
+	TracksCorrupt  int
+	Errors         []string
+}
+
+// Verifier decodes the library's FLAC tracks to catch silent corruption such
+// as bit rot (see audio.VerifyFLAC), recording the result per track. Runs
+// happen in the background, one at a time, and only check tracks that are
+// due: never verified, changed since, or not verified for ReverifyAfterDays.
+type Verifier struct {
+	musicPath string
+	trashPath string
+	store     VerifyStore
+	logger    *log.Logger
+	job.Runner
+}
+
+// NewVerifier creates a Verifier for the library at musicPath that moves
+// discarded tracks under trashPath.
+func NewVerifier(musicPath, trashPath string, store VerifyStore) *Verifier {
+	return &Verifier{
+		musicPath: musicPath,
+		trashPath: trashPath,
+		store:     store,
+		logger:    log.New(os.Stderr, "[verify] ", log.LstdFlags),
+	}
+}
+
+// Start runs a verification in the background, detached from ctx's
+// cancellation. It returns ErrVerifyInProgress if one is already running.
+func (v *Verifier) Start(ctx context.Context) error {
+	if !v.Runner.Start(ctx, func(ctx context.Context) {
+		result, err := v.Verify(ctx)
+		if err != nil {
+			v.logger.Printf("verification failed: %v", err)
+			return
+		}
+		v.logger.Printf("verification complete: %d tracks, %d corrupt, %d errors",
+			result.TracksVerified, result.TracksCorrupt, len(result.Errors))
+	}) {
+		return ErrVerifyInProgress
+	}
+	return nil
+}
+
+// Verify decodes every track that is due and records the result. A track
+// that cannot be read at all, e.g. because it was deleted since the last
+// scan, is reported in VerifyResult.Errors rather than marked corrupt. It
+// returns an error only if the tracks cannot be listed.
+func (v *Verifier) Verify(ctx context.Context) (*VerifyResult, error) {
+	tracks, err := v.store.ListTracksDueForVerification(ctx, ReverifyAfterDays)
+	if err != nil {
+		return nil, fmt.Errorf("library: listing tracks to verify: %w", err)
+	}
+
+	result := &VerifyResult{}
+	for _, t := range tracks {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		verifyErr := audio.VerifyFLAC(t.Path)
+		if verifyErr != nil && !errors.Is(verifyErr, audio.ErrCorrupt) {
+			msg := fmt.Sprintf("verifying %s: %v", t.Path, verifyErr)
+			v.logger.Println(msg)
+			result.Errors = append(result.Errors, msg)
+			continue
+		}
+
+		msg := ""
+		if verifyErr != nil {
+			msg = verifyErr.Error()
+			v.logger.Printf("corrupt: %v", verifyErr)
+		}
+		if err := v.store.RecordTrackVerification(ctx, t.ID, t.SizeBytes, msg); err != nil {
+			msg := fmt.Sprintf("recording verification of %s: %v", t.Path, err)
+			v.logger.Println(msg)
+			result.Errors = append(result.Errors, msg)
+			continue
+		}
+
+		result.TracksVerified++
+		if verifyErr != nil {
+			result.TracksCorrupt++
+		}
+	}
+	return result, nil
+}
+
+// Discard moves tracks to the trash (see MoveToTrash) and removes them from
+// the index, so replacements can be downloaded in their place. A file that is
+// already gone is only removed from the index.
+func (v *Verifier) Discard(ctx context.Context, tracks []db.LibraryTrack) error {
+	for _, t := range tracks {
+		trashed, err := MoveToTrash(v.musicPath, v.trashPath, t.Path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("library: moving %s to trash: %w", t.Path, err)
+		}
+		if err := v.store.DeleteLibraryTrack(ctx, t.ID); err != nil {
+			return fmt.Errorf("library: removing track %d: %w", t.ID, err)
+		}
+		if trashed != "" {
+			v.logger.Printf("moved %s to %s", t.Path, trashed)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package library
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+)
+
+type mockVerifyStore struct {
+	due      []db.LibraryTrack
+	recorded map[int64]string
+	deleted  []int64
+}
+
+func (m *mockVerifyStore) ListTracksDueForVerification(_ context.Context, _ int) ([]db.LibraryTrack, error) {
+	return m.due, nil
+}
+
+func (m *mockVerifyStore) RecordTrackVerification(_ context.Context, trackID, _ int64, verifyErr string) error {
+	if m.recorded == nil {
+		m.recorded = make(map[int64]string)
+	}
+	m.recorded[trackID] = verifyErr
+	return nil
+}
+
+func (m *mockVerifyStore) DeleteLibraryTrack(_ context.Context, id int64) error {
+	m.deleted = append(m.deleted, id)
+	return nil
+}
+
+// emptyFLAC is a FLAC file with a STREAMINFO block and no audio: it has no
+// frames to damage, so it always verifies.
+func emptyFLAC() []byte {
+	si := make([]byte, 34)
+	si[10], si[11], si[12], si[13] = 0x0a, 0xc4, 0x42, 0xf0 // 44.1 kHz, stereo, 16-bit
+	return append([]byte{'f', 'L', 'a', 'C', 0x80, 0, 0, 34}, si...)
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "01 - Airbag.flac")
+	bad := filepath.Join(dir, "02 - Paranoid Android.flac")
+	if err := os.WriteFile(good, emptyFLAC(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bad, []byte("not a flac at all"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store := &mockVerifyStore{due: []db.LibraryTrack{
+		{ID: 1, Path: good},
+		{ID: 2, Path: bad},
+		{ID: 3, Path: filepath.Join(dir, "03 - gone.flac")},
+	}}
+	result, err := NewVerifier(dir, filepath.Join(dir, ".trash"), store).Verify(context.Background())
+	if err != nil {
+		t.Fatalf("Verify() returned unexpected error: %v", err)
+	}
+
+	if result.TracksVerified != 2 || result.TracksCorrupt != 1 || len(result.Errors) != 1 {
+		t.Errorf("result = %+v, want 2 verified, 1 corrupt, 1 error", result)
+	}
+	if msg, ok := store.recorded[1]; !ok || msg != "" {
+		t.Errorf("track 1 recorded %q (%v), want intact", msg, ok)
+	}
+	if msg := store.recorded[2]; !strings.Contains(msg, "corrupt") {
+		t.Errorf("track 2 recorded %q, want a corruption message", msg)
+	}
+	if _, ok := store.recorded[3]; ok {
+		t.Error("unreadable track 3 should not be recorded")
+	}
+}
+
+func TestVerifier_Discard(t *testing.T) {
+	root := t.TempDir()
+	trash := filepath.Join(root, ".trash")
+	dir := writeAlbum(t, root, "Radiohead", "OK Computer", "01 - Airbag.flac", "02 - Paranoid Android.flac")
+
+	store := &mockVerifyStore{}
+	tracks := []db.LibraryTrack{
+		{ID: 10, Path: filepath.Join(dir, "02 - Paranoid Android.flac")},
+		{ID: 11, Path: filepath.Join(dir, "03 - gone.flac")},
+	}
+
+	if err := NewVerifier(root, trash, store).Discard(context.Background(), tracks); err != nil {
+		t.Fatalf("Discard() returned unexpected error: %v", err)
+	}
+	if !slices.Equal(store.deleted, []int64{10, 11}) {
+		t.Errorf("deleted = %v, want [10 11]", store.deleted)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "02 - Paranoid Android.flac")); !os.IsNotExist(err) {
+		t.Errorf("corrupt track should be gone, stat err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "01 - Airbag.flac")); err != nil {
+		t.Errorf("intact track should stay: %v", err)
+	}
+	trashed, _ := filepath.Glob(filepath.Join(trash, "*", "Radiohead", "OK Computer", "02 - Paranoid Android.flac"))
+	if len(trashed) != 1 {
+		t.Errorf("corrupt track not in trash: %v", trashed)
+	}
+}
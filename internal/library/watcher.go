@@ -0,0 +1,262 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Watch modes accepted by NewWatcher.
+const (
+	WatchOff     = "off"
+	WatchInotify = "inotify"
+	WatchPoll    = "poll"
+)
+
+// ArtistRescanner is the subset of ScanManager needed by the watcher.
+type ArtistRescanner interface {
+	StartArtists(ctx context.Context, artists []string) (int64, error)
+}
+
+// Watcher observes the music directory and triggers targeted rescans of the
+// artist folders that changed. Filesystem events are debounced so that
+// copying an album in triggers a single rescan once the copy settles. The
+// rescans run as background scans, recorded in the scan history like any
+// other.
+type Watcher struct {
+	musicPath    string
+	scanner      ArtistRescanner
+	mode         string
+	debounce     time.Duration
+	pollInterval time.Duration
+	logger       *log.Logger
+}
+
+// NewWatcher creates a Watcher for musicPath. mode is one of WatchInotify or
+// WatchPoll; debounce is the quiet period required before a changed artist is
+// rescanned, and pollInterval is how often the tree is walked in poll mode.
+func NewWatcher(musicPath string, scanner ArtistRescanner, mode string, debounce, pollInterval time.Duration) *Watcher {
+	return &Watcher{
+		musicPath:    musicPath,
+		scanner:      scanner,
+		mode:         mode,
+		debounce:     debounce,
+		pollInterval: pollInterval,
+		logger:       log.New(os.Stderr, "[watcher] ", log.LstdFlags),
+	}
+}
+
+// Run watches the music directory until ctx is cancelled. If inotify cannot
+// be initialised (unsupported platform, watch limit exhausted) the watcher
+// falls back to polling.
+func (w *Watcher) Run(ctx context.Context) error {
+	changes := make(chan string, 64)
+
+	mode := w.mode
+	switch mode {
+	case WatchInotify:
+		if err := w.watchInotify(ctx, changes); err != nil {
+			w.logger.Printf("inotify unavailable, falling back to polling every %s: %v", w.pollInterval, err)
+			mode = WatchPoll
+			go w.poll(ctx, changes)
+		}
+	case WatchPoll:
+		go w.poll(ctx, changes)
+	default:
+		return fmt.Errorf("watcher: unsupported mode %q", w.mode)
+	}
+
+	w.logger.Printf("watching %s (%s mode)", w.musicPath, mode)
+	w.debounceLoop(ctx, changes)
+	return nil
+}
+
+// debounceLoop collects changed artist folders and rescans them once no new
+// change has arrived for the debounce period. While another scan is
+// running they are held, and tried again after another debounce period.
+func (w *Watcher) debounceLoop(ctx context.Context, changes <-chan string) {
+	pending := make(map[string]bool)
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case artist := <-changes:
+			pending[artist] = true
+			timer.Reset(w.debounce)
+		case <-timer.C:
+			if !w.flush(ctx, pending) {
+				timer.Reset(w.debounce)
+				continue
+			}
+			pending = make(map[string]bool)
+		}
+	}
+}
+
+// flush starts a background rescan of every pending artist folder, in name
+// order. It reports false, starting nothing, if another scan is running.
+func (w *Watcher) flush(ctx context.Context, pending map[string]bool) bool {
+	artists := make([]string, 0, len(pending))
+	for artist := range pending {
+		artists = append(artists, artist)
+	}
+	sort.Strings(artists)
+
+	id, err := w.scanner.StartArtists(ctx, artists)
+	switch {
+	case errors.Is(err, ErrScanInProgress):
+		return false
+	case err != nil:
+		w.logger.Printf("rescanning %s: %v", strings.Join(artists, ", "), err)
+	default:
+		w.logger.Printf("scan %d: rescanning %s", id, strings.Join(artists, ", "))
+	}
+	return true
+}
+
+// rescanAll reports every artist folder as changed. It is used when events
+// may have been lost, so that the whole library is rescanned.
+func (w *Watcher) rescanAll(ctx context.Context, changes chan<- string) error {
+	snap, err := snapshotLibrary(w.musicPath)
+	if err != nil {
+		return err
+	}
+
+	artists := make([]string, 0, len(snap))
+	for artist := range snap {
+		artists = append(artists, artist)
+	}
+	sort.Strings(artists)
+
+	for _, artist := range artists {
+		select {
+		case changes <- artist:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return nil
+}
+
+// poll walks the music directory every pollInterval and reports artist
+// folders whose contents differ from the previous walk.
+func (w *Watcher) poll(ctx context.Context, changes chan<- string) {
+	prev, err := snapshotLibrary(w.musicPath)
+	if err != nil {
+		w.logger.Printf("initial snapshot: %v", err)
+	}
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		next, err := snapshotLibrary(w.musicPath)
+		if err != nil {
+			w.logger.Printf("snapshot: %v", err)
+			continue
+		}
+
+		for _, artist := range diffSnapshots(prev, next) {
+			select {
+			case changes <- artist:
+			case <-ctx.Done():
+				return
+			}
+		}
+		prev = next
+	}
+}
+
+// snapshotLibrary returns a fingerprint per artist folder covering the names,
+// sizes and modification times of everything up to three levels deep
+// (artist/album/file). Folders skipped by the scanner are ignored.
+func snapshotLibrary(root string) (map[string]uint64, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("reading music directory %s: %w", root, err)
+	}
+
+	snap := make(map[string]uint64, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() || skipArtistFolder(entry.Name()) {
+			continue
+		}
+
+		h := fnv.New64a()
+		artistPath := filepath.Join(root, entry.Name())
+		_ = filepath.WalkDir(artistPath, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return nil //nolint:nilerr // unreadable entries just drop out of the fingerprint
+			}
+			rel, _ := filepath.Rel(artistPath, path)
+			if d.IsDir() && strings.Count(rel, string(filepath.Separator)) >= 1 {
+				return filepath.SkipDir
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil //nolint:nilerr // file vanished mid-walk
+			}
+			_, _ = fmt.Fprintf(h, "%s|%d|%d\n", rel, info.Size(), info.ModTime().UnixNano())
+			return nil
+		})
+		snap[entry.Name()] = h.Sum64()
+	}
+
+	return snap, nil
+}
+
+// diffSnapshots returns the artist folders that were added, removed or
+// changed between two snapshots, sorted by name.
+func diffSnapshots(prev, next map[string]uint64) []string {
+	var changed []string
+	for artist, sum := range next {
+		if old, ok := prev[artist]; !ok || old != sum {
+			changed = append(changed, artist)
+		}
+	}
+	for artist := range prev {
+		if _, ok := next[artist]; !ok {
+			changed = append(changed, artist)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// artistFromPath maps a path inside the music directory to the artist folder
+// it belongs to. It returns "" for the root itself and for folders the
+// scanner skips.
+func artistFromPath(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	artist := strings.SplitN(rel, string(filepath.Separator), 2)[0]
+	if skipArtistFolder(artist) {
+		return ""
+	}
+	return artist
+}
+
+// skipArtistFolder reports whether a top-level folder is ignored by the
+// scanner and watcher.
+func skipArtistFolder(name string) bool {
+	return name == PlaylistsFolder || strings.HasPrefix(name, ".")
+}
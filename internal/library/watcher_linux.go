@@ -0,0 +1,177 @@
+//go:build linux
+
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const inotifyMask = unix.IN_CREATE | unix.IN_DELETE | unix.IN_CLOSE_WRITE |
+	unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_DELETE_SELF
+
+// inotifyWatch tracks the watch descriptors registered for the music root,
+// each artist folder and each album folder.
+type inotifyWatch struct {
+	fd    int
+	root  string
+	paths map[int]string // watch descriptor -> directory path
+}
+
+// watchInotify registers inotify watches on the music directory and starts a
+// goroutine that reports changed artist folders until ctx is cancelled.
+func (w *Watcher) watchInotify(ctx context.Context, changes chan<- string) error {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return fmt.Errorf("inotify init: %w", err)
+	}
+
+	iw := &inotifyWatch{fd: fd, root: w.musicPath, paths: make(map[int]string)}
+	if err := iw.addTree(w.musicPath, 0); err != nil {
+		_ = unix.Close(fd)
+		return err
+	}
+
+	go func() {
+		defer func() { _ = unix.Close(fd) }()
+		if err := iw.readLoop(ctx, changes, w.overflowed); err != nil {
+			w.logger.Printf("inotify: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// addTree watches dir and its subdirectories down to album level. depth is 0
+// for the music root, 1 for artist folders and 2 for album folders.
+func (iw *inotifyWatch) addTree(dir string, depth int) error {
+	wd, err := unix.InotifyAddWatch(iw.fd, dir, inotifyMask)
+	if err != nil {
+		return fmt.Errorf("inotify watch %s: %w", dir, err)
+	}
+	iw.paths[wd] = dir
+
+	if depth >= 2 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", dir, err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || (depth == 0 && skipArtistFolder(entry.Name())) {
+			continue
+		}
+		if err := iw.addTree(filepath.Join(dir, entry.Name()), depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// depth returns how many levels below the music root dir is.
+func (iw *inotifyWatch) depth(dir string) int {
+	rel, err := filepath.Rel(iw.root, dir)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+// overflowed handles the kernel dropping events because its queue filled up,
+// as when a large library is copied in at once: the changes are unknown, so
+// every artist folder is rescanned.
+func (w *Watcher) overflowed(ctx context.Context, changes chan<- string) {
+	w.logger.Printf("inotify event queue overflowed, rescanning the whole library")
+	if err := w.rescanAll(ctx, changes); err != nil {
+		w.logger.Printf("scheduling full rescan: %v", err)
+	}
+}
+
+// readLoop drains inotify events, translating each into the artist folder it
+// affects. New directories are watched as they appear. When the kernel's
+// event queue overflows, onOverflow is called instead.
+func (iw *inotifyWatch) readLoop(ctx context.Context, changes chan<- string, onOverflow func(context.Context, chan<- string)) error {
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	fds := []unix.PollFd{{Fd: int32(iw.fd), Events: unix.POLLIN}} //nolint:gosec // fd fits in int32
+
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		n, err := unix.Poll(fds, 500)
+		if err != nil {
+			if errors.Is(err, unix.EINTR) {
+				continue
+			}
+			return fmt.Errorf("poll: %w", err)
+		}
+		if n == 0 {
+			continue
+		}
+
+		read, err := unix.Read(iw.fd, buf)
+		if err != nil {
+			if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) {
+				continue
+			}
+			return fmt.Errorf("read: %w", err)
+		}
+
+		for offset := 0; offset+unix.SizeofInotifyEvent <= read; {
+			ev := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset])) //nolint:gosec // kernel-provided event layout
+			nameBytes := buf[offset+unix.SizeofInotifyEvent : offset+unix.SizeofInotifyEvent+int(ev.Len)]
+			name := strings.TrimRight(string(nameBytes), "\x00")
+			offset += unix.SizeofInotifyEvent + int(ev.Len)
+
+			if ev.Mask&unix.IN_Q_OVERFLOW != 0 {
+				// Directories created among the lost events are not
+				// watched yet; best-effort, as for those created below.
+				_ = iw.addTree(iw.root, 0)
+				onOverflow(ctx, changes)
+				continue
+			}
+
+			dir, ok := iw.paths[int(ev.Wd)]
+			if !ok {
+				continue
+			}
+			if ev.Mask&unix.IN_IGNORED != 0 {
+				delete(iw.paths, int(ev.Wd))
+				continue
+			}
+
+			path := dir
+			if name != "" {
+				path = filepath.Join(dir, name)
+			}
+
+			if ev.Mask&unix.IN_ISDIR != 0 && ev.Mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0 {
+				if depth := iw.depth(path); depth == 2 || (depth == 1 && !skipArtistFolder(name)) {
+					// Best-effort: a directory removed straight after
+					// creation simply won't be watched.
+					_ = iw.addTree(path, depth)
+				}
+			}
+
+			artist := artistFromPath(iw.root, path)
+			if artist == "" {
+				continue
+			}
+			select {
+			case changes <- artist:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
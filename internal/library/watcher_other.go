@@ -0,0 +1,17 @@
+//go:build !linux
+
+package library
+
+import (
+	"context"
+	"errors"
+)
+
+// errInotifyUnsupported is returned by watchInotify on platforms without
+// inotify so the watcher can fall back to polling.
+var errInotifyUnsupported = errors.New("inotify is not supported on this platform")
+
+// watchInotify is unavailable outside Linux; Run falls back to polling.
+func (w *Watcher) watchInotify(_ context.Context, _ chan<- string) error {
+	return errInotifyUnsupported
+}
@@ -0,0 +1,248 @@
+package library
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockRescanner struct {
+	mu      sync.Mutex
+	artists []string
+	busy    int // StartArtists calls to refuse with ErrScanInProgress
+	called  chan struct{}
+}
+
+func newMockRescanner() *mockRescanner {
+	return &mockRescanner{called: make(chan struct{}, 16)}
+}
+
+func (m *mockRescanner) StartArtists(_ context.Context, artists []string) (int64, error) {
+	m.mu.Lock()
+	if m.busy > 0 {
+		m.busy--
+		m.mu.Unlock()
+		return 1, ErrScanInProgress
+	}
+	m.artists = append(m.artists, artists...)
+	m.mu.Unlock()
+	for range artists {
+		m.called <- struct{}{}
+	}
+	return 2, nil
+}
+
+func (m *mockRescanner) scanned() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.artists...)
+}
+
+// waitForScans blocks until n rescans have happened or the timeout expires.
+func (m *mockRescanner) waitForScans(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-m.called:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for rescan %d of %d (got %v)", i+1, n, m.scanned())
+		}
+	}
+}
+
+func TestArtistFromPath(t *testing.T) {
+	root := filepath.Join("/music")
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "root itself", path: root, want: ""},
+		{name: "artist folder", path: filepath.Join(root, "Radiohead"), want: "Radiohead"},
+		{name: "track file", path: filepath.Join(root, "Radiohead", "OK Computer", "01.flac"), want: "Radiohead"},
+		{name: "playlists skipped", path: filepath.Join(root, "Playlists", "mix.m3u8"), want: ""},
+		{name: "hidden folder skipped", path: filepath.Join(root, ".trash", "x"), want: ""},
+		{name: "outside root", path: "/elsewhere/file", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := artistFromPath(root, tt.path); got != tt.want {
+				t.Errorf("artistFromPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnapshotDiff(t *testing.T) {
+	root := setupMusicDir(t)
+
+	before, err := snapshotLibrary(root)
+	if err != nil {
+		t.Fatalf("snapshotLibrary() returned unexpected error: %v", err)
+	}
+	if _, ok := before["Playlists"]; ok {
+		t.Error("snapshot should skip Playlists folder")
+	}
+
+	// Add a track to ArtistB and remove ArtistC entirely.
+	f, err := os.Create(filepath.Join(root, "ArtistB", "Album3", "02.flac"))
+	if err != nil {
+		t.Fatalf("creating file: %v", err)
+	}
+	f.Close()
+	if err := os.RemoveAll(filepath.Join(root, "ArtistC")); err != nil {
+		t.Fatalf("removing artist: %v", err)
+	}
+
+	after, err := snapshotLibrary(root)
+	if err != nil {
+		t.Fatalf("snapshotLibrary() returned unexpected error: %v", err)
+	}
+
+	got := diffSnapshots(before, after)
+	want := []string{"ArtistB", "ArtistC"}
+	if len(got) != len(want) {
+		t.Fatalf("diffSnapshots() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("diffSnapshots()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestWatcher_DebouncesEvents(t *testing.T) {
+	rescanner := newMockRescanner()
+	w := NewWatcher(t.TempDir(), rescanner, WatchPoll, 50*time.Millisecond, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan string)
+	go w.debounceLoop(ctx, changes)
+
+	for _, artist := range []string{"ArtistA", "ArtistB", "ArtistA", "ArtistA"} {
+		changes <- artist
+	}
+
+	rescanner.waitForScans(t, 2)
+
+	got := rescanner.scanned()
+	if len(got) != 2 || got[0] != "ArtistA" || got[1] != "ArtistB" {
+		t.Errorf("rescanned = %v, want [ArtistA ArtistB]", got)
+	}
+}
+
+func TestWatcher_WaitsForRunningScan(t *testing.T) {
+	rescanner := newMockRescanner()
+	rescanner.busy = math.MaxInt
+	w := NewWatcher(t.TempDir(), rescanner, WatchPoll, 5*time.Millisecond, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan string)
+	go w.debounceLoop(ctx, changes)
+
+	// Changes keep being taken while the rescan is held back, and join it.
+	changes <- "ArtistB"
+	time.Sleep(20 * time.Millisecond)
+	changes <- "ArtistA"
+
+	rescanner.mu.Lock()
+	rescanner.busy = 0
+	rescanner.mu.Unlock()
+	rescanner.waitForScans(t, 2)
+
+	got := rescanner.scanned()
+	if len(got) != 2 || got[0] != "ArtistA" || got[1] != "ArtistB" {
+		t.Errorf("rescanned = %v, want [ArtistA ArtistB]", got)
+	}
+}
+
+func TestWatcher_PollMode(t *testing.T) {
+	root := setupMusicDir(t)
+	rescanner := newMockRescanner()
+	w := NewWatcher(root, rescanner, WatchPoll, 10*time.Millisecond, 20*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = w.Run(ctx) }()
+
+	// Give the poller time to take its initial snapshot.
+	time.Sleep(50 * time.Millisecond)
+
+	newAlbum := filepath.Join(root, "NewArtist", "Debut")
+	if err := os.MkdirAll(newAlbum, 0o755); err != nil {
+		t.Fatalf("creating album: %v", err)
+	}
+
+	rescanner.waitForScans(t, 1)
+
+	if got := rescanner.scanned(); got[0] != "NewArtist" {
+		t.Errorf("rescanned = %v, want [NewArtist]", got)
+	}
+}
+
+func TestWatcher_InotifyMode(t *testing.T) {
+	root := setupMusicDir(t)
+	rescanner := newMockRescanner()
+	w := NewWatcher(root, rescanner, WatchInotify, 20*time.Millisecond, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan string, 64)
+	if err := w.watchInotify(ctx, changes); err != nil {
+		t.Skipf("inotify unavailable: %v", err)
+	}
+	go w.debounceLoop(ctx, changes)
+
+	// A new artist folder is watched as it appears, so files copied into
+	// an album beneath it are attributed to that artist.
+	albumDir := filepath.Join(root, "Fresh", "Album")
+	if err := os.MkdirAll(albumDir, 0o755); err != nil {
+		t.Fatalf("creating album: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "ArtistA", "Album1", "03.flac"), nil, 0o644); err != nil {
+		t.Fatalf("writing file: %v", err)
+	}
+
+	rescanner.waitForScans(t, 2)
+
+	got := rescanner.scanned()
+	if len(got) != 2 || got[0] != "ArtistA" || got[1] != "Fresh" {
+		t.Errorf("rescanned = %v, want [ArtistA Fresh]", got)
+	}
+}
+
+func TestWatcher_RescanAll(t *testing.T) {
+	root := setupMusicDir(t)
+	w := NewWatcher(root, newMockRescanner(), WatchInotify, time.Millisecond, time.Hour)
+
+	changes := make(chan string, 64)
+	if err := w.rescanAll(context.Background(), changes); err != nil {
+		t.Fatalf("rescanAll: %v", err)
+	}
+	close(changes)
+
+	var got []string
+	for artist := range changes {
+		got = append(got, artist)
+	}
+	want := []string{"ArtistA", "ArtistB", "ArtistC", "EmptyArtist"}
+	if len(got) != len(want) {
+		t.Fatalf("rescanAll reported %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("rescanAll()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
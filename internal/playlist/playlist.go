@@ -0,0 +1,379 @@
+// Package playlist keeps Tidal playlists in sync with M3U files in the
+// library's Playlists folder, downloading the tracks the library lacks into
+// their album folders.
+package playlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/downloader"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+	"github.com/MattHbrook/Crescendo/internal/job"
+	"github.com/MattHbrook/Crescendo/internal/library"
+)
+
+// ErrSyncInProgress is returned by Syncer.Start and Syncer.StartRefresh when
+// a sync is already running.
+var ErrSyncInProgress = errors.New("playlist: a sync is already running")
+
+// Store is the subset of db.Store needed by the syncer.
+type Store interface {
+	AddPlaylist(ctx context.Context, tidalUUID string) error
+	GetPlaylist(ctx context.Context, tidalUUID string) (*db.Playlist, error)
+	ListPlaylists(ctx context.Context) ([]db.Playlist, error)
+	RecordPlaylistSync(ctx context.Context, tidalUUID, title, tidalUpdated, m3uPath string, totalTracks, localTracks int) error
+	GetAlbumHoldings(ctx context.Context, tidalAlbumIDs []int64) (map[int64]db.AlbumHolding, error)
+	ListTracksForAlbum(ctx context.Context, albumID int64) ([]db.LibraryTrack, error)
+}
+
+// Fetcher is the subset of hifi.Client needed to read playlists and the
+// albums their tracks come from.
+type Fetcher interface {
+	GetPlaylist(ctx context.Context, id string) (*hifi.PlaylistDetail, error)
+	GetAlbum(ctx context.Context, id int64) (*hifi.AlbumDetail, error)
+}
+
+// Downloader is the subset of downloader.Downloader used to fetch missing
+// tracks.
+type Downloader interface {
+	Download(ctx context.Context, req downloader.Request) error
+}
+
+// SyncResult holds the outcome of syncing one playlist.
+type SyncResult struct {
+	Title            string
+	M3UPath          string
+	TracksTotal      int // tracks on the Tidal playlist
+	TracksLocal      int // tracks written to the M3U file
+	TracksDownloaded int
+	Errors           []string
+}
+
+// Syncer downloads the tracks of Tidal playlists and writes each playlist
+// as Playlists/<title>.m3u8 in the music root, with paths relative to the
+// file. Syncs run in the background, one at a time.
+type Syncer struct {
+	musicPath string
+	quality   string
+	store     Store
+	tidal     Fetcher
+	downloads Downloader
+	logger    *log.Logger
+	job.Runner
+}
+
+// NewSyncer creates a Syncer for the library at musicPath that downloads
+// missing tracks in the given quality.
+func NewSyncer(musicPath, quality string, store Store, tidal Fetcher, downloads Downloader) *Syncer {
+	return &Syncer{
+		musicPath: musicPath,
+		quality:   quality,
+		store:     store,
+		tidal:     tidal,
+		downloads: downloads,
+		logger:    log.New(os.Stderr, "[playlist] ", log.LstdFlags),
+	}
+}
+
+// Start adds a playlist and syncs it in the background, detached from ctx's
+// cancellation. It returns ErrSyncInProgress if a sync is already running;
+// the playlist is still added, so the next refresh picks it up.
+func (s *Syncer) Start(ctx context.Context, id string) error {
+	if err := s.store.AddPlaylist(ctx, id); err != nil {
+		return fmt.Errorf("playlist: adding %s: %w", id, err)
+	}
+	return s.start(ctx, func(ctx context.Context) {
+		if _, err := s.Sync(ctx, id); err != nil {
+			s.logger.Printf("sync of %s failed: %v", id, err)
+		}
+	})
+}
+
+// StartRefresh runs Refresh in the background, detached from ctx's
+// cancellation. It returns ErrSyncInProgress if a sync is already running.
+func (s *Syncer) StartRefresh(ctx context.Context) error {
+	return s.start(ctx, func(ctx context.Context) {
+		n, err := s.Refresh(ctx)
+		if err != nil {
+			s.logger.Printf("refresh failed: %v", err)
+			return
+		}
+		s.logger.Printf("refresh complete: %d playlists synced", n)
+	})
+}
+
+// start runs fn in the background unless a sync is already running.
+func (s *Syncer) start(ctx context.Context, fn func(ctx context.Context)) error {
+	if !s.Runner.Start(ctx, fn) {
+		return ErrSyncInProgress
+	}
+	return nil
+}
+
+// Refresh syncs every playlist that changed on Tidal since its last sync,
+// is missing tracks or whose M3U file has gone, and returns how many it
+// synced. A playlist that fails to sync is logged and skipped; Refresh
+// returns an error only if the playlists cannot be listed.
+func (s *Syncer) Refresh(ctx context.Context) (int, error) {
+	playlists, err := s.store.ListPlaylists(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("playlist: listing playlists: %w", err)
+	}
+
+	synced := 0
+	for _, p := range playlists {
+		if err := ctx.Err(); err != nil {
+			return synced, err
+		}
+
+		detail, err := s.tidal.GetPlaylist(ctx, p.TidalUUID)
+		if err != nil {
+			s.logger.Printf("fetching playlist %s: %v", p.TidalUUID, err)
+			continue
+		}
+		if upToDate(p, detail) {
+			continue
+		}
+		if _, err := s.sync(ctx, p, detail); err != nil {
+			s.logger.Printf("syncing playlist %s: %v", p.TidalUUID, err)
+			continue
+		}
+		synced++
+	}
+	return synced, nil
+}
+
+// upToDate reports whether a playlist's last sync still reflects Tidal.
+func upToDate(p db.Playlist, detail *hifi.PlaylistDetail) bool {
+	if p.SyncedAt == nil || p.TidalUpdated != detail.LastUpdated || p.LocalTracks < p.TotalTracks {
+		return false
+	}
+	_, err := os.Stat(p.M3UPath)
+	return err == nil
+}
+
+// Sync downloads the tracks of a playlist the library lacks and rewrites its
+// M3U file. Tracks that cannot be located or downloaded are reported in
+// SyncResult.Errors and left out of the file.
+func (s *Syncer) Sync(ctx context.Context, id string) (*SyncResult, error) {
+	p, err := s.store.GetPlaylist(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("playlist: %w", err)
+	}
+	detail, err := s.tidal.GetPlaylist(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("playlist: fetching %s: %w", id, err)
+	}
+	return s.sync(ctx, *p, detail)
+}
+
+// sync brings the stored playlist p in line with detail, fetched from Tidal.
+func (s *Syncer) sync(ctx context.Context, p db.Playlist, detail *hifi.PlaylistDetail) (*SyncResult, error) {
+	result := &SyncResult{Title: detail.Title, TracksTotal: len(detail.Tracks)}
+
+	// Group the tracks by album, in playlist order, so each album is
+	// located and downloaded once.
+	var albumIDs []int64
+	byAlbum := make(map[int64][]hifi.Track)
+	for _, t := range detail.Tracks {
+		if _, ok := byAlbum[t.Album.ID]; !ok {
+			albumIDs = append(albumIDs, t.Album.ID)
+		}
+		byAlbum[t.Album.ID] = append(byAlbum[t.Album.ID], t)
+	}
+
+	holdings, err := s.store.GetAlbumHoldings(ctx, albumIDs)
+	if err != nil {
+		return nil, fmt.Errorf("playlist: loading holdings: %w", err)
+	}
+
+	paths := make(map[int64]string, len(detail.Tracks)) // track ID → file
+	for _, albumID := range albumIDs {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		n, err := s.fetchAlbumTracks(ctx, albumID, byAlbum[albumID], holdings, paths)
+		result.TracksDownloaded += n
+		if err != nil {
+			msg := fmt.Sprintf("album %d: %v", albumID, err)
+			s.logger.Println(msg)
+			result.Errors = append(result.Errors, msg)
+		}
+	}
+
+	m3uPath, err := s.m3uPath(ctx, p.TidalUUID, detail.Title)
+	if err != nil {
+		return result, err
+	}
+	result.TracksLocal, err = writeM3U(m3uPath, detail, paths)
+	if err != nil {
+		return result, fmt.Errorf("playlist: writing %s: %w", m3uPath, err)
+	}
+	result.M3UPath = m3uPath
+
+	// A renamed playlist leaves its old file behind.
+	if p.M3UPath != "" && p.M3UPath != m3uPath {
+		if err := os.Remove(p.M3UPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			s.logger.Printf("removing old playlist file %s: %v", p.M3UPath, err)
+		}
+	}
+
+	if err := s.store.RecordPlaylistSync(ctx, p.TidalUUID, detail.Title, detail.LastUpdated, m3uPath, result.TracksTotal, result.TracksLocal); err != nil {
+		return result, fmt.Errorf("playlist: %w", err)
+	}
+	s.logger.Printf("synced %q: %d of %d tracks, %d downloaded", detail.Title, result.TracksLocal, result.TracksTotal, result.TracksDownloaded)
+	return result, nil
+}
+
+// fetchAlbumTracks records in paths where the given playlist tracks of one
+// album are on disk, downloading those that are not into the album's folder:
+// the library copy if there is one, otherwise where the downloader would
+// put the album. The album art already in the folder is kept, and the
+// tracks alone do not make the album count as owned. It returns how many
+// tracks it downloaded.
+func (s *Syncer) fetchAlbumTracks(ctx context.Context, albumID int64, tracks []hifi.Track, holdings map[int64]db.AlbumHolding, paths map[int64]string) (int, error) {
+	var (
+		dir    string
+		naming library.TrackNaming
+		local  []db.LibraryTrack
+	)
+	switch h, ok := holdings[albumID]; {
+	case ok && h.LibraryAlbumID != 0:
+		var err error
+		local, err = s.store.ListTracksForAlbum(ctx, h.LibraryAlbumID)
+		if err != nil {
+			return 0, err
+		}
+		names := make([]string, 0, len(local))
+		for _, t := range local {
+			names = append(names, t.Filename)
+		}
+		dir, naming = h.Path, library.DetectTrackNaming(names)
+	case ok:
+		dir = h.Path
+	default:
+		album, err := s.tidal.GetAlbum(ctx, albumID)
+		if err != nil {
+			return 0, err
+		}
+		dir = library.AlbumDir(s.musicPath, album.Artist.Name, album.Title)
+	}
+
+	req := downloader.Request{
+		TidalAlbumID: albumID,
+		Quality:      s.quality,
+		OutputDir:    dir,
+		Naming:       naming,
+	}
+	for _, t := range tracks {
+		if l, ok := library.LocalTrack(t, local); ok {
+			paths[t.ID] = l.Path
+			continue
+		}
+		path := filepath.Join(dir, naming.Filename(t.TrackNumber, t.Title))
+		if fileExists(path) {
+			paths[t.ID] = path
+			continue
+		}
+		req.TrackIDs = append(req.TrackIDs, t.ID)
+	}
+	if len(req.TrackIDs) == 0 {
+		return 0, nil
+	}
+
+	// A failed download may still have fetched some tracks, so every
+	// requested track is looked for either way.
+	err := s.downloads.Download(ctx, req)
+	downloaded := 0
+	for _, t := range tracks {
+		path := filepath.Join(dir, naming.Filename(t.TrackNumber, t.Title))
+		if _, ok := paths[t.ID]; !ok && fileExists(path) {
+			paths[t.ID] = path
+			downloaded++
+		}
+	}
+	return downloaded, err
+}
+
+// m3uPath returns the file a playlist is written to. Two playlists with the
+// same title are told apart by the start of their UUID.
+func (s *Syncer) m3uPath(ctx context.Context, id, title string) (string, error) {
+	path := filepath.Join(s.musicPath, library.PlaylistsFolder, library.SanitizeName(title)+".m3u8")
+
+	playlists, err := s.store.ListPlaylists(ctx)
+	if err != nil {
+		return "", fmt.Errorf("playlist: listing playlists: %w", err)
+	}
+	for _, p := range playlists {
+		if p.TidalUUID != id && p.M3UPath == path {
+			return filepath.Join(s.musicPath, library.PlaylistsFolder, fmt.Sprintf("%s (%.8s).m3u8", library.SanitizeName(title), id)), nil
+		}
+	}
+	return path, nil
+}
+
+// writeM3U writes an extended M3U playlist of the tracks in detail found in
+// paths, replacing the file at path atomically. Entries are relative to the
+// playlist's folder, with forward slashes, so the file works wherever the
+// library is mounted. It returns how many tracks it wrote.
+func writeM3U(path string, detail *hifi.PlaylistDetail, paths map[int64]string) (int, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return 0, err
+	}
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	b.WriteString("#PLAYLIST:" + oneLine(detail.Title) + "\n")
+	n := 0
+	for _, t := range detail.Tracks {
+		trackPath, ok := paths[t.ID]
+		if !ok {
+			continue
+		}
+		entry := trackPath
+		if rel, err := filepath.Rel(dir, trackPath); err == nil {
+			entry = filepath.ToSlash(rel)
+		}
+		b.WriteString("#EXTINF:" + strconv.Itoa(t.Duration) + "," + oneLine(t.Artist.Name+" - "+t.Title) + "\n")
+		b.WriteString(entry + "\n")
+		n++
+	}
+
+	tmp, err := os.CreateTemp(dir, ".playlist-*.m3u8")
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }() // a no-op once renamed
+
+	if _, err := tmp.WriteString(b.String()); err != nil {
+		_ = tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil { //nolint:gosec // playlists are meant to be read by media players
+		return 0, err
+	}
+	return n, os.Rename(tmp.Name(), path)
+}
+
+// oneLine replaces line breaks, which would end an M3U directive early.
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+// fileExists reports whether a regular file exists at path.
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
@@ -0,0 +1,302 @@
+package playlist
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/downloader"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+	"github.com/MattHbrook/Crescendo/internal/library"
+)
+
+const roadTrip = "36ea71a8-445e-41a4-82ab-6628c581535d"
+
+type mockStore struct {
+	playlists map[string]*db.Playlist
+	holdings  map[int64]db.AlbumHolding
+	tracks    map[int64][]db.LibraryTrack // key is library album ID
+}
+
+func (m *mockStore) AddPlaylist(_ context.Context, id string) error {
+	if _, ok := m.playlists[id]; !ok {
+		m.playlists[id] = &db.Playlist{TidalUUID: id, Title: id}
+	}
+	return nil
+}
+
+func (m *mockStore) GetPlaylist(_ context.Context, id string) (*db.Playlist, error) {
+	p, ok := m.playlists[id]
+	if !ok {
+		return nil, db.ErrPlaylistNotFound
+	}
+	cp := *p
+	return &cp, nil
+}
+
+func (m *mockStore) ListPlaylists(_ context.Context) ([]db.Playlist, error) {
+	var all []db.Playlist
+	for _, p := range m.playlists {
+		all = append(all, *p)
+	}
+	return all, nil
+}
+
+func (m *mockStore) RecordPlaylistSync(_ context.Context, id, title, updated, m3uPath string, total, local int) error {
+	synced := "2024-05-02 09:00:00"
+	m.playlists[id] = &db.Playlist{TidalUUID: id, Title: title, TidalUpdated: updated, M3UPath: m3uPath, TotalTracks: total, LocalTracks: local, SyncedAt: &synced}
+	return nil
+}
+
+func (m *mockStore) GetAlbumHoldings(_ context.Context, ids []int64) (map[int64]db.AlbumHolding, error) {
+	held := make(map[int64]db.AlbumHolding)
+	for _, id := range ids {
+		if h, ok := m.holdings[id]; ok {
+			held[id] = h
+		}
+	}
+	return held, nil
+}
+
+func (m *mockStore) ListTracksForAlbum(_ context.Context, albumID int64) ([]db.LibraryTrack, error) {
+	return m.tracks[albumID], nil
+}
+
+type mockFetcher struct {
+	playlists map[string]*hifi.PlaylistDetail
+	albums    map[int64]*hifi.AlbumDetail
+	fetched   []string
+}
+
+func (m *mockFetcher) GetPlaylist(_ context.Context, id string) (*hifi.PlaylistDetail, error) {
+	m.fetched = append(m.fetched, id)
+	p, ok := m.playlists[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return p, nil
+}
+
+func (m *mockFetcher) GetAlbum(_ context.Context, id int64) (*hifi.AlbumDetail, error) {
+	a, ok := m.albums[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return a, nil
+}
+
+// mockDownloader writes an empty file for each requested track, except on
+// albums listed in fail.
+type mockDownloader struct {
+	fail     map[int64]bool
+	requests []downloader.Request
+	tracks   map[int64]hifi.Track
+}
+
+func (m *mockDownloader) Download(_ context.Context, req downloader.Request) error {
+	m.requests = append(m.requests, req)
+	if m.fail[req.TidalAlbumID] {
+		return errors.New("playback unavailable")
+	}
+	for _, id := range req.TrackIDs {
+		t := m.tracks[id]
+		if err := os.MkdirAll(req.OutputDir, 0o750); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(req.OutputDir, req.Naming.Filename(t.TrackNumber, t.Title)), nil, 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// roadTripFixture is a playlist of four tracks: one on a library album, two
+// on an album we do not hold and one on an album whose download fails.
+func roadTripFixture(t *testing.T) (root string, store *mockStore, tidal *mockFetcher, dl *mockDownloader) {
+	t.Helper()
+	root = t.TempDir()
+
+	okc := filepath.Join(root, "Radiohead", "OK Computer")
+	if err := os.MkdirAll(okc, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	airbag := filepath.Join(okc, "1. Airbag.flac")
+	if err := os.WriteFile(airbag, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tracks := []hifi.Track{
+		{ID: 1, Title: "Airbag", TrackNumber: 1, Duration: 284, Artist: hifi.ArtistRef{Name: "Radiohead"}, Album: hifi.AlbumRef{ID: 100}},
+		{ID: 2, Title: "Lucky", TrackNumber: 11, Duration: 259, Artist: hifi.ArtistRef{Name: "Radiohead"}, Album: hifi.AlbumRef{ID: 100}},
+		{ID: 3, Title: "Hey Ya!", TrackNumber: 9, Duration: 235, Artist: hifi.ArtistRef{Name: "OutKast"}, Album: hifi.AlbumRef{ID: 200}},
+		{ID: 4, Title: "Roses", TrackNumber: 10, Duration: 369, Artist: hifi.ArtistRef{Name: "OutKast"}, Album: hifi.AlbumRef{ID: 200}},
+		{ID: 5, Title: "Jolene", TrackNumber: 1, Duration: 162, Artist: hifi.ArtistRef{Name: "Dolly Parton"}, Album: hifi.AlbumRef{ID: 300}},
+	}
+	byID := make(map[int64]hifi.Track)
+	for _, tr := range tracks {
+		byID[tr.ID] = tr
+	}
+
+	store = &mockStore{
+		playlists: map[string]*db.Playlist{roadTrip: {TidalUUID: roadTrip, Title: roadTrip}},
+		holdings:  map[int64]db.AlbumHolding{100: {TidalAlbumID: 100, LibraryAlbumID: 7, Path: okc}},
+		tracks:    map[int64][]db.LibraryTrack{7: {{ID: 70, AlbumID: 7, Filename: "1. Airbag.flac", Path: airbag, TrackNumber: 1}}},
+	}
+	tidal = &mockFetcher{
+		playlists: map[string]*hifi.PlaylistDetail{roadTrip: {
+			Playlist: hifi.Playlist{UUID: roadTrip, Title: "Road Trip", LastUpdated: "2024-05-01T10:00:00.000+0000"},
+			Tracks:   tracks,
+		}},
+		albums: map[int64]*hifi.AlbumDetail{
+			200: {Album: hifi.Album{ID: 200, Title: "Speakerboxxx/The Love Below", Artist: hifi.ArtistRef{Name: "OutKast"}}},
+			300: {Album: hifi.Album{ID: 300, Title: "Jolene", Artist: hifi.ArtistRef{Name: "Dolly Parton"}}},
+		},
+	}
+	dl = &mockDownloader{fail: map[int64]bool{300: true}, tracks: byID}
+	return root, store, tidal, dl
+}
+
+func TestSyncer_Sync(t *testing.T) {
+	root, store, tidal, dl := roadTripFixture(t)
+
+	result, err := NewSyncer(root, "LOSSLESS", store, tidal, dl).Sync(context.Background(), roadTrip)
+	if err != nil {
+		t.Fatalf("Sync() returned unexpected error: %v", err)
+	}
+
+	if result.TracksTotal != 5 || result.TracksLocal != 4 || result.TracksDownloaded != 3 || len(result.Errors) != 1 {
+		t.Errorf("result = %+v, want 4 of 5 tracks, 3 downloaded and 1 error", result)
+	}
+
+	if len(dl.requests) != 3 {
+		t.Fatalf("got %d download requests, want 3: %+v", len(dl.requests), dl.requests)
+	}
+	okc := dl.requests[0]
+	if okc.TidalAlbumID != 100 || okc.OutputDir != filepath.Join(root, "Radiohead", "OK Computer") ||
+		!slices.Equal(okc.TrackIDs, []int64{2}) || okc.Naming.Filename(11, "Lucky") != "11. Lucky.flac" {
+		t.Errorf("library album request = %+v, want track 2 into the library folder with its naming", okc)
+	}
+	outkast := dl.requests[1]
+	if outkast.OutputDir != library.AlbumDir(root, "OutKast", "Speakerboxxx/The Love Below") || !slices.Equal(outkast.TrackIDs, []int64{3, 4}) {
+		t.Errorf("new album request = %+v, want tracks 3 and 4 into the album folder", outkast)
+	}
+	if outkast.Quality != "LOSSLESS" {
+		t.Errorf("Quality = %q, want LOSSLESS", outkast.Quality)
+	}
+
+	wantPath := filepath.Join(root, "Playlists", "Road Trip.m3u8")
+	if result.M3UPath != wantPath {
+		t.Errorf("M3UPath = %q, want %q", result.M3UPath, wantPath)
+	}
+	data, err := os.ReadFile(wantPath)
+	if err != nil {
+		t.Fatalf("reading playlist: %v", err)
+	}
+	want := `#EXTM3U
+#PLAYLIST:Road Trip
+#EXTINF:284,Radiohead - Airbag
+../Radiohead/OK Computer/1. Airbag.flac
+#EXTINF:259,Radiohead - Lucky
+../Radiohead/OK Computer/11. Lucky.flac
+#EXTINF:235,OutKast - Hey Ya!
+../OutKast/Speakerboxxx_The Love Below/09 - Hey Ya!.flac
+#EXTINF:369,OutKast - Roses
+../OutKast/Speakerboxxx_The Love Below/10 - Roses.flac
+`
+	if string(data) != want {
+		t.Errorf("playlist file =\n%s\nwant\n%s", data, want)
+	}
+
+	p := store.playlists[roadTrip]
+	if p.Title != "Road Trip" || p.TidalUpdated != "2024-05-01T10:00:00.000+0000" || p.M3UPath != wantPath || p.TotalTracks != 5 || p.LocalTracks != 4 {
+		t.Errorf("recorded sync = %+v", p)
+	}
+
+	t.Run("second sync downloads only what is still missing", func(t *testing.T) {
+		dl.requests = nil
+		result, err := NewSyncer(root, "LOSSLESS", store, tidal, dl).Sync(context.Background(), roadTrip)
+		if err != nil {
+			t.Fatalf("Sync() returned unexpected error: %v", err)
+		}
+		if len(dl.requests) != 1 || dl.requests[0].TidalAlbumID != 300 {
+			t.Errorf("download requests = %+v, want only album 300", dl.requests)
+		}
+		if result.TracksLocal != 4 || result.TracksDownloaded != 0 {
+			t.Errorf("result = %+v, want 4 local tracks and none downloaded", result)
+		}
+	})
+}
+
+func TestSyncer_Sync_Rename(t *testing.T) {
+	root, store, tidal, dl := roadTripFixture(t)
+	s := NewSyncer(root, "LOSSLESS", store, tidal, dl)
+	if _, err := s.Sync(context.Background(), roadTrip); err != nil {
+		t.Fatalf("Sync() returned unexpected error: %v", err)
+	}
+
+	// Another playlist already owns the new title's file.
+	taken := filepath.Join(root, "Playlists", "Summer.m3u8")
+	store.playlists["other"] = &db.Playlist{TidalUUID: "other", Title: "Summer", M3UPath: taken}
+	tidal.playlists[roadTrip].Title = "Summer"
+
+	result, err := s.Sync(context.Background(), roadTrip)
+	if err != nil {
+		t.Fatalf("Sync() returned unexpected error: %v", err)
+	}
+	if want := filepath.Join(root, "Playlists", "Summer (36ea71a8).m3u8"); result.M3UPath != want {
+		t.Errorf("M3UPath = %q, want %q", result.M3UPath, want)
+	}
+	if _, err := os.Stat(filepath.Join(root, "Playlists", "Road Trip.m3u8")); !os.IsNotExist(err) {
+		t.Errorf("old playlist file should be removed, stat err = %v", err)
+	}
+}
+
+func TestSyncer_Refresh(t *testing.T) {
+	root, store, tidal, dl := roadTripFixture(t)
+	dl.fail = nil
+	s := NewSyncer(root, "LOSSLESS", store, tidal, dl)
+
+	if n, err := s.Refresh(context.Background()); err != nil || n != 1 {
+		t.Fatalf("first Refresh() = %d, %v; want 1 playlist synced", n, err)
+	}
+	if n, err := s.Refresh(context.Background()); err != nil || n != 0 {
+		t.Errorf("Refresh() of an unchanged playlist = %d, %v; want 0", n, err)
+	}
+
+	tidal.playlists[roadTrip].LastUpdated = "2024-06-01T10:00:00.000+0000"
+	if n, err := s.Refresh(context.Background()); err != nil || n != 1 {
+		t.Errorf("Refresh() after a change = %d, %v; want 1", n, err)
+	}
+
+	if err := os.Remove(store.playlists[roadTrip].M3UPath); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := s.Refresh(context.Background()); err != nil || n != 1 {
+		t.Errorf("Refresh() with the file gone = %d, %v; want 1", n, err)
+	}
+}
+
+func TestSyncer_Start(t *testing.T) {
+	root, store, tidal, dl := roadTripFixture(t)
+	s := NewSyncer(root, "LOSSLESS", store, tidal, dl)
+
+	const added = "0b5df2a0-7c6c-4bd4-9ad2-8d4e1a1e0f4a"
+	if err := s.Start(context.Background(), added); err != nil {
+		t.Fatalf("Start() returned unexpected error: %v", err)
+	}
+	s.Wait()
+
+	if _, ok := store.playlists[added]; !ok {
+		t.Error("Start() should add the playlist")
+	}
+	if !slices.Contains(tidal.fetched, added) {
+		t.Errorf("fetched %v, want the added playlist", tidal.fetched)
+	}
+	if s.Running() {
+		t.Error("Running() = true after Wait()")
+	}
+}
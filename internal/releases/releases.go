@@ -0,0 +1,302 @@
+// Package releases watches the Tidal discographies of library and
+// watchlist artists for new albums, queueing downloads as the artists'
+// watch rules ask.
+package releases
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/downloader"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+	"github.com/MattHbrook/Crescendo/internal/job"
+)
+
+// ErrCheckInProgress is returned by Monitor.Start when a check is already
+// running.
+var ErrCheckInProgress = errors.New("releases: a check is already running")
+
+// Store is the subset of db.Store needed by the monitor.
+type Store interface {
+	ListArtistMappings(ctx context.Context) ([]db.ArtistMapping, error)
+	ReleaseCheckedAt(ctx context.Context, artistTidalID int64) (string, error)
+	LastReleaseCheck(ctx context.Context) (string, error)
+	KnownReleases(ctx context.Context, tidalAlbumIDs []int64) (map[int64]bool, error)
+	RecordReleases(ctx context.Context, artistTidalID int64, releases []db.Release) error
+	MarkReleaseQueued(ctx context.Context, tidalAlbumID int64) error
+	ListWatchRules(ctx context.Context) (map[int64]db.WatchRule, error)
+	GetAlbumHoldings(ctx context.Context, tidalAlbumIDs []int64) (map[int64]db.AlbumHolding, error)
+}
+
+// Fetcher is the subset of hifi.Client needed to read discographies.
+type Fetcher interface {
+	GetArtistAlbums(ctx context.Context, id int64) ([]hifi.Album, error)
+}
+
+// Downloader is the subset of downloader.Downloader used to queue new
+// releases.
+type Downloader interface {
+	DownloadAsync(ctx context.Context, req downloader.Request)
+}
+
+// CheckResult holds aggregate statistics from a release check.
+type CheckResult struct {
+	ArtistsChecked int
+	NewReleases    int
+	Queued         int
+	Errors         []string
+}
+
+// Monitor records the albums of every Tidal-mapped library artist and
+// every artist on the watchlist, and flags those released since the artist
+// was last checked. The first check of an artist only records its
+// discography, so nothing is flagged until the second. Checks run in the
+// background, one at a time.
+type Monitor struct {
+	quality   string
+	store     Store
+	tidal     Fetcher
+	downloads Downloader
+	logger    *log.Logger
+	job.Runner
+}
+
+// NewMonitor creates a Monitor that queues new releases in the given quality
+// unless an artist's watch rule names another.
+func NewMonitor(quality string, store Store, tidal Fetcher, downloads Downloader) *Monitor {
+	return &Monitor{
+		quality:   quality,
+		store:     store,
+		tidal:     tidal,
+		downloads: downloads,
+		logger:    log.New(os.Stderr, "[releases] ", log.LstdFlags),
+	}
+}
+
+// Run checks for new releases every interval until ctx is cancelled,
+// starting straight away if the last check is older than interval.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	last, err := m.store.LastReleaseCheck(ctx)
+	if err != nil {
+		m.logger.Printf("reading last check: %v", err)
+	}
+	if checked, err := time.Parse(time.DateTime, last); err != nil || time.Since(checked) >= interval {
+		m.startScheduled(ctx)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.startScheduled(ctx)
+		}
+	}
+}
+
+// startScheduled starts a scheduled check, logging rather than returning
+// a clash with one started by hand.
+func (m *Monitor) startScheduled(ctx context.Context) {
+	if err := m.Start(ctx); err != nil {
+		m.logger.Printf("scheduled check skipped: %v", err)
+	}
+}
+
+// Start runs a check in the background, detached from ctx's cancellation.
+// It returns ErrCheckInProgress if a check is already running.
+func (m *Monitor) Start(ctx context.Context) error {
+	if !m.Runner.Start(ctx, func(ctx context.Context) {
+		result, err := m.Check(ctx)
+		if err != nil {
+			m.logger.Printf("check failed: %v", err)
+			return
+		}
+		m.logger.Printf("check complete: %d artists, %d new releases, %d queued, %d errors",
+			result.ArtistsChecked, result.NewReleases, result.Queued, len(result.Errors))
+	}) {
+		return ErrCheckInProgress
+	}
+	return nil
+}
+
+// Check fetches the albums of every mapped and watched artist, records
+// those not seen before and queues downloads of the new ones the artist's
+// watch rule accepts (see Evaluate). It returns an error only if the
+// artists or rules cannot be listed; per-artist errors are collected in
+// CheckResult.Errors.
+func (m *Monitor) Check(ctx context.Context) (*CheckResult, error) {
+	mappings, err := m.store.ListArtistMappings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("releases: listing artists: %w", err)
+	}
+	rules, err := m.store.ListWatchRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("releases: listing watch rules: %w", err)
+	}
+
+	result := &CheckResult{}
+	for _, a := range monitoredArtists(mappings, rules) {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		fresh, err := m.checkArtist(ctx, a.id, a.name)
+		if err != nil {
+			msg := fmt.Sprintf("checking %s: %v", a.name, err)
+			m.logger.Println(msg)
+			result.Errors = append(result.Errors, msg)
+			continue
+		}
+		result.ArtistsChecked++
+		result.NewReleases += len(fresh)
+
+		rule := rules[a.id]
+		if !rule.AutoDownload || len(fresh) == 0 {
+			continue
+		}
+		queued, err := m.queue(ctx, fresh, rule, a.name)
+		result.Queued += queued
+		if err != nil {
+			msg := fmt.Sprintf("queueing releases of %s: %v", a.name, err)
+			m.logger.Println(msg)
+			result.Errors = append(result.Errors, msg)
+		}
+	}
+	return result, nil
+}
+
+// artist is a Tidal artist whose releases are monitored.
+type artist struct {
+	id   int64
+	name string
+}
+
+// monitoredArtists returns the Tidal-mapped library artists followed by the
+// watchlist artists not in the library, ordered by ID, each once: several
+// folders can map to the same Tidal artist.
+func monitoredArtists(mappings []db.ArtistMapping, rules map[int64]db.WatchRule) []artist {
+	var artists []artist
+	seen := make(map[int64]bool)
+	for _, a := range mappings {
+		if a.TidalID == nil || seen[*a.TidalID] {
+			continue
+		}
+		seen[*a.TidalID] = true
+
+		name := a.FolderName
+		if a.TidalName != nil {
+			name = *a.TidalName
+		}
+		artists = append(artists, artist{id: *a.TidalID, name: name})
+	}
+
+	var watched []artist
+	for id, rule := range rules {
+		if !seen[id] {
+			watched = append(watched, artist{id: id, name: rule.ArtistName})
+		}
+	}
+	slices.SortFunc(watched, func(a, b artist) int { return cmp.Compare(a.id, b.id) })
+	return append(artists, watched...)
+}
+
+// checkArtist records the artist's albums not seen before and returns
+// those that are new: released on or after the day of the previous check.
+// Older unseen albums, e.g. back catalogue added to Tidal, are recorded
+// without being flagged.
+func (m *Monitor) checkArtist(ctx context.Context, artistID int64, name string) ([]hifi.Album, error) {
+	checkedAt, err := m.store.ReleaseCheckedAt(ctx, artistID)
+	if err != nil {
+		return nil, err
+	}
+	// A cached album list could be missing the very releases looked for.
+	albums, err := m.tidal.GetArtistAlbums(hifi.Fresh(ctx), artistID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(albums))
+	for _, a := range albums {
+		ids = append(ids, a.ID)
+	}
+	known, err := m.store.KnownReleases(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		unseen []db.Release
+		fresh  []hifi.Album
+	)
+	for _, a := range albums {
+		if known[a.ID] {
+			continue
+		}
+		known[a.ID] = true // albums can be listed twice
+
+		r := db.Release{
+			TidalAlbumID:  a.ID,
+			ArtistTidalID: artistID,
+			ArtistName:    name,
+			Title:         a.Title,
+			Cover:         a.Cover,
+			ReleaseDate:   a.ReleaseDate,
+			IsNew:         len(checkedAt) >= 10 && a.ReleaseDate >= checkedAt[:10],
+		}
+		unseen = append(unseen, r)
+		if r.IsNew {
+			fresh = append(fresh, a)
+		}
+	}
+
+	if err := m.store.RecordReleases(ctx, artistID, unseen); err != nil {
+		return nil, err
+	}
+	return fresh, nil
+}
+
+// queue queues downloads of the new albums the rule accepts and that are
+// not already held, in the rule's quality, and returns how many it queued.
+func (m *Monitor) queue(ctx context.Context, albums []hifi.Album, rule db.WatchRule, artistName string) (int, error) {
+	ids := make([]int64, 0, len(albums))
+	for _, a := range albums {
+		ids = append(ids, a.ID)
+	}
+	held, err := m.store.GetAlbumHoldings(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	quality := rule.Quality
+	if quality == "" {
+		quality = m.quality
+	}
+
+	queued := 0
+	for _, a := range albums {
+		if _, ok := held[a.ID]; ok {
+			continue
+		}
+		if skip := Evaluate(rule, a); skip != "" {
+			m.logger.Printf("not queueing %s – %s: %s", artistName, a.Title, skip)
+			continue
+		}
+		m.downloads.DownloadAsync(ctx, downloader.Request{TidalAlbumID: a.ID, Quality: quality})
+		if err := m.store.MarkReleaseQueued(ctx, a.ID); err != nil {
+			return queued, err
+		}
+		m.logger.Printf("queued %s – %s", artistName, a.Title)
+		queued++
+	}
+	return queued, nil
+}
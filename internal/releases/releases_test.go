@@ -0,0 +1,285 @@
+package releases
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/downloader"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+)
+
+type mockStore struct {
+	mappings []db.ArtistMapping
+	checked  map[int64]string // artist ID → checked_at
+	releases map[int64]db.Release
+	rules    map[int64]db.WatchRule
+	holdings map[int64]db.AlbumHolding
+}
+
+func newMockStore(mappings ...db.ArtistMapping) *mockStore {
+	return &mockStore{
+		mappings: mappings,
+		checked:  make(map[int64]string),
+		releases: make(map[int64]db.Release),
+		rules:    make(map[int64]db.WatchRule),
+		holdings: make(map[int64]db.AlbumHolding),
+	}
+}
+
+func (m *mockStore) ListArtistMappings(_ context.Context) ([]db.ArtistMapping, error) {
+	return m.mappings, nil
+}
+
+func (m *mockStore) ReleaseCheckedAt(_ context.Context, id int64) (string, error) {
+	return m.checked[id], nil
+}
+
+func (m *mockStore) LastReleaseCheck(_ context.Context) (string, error) {
+	return "", nil
+}
+
+func (m *mockStore) KnownReleases(_ context.Context, ids []int64) (map[int64]bool, error) {
+	known := make(map[int64]bool)
+	for _, id := range ids {
+		if _, ok := m.releases[id]; ok {
+			known[id] = true
+		}
+	}
+	return known, nil
+}
+
+func (m *mockStore) RecordReleases(_ context.Context, artistID int64, releases []db.Release) error {
+	for _, r := range releases {
+		if _, ok := m.releases[r.TidalAlbumID]; !ok {
+			r.ArtistTidalID = artistID
+			m.releases[r.TidalAlbumID] = r
+		}
+	}
+	m.checked[artistID] = "2024-05-10T09:00:00Z"
+	return nil
+}
+
+func (m *mockStore) MarkReleaseQueued(_ context.Context, id int64) error {
+	r := m.releases[id]
+	r.Queued = true
+	m.releases[id] = r
+	return nil
+}
+
+func (m *mockStore) ListWatchRules(_ context.Context) (map[int64]db.WatchRule, error) {
+	return m.rules, nil
+}
+
+func (m *mockStore) GetAlbumHoldings(_ context.Context, ids []int64) (map[int64]db.AlbumHolding, error) {
+	held := make(map[int64]db.AlbumHolding)
+	for _, id := range ids {
+		if h, ok := m.holdings[id]; ok {
+			held[id] = h
+		}
+	}
+	return held, nil
+}
+
+type mockFetcher struct {
+	albums map[int64][]hifi.Album
+	calls  []int64
+}
+
+func (m *mockFetcher) GetArtistAlbums(_ context.Context, id int64) ([]hifi.Album, error) {
+	m.calls = append(m.calls, id)
+	albums, ok := m.albums[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return albums, nil
+}
+
+type mockDownloader struct {
+	reqs []downloader.Request
+}
+
+func (m *mockDownloader) DownloadAsync(_ context.Context, req downloader.Request) {
+	m.reqs = append(m.reqs, req)
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func portishead() db.ArtistMapping {
+	return db.ArtistMapping{ID: 1, FolderName: "Portishead", TidalID: ptr(int64(10)), TidalName: ptr("Portishead")}
+}
+
+func TestCheck_FirstCheckOnlyRecords(t *testing.T) {
+	store := newMockStore(portishead())
+	tidal := &mockFetcher{albums: map[int64][]hifi.Album{
+		10: {{ID: 100, Title: "Dummy", ReleaseDate: "1994-08-22"}, {ID: 101, Title: "Third", ReleaseDate: "2008-04-28"}},
+	}}
+
+	result, err := NewMonitor("LOSSLESS", store, tidal, &mockDownloader{}).Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if result.ArtistsChecked != 1 || result.NewReleases != 0 {
+		t.Errorf("result = %+v, want 1 artist and nothing new", result)
+	}
+	if len(store.releases) != 2 || store.releases[100].IsNew || store.releases[101].IsNew {
+		t.Errorf("releases = %+v, want both recorded, neither new", store.releases)
+	}
+	if store.checked[10] == "" {
+		t.Error("artist not marked as checked")
+	}
+}
+
+func TestCheck_FlagsNewReleases(t *testing.T) {
+	store := newMockStore(portishead())
+	store.checked[10] = "2024-05-01T09:00:00Z"
+	store.releases[100] = db.Release{TidalAlbumID: 100}
+	tidal := &mockFetcher{albums: map[int64][]hifi.Album{
+		10: {
+			{ID: 100, Title: "Dummy", ReleaseDate: "1994-08-22"},
+			{ID: 102, Title: "Roseland NYC Live", ReleaseDate: "1998-11-02"}, // newly added back catalogue
+			{ID: 103, Title: "Fourth", ReleaseDate: "2024-05-01"},            // out the day of the last check
+			{ID: 104, Title: "Fifth", ReleaseDate: "2024-05-09"},
+		},
+	}}
+	dl := &mockDownloader{}
+
+	result, err := NewMonitor("LOSSLESS", store, tidal, dl).Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if result.NewReleases != 2 || result.Queued != 0 {
+		t.Errorf("result = %+v, want 2 new releases and none queued", result)
+	}
+	var fresh []int64
+	for id, r := range store.releases {
+		if r.IsNew {
+			fresh = append(fresh, id)
+		}
+	}
+	slices.Sort(fresh)
+	if !slices.Equal(fresh, []int64{103, 104}) {
+		t.Errorf("new releases = %v, want [103 104]", fresh)
+	}
+	if _, ok := store.releases[102]; !ok {
+		t.Error("back catalogue album should still be recorded")
+	}
+	if len(dl.reqs) != 0 {
+		t.Errorf("queued %d downloads without a watch rule", len(dl.reqs))
+	}
+}
+
+func TestCheck_WatchRuleQueuesDownloads(t *testing.T) {
+	store := newMockStore(portishead())
+	store.checked[10] = "2024-05-01T09:00:00Z"
+	store.rules[10] = db.WatchRule{ArtistTidalID: 10, AutoDownload: true, Quality: "HI_RES_LOSSLESS"}
+	store.holdings[104] = db.AlbumHolding{TidalAlbumID: 104} // downloaded by hand already
+	tidal := &mockFetcher{albums: map[int64][]hifi.Album{
+		10: {{ID: 103, Title: "Fourth", ReleaseDate: "2024-05-02"}, {ID: 104, Title: "Fifth", ReleaseDate: "2024-05-09"}},
+	}}
+	dl := &mockDownloader{}
+
+	result, err := NewMonitor("LOSSLESS", store, tidal, dl).Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if result.Queued != 1 {
+		t.Errorf("result.Queued = %d, want 1", result.Queued)
+	}
+	if len(dl.reqs) != 1 || dl.reqs[0].TidalAlbumID != 103 || dl.reqs[0].Quality != "HI_RES_LOSSLESS" {
+		t.Errorf("download requests = %+v, want Fourth in HI_RES_LOSSLESS", dl.reqs)
+	}
+	if !store.releases[103].Queued || store.releases[104].Queued {
+		t.Errorf("queued flags = %v/%v, want only Fourth", store.releases[103].Queued, store.releases[104].Queued)
+	}
+
+	// The default quality applies when the rule names none.
+	store.rules[10] = db.WatchRule{ArtistTidalID: 10, AutoDownload: true}
+	tidal.albums[10] = append(tidal.albums[10], hifi.Album{ID: 105, Title: "Sixth", ReleaseDate: "2024-06-01"})
+	dl.reqs = nil
+	if _, err := NewMonitor("LOSSLESS", store, tidal, dl).Check(context.Background()); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if len(dl.reqs) != 1 || dl.reqs[0].TidalAlbumID != 105 || dl.reqs[0].Quality != "LOSSLESS" {
+		t.Errorf("download requests = %+v, want Sixth in LOSSLESS", dl.reqs)
+	}
+}
+
+func TestCheck_SkipsUnmappedAndDuplicateArtists(t *testing.T) {
+	store := newMockStore(
+		portishead(),
+		db.ArtistMapping{ID: 2, FolderName: "Portishead (Live)", TidalID: ptr(int64(10))},
+		db.ArtistMapping{ID: 3, FolderName: "Unknown"},
+		db.ArtistMapping{ID: 4, FolderName: "Gone", TidalID: ptr(int64(99))},
+	)
+	tidal := &mockFetcher{albums: map[int64][]hifi.Album{10: {}}}
+
+	result, err := NewMonitor("LOSSLESS", store, tidal, &mockDownloader{}).Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if !slices.Equal(tidal.calls, []int64{10, 99}) {
+		t.Errorf("fetched artists %v, want [10 99]", tidal.calls)
+	}
+	if result.ArtistsChecked != 1 || len(result.Errors) != 1 {
+		t.Errorf("result = %+v, want 1 artist checked and 1 error", result)
+	}
+	if _, ok := store.checked[99]; ok {
+		t.Error("failed artist should not be marked as checked")
+	}
+}
+
+func TestStart(t *testing.T) {
+	store := newMockStore(portishead())
+	tidal := &mockFetcher{albums: map[int64][]hifi.Album{10: {{ID: 100, Title: "Dummy"}}}}
+	m := NewMonitor("LOSSLESS", store, tidal, &mockDownloader{})
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	m.Wait()
+	if m.Running() {
+		t.Error("Running() after Wait")
+	}
+	if _, ok := store.releases[100]; !ok {
+		t.Error("background check did not record releases")
+	}
+}
+
+func TestCheck_WatchlistArtists(t *testing.T) {
+	store := newMockStore(portishead())
+	store.checked[10] = "2024-05-01T09:00:00Z"
+	store.checked[20] = "2024-05-01T09:00:00Z"
+	store.checked[30] = "2024-05-01T09:00:00Z"
+	store.rules[20] = db.WatchRule{ArtistTidalID: 20, ArtistName: "Beth Gibbons", AutoDownload: true, SkipSingles: true, SkipLive: true}
+	store.rules[30] = db.WatchRule{ArtistTidalID: 30, ArtistName: "Adrian Utley"}
+	tidal := &mockFetcher{albums: map[int64][]hifi.Album{
+		10: {},
+		20: {
+			{ID: 200, Title: "Lives Outgrown", Type: "ALBUM", ReleaseDate: "2024-05-17"},
+			{ID: 201, Title: "Floating on a Moment", Type: "SINGLE", ReleaseDate: "2024-05-02"},
+			{ID: 202, Title: "Lives Outgrown (Live at Hackney)", Type: "ALBUM", ReleaseDate: "2024-06-01"},
+		},
+		30: {{ID: 300, Title: "Mirrors", Type: "ALBUM", ReleaseDate: "2024-05-20"}},
+	}}
+	dl := &mockDownloader{}
+
+	result, err := NewMonitor("LOSSLESS", store, tidal, dl).Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if !slices.Equal(tidal.calls, []int64{10, 20, 30}) {
+		t.Errorf("fetched artists %v, want library artist then watchlist by ID", tidal.calls)
+	}
+	if result.ArtistsChecked != 3 || result.NewReleases != 4 || result.Queued != 1 {
+		t.Errorf("result = %+v, want 3 artists, 4 new releases, 1 queued", result)
+	}
+	if len(dl.reqs) != 1 || dl.reqs[0].TidalAlbumID != 200 {
+		t.Errorf("download requests = %+v, want only Lives Outgrown", dl.reqs)
+	}
+	if r := store.releases[300]; !r.IsNew || r.ArtistName != "Adrian Utley" {
+		t.Errorf("watchlist release = %+v, want new and named after the watchlist entry", r)
+	}
+}
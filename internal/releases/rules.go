@@ -0,0 +1,79 @@
+package releases
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+)
+
+// Reasons a watch rule doesn't download an album.
+const (
+	SkipManual = "auto-download off"
+	SkipSingle = "single"
+	SkipLive   = "live album"
+	SkipHeld   = "in library"
+)
+
+// liveTitle matches the ways Tidal titles mark a live recording, e.g.
+// "Roseland NYC (Live)", "Dummy - Live", "Live at the BBC" or "MTV
+// Unplugged", but not a title that merely starts with the word, such as
+// "Live Forever".
+var liveTitle = regexp.MustCompile(`(?i)([(\[]|- )live\b|\blive (at|in|from)\b|\bunplugged\b`)
+
+// Evaluate returns why rule would not download album, or "" if it would.
+// Only the album itself is considered; whether it is new or already held is
+// up to the caller.
+func Evaluate(rule db.WatchRule, album hifi.Album) string {
+	switch {
+	case !rule.AutoDownload:
+		return SkipManual
+	case rule.SkipSingles && album.Type == "SINGLE":
+		return SkipSingle
+	case rule.SkipLive && liveTitle.MatchString(album.Title):
+		return SkipLive
+	default:
+		return ""
+	}
+}
+
+// Decision is what a watch rule would do with one of the artist's albums.
+type Decision struct {
+	Album  hifi.Album
+	Skip   string // why the album would not be downloaded; "" if it would
+	IsHeld bool
+}
+
+// Preview is a dry run of rule over the artist's whole discography: what it
+// would do with each album were it a new release. Albums already in the
+// library are skipped, as Check would.
+func (m *Monitor) Preview(ctx context.Context, rule db.WatchRule) ([]Decision, error) {
+	albums, err := m.tidal.GetArtistAlbums(ctx, rule.ArtistTidalID)
+	if err != nil {
+		return nil, fmt.Errorf("releases: fetching albums of artist %d: %w", rule.ArtistTidalID, err)
+	}
+
+	ids := make([]int64, 0, len(albums))
+	for _, a := range albums {
+		ids = append(ids, a.ID)
+	}
+	held, err := m.store.GetAlbumHoldings(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("releases: loading holdings: %w", err)
+	}
+
+	decisions := make([]Decision, 0, len(albums))
+	for _, a := range albums {
+		d := Decision{Album: a, Skip: Evaluate(rule, a)}
+		if _, ok := held[a.ID]; ok {
+			d.IsHeld = true
+			if d.Skip == "" {
+				d.Skip = SkipHeld
+			}
+		}
+		decisions = append(decisions, d)
+	}
+	return decisions, nil
+}
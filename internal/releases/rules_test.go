@@ -0,0 +1,77 @@
+package releases
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MattHbrook/Crescendo/internal/db"
+	"github.com/MattHbrook/Crescendo/internal/hifi"
+)
+
+func TestEvaluate(t *testing.T) {
+	strict := db.WatchRule{AutoDownload: true, SkipSingles: true, SkipLive: true}
+
+	tests := []struct {
+		name  string
+		rule  db.WatchRule
+		album hifi.Album
+		want  string
+	}{
+		{"album", strict, hifi.Album{Title: "Third", Type: "ALBUM"}, ""},
+		{"EP", strict, hifi.Album{Title: "Numb", Type: "EP"}, ""},
+		{"single", strict, hifi.Album{Title: "Machine Gun", Type: "SINGLE"}, SkipSingle},
+		{"single allowed", db.WatchRule{AutoDownload: true}, hifi.Album{Title: "Machine Gun", Type: "SINGLE"}, ""},
+		{"live in parentheses", strict, hifi.Album{Title: "Roseland NYC (Live)", Type: "ALBUM"}, SkipLive},
+		{"live in brackets", strict, hifi.Album{Title: "Glory Box [Live]", Type: "ALBUM"}, SkipLive},
+		{"live suffix", strict, hifi.Album{Title: "Dummy - Live", Type: "ALBUM"}, SkipLive},
+		{"live at", strict, hifi.Album{Title: "Live at the BBC", Type: "ALBUM"}, SkipLive},
+		{"unplugged", strict, hifi.Album{Title: "MTV Unplugged in New York", Type: "ALBUM"}, SkipLive},
+		{"title starting with live", strict, hifi.Album{Title: "Live Forever", Type: "ALBUM"}, ""},
+		{"title containing live", strict, hifi.Album{Title: "Lives Outgrown", Type: "ALBUM"}, ""},
+		{"live allowed", db.WatchRule{AutoDownload: true, SkipSingles: true}, hifi.Album{Title: "Roseland NYC (Live)"}, ""},
+		{"auto-download off", db.WatchRule{}, hifi.Album{Title: "Third", Type: "ALBUM"}, SkipManual},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Evaluate(tt.rule, tt.album); got != tt.want {
+				t.Errorf("Evaluate(%+v, %q) = %q, want %q", tt.rule, tt.album.Title, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreview(t *testing.T) {
+	store := newMockStore()
+	store.holdings[100] = db.AlbumHolding{TidalAlbumID: 100}
+	tidal := &mockFetcher{albums: map[int64][]hifi.Album{
+		10: {
+			{ID: 100, Title: "Dummy", Type: "ALBUM"},
+			{ID: 101, Title: "Third", Type: "ALBUM"},
+			{ID: 102, Title: "Roseland NYC (Live)", Type: "ALBUM"},
+			{ID: 103, Title: "Machine Gun", Type: "SINGLE"},
+		},
+	}}
+	rule := db.WatchRule{ArtistTidalID: 10, AutoDownload: true, SkipSingles: true, SkipLive: true}
+
+	decisions, err := NewMonitor("LOSSLESS", store, tidal, &mockDownloader{}).Preview(context.Background(), rule)
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+
+	want := map[int64]string{100: SkipHeld, 101: "", 102: SkipLive, 103: SkipSingle}
+	if len(decisions) != len(want) {
+		t.Fatalf("got %d decisions, want %d", len(decisions), len(want))
+	}
+	for _, d := range decisions {
+		if d.Skip != want[d.Album.ID] {
+			t.Errorf("%s: skip = %q, want %q", d.Album.Title, d.Skip, want[d.Album.ID])
+		}
+		if d.IsHeld != (d.Album.ID == 100) {
+			t.Errorf("%s: IsHeld = %v", d.Album.Title, d.IsHeld)
+		}
+	}
+
+	if _, err := NewMonitor("LOSSLESS", store, tidal, &mockDownloader{}).Preview(context.Background(), db.WatchRule{ArtistTidalID: 99}); err == nil {
+		t.Error("Preview of an unknown artist should fail")
+	}
+}